@@ -0,0 +1,619 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"promptforge/internal/auth"
+	"promptforge/internal/config"
+	"promptforge/internal/database"
+	"promptforge/internal/gitsync"
+	"promptforge/internal/library"
+	"promptforge/internal/models"
+	"promptforge/internal/secrets"
+)
+
+// runCommand handles CLI subcommands. It reports false when args name no subcommand and the server should start.
+func runCommand(args []string) bool {
+	if len(args) == 0 {
+		return false
+	}
+
+	switch args[0] {
+	case "migrate":
+		if err := runMigrate(args[1:]); err != nil {
+			fmt.Printf("❌ %v\n", err)
+			os.Exit(1)
+		}
+		return true
+	case "library":
+		if err := runLibrary(args[1:]); err != nil {
+			fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+			os.Exit(1)
+		}
+		return true
+	case "backup", "restore", "retention":
+		if err := runMaintenance(args[0], args[1:]); err != nil {
+			fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+			os.Exit(1)
+		}
+		return true
+	case "user":
+		if err := runUser(args[1:]); err != nil {
+			fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+			os.Exit(1)
+		}
+		return true
+	default:
+		return false
+	}
+}
+
+// runMigrate implements `migrate up|down|status [-dry-run] [-steps N]`
+func runMigrate(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: migrate up|down|status [-dry-run] [-steps N]")
+	}
+
+	action := args[0]
+	flags := flag.NewFlagSet("migrate "+action, flag.ContinueOnError)
+	dryRun := flags.Bool("dry-run", false, "show migrations without applying them")
+	steps := flags.Int("steps", 1, "number of migrations to roll back (down only)")
+	if err := flags.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	db, err := openDatabase()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	switch action {
+	case "up":
+		applied, err := db.Migrate(*dryRun)
+		printMigrations(applied, *dryRun, "apply", "Applied")
+		return err
+	case "down":
+		rolledBack, err := db.MigrateDown(*steps, *dryRun)
+		printMigrations(rolledBack, *dryRun, "roll back", "Rolled back")
+		return err
+	case "status":
+		statuses, err := db.MigrationStatus()
+		if err != nil {
+			return err
+		}
+		for _, status := range statuses {
+			state := "pending"
+			if status.Applied {
+				state = "applied " + status.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%04d_%-30s %s\n", status.Version, status.Name, state)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown migrate action %q (expected up, down or status)", action)
+	}
+}
+
+// runLibrary implements `library export [-format F] [-out PATH]`,
+// `library import [-format F] [-on-conflict skip|overwrite|rename] PATH` and
+// `library sync [-pull] [-status]`. The markdown format reads and writes a
+// directory with one file per prompt.
+func runLibrary(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: library export|import|sync [flags]")
+	}
+
+	action := args[0]
+	flags := flag.NewFlagSet("library "+action, flag.ContinueOnError)
+	formatFlag := flags.String("format", "", "json, yaml or markdown (default: from the path, else json)")
+	out := flags.String("out", "", "file or directory to export to (default: stdout)")
+	onConflict := flags.String("on-conflict", models.ConflictSkip, "skip, overwrite or rename prompts whose title exists")
+	pull := flags.Bool("pull", false, "pull upstream changes before syncing (sync only)")
+	status := flags.Bool("status", false, "report pending changes and conflicts without syncing (sync only)")
+	if err := flags.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	db, err := openDatabase()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	if err := db.InitSchema(config.AppConfig.Database.AutoMigrate); err != nil {
+		return err
+	}
+
+	switch action {
+	case "export":
+		format := *formatFlag
+		if format == "" && *out != "" {
+			format = library.FormatFromPath(*out, filepath.Ext(*out) == "")
+		}
+		return exportLibrary(db, format, *out)
+	case "import":
+		if flags.NArg() != 1 {
+			return fmt.Errorf("usage: library import [-format F] [-on-conflict S] PATH")
+		}
+		return importLibrary(db, *formatFlag, flags.Arg(0), *onConflict)
+	case "sync":
+		return syncLibrary(db, *pull, *status)
+	default:
+		return fmt.Errorf("unknown library action %q (expected export, import or sync)", action)
+	}
+}
+
+// runMaintenance implements `backup [-dir DIR] [-keep N]`, `restore [-dir DIR] BACKUP` and `retention`.
+// restore accepts a backup name from the backup directory or a path, and saves the current database
+// as a backup before replacing it.
+func runMaintenance(action string, args []string) error {
+	flags := flag.NewFlagSet(action, flag.ContinueOnError)
+	dir := flags.String("dir", config.AppConfig.Backup.Dir, "backup directory")
+	keep := flags.Int("keep", 0, "delete all but the newest N backups after backing up (backup only)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	cfg := config.AppConfig.Database
+	if action == "restore" {
+		if flags.NArg() != 1 {
+			return fmt.Errorf("usage: restore [-dir DIR] BACKUP")
+		}
+		return restoreDatabase(cfg, *dir, flags.Arg(0))
+	}
+
+	db, err := openDatabase()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	switch action {
+	case "backup":
+		return runBackup(db, *dir, *keep)
+	default:
+		if !config.AppConfig.Retention.Enabled() {
+			return fmt.Errorf("no retention limits are configured (set HISTORY_MAX_AGE, HISTORY_MAX_ROWS, CONVERSATION_MAX_AGE or CONVERSATION_MAX_ROWS)")
+		}
+		if err := db.InitSchema(cfg.AutoMigrate); err != nil {
+			return err
+		}
+		result, err := db.ApplyRetention(retentionPolicy())
+		if err != nil {
+			return err
+		}
+		fmt.Printf("✅ Removed %d history entries and %d conversations\n", result.HistoryDeleted, result.ConversationsDeleted)
+		return nil
+	}
+}
+
+func restoreDatabase(cfg config.DatabaseConfig, dir, backup string) error {
+	if cfg.Driver == database.DriverPostgres {
+		return fmt.Errorf("restore only supports SQLite databases, use pg_restore for PostgreSQL")
+	}
+
+	path := backup
+	if _, err := os.Stat(path); os.IsNotExist(err) && !strings.ContainsRune(backup, os.PathSeparator) {
+		path = filepath.Join(dir, backup)
+	}
+
+	// Keep the database being replaced in case the wrong backup was picked
+	if _, err := os.Stat(cfg.Path); err == nil {
+		db, err := database.Open(database.DriverSQLite, cfg.Path)
+		if err != nil {
+			return err
+		}
+		saved, err := db.Backup(dir)
+		db.Close()
+		if err != nil {
+			return fmt.Errorf("failed to save the current database before restoring: %v", err)
+		}
+		fmt.Printf("Saved the current database as %s\n", saved.Name)
+	}
+
+	if err := database.Restore(path, cfg.Path); err != nil {
+		return err
+	}
+	fmt.Printf("✅ Restored %s from %s\n", cfg.Path, path)
+	return nil
+}
+
+// runUser implements `user create [-admin] [-claim] NAME`, `user key [-name LABEL] NAME` and `user list`.
+// create reads the password from PROMPTFORGE_PASSWORD or the first line of stdin; -claim gives the
+// new user every row created before authentication was turned on.
+func runUser(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: user create|key|list [flags] [NAME]")
+	}
+
+	action := args[0]
+	flags := flag.NewFlagSet("user "+action, flag.ContinueOnError)
+	admin := flags.Bool("admin", false, "make the user an administrator (create only)")
+	claim := flags.Bool("claim", false, "assign existing unowned data to the user (create only)")
+	keyName := flags.String("name", "cli", "label for the API key (key only)")
+	if err := flags.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	db, err := openDatabase()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	if err := db.InitSchema(config.AppConfig.Database.AutoMigrate); err != nil {
+		return err
+	}
+
+	switch action {
+	case "list":
+		users, err := db.ListUsers()
+		if err != nil {
+			return err
+		}
+		for _, user := range users {
+			role := ""
+			if user.IsAdmin {
+				role = " (admin)"
+			}
+			fmt.Printf("%d\t%s%s\n", user.ID, user.Username, role)
+		}
+		return nil
+	case "create", "key":
+		if flags.NArg() != 1 {
+			return fmt.Errorf("usage: user %s [flags] NAME", action)
+		}
+	default:
+		return fmt.Errorf("unknown user action %q (expected create, key or list)", action)
+	}
+
+	username := flags.Arg(0)
+	if action == "key" {
+		user, _, err := db.GetUserCredentials(username)
+		if err != nil {
+			return fmt.Errorf("%s: %v", username, err)
+		}
+		key, prefix, hash, err := auth.NewAPIKey()
+		if err != nil {
+			return err
+		}
+		if _, err := db.CreateAPIKey(user.ID, *keyName, prefix, hash); err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "✅ Created API key %q for %s. It is shown only once:\n", *keyName, username)
+		fmt.Println(key)
+		return nil
+	}
+
+	password, err := readPassword()
+	if err != nil {
+		return err
+	}
+	passwordHash, err := auth.HashPassword(password)
+	if err != nil {
+		return err
+	}
+	user, err := db.CreateUser(username, passwordHash, *admin)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("✅ Created user %s (id %d)\n", user.Username, user.ID)
+
+	if *claim {
+		claimed, err := db.ClaimUnownedData(user.ID)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("✅ Assigned %d existing rows to %s\n", claimed, user.Username)
+	}
+	return nil
+}
+
+// runSecrets implements `secrets keygen|list|set|delete [NAME]`. keygen prints a new master key;
+// the others manage the keystore, and set reads the value from the first line of stdin.
+func runSecrets(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: secrets keygen|list|set|delete [NAME]")
+	}
+
+	action := args[0]
+	switch action {
+	case "keygen":
+		key, err := secrets.GenerateKey()
+		if err != nil {
+			return err
+		}
+		fmt.Println(key)
+		return nil
+	case "list":
+	case "set", "delete":
+		if len(args) != 2 {
+			return fmt.Errorf("usage: secrets %s NAME", action)
+		}
+	default:
+		return fmt.Errorf("unknown secrets action %q (expected keygen, list, set or delete)", action)
+	}
+
+	keystore, err := config.OpenKeystore()
+	if err != nil {
+		return err
+	}
+
+	switch action {
+	case "list":
+		names, err := keystore.Names()
+		if err != nil {
+			return err
+		}
+		for _, name := range names {
+			fmt.Println(name)
+		}
+		return nil
+	case "delete":
+		if err := keystore.Delete(args[1]); err != nil {
+			return err
+		}
+		fmt.Printf("✅ Deleted secret %s\n", args[1])
+		return nil
+	}
+
+	fmt.Fprint(os.Stderr, "Value: ")
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return fmt.Errorf("failed to read secret: %v", err)
+	}
+	value := strings.TrimRight(line, "\r\n")
+	if value == "" {
+		return fmt.Errorf("a value is required")
+	}
+	if err := keystore.Set(args[1], value); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "\n✅ Stored secret %s; refer to it as ${keystore:%s}\n", args[1], args[1])
+	return nil
+}
+
+// readPassword takes the password from PROMPTFORGE_PASSWORD, else the first line of stdin
+func readPassword() (string, error) {
+	if password := os.Getenv("PROMPTFORGE_PASSWORD"); password != "" {
+		return password, nil
+	}
+
+	fmt.Fprint(os.Stderr, "Password: ")
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return "", fmt.Errorf("failed to read password: %v", err)
+	}
+	password := strings.TrimRight(line, "\r\n")
+	if password == "" {
+		return "", fmt.Errorf("a password is required")
+	}
+	return password, nil
+}
+
+func exportLibrary(db *database.Database, format, out string) error {
+	format, err := library.ParseFormat(format)
+	if err != nil {
+		return err
+	}
+
+	lib, err := db.ExportLibrary()
+	if err != nil {
+		return err
+	}
+
+	if format == models.LibraryFormatMarkdown {
+		if out == "" {
+			return fmt.Errorf("the markdown format needs -out DIRECTORY")
+		}
+		if err := library.WriteDir(out, lib); err != nil {
+			return err
+		}
+	} else {
+		var w io.Writer = os.Stdout
+		if out != "" {
+			file, err := os.Create(out)
+			if err != nil {
+				return fmt.Errorf("failed to create %s: %v", out, err)
+			}
+			defer file.Close()
+			w = file
+		}
+		if err := library.Encode(w, format, lib); err != nil {
+			return err
+		}
+	}
+
+	if out != "" {
+		fmt.Printf("✅ Exported %d prompts to %s\n", len(lib.Prompts), out)
+	}
+	return nil
+}
+
+func importLibrary(db *database.Database, format, path, strategy string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if format == "" {
+		format = library.FormatFromPath(path, info.IsDir())
+	}
+	if format, err = library.ParseFormat(format); err != nil {
+		return err
+	}
+
+	var lib *models.Library
+	switch {
+	case info.IsDir():
+		lib, err = library.ReadDir(path)
+	case format == models.LibraryFormatMarkdown && strings.EqualFold(filepath.Ext(path), ".zip"):
+		var data []byte
+		if data, err = os.ReadFile(path); err == nil {
+			lib, err = library.ReadZip(data)
+		}
+	case format == models.LibraryFormatMarkdown:
+		var data []byte
+		var prompt *models.LibraryPrompt
+		if data, err = os.ReadFile(path); err == nil {
+			if prompt, err = library.UnmarshalMarkdown(data, path); err == nil {
+				lib = &models.Library{SchemaVersion: models.LibrarySchemaVersion, Prompts: []models.LibraryPrompt{*prompt}}
+			}
+		}
+	default:
+		var data []byte
+		if data, err = os.ReadFile(path); err == nil {
+			lib, err = library.Decode(data, format)
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	result, err := db.ImportLibrary(lib, strategy)
+	if err != nil {
+		return err
+	}
+
+	for _, outcome := range result.Prompts {
+		if outcome.NewTitle != "" {
+			fmt.Printf("%-11s %s -> %s\n", outcome.Action, outcome.Title, outcome.NewTitle)
+		} else {
+			fmt.Printf("%-11s %s\n", outcome.Action, outcome.Title)
+		}
+	}
+	fmt.Printf("✅ Imported %s: %d created, %d overwritten, %d renamed, %d skipped\n",
+		path, result.Created, result.Overwritten, result.Renamed, result.Skipped)
+	return nil
+}
+
+func syncLibrary(db *database.Database, pull, status bool) error {
+	syncer, err := initLibrarySync(db)
+	if err != nil {
+		return err
+	}
+	if syncer == nil {
+		return fmt.Errorf("library sync is not configured (set LIBRARY_SYNC_DIR)")
+	}
+
+	var result *models.SyncResult
+	switch {
+	case status:
+		result, err = syncer.Status()
+	case pull:
+		result, err = syncer.Pull()
+	default:
+		result, err = syncer.Sync("Sync prompt library")
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, change := range result.Changes {
+		fmt.Printf("%-9s %s\n", change.Action, change.Path)
+	}
+	for _, conflict := range result.Conflicts {
+		fmt.Printf("conflict  %s: %s\n", conflict.Path, conflict.Reason)
+	}
+	if result.Commit != "" {
+		fmt.Printf("✅ Committed %s\n", result.Commit)
+	}
+	if len(result.Conflicts) > 0 {
+		return fmt.Errorf("%d conflicts need resolving", len(result.Conflicts))
+	}
+	return nil
+}
+
+// initLibrarySync returns nil when LIBRARY_SYNC_DIR is unset
+func initLibrarySync(db *database.Database) (*gitsync.Syncer, error) {
+	cfg := config.AppConfig.LibrarySync
+	if cfg.Dir == "" {
+		return nil, nil
+	}
+	store, err := librarySyncStore(db, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return gitsync.New(store, gitsync.Options{
+		Dir:         cfg.Dir,
+		Path:        cfg.Path,
+		Remote:      cfg.Remote,
+		Branch:      cfg.Branch,
+		Push:        cfg.Push,
+		AuthorName:  cfg.AuthorName,
+		AuthorEmail: cfg.AuthorEmail,
+	})
+}
+
+// librarySyncStore limits the synced library to library_sync.user's own prompts, or to those of
+// library_sync.workspace, so private prompts never reach the shared tree. Without a user, as when
+// authentication is off, every prompt is synced.
+func librarySyncStore(db *database.Database, cfg config.LibrarySyncConfig) (database.Store, error) {
+	if cfg.User == "" {
+		return db, nil
+	}
+	user, err := db.GetUserByName(cfg.User)
+	if err != nil {
+		return nil, fmt.Errorf("library_sync.user %q: %v", cfg.User, err)
+	}
+	if cfg.Workspace == 0 {
+		return db.ForUser(user.ID), nil
+	}
+	if _, err := db.GetWorkspaceRole(cfg.Workspace, user.ID); err != nil {
+		return nil, fmt.Errorf("library_sync.workspace %d: %v", cfg.Workspace, err)
+	}
+	return db.ForWorkspace(user.ID, cfg.Workspace), nil
+}
+
+func printMigrations(migrations []database.Migration, dryRun bool, verb, done string) {
+	if len(migrations) == 0 {
+		fmt.Printf("✅ Nothing to %s\n", verb)
+		return
+	}
+
+	for _, migration := range migrations {
+		if dryRun {
+			fmt.Printf("Would %s %04d_%s\n", verb, migration.Version, migration.Name)
+		} else {
+			fmt.Printf("%s %04d_%s\n", done, migration.Version, migration.Name)
+		}
+	}
+}
+
+func openDatabase() (*database.Database, error) {
+	cfg := config.AppConfig.Database
+	return database.Open(cfg.Driver, cfg.DSN())
+}
+
+// initDatabase opens the configured database and prepares its schema.
+// In dry-run mode it lists pending migrations and exits.
+func initDatabase() (*database.Database, error) {
+	db, err := openDatabase()
+	if err != nil {
+		return nil, err
+	}
+
+	if config.AppConfig.Database.MigrateDryRun {
+		pending, err := db.PendingMigrations()
+		db.Close()
+		if err != nil {
+			return nil, err
+		}
+		printMigrations(pending, true, "apply", "Applied")
+		os.Exit(0)
+	}
+
+	if err := db.InitSchema(config.AppConfig.Database.AutoMigrate); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return db, nil
+}
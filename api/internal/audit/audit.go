@@ -0,0 +1,200 @@
+// Package audit records who changed what through the API
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/labstack/echo/v4"
+
+	"promptforge/internal/auth"
+	"promptforge/internal/database"
+	"promptforge/internal/logging"
+	"promptforge/internal/models"
+)
+
+// maxSummary bounds the before and after summaries, in bytes
+const maxSummary = 1000
+
+// maxCapture bounds how much of a request body is kept to summarize it, and how much of a response is
+// kept to find the ID of a created entity
+const maxCapture = 64 << 10
+
+// Snapshot describes the entity a request is about to change, for the before summary. It returns ""
+// when there is nothing to describe.
+type Snapshot func(c echo.Context) string
+
+// Middleware records every POST, PUT and DELETE request to the API in the audit log once it has been
+// handled, whether or not it succeeded. snapshots are keyed by method and route, as in "PUT /api/prompts/:id".
+// It must run after authentication so the actor is known.
+func Middleware(log database.AuditStore, snapshots map[string]Snapshot) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			if !mutating(req.Method) || !strings.HasPrefix(req.URL.Path, "/api/") {
+				return next(c)
+			}
+
+			// Only the start of the body is read here; the handler still gets all of it
+			body, err := io.ReadAll(io.LimitReader(req.Body, maxCapture+1))
+			if err != nil {
+				return err
+			}
+			req.Body = readCloser{io.MultiReader(bytes.NewReader(body), req.Body), req.Body}
+
+			entry := models.AuditEntry{
+				Method: req.Method,
+				Route:  c.Path(),
+				Path:   req.URL.Path,
+				After:  summarize(body, req.Header.Get(echo.HeaderContentType)),
+			}
+			if snapshot := snapshots[req.Method+" "+c.Path()]; snapshot != nil {
+				entry.Before = truncate(snapshot(c))
+			}
+
+			capture := &captureWriter{ResponseWriter: c.Response().Writer}
+			c.Response().Writer = capture
+			if err := next(c); err != nil {
+				c.Error(err)
+			}
+
+			if user := auth.UserFrom(c); user != nil {
+				entry.UserID, entry.Username = &user.ID, user.Username
+			}
+			if membership := auth.WorkspaceFrom(c); membership != nil {
+				entry.WorkspaceID = &membership.WorkspaceID
+			}
+			entry.Status = c.Response().Status
+			entry.EntityID = entityID(c, capture.body.Bytes())
+
+			if err := log.AppendAudit(entry); err != nil {
+				slog.ErrorContext(c.Request().Context(), "failed to write audit log", "route", entry.Route, "error", err)
+			}
+			return nil
+		}
+	}
+}
+
+func mutating(method string) bool {
+	return method == http.MethodPost || method == http.MethodPut || method == http.MethodDelete
+}
+
+// entityID is the :id route parameter, or the ID of the entity a request created
+func entityID(c echo.Context, response []byte) string {
+	if id := c.Param("id"); id != "" {
+		return id
+	}
+
+	var created struct {
+		Data struct {
+			ID json.RawMessage `json:"id"`
+		} `json:"data"`
+	}
+	if json.Unmarshal(response, &created) != nil || len(created.Data.ID) == 0 {
+		return ""
+	}
+	if id, err := strconv.Unquote(string(created.Data.ID)); err == nil {
+		return id
+	}
+	return string(created.Data.ID)
+}
+
+// summarize describes a request body for the audit log. JSON is kept with secret fields redacted;
+// other bodies are described by type and size.
+func summarize(body []byte, contentType string) string {
+	if len(bytes.TrimSpace(body)) == 0 {
+		return ""
+	}
+	if contentType == "" {
+		contentType = "unknown content"
+	}
+	// A cut-off body cannot be parsed, so neither can its secrets be redacted
+	if len(body) > maxCapture {
+		return fmt.Sprintf("%s, over %d bytes", contentType, maxCapture)
+	}
+
+	var value interface{}
+	if !strings.HasPrefix(contentType, echo.MIMEApplicationJSON) || json.Unmarshal(body, &value) != nil {
+		return fmt.Sprintf("%s, %d bytes", contentType, len(body))
+	}
+
+	summary, err := json.Marshal(redact(value))
+	if err != nil {
+		return fmt.Sprintf("%d bytes", len(body))
+	}
+	return truncate(string(summary))
+}
+
+// redact replaces the values of fields that look like credentials
+func redact(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, field := range v {
+			if logging.IsSecretKey(key) {
+				v[key] = "[redacted]"
+			} else {
+				v[key] = redact(field)
+			}
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = redact(item)
+		}
+	}
+	return value
+}
+
+func truncate(s string) string {
+	if len(s) <= maxSummary {
+		return s
+	}
+	cut := maxSummary
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	return s[:cut] + "…"
+}
+
+// readCloser reads the replayed start of a request body, then the rest, and closes the original
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// captureWriter keeps the start of the response body while passing it through
+type captureWriter struct {
+	http.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *captureWriter) Write(b []byte) (int, error) {
+	if room := maxCapture - w.body.Len(); room > 0 {
+		if len(b) < room {
+			room = len(b)
+		}
+		w.body.Write(b[:room])
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *captureWriter) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (w *captureWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if hijacker, ok := w.ResponseWriter.(http.Hijacker); ok {
+		return hijacker.Hijack()
+	}
+	return nil, nil, fmt.Errorf("response does not support hijacking")
+}
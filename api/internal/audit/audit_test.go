@@ -0,0 +1,92 @@
+package audit
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+
+	"promptforge/internal/database"
+	"promptforge/internal/models"
+)
+
+func TestMiddleware(t *testing.T) {
+	db, err := database.Open(database.DriverSQLite, filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+	if err := db.InitSchema(true); err != nil {
+		t.Fatalf("Failed to initialize database: %v", err)
+	}
+
+	e := echo.New()
+	e.Use(Middleware(db, map[string]Snapshot{
+		"PUT /api/things/:id": func(c echo.Context) string { return `{"name":"old"}` },
+	}))
+	e.POST("/api/things", func(c echo.Context) error {
+		return c.JSON(http.StatusCreated, map[string]interface{}{"success": true, "data": map[string]int{"id": 7}})
+	})
+	e.PUT("/api/things/:id", func(c echo.Context) error {
+		return echo.NewHTTPError(http.StatusConflict, "changed")
+	})
+	e.GET("/api/things", func(c echo.Context) error { return c.NoContent(http.StatusOK) })
+	var uploaded int
+	e.POST("/api/uploads", func(c echo.Context) error {
+		data, err := io.ReadAll(c.Request().Body)
+		uploaded = len(data)
+		return err
+	})
+
+	do := func(method, path, body string) {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		e.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	do(http.MethodPost, "/api/things", `{"name": "new", "password": "hunter2", "max_tokens": 5}`)
+	do(http.MethodPut, "/api/things/7", `{"name": "newer"}`)
+	do(http.MethodGet, "/api/things", "")
+
+	entries, page, err := db.GetAuditLog(models.AuditFilter{PageOptions: models.PageOptions{Order: models.SortAsc}})
+	if err != nil {
+		t.Fatalf("Failed to read audit log: %v", err)
+	}
+	if page.Total != 2 {
+		t.Fatalf("Expected only the POST and PUT to be recorded, got %+v", entries)
+	}
+
+	created := entries[0]
+	if created.Route != "/api/things" || created.EntityID != "7" || created.Status != http.StatusCreated {
+		t.Errorf("Expected the created entity's ID and status, got %+v", created)
+	}
+	if strings.Contains(created.After, "hunter2") || !strings.Contains(created.After, `"max_tokens":5`) {
+		t.Errorf("Expected only the password to be redacted, got %s", created.After)
+	}
+
+	updated := entries[1]
+	if updated.Route != "/api/things/:id" || updated.EntityID != "7" || updated.Status != http.StatusConflict {
+		t.Errorf("Expected the failed update to be recorded, got %+v", updated)
+	}
+	if updated.Before != `{"name":"old"}` || updated.After != `{"name":"newer"}` {
+		t.Errorf("Expected before and after summaries, got %q and %q", updated.Before, updated.After)
+	}
+
+	if filtered, _, err := db.GetAuditLog(models.AuditFilter{Method: http.MethodPut}); err != nil || len(filtered) != 1 {
+		t.Errorf("Expected to filter by method, got %+v %v", filtered, err)
+	}
+
+	// A large body reaches the handler whole but is only described
+	large := `{"text": "` + strings.Repeat("x", 2*maxCapture) + `"}`
+	do(http.MethodPost, "/api/uploads", large)
+	if uploaded != len(large) {
+		t.Errorf("Expected the handler to read all %d bytes, got %d", len(large), uploaded)
+	}
+	entries, _, _ = db.GetAuditLog(models.AuditFilter{PageOptions: models.PageOptions{Order: models.SortDesc}})
+	if want := "application/json, over 65536 bytes"; entries[0].After != want {
+		t.Errorf("Expected %q, got %q", want, entries[0].After)
+	}
+}
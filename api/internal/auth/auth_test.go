@@ -0,0 +1,133 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"promptforge/internal/database"
+)
+
+func TestPasswordHash(t *testing.T) {
+	hash, err := HashPassword("correct horse")
+	if err != nil {
+		t.Fatalf("Failed to hash password: %v", err)
+	}
+	if !strings.HasPrefix(hash, "$argon2id$v=19$m=65536,t=3,p=2$") {
+		t.Errorf("Expected an Argon2id hash, got %s", hash)
+	}
+	if !CheckPassword(hash, "correct horse") {
+		t.Error("Expected the password to match its hash")
+	}
+	if CheckPassword(hash, "wrong horse") {
+		t.Error("Expected a different password not to match")
+	}
+	if CheckPassword("", "") || CheckPassword("plain", "plain") {
+		t.Error("Expected empty and malformed hashes never to match")
+	}
+
+	other, _ := HashPassword("correct horse")
+	if other == hash {
+		t.Error("Expected every hash to use a fresh salt")
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	db, err := database.Open(database.DriverSQLite, filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+	if err := db.InitSchema(true); err != nil {
+		t.Fatalf("Failed to initialize database: %v", err)
+	}
+
+	user, err := db.CreateUser("alice", "", false)
+	if err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+	key, prefix, keyHash, err := NewAPIKey()
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	if _, err := db.CreateAPIKey(user.ID, "test", prefix, keyHash); err != nil {
+		t.Fatalf("Failed to store key: %v", err)
+	}
+	token, tokenHash, err := NewSessionToken()
+	if err != nil {
+		t.Fatalf("Failed to generate session token: %v", err)
+	}
+	if err := db.CreateSession(user.ID, tokenHash, time.Now().Add(time.Hour)); err != nil {
+		t.Fatalf("Failed to store session: %v", err)
+	}
+
+	e := echo.New()
+	e.Use(Middleware(db))
+	whoami := func(c echo.Context) error {
+		if user := UserFrom(c); user != nil {
+			return c.String(http.StatusOK, user.Username)
+		}
+		return c.String(http.StatusOK, "anonymous")
+	}
+	e.GET("/api/history", whoami)
+	e.GET("/api/health", whoami)
+	e.GET("/api/users", whoami, RequireAdmin)
+	e.GET("/index.html", whoami)
+
+	tests := []struct {
+		name   string
+		path   string
+		header string
+		value  string
+		cookie string
+		status int
+		body   string
+	}{
+		{name: "anonymous", path: "/api/history", status: http.StatusUnauthorized},
+		{name: "bearer key", path: "/api/history", header: "Authorization", value: "Bearer " + key, status: http.StatusOK, body: "alice"},
+		{name: "header key", path: "/api/history", header: "X-API-Key", value: key, status: http.StatusOK, body: "alice"},
+		{name: "unknown key", path: "/api/history", header: "X-API-Key", value: APIKeyPrefix + "nope", status: http.StatusUnauthorized},
+		{name: "session", path: "/api/history", cookie: token, status: http.StatusOK, body: "alice"},
+		{name: "bad session", path: "/api/history", cookie: "nope", status: http.StatusUnauthorized},
+		{name: "public route", path: "/api/health", status: http.StatusOK, body: "anonymous"},
+		{name: "static file", path: "/index.html", status: http.StatusOK, body: "anonymous"},
+		{name: "not an admin", path: "/api/users", header: "X-API-Key", value: key, status: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.header != "" {
+				req.Header.Set(tt.header, tt.value)
+			}
+			if tt.cookie != "" {
+				req.AddCookie(&http.Cookie{Name: SessionCookie, Value: tt.cookie})
+			}
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+
+			if rec.Code != tt.status {
+				t.Fatalf("Expected status %d, got %d: %s", tt.status, rec.Code, rec.Body.String())
+			}
+			if tt.body != "" && rec.Body.String() != tt.body {
+				t.Errorf("Expected %q, got %q", tt.body, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestRequireAdminWithoutAuthentication(t *testing.T) {
+	e := echo.New()
+	e.GET("/api/backups", func(c echo.Context) error { return c.NoContent(http.StatusOK) }, RequireAdmin)
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/backups", nil))
+	if rec.Code != http.StatusForbidden || !strings.Contains(rec.Body.String(), "AUTH_ENABLED") {
+		t.Errorf("Expected admin routes to be refused when authentication is off, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
@@ -0,0 +1,180 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+
+	"promptforge/internal/database"
+	"promptforge/internal/models"
+)
+
+// SessionCookie holds the session token of a browser login
+const SessionCookie = "promptforge_session"
+
+// WorkspaceHeader selects the workspace whose shared library a request works on
+const WorkspaceHeader = "X-Workspace-ID"
+
+const (
+	userContextKey      = "user"
+	workspaceContextKey = "workspace"
+)
+
+// Accounts is the storage requests are authenticated against
+type Accounts interface {
+	database.UserStore
+	database.WorkspaceStore
+}
+
+// Membership is the workspace a request selected and the user's role in it
+type Membership struct {
+	WorkspaceID int64
+	Role        string
+}
+
+// publicPaths are API routes that work without signing in
+var publicPaths = map[string]bool{
+	"/api/health":     true,
+	"/api/auth/login": true,
+}
+
+// Middleware authenticates API requests by API key (an "Authorization: Bearer" or X-API-Key header)
+// or session cookie and rejects anonymous ones. Static files and public API routes are let through.
+// A workspace named by the X-Workspace-ID header must be one the user belongs to.
+func Middleware(users Accounts) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			path := c.Request().URL.Path
+			if !strings.HasPrefix(path, "/api/") || publicPaths[path] {
+				return next(c)
+			}
+
+			user, err := authenticate(c, users)
+			if err != nil {
+				return c.JSON(http.StatusInternalServerError, models.APIResponse{
+					Success: false,
+					Error:   fmt.Sprintf("Failed to authenticate: %v", err),
+				})
+			}
+			if user == nil {
+				return c.JSON(http.StatusUnauthorized, models.APIResponse{
+					Success: false,
+					Error:   "Authentication required",
+				})
+			}
+
+			c.Set(userContextKey, user)
+
+			if header := c.Request().Header.Get(WorkspaceHeader); header != "" {
+				workspaceID, err := strconv.ParseInt(header, 10, 64)
+				if err != nil {
+					return c.JSON(http.StatusBadRequest, models.APIResponse{
+						Success: false,
+						Error:   "Invalid workspace ID",
+					})
+				}
+				role, err := users.GetWorkspaceRole(workspaceID, user.ID)
+				if errors.Is(err, database.ErrWorkspaceNotFound) {
+					return c.JSON(http.StatusForbidden, models.APIResponse{
+						Success: false,
+						Error:   "Not a member of this workspace",
+					})
+				}
+				if err != nil {
+					return c.JSON(http.StatusInternalServerError, models.APIResponse{
+						Success: false,
+						Error:   fmt.Sprintf("Failed to check workspace membership: %v", err),
+					})
+				}
+				c.Set(workspaceContextKey, &Membership{WorkspaceID: workspaceID, Role: role})
+			}
+
+			return next(c)
+		}
+	}
+}
+
+// RequireAdmin rejects requests from users who are not administrators, and every request when
+// authentication is off
+func RequireAdmin(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		user := UserFrom(c)
+		if user == nil {
+			// Only requests without authentication get here without a user
+			return c.JSON(http.StatusForbidden, models.APIResponse{
+				Success: false,
+				Error:   "Administrator access required; enable authentication with AUTH_ENABLED=true",
+			})
+		}
+		if !user.IsAdmin {
+			return c.JSON(http.StatusForbidden, models.APIResponse{
+				Success: false,
+				Error:   "Administrator access required",
+			})
+		}
+		return next(c)
+	}
+}
+
+// RequireEditor rejects requests that would change a workspace's shared library from viewers of that workspace
+func RequireEditor(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if !CanEdit(c) {
+			return c.JSON(http.StatusForbidden, models.APIResponse{
+				Success: false,
+				Error:   "Viewers cannot change this workspace",
+			})
+		}
+		return next(c)
+	}
+}
+
+// CanEdit reports whether the request may change the data it is scoped to. Outside a workspace users
+// own their data; inside one only viewers are read-only.
+func CanEdit(c echo.Context) bool {
+	membership := WorkspaceFrom(c)
+	return membership == nil || membership.Role != models.RoleViewer
+}
+
+// WorkspaceFrom returns the workspace the request selected, or nil when it works on the user's own data
+func WorkspaceFrom(c echo.Context) *Membership {
+	membership, _ := c.Get(workspaceContextKey).(*Membership)
+	return membership
+}
+
+// UserFrom returns the authenticated user of the request, or nil when authentication is off
+func UserFrom(c echo.Context) *models.User {
+	user, _ := c.Get(userContextKey).(*models.User)
+	return user
+}
+
+// authenticate returns the user behind the request's credentials, or nil when there are none or
+// they are not valid
+func authenticate(c echo.Context, users Accounts) (*models.User, error) {
+	var user *models.User
+	var err error
+	if key := requestAPIKey(c.Request()); key != "" {
+		user, err = users.GetUserByAPIKey(HashToken(key))
+	} else if cookie, cookieErr := c.Cookie(SessionCookie); cookieErr == nil && cookie.Value != "" {
+		user, err = users.GetUserBySession(HashToken(cookie.Value))
+	}
+
+	if errors.Is(err, database.ErrUserNotFound) {
+		return nil, nil
+	}
+	return user, err
+}
+
+func requestAPIKey(r *http.Request) string {
+	if key := r.Header.Get("X-API-Key"); key != "" {
+		return key
+	}
+	if token, ok := strings.CutPrefix(r.Header.Get(echo.HeaderAuthorization), "Bearer "); ok {
+		return strings.TrimSpace(token)
+	}
+	return ""
+}
@@ -0,0 +1,74 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// Argon2id parameters for new hashes, as recommended by RFC 9106 for memory-constrained servers:
+// 64 MiB, three passes
+const (
+	argon2Memory  = 64 * 1024
+	argon2Time    = 3
+	argon2Threads = 2
+	argon2KeyLen  = 32
+	saltLen       = 16
+)
+
+// HashPassword derives a salted Argon2id hash of password in the PHC string format,
+// $argon2id$v=19$m=65536,t=3,p=2$salt$hash
+func HashPassword(password string) (string, error) {
+	if password == "" {
+		return "", fmt.Errorf("password is required")
+	}
+
+	salt := make([]byte, saltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return "", fmt.Errorf("failed to generate salt: %v", err)
+	}
+
+	key := argon2.IDKey([]byte(password), salt, argon2Time, argon2Memory, argon2Threads, argon2KeyLen)
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s", argon2.Version, argon2Memory, argon2Time, argon2Threads,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+// CheckPassword reports whether password matches a hash from HashPassword. Malformed and empty
+// hashes never match.
+func CheckPassword(hash, password string) bool {
+	// "", "argon2id", "v=19", "m=65536,t=3,p=2", salt, hash
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 || parts[0] != "" || parts[1] != "argon2id" || parts[2] != fmt.Sprintf("v=%d", argon2.Version) {
+		return false
+	}
+	var memory, passes uint32
+	var threads uint8
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &memory, &passes, &threads); err != nil || memory == 0 || passes == 0 || threads == 0 {
+		return false
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return false
+	}
+	want, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(want) == 0 {
+		return false
+	}
+
+	got := argon2.IDKey([]byte(password), salt, passes, memory, threads, uint32(len(want)))
+	return subtle.ConstantTimeCompare(got, want) == 1
+}
+
+// dummyHash is checked against when a login names an unknown user, so the response takes as long
+// as for a wrong password
+var dummyHash = fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$AAAAAAAAAAAAAAAAAAAAAA$AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA",
+	argon2.Version, argon2Memory, argon2Time, argon2Threads)
+
+// CheckNoPassword spends the time of a password check without matching anything
+func CheckNoPassword(password string) {
+	CheckPassword(dummyHash, password)
+}
@@ -0,0 +1,49 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+)
+
+// APIKeyPrefix starts every API key so leaked keys are easy to recognize
+const APIKeyPrefix = "pf_"
+
+// apiKeyShownLen is how much of a key is kept to tell keys apart in listings
+const apiKeyShownLen = len(APIKeyPrefix) + 6
+
+// NewAPIKey generates an API key and returns it with its display prefix and the hash to store
+func NewAPIKey() (key, prefix, hash string, err error) {
+	secret, err := randomToken()
+	if err != nil {
+		return "", "", "", err
+	}
+	key = APIKeyPrefix + secret
+	return key, key[:apiKeyShownLen], HashToken(key), nil
+}
+
+// NewSessionToken generates a session token and returns it with the hash to store
+func NewSessionToken() (token, hash string, err error) {
+	token, err = randomToken()
+	if err != nil {
+		return "", "", err
+	}
+	return token, HashToken(token), nil
+}
+
+// HashToken returns the SHA-256 digest that API keys and session tokens are stored and looked up by.
+// Tokens carry 256 random bits, so an unsalted fast hash is enough.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func randomToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate token: %v", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,206 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// AzureDeployment maps a model to an Azure OpenAI deployment. A model may have deployments in several
+// regions; they are tried in the order they are configured.
+type AzureDeployment struct {
+	Model      string
+	Deployment string
+	Region     string
+	Endpoint   string // Defaults to azure_openai.base_url
+	APIVersion string // Defaults to azure_openai.api_version
+	APIKey     string // Defaults to azure_openai.api_key
+}
+
+// URL is the deployment's chat completions endpoint
+func (d AzureDeployment) URL() string {
+	return fmt.Sprintf("%s/openai/deployments/%s/chat/completions?api-version=%s", strings.TrimSuffix(d.Endpoint, "/"), d.Deployment, d.APIVersion)
+}
+
+// Name identifies the deployment in logs and errors, e.g. gpt-4.1 (eastus)
+func (d AzureDeployment) Name() string {
+	if d.Region == "" {
+		return d.Deployment
+	}
+	return d.Deployment + " (" + d.Region + ")"
+}
+
+// DeploymentsFor returns the deployments serving model, in order
+func (c AzureOpenAIConfig) DeploymentsFor(model string) []AzureDeployment {
+	var deployments []AzureDeployment
+	for _, d := range c.Deployments {
+		if d.Model == model {
+			deployments = append(deployments, d)
+		}
+	}
+	return deployments
+}
+
+// Models lists the models that have a deployment
+func (c AzureOpenAIConfig) Models() []string {
+	seen := map[string]bool{}
+	var names []string
+	for _, d := range c.Deployments {
+		if !seen[d.Model] {
+			seen[d.Model] = true
+			names = append(names, d.Model)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Configured reports whether any deployment has an API key
+func (c AzureOpenAIConfig) Configured() bool {
+	for _, d := range c.Deployments {
+		if d.APIKey != "" {
+			return true
+		}
+	}
+	return c.APIKey != ""
+}
+
+// GetEndpointURL builds the endpoint URL of the first deployment of model
+func GetEndpointURL(model string) (string, error) {
+	deployments := Current().AzureOpenAI.DeploymentsFor(model)
+	if len(deployments) == 0 {
+		return "", fmt.Errorf("no Azure OpenAI deployment configured for model %q", model)
+	}
+	return deployments[0].URL(), nil
+}
+
+// deploymentFields are the keys of a deployment in a config file
+var deploymentFields = []string{"model", "deployment", "region", "endpoint", "api_version", "api_key"}
+
+// deployments reads a list of deployment tables, from a config file or as YAML or JSON in the
+// environment, or the short form "model=deployment,model=deployment". Missing endpoints, API versions
+// and keys are filled in from azure_openai.
+func (p *parser) deployments(key string, defaults AzureOpenAIConfig) []AzureDeployment {
+	value := p.values[key].value
+	if s, ok := value.(string); ok && strings.HasPrefix(strings.TrimSpace(s), "[") {
+		var list []interface{}
+		if err := yaml.Unmarshal([]byte(s), &list); err != nil {
+			p.fail(key, "invalid list: %v", err)
+			return nil
+		}
+		value = list
+	}
+
+	var deployments []AzureDeployment
+	switch value := value.(type) {
+	case []interface{}:
+		for i, item := range value {
+			fields, ok := item.(map[string]interface{})
+			if !ok {
+				p.fail(key, "entry %d is not a table of %s", i+1, strings.Join(deploymentFields, ", "))
+				continue
+			}
+			d, err := parseDeployment(fields)
+			if err != nil {
+				p.fail(key, "entry %d: %v", i+1, err)
+				continue
+			}
+			deployments = append(deployments, d)
+		}
+	default:
+		for _, entry := range strings.Split(scalarString(value), ",") {
+			if entry = strings.TrimSpace(entry); entry == "" {
+				continue
+			}
+			model, deployment, _ := strings.Cut(entry, "=")
+			if strings.TrimSpace(model) == "" || strings.TrimSpace(deployment) == "" {
+				p.fail(key, "%q is not model=deployment", entry)
+				continue
+			}
+			deployments = append(deployments, AzureDeployment{Model: strings.TrimSpace(model), Deployment: strings.TrimSpace(deployment)})
+		}
+	}
+
+	seen := map[string]bool{}
+	for i := range deployments {
+		d := &deployments[i]
+		if seen[d.Model+"\x00"+d.Region] {
+			p.fail(key, "model %s has more than one deployment in region %q", d.Model, d.Region)
+		}
+		seen[d.Model+"\x00"+d.Region] = true
+		if d.Endpoint == "" {
+			d.Endpoint = defaults.BaseURL
+		}
+		if d.APIVersion == "" {
+			d.APIVersion = defaults.APIVersion
+		}
+		if d.APIKey == "" {
+			d.APIKey = defaults.APIKey
+		} else if apiKey, err := p.secret(d.APIKey); err != nil {
+			p.fail(key, "deployment %s: %v", d.Name(), err)
+		} else {
+			d.APIKey = apiKey
+		}
+	}
+	return deployments
+}
+
+func parseDeployment(fields map[string]interface{}) (AzureDeployment, error) {
+	var d AzureDeployment
+	for name, value := range fields {
+		s := scalarString(value)
+		switch name {
+		case "model":
+			d.Model = s
+		case "deployment":
+			d.Deployment = s
+		case "region":
+			d.Region = s
+		case "endpoint":
+			d.Endpoint = s
+		case "api_version":
+			d.APIVersion = s
+		case "api_key":
+			d.APIKey = s
+		default:
+			return d, fmt.Errorf("unknown field %q, expected %s", name, strings.Join(deploymentFields, ", "))
+		}
+	}
+	if d.Model == "" || d.Deployment == "" {
+		return d, fmt.Errorf("model and deployment are required")
+	}
+	return d, nil
+}
+
+// validateDeployments requires every deployment to be callable when Azure OpenAI is in use
+func (c *Config) validateDeployments(p *parser) {
+	azure := c.AzureOpenAI
+	if c.DefaultProvider != ProviderAzureOpenAI && !azure.Configured() {
+		return
+	}
+	if len(azure.Deployments) == 0 {
+		p.fail("azure_openai.deployments", "at least one deployment is required to use Azure OpenAI")
+	}
+	for _, d := range azure.Deployments {
+		if d.Endpoint == "" {
+			p.fail("azure_openai.deployments", "%s for %s has no endpoint: set its endpoint or azure_openai.base_url", d.Name(), d.Model)
+		}
+		if d.APIVersion == "" {
+			p.fail("azure_openai.deployments", "%s for %s has no API version: set its api_version or azure_openai.api_version", d.Name(), d.Model)
+		}
+		if d.APIKey == "" {
+			p.fail("azure_openai.deployments", "%s for %s has no API key: set its api_key or azure_openai.api_key", d.Name(), d.Model)
+		}
+	}
+}
+
+// describeDeployments shows deployments in /api/config without their keys
+func describeDeployments(deployments []AzureDeployment) string {
+	entries := make([]string, len(deployments))
+	for i, d := range deployments {
+		entries[i] = fmt.Sprintf("%s=%s %s", d.Model, d.Name(), d.URL())
+	}
+	return strings.Join(entries, ", ")
+}
@@ -2,7 +2,13 @@ package config
 
 import (
 	"fmt"
+	"net/url"
 	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"promptforge/internal/secrets"
 )
 
 // Provider types
@@ -16,10 +22,45 @@ const (
 
 // Configuration structure
 type Config struct {
+	Profile         string // Selected profile, if any
+	File            string // Config file read, if any
 	DefaultProvider AIProvider
+	Server          ServerConfig
 	OpenAI          OpenAIConfig
 	AzureOpenAI     AzureOpenAIConfig
 	Anthropic       AnthropicConfig
+	Database        DatabaseConfig
+	LibrarySync     LibrarySyncConfig
+	Context         ContextConfig
+	Backup          BackupConfig
+	Retention       RetentionConfig
+	Auth            AuthConfig
+	CORS            CORSConfig
+	Metrics         MetricsConfig
+	Tracing         TracingConfig
+	Logging         LoggingConfig
+	Watch           WatchConfig
+	Encryption      EncryptionConfig
+
+	effective []Setting
+	files     []string // Files the settings were read from, watched for changes
+}
+
+// EncryptionConfig holds the master key that encrypts the keystore and the workspace credentials
+// stored in the database, and the path of the keystore
+type EncryptionConfig struct {
+	MasterKey string
+	Keystore  string
+}
+
+// WatchConfig controls how often config and secret files are checked for changes. Zero turns
+// checking off; SIGHUP still reloads.
+type WatchConfig struct {
+	Interval time.Duration
+}
+
+type ServerConfig struct {
+	Port string
 }
 
 type OpenAIConfig struct {
@@ -28,9 +69,10 @@ type OpenAIConfig struct {
 }
 
 type AzureOpenAIConfig struct {
-	APIKey     string
-	BaseURL    string
-	APIVersion string
+	APIKey      string
+	BaseURL     string
+	APIVersion  string
+	Deployments []AzureDeployment
 }
 
 type AnthropicConfig struct {
@@ -38,61 +80,412 @@ type AnthropicConfig struct {
 	BaseURL string // Optional, for custom endpoints
 }
 
+type DatabaseConfig struct {
+	Driver        string // "sqlite" or "postgres"
+	Path          string // SQLite database file
+	URL           string // PostgreSQL connection string
+	AutoMigrate   bool   // Apply pending schema migrations on startup
+	MigrateDryRun bool   // Report pending migrations on startup and exit without applying them
+}
+
+// LibrarySyncConfig mirrors the prompt library to a git working tree. Sync is off when Dir is empty.
+type LibrarySyncConfig struct {
+	Dir         string // Git working tree
+	Path        string // Directory for prompt files inside the working tree
+	Remote      string
+	Branch      string // Empty uses the current branch's upstream
+	Push        bool   // Push after every commit
+	AuthorName  string
+	AuthorEmail string
+	User        string // Whose prompts are synced, and who owns imported ones; required with authentication
+	Workspace   int64  // Syncs this workspace's prompts instead of User's own; User must be a member
+}
+
+// ContextConfig controls how long conversations are fitted into a model's context window
+type ContextConfig struct {
+	SummaryModel     string  // Cheaper model that summarizes older turns
+	Threshold        float64 // Fraction of the context window a request may fill before older turns are summarized
+	KeepRecent       int     // Most recent messages that are always sent verbatim
+	SummaryMaxTokens int     // Length limit for a summary
+}
+
+// BackupConfig controls SQLite backups. Scheduled backups are off when Interval is zero.
+type BackupConfig struct {
+	Dir      string
+	Interval time.Duration
+	Keep     int // Newest backups kept by scheduled backups; zero keeps all
+}
+
+// RetentionConfig limits how much history and how many conversations are kept. Zero limits are not enforced.
+type RetentionConfig struct {
+	HistoryMaxAge       time.Duration
+	HistoryMaxRows      int
+	ConversationMaxAge  time.Duration
+	ConversationMaxRows int
+	Interval            time.Duration // How often the retention job runs
+}
+
+// Enabled reports whether any retention limit is set
+func (c RetentionConfig) Enabled() bool {
+	return c.HistoryMaxAge > 0 || c.HistoryMaxRows > 0 || c.ConversationMaxAge > 0 || c.ConversationMaxRows > 0
+}
+
+// AuthConfig controls authentication. With it off every request is anonymous and sees all data.
+type AuthConfig struct {
+	Enabled      bool
+	SessionTTL   time.Duration // How long a login session lasts
+	CookieSecure bool          // Only send the session cookie over HTTPS
+}
+
+// CORSConfig lists the origins allowed to call the API from a browser. Only same-origin requests
+// are allowed when it is empty.
+type CORSConfig struct {
+	AllowedOrigins []string
+}
+
+// MetricsConfig controls the Prometheus endpoint at /metrics. Listen is a separate address to serve
+// it on, such as :9090; when empty it is served by the API server.
+type MetricsConfig struct {
+	Enabled bool
+	Listen  string
+}
+
+// TracingConfig controls where trace spans are exported. Tracing is off when Exporter is "none".
+type TracingConfig struct {
+	Exporter    string // "none", "stdout" or "otlp"
+	Endpoint    string // OTLP/HTTP collector, e.g. http://localhost:4318
+	ServiceName string
+	SampleRatio float64 // Fraction of new traces recorded; incoming sampled traces are always recorded
+}
+
+// LoggingConfig controls the structured log written to stdout
+type LoggingConfig struct {
+	Level         string // debug, info, warn or error
+	Format        string // json or text
+	RedactPrompts bool   // Replace prompt and response text in log entries with its length
+}
+
+// DSN returns the data source for the configured driver
+func (c DatabaseConfig) DSN() string {
+	if c.Driver == "postgres" {
+		return c.URL
+	}
+	return c.Path
+}
+
 // Global configuration instance
 var AppConfig *Config
 
-// Initialize configuration from environment variables
-func InitConfig() {
-	AppConfig = &Config{
-		DefaultProvider: getDefaultProvider(),
+// InitConfig loads the configuration into AppConfig and makes it current. It fails on unknown
+// settings or providers, invalid values and missing required settings.
+func InitConfig() error {
+	cfg, err := Load()
+	if err != nil {
+		return err
+	}
+	AppConfig = cfg
+	Set(cfg)
+	return nil
+}
+
+// Load reads the configuration. Each setting comes from the first of these that sets it: its
+// environment variable, the profile's overlay file (e.g. promptforge.prod.yaml), the profiles
+// section of the config file, the config file, the profile's defaults, and the built-in default.
+func Load() (*Config, error) {
+	src, err := readSources()
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{values: resolve(src.layers)}
+	p.resolveReferences()
+	cfg := build(p)
+	cfg.Profile = src.profile
+	cfg.File = src.path
+	cfg.files = append(src.files, p.files...)
+	if err := cfg.validate(p); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// sources are the layers of settings in order of precedence, and the files they were read from
+type sources struct {
+	layers  []layer
+	profile string
+	path    string
+	files   []string
+}
+
+func readSources() (*sources, error) {
+	path, err := findConfigFile()
+	if err != nil {
+		return nil, err
+	}
+
+	var layers []layer
+	var files []string
+	var file *configFile
+	if path != "" {
+		files = append(files, path)
+		if file, err = readConfigFile(path, true); err != nil {
+			return nil, err
+		}
+	}
+
+	profile := os.Getenv(ProfileEnv)
+	if profile == "" && file != nil {
+		profile = file.profile
+	}
+	if profile != "" {
+		defaults, builtIn := profiles[profile]
+		var section map[string]interface{}
+		if file != nil {
+			section = file.profiles[profile]
+		}
+		if !builtIn && section == nil {
+			return nil, fmt.Errorf("unknown profile %q, expected dev, staging, prod or one defined in the config file", profile)
+		}
+
+		values := map[string]interface{}{}
+		for key, value := range defaults {
+			values[key] = value
+		}
+		layers = append(layers, layer{name: "profile " + profile, values: values})
+		if file != nil {
+			layers = append(layers, layer{name: file.path, values: file.values})
+			layers = append(layers, layer{name: file.path + " (profiles." + profile + ")", values: section})
+
+			overlay := overlayPath(file.path, profile)
+			files = append(files, overlay) // Watched even before it exists
+			if _, err := os.Stat(overlay); err == nil {
+				overlayFile, err := readConfigFile(overlay, false)
+				if err != nil {
+					return nil, err
+				}
+				layers = append(layers, layer{name: overlay, values: overlayFile.values})
+			}
+		}
+	} else if file != nil {
+		layers = append(layers, layer{name: file.path, values: file.values})
+	}
+	layers = append(layers, envLayer())
+	fileLayers, valueFiles, err := fileLayers()
+	if err != nil {
+		return nil, err
+	}
+	layers = append(layers, fileLayers...)
+	files = append(files, valueFiles...)
+
+	return &sources{layers: layers, profile: profile, path: path, files: files}, nil
+}
+
+// build converts resolved settings into a Config, collecting invalid values in p
+func build(p *parser) *Config {
+	cfg := &Config{
+		DefaultProvider: AIProvider(p.string("default_provider")),
+		Server: ServerConfig{
+			Port: p.string("server.port"),
+		},
 		OpenAI: OpenAIConfig{
-			APIKey:  getEnv("OPENAI_API_KEY", ""),
-			BaseURL: getEnv("OPENAI_BASE_URL", "https://api.openai.com/v1"),
+			APIKey:  p.string("openai.api_key"),
+			BaseURL: p.string("openai.base_url"),
 		},
 		AzureOpenAI: AzureOpenAIConfig{
-			APIKey:     getEnv("AZURE_OPENAI_API_KEY", ""),
-			BaseURL:    getEnv("AZURE_OPENAI_BASE_URL", "https://it-li-m9l4hi9c-eastus2.cognitiveservices.azure.com/"),
-			APIVersion: getEnv("AZURE_OPENAI_API_VERSION", ""),
+			APIKey:     p.string("azure_openai.api_key"),
+			BaseURL:    p.string("azure_openai.base_url"),
+			APIVersion: p.string("azure_openai.api_version"),
 		},
 		Anthropic: AnthropicConfig{
-			APIKey:  getEnv("ANTHROPIC_API_KEY", ""),
-			BaseURL: getEnv("ANTHROPIC_BASE_URL", "https://api.anthropic.com"),
+			APIKey:  p.string("anthropic.api_key"),
+			BaseURL: p.string("anthropic.base_url"),
+		},
+		Database: DatabaseConfig{
+			Driver:        p.string("database.driver"),
+			Path:          p.string("database.path"),
+			URL:           p.string("database.url"),
+			AutoMigrate:   p.bool("database.auto_migrate"),
+			MigrateDryRun: p.bool("database.migrate_dry_run"),
+		},
+		LibrarySync: LibrarySyncConfig{
+			Dir:         p.string("library_sync.dir"),
+			Path:        p.string("library_sync.path"),
+			Remote:      p.string("library_sync.remote"),
+			Branch:      p.string("library_sync.branch"),
+			Push:        p.bool("library_sync.push"),
+			AuthorName:  p.string("library_sync.author_name"),
+			AuthorEmail: p.string("library_sync.author_email"),
+			User:        p.string("library_sync.user"),
+			Workspace:   int64(p.int("library_sync.workspace")),
+		},
+		Context: ContextConfig{
+			SummaryModel:     p.string("context.summary_model"),
+			Threshold:        p.float("context.compact_threshold"),
+			KeepRecent:       p.int("context.keep_recent"),
+			SummaryMaxTokens: p.int("context.summary_max_tokens"),
+		},
+		Backup: BackupConfig{
+			Dir:      p.string("backup.dir"),
+			Interval: p.duration("backup.interval"),
+			Keep:     p.int("backup.keep"),
+		},
+		Retention: RetentionConfig{
+			HistoryMaxAge:       p.duration("retention.history_max_age"),
+			HistoryMaxRows:      p.int("retention.history_max_rows"),
+			ConversationMaxAge:  p.duration("retention.conversation_max_age"),
+			ConversationMaxRows: p.int("retention.conversation_max_rows"),
+			Interval:            p.duration("retention.interval"),
+		},
+		Auth: AuthConfig{
+			Enabled:      p.bool("auth.enabled"),
+			SessionTTL:   p.duration("auth.session_ttl"),
+			CookieSecure: p.bool("auth.cookie_secure"),
+		},
+		CORS: CORSConfig{
+			AllowedOrigins: p.list("cors.allowed_origins"),
+		},
+		Metrics: MetricsConfig{
+			Enabled: p.bool("metrics.enabled"),
+			Listen:  p.string("metrics.listen"),
+		},
+		Tracing: TracingConfig{
+			Exporter:    p.string("tracing.exporter"),
+			Endpoint:    p.string("tracing.endpoint"),
+			ServiceName: p.string("tracing.service_name"),
+			SampleRatio: p.float("tracing.sample_ratio"),
+		},
+		Logging: LoggingConfig{
+			Level:         p.string("logging.level"),
+			Format:        p.string("logging.format"),
+			RedactPrompts: p.bool("logging.redact_prompts"),
+		},
+		Watch: WatchConfig{
+			Interval: p.duration("config.watch_interval"),
+		},
+		Encryption: EncryptionConfig{
+			MasterKey: p.string("encryption.master_key"),
+			Keystore:  p.string("encryption.keystore"),
 		},
 	}
+	cfg.AzureOpenAI.Deployments = p.deployments("azure_openai.deployments", cfg.AzureOpenAI)
+	cfg.effective = effective(p.values)
+	return cfg
 }
 
-func getDefaultProvider() AIProvider {
-	provider := getEnv("DEFAULT_AI_PROVIDER", "anthropic")
-	switch provider {
-	case "openai":
-		return ProviderOpenAI
-	case "anthropic":
-		return ProviderAnthropic
-	default:
-		return ProviderAzureOpenAI
+// validate reports every invalid value and missing required setting at once
+func (c *Config) validate(p *parser) error {
+	if _, err := ParseProvider(string(c.DefaultProvider)); err != nil {
+		p.fail("default_provider", "%v", err)
+	}
+	if port, err := strconv.Atoi(c.Server.Port); err != nil || port < 1 || port > 65535 {
+		p.fail("server.port", "%q is not a port number", c.Server.Port)
+	}
+	p.oneOf("database.driver", "sqlite", "postgres")
+	p.oneOf("tracing.exporter", "none", "stdout", "otlp")
+	p.oneOf("logging.level", "debug", "info", "warn", "error")
+	p.oneOf("logging.format", "json", "text")
+	if c.Context.Threshold <= 0 || c.Context.Threshold > 1 {
+		p.fail("context.compact_threshold", "must be above 0 and at most 1")
+	}
+	if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+		p.fail("tracing.sample_ratio", "must be between 0 and 1")
+	}
+
+	require := func(key, value, why string) {
+		if value == "" {
+			p.fail(key, "required %s", why)
+		}
+	}
+	// With a master key, workspaces may bring their own provider keys, so the server needs none
+	if c.Encryption.MasterKey == "" {
+		switch c.DefaultProvider {
+		case ProviderOpenAI:
+			require("openai.api_key", c.OpenAI.APIKey, "for the default provider")
+		case ProviderAnthropic:
+			require("anthropic.api_key", c.Anthropic.APIKey, "for the default provider")
+		}
+	}
+	c.validateDeployments(p)
+	if c.Database.Driver == "postgres" {
+		require("database.url", c.Database.URL, "for the postgres driver")
+	}
+	if c.Encryption.MasterKey != "" {
+		if _, err := secrets.NewCipher(c.Encryption.MasterKey); err != nil {
+			p.fail("encryption.master_key", "%v", err)
+		}
+	}
+	if c.LibrarySync.Dir != "" && c.Auth.Enabled {
+		require("library_sync.user", c.LibrarySync.User, "for library sync with authentication")
 	}
+	if c.LibrarySync.Workspace != 0 && c.LibrarySync.User == "" {
+		p.fail("library_sync.workspace", "needs library_sync.user, a member of the workspace")
+	}
+	if c.Tracing.Exporter == "otlp" {
+		require("tracing.endpoint", c.Tracing.Endpoint, "for the otlp exporter")
+	}
+
+	if len(p.errs) > 0 {
+		return fmt.Errorf("invalid configuration:\n  %s", strings.Join(p.errs, "\n  "))
+	}
+	return nil
 }
 
-func getEnv(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
-		return value
+// Effective lists every setting with its value and where it came from. Secret values are replaced
+// with "[set]" when they are set.
+func (c *Config) Effective() []Setting {
+	out := make([]Setting, len(c.effective))
+	copy(out, c.effective)
+	for i := range out {
+		if out[i].Secret && out[i].Value != "" {
+			out[i].Value = "[set]"
+		}
+		if out[i].Key == "azure_openai.deployments" {
+			out[i].Value = describeDeployments(c.AzureOpenAI.Deployments)
+		}
 	}
-	return defaultValue
+	return out
 }
 
-// Model deployment mappings for Azure OpenAI (backwards compatibility)
-var ModelDeployments = map[string]string{
-	"gpt-4.1": "gpt-4.1",
-	"o3":      "o3",
+// Secrets lists the configured credentials, which are never written to logs
+func (c *Config) Secrets() []string {
+	var secrets []string
+	candidates := []string{c.OpenAI.APIKey, c.AzureOpenAI.APIKey, c.Anthropic.APIKey, c.Encryption.MasterKey}
+	for _, d := range c.AzureOpenAI.Deployments {
+		candidates = append(candidates, d.APIKey)
+	}
+	for _, secret := range candidates {
+		if secret != "" {
+			secrets = append(secrets, secret)
+		}
+	}
+	if u, err := url.Parse(c.Database.URL); err == nil && u.User != nil {
+		if password, ok := u.User.Password(); ok && password != "" {
+			secrets = append(secrets, password)
+		}
+	}
+	return secrets
+}
+
+// ParseProvider accepts the supported provider names
+func ParseProvider(name string) (AIProvider, error) {
+	switch provider := AIProvider(name); provider {
+	case ProviderOpenAI, ProviderAzureOpenAI, ProviderAnthropic:
+		return provider, nil
+	default:
+		return "", fmt.Errorf("unknown provider %q, expected openai, azure-openai or anthropic", name)
+	}
 }
 
-// GetEndpointURL builds the complete endpoint URL for Azure OpenAI (backwards compatibility)
-func GetEndpointURL(model string) string {
-	deployment, exists := ModelDeployments[model]
-	if !exists {
-		deployment = "gpt-4.1" // fallback to default
+// ParseDuration parses a Go duration or a number of days with a "d" suffix
+func ParseDuration(value string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid duration %q", value)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
 	}
-	return fmt.Sprintf("%s/openai/deployments/%s/chat/completions?api-version=%s", AppConfig.AzureOpenAI.BaseURL, deployment, AppConfig.AzureOpenAI.APIVersion)
+	return time.ParseDuration(value)
 }
 
 // Backward compatibility constants (deprecated - use AppConfig instead)
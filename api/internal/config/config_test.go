@@ -2,28 +2,21 @@ package config
 
 import (
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestInitConfig(t *testing.T) {
-	// Save original env vars
-	originalProvider := os.Getenv("DEFAULT_AI_PROVIDER")
-	originalOpenAIKey := os.Getenv("OPENAI_API_KEY")
-	originalAnthropicKey := os.Getenv("ANTHROPIC_API_KEY")
+	clearEnv(t)
+	t.Setenv("DEFAULT_AI_PROVIDER", "openai")
+	t.Setenv("OPENAI_API_KEY", "test-openai-key")
+	t.Setenv("ANTHROPIC_API_KEY", "test-anthropic-key")
 
-	// Clean up after test
-	defer func() {
-		os.Setenv("DEFAULT_AI_PROVIDER", originalProvider)
-		os.Setenv("OPENAI_API_KEY", originalOpenAIKey)
-		os.Setenv("ANTHROPIC_API_KEY", originalAnthropicKey)
-	}()
-
-	// Test with environment variables set
-	os.Setenv("DEFAULT_AI_PROVIDER", "openai")
-	os.Setenv("OPENAI_API_KEY", "test-openai-key")
-	os.Setenv("ANTHROPIC_API_KEY", "test-anthropic-key")
-
-	InitConfig()
+	if err := InitConfig(); err != nil {
+		t.Fatalf("Failed to load configuration: %v", err)
+	}
 
 	if AppConfig == nil {
 		t.Fatal("AppConfig should not be nil after initialization")
@@ -40,64 +33,228 @@ func TestInitConfig(t *testing.T) {
 	if AppConfig.Anthropic.APIKey != "test-anthropic-key" {
 		t.Errorf("Expected Anthropic API key to be 'test-anthropic-key', got %s", AppConfig.Anthropic.APIKey)
 	}
+
+	if AppConfig.Retention.Interval != time.Hour || AppConfig.Auth.SessionTTL != 7*24*time.Hour || AppConfig.AzureOpenAI.BaseURL != "" {
+		t.Errorf("Expected built-in defaults, got %+v", AppConfig)
+	}
 }
 
-func TestGetDefaultProvider(t *testing.T) {
+func TestParseProvider(t *testing.T) {
 	tests := []struct {
-		envValue string
+		value    string
 		expected AIProvider
+		valid    bool
 	}{
-		{"openai", ProviderOpenAI},
-		{"anthropic", ProviderAnthropic},
-		{"azure-openai", ProviderAzureOpenAI},
-		{"invalid", ProviderAzureOpenAI}, // Should default to AzureOpenAI
-		{"", ProviderAnthropic},          // Should default to Anthropic (as per getEnv default)
+		{"openai", ProviderOpenAI, true},
+		{"anthropic", ProviderAnthropic, true},
+		{"azure-openai", ProviderAzureOpenAI, true},
+		{"invalid", "", false}, // No longer falls back to Azure OpenAI
+		{"", "", false},
 	}
 
-	originalProvider := os.Getenv("DEFAULT_AI_PROVIDER")
-	defer os.Setenv("DEFAULT_AI_PROVIDER", originalProvider)
-
 	for _, test := range tests {
-		os.Setenv("DEFAULT_AI_PROVIDER", test.envValue)
-		result := getDefaultProvider()
-		if result != test.expected {
-			t.Errorf("For env value '%s', expected %s, got %s", test.envValue, test.expected, result)
+		result, err := ParseProvider(test.value)
+		if result != test.expected || (err == nil) != test.valid {
+			t.Errorf("For value '%s', expected %s, got %s (%v)", test.value, test.expected, result, err)
 		}
 	}
 }
 
-func TestGetEnv(t *testing.T) {
-	tests := []struct {
-		key          string
-		defaultValue string
-		envValue     string
-		expected     string
-	}{
-		{"TEST_KEY", "default", "env_value", "env_value"},
-		{"NONEXISTENT_KEY", "default", "", "default"},
-	}
+func TestValidation(t *testing.T) {
+	clearEnv(t)
+	t.Setenv("DEFAULT_AI_PROVIDER", "azure")
+	t.Setenv("AUTH_ENABLED", "yes please")
+	t.Setenv("LIBRARY_SYNC_WORKSPACE", "3")
+	t.Setenv("HISTORY_MAX_AGE", "-2d")
+	t.Setenv("DB_DRIVER", "postgres")
 
-	for _, test := range tests {
-		if test.envValue != "" {
-			os.Setenv(test.key, test.envValue)
-			defer os.Unsetenv(test.key)
+	_, err := Load()
+	if err == nil {
+		t.Fatal("Expected invalid configuration to fail")
+	}
+	for _, want := range []string{
+		`unknown provider "azure"`,
+		"auth.enabled (AUTH_ENABLED, from env)",
+		"retention.history_max_age",
+		"database.url (DATABASE_URL, from default): required for the postgres driver",
+		"library_sync.workspace (LIBRARY_SYNC_WORKSPACE, from env): needs library_sync.user",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected %q in %v", want, err)
 		}
+	}
 
-		result := getEnv(test.key, test.defaultValue)
-		if result != test.expected {
-			t.Errorf("For key '%s', expected '%s', got '%s'", test.key, test.expected, result)
-		}
+	t.Setenv("DEFAULT_AI_PROVIDER", "azure-openai")
+	t.Setenv("AUTH_ENABLED", "")
+	t.Setenv("HISTORY_MAX_AGE", "30d")
+	t.Setenv("DB_DRIVER", "")
+	t.Setenv("LIBRARY_SYNC_WORKSPACE", "")
+	t.Setenv("AZURE_OPENAI_API_KEY", "azure-key")
+	if _, err := Load(); err == nil || !strings.Contains(err.Error(), "azure_openai.base_url") || !strings.Contains(err.Error(), "azure_openai.api_version") {
+		t.Errorf("Expected Azure OpenAI to need an endpoint and API version, got %v", err)
+	}
+
+	t.Setenv("DEFAULT_AI_PROVIDER", "anthropic")
+	t.Setenv("AZURE_OPENAI_API_KEY", "")
+	if _, err := Load(); err == nil || !strings.Contains(err.Error(), "anthropic.api_key") {
+		t.Errorf("Expected the default provider to need a key, got %v", err)
+	}
+	t.Setenv("PROMPTFORGE_MASTER_KEY", "MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY=")
+	if _, err := Load(); err != nil {
+		t.Errorf("Expected workspaces to be able to bring the only keys once a master key is set, got %v", err)
+	}
+}
+
+func TestConfigFile(t *testing.T) {
+	clearEnv(t)
+	dir := t.TempDir()
+	path := filepath.Join(dir, "promptforge.yaml")
+	writeFile(t, path, `
+profile: prod
+default_provider: openai
+openai:
+  api_key: file-key
+database:
+  path: /data/base.db
+cors:
+  allowed_origins: [https://a.example.com, https://b.example.com]
+profiles:
+  prod:
+    database:
+      path: /data/prod.db
+    logging:
+      level: warn
+`)
+	writeFile(t, filepath.Join(dir, "promptforge.prod.yaml"), "logging:\n  level: error\nbackup:\n  interval: 6h\n")
+	t.Setenv(FileEnv, path)
+	t.Setenv("BACKUP_KEEP", "3")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Failed to load configuration: %v", err)
+	}
+	if cfg.Profile != "prod" || !cfg.Auth.Enabled || !cfg.Auth.CookieSecure {
+		t.Errorf("Expected the prod profile's defaults, got %+v", cfg.Auth)
+	}
+	if cfg.Database.Path != "/data/prod.db" || cfg.Logging.Level != "error" || cfg.Backup.Interval != 6*time.Hour || cfg.Backup.Keep != 3 {
+		t.Errorf("Expected profile section, overlay file and environment to override the file, got %+v %+v %+v", cfg.Database, cfg.Logging, cfg.Backup)
+	}
+	if len(cfg.CORS.AllowedOrigins) != 2 {
+		t.Errorf("Expected a list from the file, got %v", cfg.CORS.AllowedOrigins)
+	}
+
+	sources := map[string]Setting{}
+	for _, s := range cfg.Effective() {
+		sources[s.Key] = s
+	}
+	if s := sources["openai.api_key"]; s.Value != "[set]" || s.Source != path {
+		t.Errorf("Expected the API key to be hidden, got %+v", s)
+	}
+	if s := sources["backup.keep"]; s.Source != "env" {
+		t.Errorf("Expected backup.keep to come from the environment, got %+v", s)
+	}
+	if s := sources["auth.enabled"]; s.Source != "profile prod" {
+		t.Errorf("Expected auth.enabled to come from the profile, got %+v", s)
+	}
+
+	t.Setenv(ProfileEnv, "qa")
+	if _, err := Load(); err == nil || !strings.Contains(err.Error(), `unknown profile "qa"`) {
+		t.Errorf("Expected an unknown profile to fail, got %v", err)
+	}
+
+	writeFile(t, path, "openai:\n  api_key: x\n  base_ulr: https://typo.example.com\n")
+	t.Setenv(ProfileEnv, "")
+	if _, err := Load(); err == nil || !strings.Contains(err.Error(), `unknown setting "openai.base_ulr"`) {
+		t.Errorf("Expected an unknown setting to fail, got %v", err)
+	}
+}
+
+func TestConfigFileTOML(t *testing.T) {
+	clearEnv(t)
+	path := filepath.Join(t.TempDir(), "promptforge.toml")
+	writeFile(t, path, `
+default_provider = "anthropic" # the default anyway
+context.keep_recent = 4
+tracing = { exporter = "stdout", sample_ratio = 0.25 }
+
+[anthropic]
+api_key = 'toml-key'
+
+[azure_openai]
+api_version = 2024-10-21
+
+[cors]
+allowed_origins = [
+  "https://a.example.com",
+  "https://b.example.com#frag", # trailing comma
+]
+
+[profiles.dev.context]
+compact_threshold = 0.5
+`)
+	t.Setenv(FileEnv, path)
+	t.Setenv(ProfileEnv, "dev")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Failed to load configuration: %v", err)
+	}
+	if cfg.Anthropic.APIKey != "toml-key" || cfg.Context.KeepRecent != 4 || cfg.Context.Threshold != 0.5 || cfg.Logging.Format != "text" {
+		t.Errorf("Expected settings from the TOML file and dev profile, got %+v %+v", cfg.Context, cfg.Logging)
+	}
+	if len(cfg.CORS.AllowedOrigins) != 2 || cfg.CORS.AllowedOrigins[1] != "https://b.example.com#frag" {
+		t.Errorf("Expected a TOML array, got %v", cfg.CORS.AllowedOrigins)
+	}
+	if cfg.Tracing.Exporter != "stdout" || cfg.Tracing.SampleRatio != 0.25 || cfg.AzureOpenAI.APIVersion != "2024-10-21" {
+		t.Errorf("Expected an inline table and a local date, got %+v and %q", cfg.Tracing, cfg.AzureOpenAI.APIVersion)
+	}
+
+	if _, err := parseTOML("[anthropic]\napi_key = 'a'\napi_key = 'b'\n"); err == nil {
+		t.Error("Expected a duplicate key to fail")
+	}
+}
+
+// clearEnv unsets every setting's environment variable for the test
+func clearEnv(t *testing.T) {
+	t.Helper()
+	for _, s := range settings {
+		t.Setenv(s.env, "")
+		t.Setenv(s.env+"_FILE", "")
+	}
+	t.Setenv(FileEnv, "")
+	t.Setenv(ProfileEnv, "")
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to write %s: %v", path, err)
+	}
+}
+
+func TestDatabaseDSN(t *testing.T) {
+	sqlite := DatabaseConfig{Driver: "sqlite", Path: "./test.db", URL: "postgres://ignored"}
+	if sqlite.DSN() != "./test.db" {
+		t.Errorf("Expected SQLite DSN to be the file path, got '%s'", sqlite.DSN())
+	}
+
+	postgres := DatabaseConfig{Driver: "postgres", Path: "./test.db", URL: "postgres://localhost/promptforge"}
+	if postgres.DSN() != "postgres://localhost/promptforge" {
+		t.Errorf("Expected PostgreSQL DSN to be the URL, got '%s'", postgres.DSN())
 	}
 }
 
 func TestGetEndpointURL(t *testing.T) {
-	// Initialize AppConfig for testing
-	AppConfig = &Config{
+	previous := Current()
+	defer Set(previous)
+	Set(&Config{
 		AzureOpenAI: AzureOpenAIConfig{
-			BaseURL:    "https://test.openai.azure.com",
-			APIVersion: "2024-02-15-preview",
+			Deployments: []AzureDeployment{
+				{Model: "gpt-4.1", Deployment: "gpt-4.1", Endpoint: "https://test.openai.azure.com", APIVersion: "2024-02-15-preview"},
+				{Model: "o3", Deployment: "o3", Endpoint: "https://test.openai.azure.com/", APIVersion: "2024-02-15-preview"},
+			},
 		},
-	}
+	})
 
 	tests := []struct {
 		model    string
@@ -105,13 +262,124 @@ func TestGetEndpointURL(t *testing.T) {
 	}{
 		{"gpt-4.1", "https://test.openai.azure.com/openai/deployments/gpt-4.1/chat/completions?api-version=2024-02-15-preview"},
 		{"o3", "https://test.openai.azure.com/openai/deployments/o3/chat/completions?api-version=2024-02-15-preview"},
-		{"unknown-model", "https://test.openai.azure.com/openai/deployments/gpt-4.1/chat/completions?api-version=2024-02-15-preview"}, // Should fallback to gpt-4.1
+		{"unknown-model", ""}, // No longer falls back to gpt-4.1
 	}
 
 	for _, test := range tests {
-		result := GetEndpointURL(test.model)
-		if result != test.expected {
-			t.Errorf("For model '%s', expected '%s', got '%s'", test.model, test.expected, result)
+		result, err := GetEndpointURL(test.model)
+		if result != test.expected || (err != nil) != (test.expected == "") {
+			t.Errorf("For model '%s', expected '%s', got '%s' (%v)", test.model, test.expected, result, err)
 		}
 	}
 }
+
+func TestAzureDeployments(t *testing.T) {
+	clearEnv(t)
+	path := filepath.Join(t.TempDir(), "promptforge.yaml")
+	writeFile(t, path, `
+default_provider: azure-openai
+azure_openai:
+  api_key: shared-key
+  api_version: 2024-10-21
+  deployments:
+    - model: gpt-4o
+      deployment: gpt4o-east
+      region: eastus
+      endpoint: https://east.openai.azure.com
+    - model: gpt-4o
+      deployment: gpt4o-west
+      region: westeurope
+      endpoint: https://west.openai.azure.com
+      api_key: west-key
+      api_version: 2025-01-01-preview
+`)
+	t.Setenv(FileEnv, path)
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Failed to load configuration: %v", err)
+	}
+	deployments := cfg.AzureOpenAI.DeploymentsFor("gpt-4o")
+	if len(deployments) != 2 || len(cfg.AzureOpenAI.DeploymentsFor("gpt-4.1")) != 0 {
+		t.Fatalf("Expected the file's deployments to replace the defaults, got %+v", cfg.AzureOpenAI.Deployments)
+	}
+	if deployments[0].APIKey != "shared-key" || deployments[0].URL() != "https://east.openai.azure.com/openai/deployments/gpt4o-east/chat/completions?api-version=2024-10-21" {
+		t.Errorf("Expected shared settings to fill in the first deployment, got %+v", deployments[0])
+	}
+	if deployments[1].APIKey != "west-key" || deployments[1].APIVersion != "2025-01-01-preview" {
+		t.Errorf("Expected the second deployment's own key and API version, got %+v", deployments[1])
+	}
+	for _, s := range cfg.Effective() {
+		if s.Key == "azure_openai.deployments" && strings.Contains(s.Value, "west-key") {
+			t.Errorf("Expected deployment keys to be hidden, got %s", s.Value)
+		}
+	}
+	if secrets := strings.Join(cfg.Secrets(), ","); !strings.Contains(secrets, "west-key") {
+		t.Errorf("Expected deployment keys among the secrets, got %s", secrets)
+	}
+
+	t.Setenv(FileEnv, "")
+	t.Setenv("AZURE_OPENAI_API_KEY", "key")
+	t.Setenv("AZURE_OPENAI_API_VERSION", "2024-10-21")
+	t.Setenv("AZURE_OPENAI_DEPLOYMENTS", `[{"model": "gpt-4o", "deployment": "gpt4o", "endpoint": "https://east.openai.azure.com"}, {"model": "o3", "deployment": "o3"}]`)
+	_, err = Load()
+	if err == nil || !strings.Contains(err.Error(), "o3 for o3 has no endpoint") || strings.Contains(err.Error(), "gpt4o for gpt-4o") {
+		t.Errorf("Expected only the deployment without an endpoint to fail, got %v", err)
+	}
+
+	t.Setenv("AZURE_OPENAI_BASE_URL", "https://test.openai.azure.com")
+	t.Setenv("AZURE_OPENAI_DEPLOYMENTS", "gpt-4o=gpt4o, o3")
+	if _, err = Load(); err == nil || !strings.Contains(err.Error(), `"o3" is not model=deployment`) {
+		t.Errorf("Expected an entry without a deployment to fail, got %v", err)
+	}
+}
+
+func TestAzureDeploymentsTOML(t *testing.T) {
+	clearEnv(t)
+	path := filepath.Join(t.TempDir(), "promptforge.toml")
+	writeFile(t, path, `
+default_provider = "azure-openai"
+
+[azure_openai]
+api_key = "shared-key"
+api_version = 2024-10-21
+
+[[azure_openai.deployments]]
+model = "gpt-4o"
+deployment = "gpt4o-east"
+region = "eastus"
+endpoint = "https://east.openai.azure.com"
+
+[[azure_openai.deployments]]
+model = "gpt-4o"
+deployment = "gpt4o-west"
+region = "westeurope"
+endpoint = "https://west.openai.azure.com"
+api_key = "west-key"
+
+[[azure_openai.deployments]]
+model = "o3"
+deployment = "o3"
+region = "eastus"
+endpoint = "https://east.openai.azure.com"
+`)
+	t.Setenv(FileEnv, path)
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Failed to load configuration: %v", err)
+	}
+	deployments := cfg.AzureOpenAI.DeploymentsFor("gpt-4o")
+	if len(deployments) != 2 || deployments[0].Name() != "gpt4o-east (eastus)" || deployments[1].Name() != "gpt4o-west (westeurope)" {
+		t.Fatalf("Expected both regions in file order for failover, got %+v", deployments)
+	}
+	if deployments[0].APIKey != "shared-key" || deployments[0].URL() != "https://east.openai.azure.com/openai/deployments/gpt4o-east/chat/completions?api-version=2024-10-21" {
+		t.Errorf("Expected shared settings to fill in the first deployment, got %+v", deployments[0])
+	}
+	if deployments[1].APIKey != "west-key" || deployments[1].Endpoint != "https://west.openai.azure.com" {
+		t.Errorf("Expected the second deployment's own endpoint and key, got %+v", deployments[1])
+	}
+	if models := cfg.AzureOpenAI.Models(); len(models) != 2 || models[0] != "gpt-4o" || models[1] != "o3" {
+		t.Errorf("Expected a deployment for each model, got %v", models)
+	}
+}
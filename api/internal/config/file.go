@@ -0,0 +1,120 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Environment variables that choose the config file and profile
+const (
+	FileEnv    = "PROMPTFORGE_CONFIG"
+	ProfileEnv = "PROMPTFORGE_PROFILE"
+)
+
+// defaultFiles are looked for in the working directory when PROMPTFORGE_CONFIG is not set
+var defaultFiles = []string{"promptforge.yaml", "promptforge.yml", "promptforge.toml"}
+
+// configFile is a parsed config file with its settings keyed like "openai.base_url"
+type configFile struct {
+	path     string
+	profile  string
+	values   map[string]interface{}
+	profiles map[string]map[string]interface{}
+}
+
+// findConfigFile returns PROMPTFORGE_CONFIG, which must exist, or the first default file present
+func findConfigFile() (string, error) {
+	if path := os.Getenv(FileEnv); path != "" {
+		if _, err := os.Stat(path); err != nil {
+			return "", fmt.Errorf("config file from %s: %v", FileEnv, err)
+		}
+		return path, nil
+	}
+	for _, path := range defaultFiles {
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		}
+	}
+	return "", nil
+}
+
+// overlayPath is the profile's overlay next to a config file, e.g. promptforge.prod.yaml
+func overlayPath(path, profile string) string {
+	ext := filepath.Ext(path)
+	return strings.TrimSuffix(path, ext) + "." + profile + ext
+}
+
+// readConfigFile parses a YAML or TOML file. Keys that are not settings are errors, so typos do not
+// go unnoticed. Only a base file may choose a profile or define profiles.
+func readConfigFile(path string, base bool) (*configFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %v", err)
+	}
+
+	raw := map[string]interface{}{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &raw)
+	case ".toml":
+		raw, err = parseTOML(string(data))
+	default:
+		return nil, fmt.Errorf("config file %s must be .yaml, .yml or .toml", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %v", path, err)
+	}
+
+	file := &configFile{path: path, values: map[string]interface{}{}, profiles: map[string]map[string]interface{}{}}
+	if base {
+		if profile, ok := raw["profile"]; ok {
+			file.profile = scalarString(profile)
+			delete(raw, "profile")
+		}
+		if sections, ok := raw["profiles"]; ok {
+			delete(raw, "profiles")
+			named, ok := sections.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("%s: profiles must be a table of named profiles", path)
+			}
+			for name, section := range named {
+				values, ok := section.(map[string]interface{})
+				if !ok {
+					return nil, fmt.Errorf("%s: profile %s must be a table of settings", path, name)
+				}
+				file.profiles[name] = map[string]interface{}{}
+				if err := flatten("", values, file.profiles[name]); err != nil {
+					return nil, fmt.Errorf("%s: profile %s: %v", path, name, err)
+				}
+			}
+		}
+	}
+
+	if err := flatten("", raw, file.values); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return file, nil
+}
+
+// flatten copies nested tables into out with dotted keys, stopping at known settings
+func flatten(prefix string, values map[string]interface{}, out map[string]interface{}) error {
+	for name, value := range values {
+		key := prefix + name
+		if lookupSetting(key) != nil {
+			out[key] = value
+			continue
+		}
+		table, ok := value.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("unknown setting %q", key)
+		}
+		if err := flatten(key+".", table, out); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,147 @@
+package config
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	current  atomic.Pointer[Config]
+	reloadMu sync.Mutex
+)
+
+// Current returns the configuration in effect. Reload replaces it as a whole, so callers should read
+// it once per operation; AppConfig keeps the configuration the server started with.
+func Current() *Config {
+	return current.Load()
+}
+
+// Set makes cfg the current configuration
+func Set(cfg *Config) {
+	current.Store(cfg)
+}
+
+// reloadable reports whether a setting takes effect without a restart: the providers, their
+// credentials and deployments, and context compaction
+func reloadable(key string) bool {
+	for _, prefix := range []string{"default_provider", "openai.", "azure_openai.", "anthropic.", "context."} {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Change is a setting whose value differs after a reload. From and To are empty for secrets.
+type Change struct {
+	Key     string
+	From    string
+	To      string
+	Secret  bool
+	Applied bool // False when the change needs a restart
+}
+
+// Reload loads the configuration again and swaps in its provider and context settings at once;
+// other changed settings are reported but keep their values until a restart. An invalid
+// configuration is rejected and the current one kept.
+func Reload() ([]Change, error) {
+	reloadMu.Lock()
+	defer reloadMu.Unlock()
+
+	next, err := Load()
+	if err != nil {
+		return nil, err
+	}
+	prev := Current()
+
+	applied := *prev
+	applied.DefaultProvider = next.DefaultProvider
+	applied.OpenAI = next.OpenAI
+	applied.AzureOpenAI = next.AzureOpenAI
+	applied.Anthropic = next.Anthropic
+	applied.Context = next.Context
+	applied.files = next.files
+	applied.effective = make([]Setting, len(prev.effective))
+
+	shownPrev, shownNext := prev.Effective(), next.Effective()
+	var changes []Change
+	for i, s := range prev.effective {
+		applied.effective[i] = s
+		if next.effective[i].Value == s.Value {
+			continue
+		}
+		change := Change{Key: s.Key, Secret: s.Secret, Applied: reloadable(s.Key)}
+		if !s.Secret {
+			change.From, change.To = shownPrev[i].Value, shownNext[i].Value
+		}
+		if change.Applied {
+			applied.effective[i] = next.effective[i]
+		}
+		changes = append(changes, change)
+	}
+
+	Set(&applied)
+	return changes, nil
+}
+
+// Watch reloads the configuration in the background when one of the files it was read from
+// changes, checking every interval, or when a signal arrives. reloaded is called with the outcome of
+// each reload. The returned function stops watching.
+func Watch(interval time.Duration, signals <-chan os.Signal, reloaded func([]Change, error)) (stop func()) {
+	var tick <-chan time.Time
+	var ticker *time.Ticker
+	if interval > 0 {
+		ticker = time.NewTicker(interval)
+		tick = ticker.C
+	}
+
+	done, stopped := make(chan struct{}), make(chan struct{})
+	last := fingerprint(Current().files)
+	go func() {
+		defer close(stopped)
+		for {
+			select {
+			case <-done:
+				return
+			case <-tick:
+				if now := fingerprint(Current().files); bytes.Equal(now, last) {
+					continue
+				}
+			case <-signals:
+			}
+
+			changes, err := Reload()
+			last = fingerprint(Current().files)
+			reloaded(changes, err)
+		}
+	}()
+
+	return func() {
+		if ticker != nil {
+			ticker.Stop()
+		}
+		close(done)
+		<-stopped
+	}
+}
+
+// fingerprint hashes the contents of files, so edits, replacements and removals are all noticed
+func fingerprint(files []string) []byte {
+	h := sha256.New()
+	for _, path := range files {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			h.Write([]byte("missing:" + path + "\x00"))
+			continue
+		}
+		h.Write([]byte(path + "\x00"))
+		h.Write(data)
+		h.Write([]byte{0})
+	}
+	return h.Sum(nil)
+}
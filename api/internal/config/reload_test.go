@@ -0,0 +1,104 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestReload(t *testing.T) {
+	clearEnv(t)
+	dir := t.TempDir()
+	path, keyFile := filepath.Join(dir, "promptforge.yaml"), filepath.Join(dir, "openai-key")
+	writeFile(t, path, "default_provider: openai\ndatabase:\n  path: /data/a.db\n")
+	writeFile(t, keyFile, "old-key\n")
+	t.Setenv(FileEnv, path)
+	t.Setenv("OPENAI_API_KEY_FILE", keyFile)
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Failed to load configuration: %v", err)
+	}
+	if cfg.OpenAI.APIKey != "old-key" {
+		t.Fatalf("Expected the key from its file, got %q", cfg.OpenAI.APIKey)
+	}
+	previous := Current()
+	Set(cfg)
+	defer Set(previous)
+
+	writeFile(t, keyFile, "new-key\n")
+	writeFile(t, path, "default_provider: openai\ndatabase:\n  path: /data/b.db\ncontext:\n  keep_recent: 2\n")
+	changes, err := Reload()
+	if err != nil {
+		t.Fatalf("Failed to reload: %v", err)
+	}
+	if Current().OpenAI.APIKey != "new-key" || Current().Context.KeepRecent != 2 || Current().Database.Path != "/data/a.db" {
+		t.Errorf("Expected provider and context settings to apply and the database to wait for a restart, got %+v", Current())
+	}
+	byKey := map[string]Change{}
+	for _, change := range changes {
+		byKey[change.Key] = change
+	}
+	if c := byKey["openai.api_key"]; !c.Secret || !c.Applied || c.From != "" || c.To != "" {
+		t.Errorf("Expected the key change to be applied without its values, got %+v", c)
+	}
+	if c := byKey["database.path"]; c.Applied || c.From != "/data/a.db" || c.To != "/data/b.db" {
+		t.Errorf("Expected the database change to need a restart, got %+v", c)
+	}
+	for _, s := range Current().Effective() {
+		if s.Key == "database.path" && s.Value != "/data/a.db" {
+			t.Errorf("Expected the effective settings to show the database in use, got %+v", s)
+		}
+	}
+
+	writeFile(t, path, "default_provider: openai\nopenai:\n  base_ulr: typo\n")
+	if _, err := Reload(); err == nil || Current().OpenAI.APIKey != "new-key" {
+		t.Errorf("Expected an invalid file to be rejected and the current settings kept, got %v", err)
+	}
+}
+
+func TestWatch(t *testing.T) {
+	clearEnv(t)
+	keyFile := filepath.Join(t.TempDir(), "anthropic-key")
+	writeFile(t, keyFile, "old-key")
+	t.Setenv("ANTHROPIC_API_KEY_FILE", keyFile)
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Failed to load configuration: %v", err)
+	}
+	previous := Current()
+	Set(cfg)
+	defer Set(previous)
+
+	signals := make(chan os.Signal, 1)
+	reloads := make(chan []Change, 1)
+	stop := Watch(10*time.Millisecond, signals, func(changes []Change, err error) {
+		if err != nil {
+			t.Errorf("Failed to reload: %v", err)
+		}
+		reloads <- changes
+	})
+	defer stop()
+
+	writeFile(t, keyFile, "new-key")
+	select {
+	case changes := <-reloads:
+		if len(changes) != 1 || changes[0].Key != "anthropic.api_key" || Current().Anthropic.APIKey != "new-key" {
+			t.Errorf("Expected the rotated key to be picked up, got %+v", changes)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the changed file to be reloaded")
+	}
+
+	signals <- os.Interrupt
+	select {
+	case changes := <-reloads:
+		if len(changes) != 0 {
+			t.Errorf("Expected a signal to reload without changes, got %+v", changes)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected a signal to reload")
+	}
+}
@@ -0,0 +1,108 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"promptforge/internal/secrets"
+)
+
+// resolveReferences replaces values such as ${file:/run/secrets/openai} or ${keystore:openai_api_key}
+// with the secret they name. The encryption settings are resolved first, since the keystore needs
+// them; they may refer to the env and file providers but not to the keystore.
+func (p *parser) resolveReferences() {
+	p.resolveReference("encryption.master_key")
+	p.resolveReference("encryption.keystore")
+
+	if path := p.string("encryption.keystore"); path != "" {
+		c, err := secrets.NewCipher(p.string("encryption.master_key"))
+		if err != nil {
+			p.fail("encryption.keystore", "needs a valid encryption.master_key: %v", err)
+		} else {
+			p.keystore = secrets.OpenKeystore(path, c)
+		}
+	}
+
+	for _, s := range settings {
+		if s.key != "encryption.master_key" && s.key != "encryption.keystore" {
+			p.resolveReference(s.key)
+		}
+	}
+}
+
+func (p *parser) resolveReference(key string) {
+	r := p.values[key]
+	value, ok := r.value.(string)
+	if !ok {
+		return
+	}
+	name, _, ok := secrets.ParseReference(value)
+	if !ok {
+		return
+	}
+	secret, err := p.secret(value)
+	if err != nil {
+		p.fail(key, "%v", err)
+		return
+	}
+	p.values[key] = resolved{value: secret, source: fmt.Sprintf("%s via %s", r.source, name)}
+}
+
+// secret returns value, or the secret it refers to
+func (p *parser) secret(value string) (string, error) {
+	name, ref, ok := secrets.ParseReference(value)
+	if !ok {
+		return value, nil
+	}
+	provider := secrets.Get(name)
+	if name == "keystore" {
+		if p.keystore == nil {
+			return "", fmt.Errorf("%s refers to the keystore, but encryption.keystore is not set", value)
+		}
+		provider = p.keystore
+	}
+	if provider == nil {
+		return "", fmt.Errorf("unknown secret provider %q", name)
+	}
+	secret, err := provider.Lookup(ref)
+	if err != nil {
+		return "", err
+	}
+	if source := provider.Source(ref); source != "" {
+		p.files = append(p.files, source)
+	}
+	return secret, nil
+}
+
+// Cipher returns the cipher for the master key, used to encrypt credentials stored in the database
+func (c *Config) Cipher() (*secrets.Cipher, error) {
+	if c.Encryption.MasterKey == "" {
+		return nil, fmt.Errorf("no master key: set PROMPTFORGE_MASTER_KEY")
+	}
+	return secrets.NewCipher(c.Encryption.MasterKey)
+}
+
+// OpenKeystore returns the configured keystore without validating the other settings, so secrets
+// can be added before the configuration is complete
+func OpenKeystore() (*secrets.Keystore, error) {
+	src, err := readSources()
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{values: resolve(src.layers)}
+	p.resolveReference("encryption.master_key")
+	p.resolveReference("encryption.keystore")
+	if len(p.errs) > 0 {
+		return nil, fmt.Errorf("invalid configuration:\n  %s", strings.Join(p.errs, "\n  "))
+	}
+	path := p.string("encryption.keystore")
+	if path == "" {
+		return nil, fmt.Errorf("no keystore: set PROMPTFORGE_KEYSTORE")
+	}
+	cfg := &Config{Encryption: EncryptionConfig{MasterKey: p.string("encryption.master_key")}}
+	c, err := cfg.Cipher()
+	if err != nil {
+		return nil, err
+	}
+	return secrets.OpenKeystore(path, c), nil
+}
@@ -0,0 +1,84 @@
+package config
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"promptforge/internal/secrets"
+)
+
+func TestSecretReferences(t *testing.T) {
+	clearEnv(t)
+	dir := t.TempDir()
+	masterKey, _ := secrets.GenerateKey()
+	keyFile := filepath.Join(dir, "master-key")
+	writeFile(t, keyFile, masterKey+"\n")
+	keystorePath := filepath.Join(dir, "keystore.json")
+	t.Setenv("PROMPTFORGE_MASTER_KEY", "${file:"+keyFile+"}")
+	t.Setenv("PROMPTFORGE_KEYSTORE", keystorePath)
+
+	ks, err := OpenKeystore()
+	if err != nil {
+		t.Fatalf("Failed to open keystore: %v", err)
+	}
+	if err := ks.Set("anthropic", "sk-ant-stored"); err != nil {
+		t.Fatalf("Failed to set secret: %v", err)
+	}
+	ks.Set("azure_east", "azure-east-stored")
+
+	path := filepath.Join(dir, "promptforge.yaml")
+	writeFile(t, path, `anthropic:
+  api_key: ${keystore:anthropic}
+azure_openai:
+  base_url: https://default.openai.azure.com
+  api_version: 2024-10-21
+  deployments:
+    - model: gpt-4.1
+      deployment: gpt41-east
+      api_key: ${keystore:azure_east}
+`)
+	t.Setenv(FileEnv, path)
+	t.Setenv("VAULT_OPENAI_KEY", "sk-openai-env")
+	t.Setenv("OPENAI_API_KEY", "${env:VAULT_OPENAI_KEY}")
+	providerFile := filepath.Join(dir, "provider")
+	writeFile(t, providerFile, "anthropic\n")
+	t.Setenv("DEFAULT_PROVIDER_FILE", providerFile)
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Failed to load configuration: %v", err)
+	}
+	if cfg.Anthropic.APIKey != "sk-ant-stored" || cfg.OpenAI.APIKey != "sk-openai-env" {
+		t.Errorf("Expected the referenced secrets, got %q and %q", cfg.Anthropic.APIKey, cfg.OpenAI.APIKey)
+	}
+	if cfg.AzureOpenAI.Deployments[0].APIKey != "azure-east-stored" {
+		t.Errorf("Expected the deployment's key from the keystore, got %q", cfg.AzureOpenAI.Deployments[0].APIKey)
+	}
+	if cfg.DefaultProvider != ProviderAnthropic {
+		t.Errorf("Expected any setting to be read from its _FILE variable, got %q", cfg.DefaultProvider)
+	}
+	for _, s := range cfg.Effective() {
+		if s.Key == "anthropic.api_key" && (s.Value != "[set]" || s.Source != path+" via keystore") {
+			t.Errorf("Expected the source to name the keystore without the value, got %+v", s)
+		}
+	}
+	watched := strings.Join(cfg.files, ",")
+	if !strings.Contains(watched, keystorePath) || !strings.Contains(watched, keyFile) {
+		t.Errorf("Expected the keystore and master key file to be watched, got %v", cfg.files)
+	}
+	if _, err := cfg.Cipher(); err != nil {
+		t.Errorf("Expected a cipher for the master key: %v", err)
+	}
+
+	writeFile(t, path, "anthropic:\n  api_key: ${keystore:missing}\nazure_openai:\n  api_key: ${vault:azure}\n")
+	_, err = Load()
+	if err == nil || !strings.Contains(err.Error(), "anthropic.api_key") || !strings.Contains(err.Error(), `unknown secret provider "vault"`) {
+		t.Errorf("Expected missing secrets and unknown providers to be reported, got %v", err)
+	}
+
+	t.Setenv("PROMPTFORGE_MASTER_KEY", "not-a-key")
+	if _, err := Load(); err == nil || !strings.Contains(err.Error(), "encryption.master_key") {
+		t.Errorf("Expected an invalid master key to be rejected, got %v", err)
+	}
+}
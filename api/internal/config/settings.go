@@ -0,0 +1,299 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"promptforge/internal/secrets"
+)
+
+// setting is one configuration value: its path in config files, its environment variable and its
+// default. Secret settings are never shown by Effective.
+type setting struct {
+	key    string
+	env    string
+	def    string
+	secret bool
+}
+
+var settings = []setting{
+	{key: "default_provider", env: "DEFAULT_AI_PROVIDER", def: "anthropic"},
+	{key: "server.port", env: "PORT", def: "8080"},
+
+	{key: "openai.api_key", env: "OPENAI_API_KEY", secret: true},
+	{key: "openai.base_url", env: "OPENAI_BASE_URL", def: "https://api.openai.com/v1"},
+	{key: "azure_openai.api_key", env: "AZURE_OPENAI_API_KEY", secret: true},
+	{key: "azure_openai.base_url", env: "AZURE_OPENAI_BASE_URL"},
+	{key: "azure_openai.api_version", env: "AZURE_OPENAI_API_VERSION"},
+	{key: "azure_openai.deployments", env: "AZURE_OPENAI_DEPLOYMENTS", def: "gpt-4.1=gpt-4.1,o3=o3"},
+	{key: "anthropic.api_key", env: "ANTHROPIC_API_KEY", secret: true},
+	{key: "anthropic.base_url", env: "ANTHROPIC_BASE_URL", def: "https://api.anthropic.com"},
+
+	{key: "database.driver", env: "DB_DRIVER", def: "sqlite"},
+	{key: "database.path", env: "DATABASE_PATH", def: "./promptforge.db"},
+	{key: "database.url", env: "DATABASE_URL", secret: true},
+	{key: "database.auto_migrate", env: "DB_AUTO_MIGRATE", def: "true"},
+	{key: "database.migrate_dry_run", env: "DB_MIGRATE_DRY_RUN", def: "false"},
+
+	{key: "library_sync.dir", env: "LIBRARY_SYNC_DIR"},
+	{key: "library_sync.path", env: "LIBRARY_SYNC_PATH", def: "prompts"},
+	{key: "library_sync.remote", env: "LIBRARY_SYNC_REMOTE", def: "origin"},
+	{key: "library_sync.branch", env: "LIBRARY_SYNC_BRANCH"},
+	{key: "library_sync.push", env: "LIBRARY_SYNC_PUSH", def: "false"},
+	{key: "library_sync.author_name", env: "LIBRARY_SYNC_AUTHOR_NAME", def: "PromptForge"},
+	{key: "library_sync.author_email", env: "LIBRARY_SYNC_AUTHOR_EMAIL", def: "promptforge@localhost"},
+	{key: "library_sync.user", env: "LIBRARY_SYNC_USER"},
+	{key: "library_sync.workspace", env: "LIBRARY_SYNC_WORKSPACE", def: "0"},
+
+	{key: "context.summary_model", env: "CONTEXT_SUMMARY_MODEL", def: "gpt-4.1-mini"},
+	{key: "context.compact_threshold", env: "CONTEXT_COMPACT_THRESHOLD", def: "0.8"},
+	{key: "context.keep_recent", env: "CONTEXT_KEEP_RECENT", def: "6"},
+	{key: "context.summary_max_tokens", env: "CONTEXT_SUMMARY_MAX_TOKENS", def: "1500"},
+
+	{key: "backup.dir", env: "BACKUP_DIR", def: "./backups"},
+	{key: "backup.interval", env: "BACKUP_INTERVAL", def: "0"},
+	{key: "backup.keep", env: "BACKUP_KEEP", def: "7"},
+
+	{key: "retention.history_max_age", env: "HISTORY_MAX_AGE", def: "0"},
+	{key: "retention.history_max_rows", env: "HISTORY_MAX_ROWS", def: "0"},
+	{key: "retention.conversation_max_age", env: "CONVERSATION_MAX_AGE", def: "0"},
+	{key: "retention.conversation_max_rows", env: "CONVERSATION_MAX_ROWS", def: "0"},
+	{key: "retention.interval", env: "RETENTION_INTERVAL", def: "1h"},
+
+	{key: "auth.enabled", env: "AUTH_ENABLED", def: "false"},
+	{key: "auth.session_ttl", env: "AUTH_SESSION_TTL", def: "7d"},
+	{key: "auth.cookie_secure", env: "AUTH_COOKIE_SECURE", def: "false"},
+
+	{key: "cors.allowed_origins", env: "CORS_ALLOWED_ORIGINS"},
+
+	{key: "metrics.enabled", env: "METRICS_ENABLED", def: "false"},
+	{key: "metrics.listen", env: "METRICS_LISTEN"},
+
+	{key: "tracing.exporter", env: "TRACING_EXPORTER", def: "none"},
+	{key: "tracing.endpoint", env: "OTEL_EXPORTER_OTLP_ENDPOINT", def: "http://localhost:4318"},
+	{key: "tracing.service_name", env: "OTEL_SERVICE_NAME", def: "promptforge"},
+	{key: "tracing.sample_ratio", env: "TRACING_SAMPLE_RATIO", def: "1"},
+
+	{key: "logging.level", env: "LOG_LEVEL", def: "info"},
+	{key: "logging.format", env: "LOG_FORMAT", def: "json"},
+	{key: "logging.redact_prompts", env: "LOG_REDACT_PROMPTS", def: "false"},
+
+	{key: "config.watch_interval", env: "CONFIG_WATCH_INTERVAL", def: "10s"},
+
+	{key: "encryption.master_key", env: "PROMPTFORGE_MASTER_KEY", secret: true},
+	{key: "encryption.keystore", env: "PROMPTFORGE_KEYSTORE"},
+}
+
+// Profiles are named sets of defaults, selected with PROMPTFORGE_PROFILE or the profile key of the
+// config file. A config file can override them in its profiles section.
+var profiles = map[string]map[string]string{
+	"dev": {
+		"logging.level":  "debug",
+		"logging.format": "text",
+	},
+	"staging": {
+		"auth.enabled": "true",
+	},
+	"prod": {
+		"auth.enabled":       "true",
+		"auth.cookie_secure": "true",
+	},
+}
+
+func lookupSetting(key string) *setting {
+	for i := range settings {
+		if settings[i].key == key {
+			return &settings[i]
+		}
+	}
+	return nil
+}
+
+// Setting is a configuration value as shown by /api/config, with where it came from
+type Setting struct {
+	Key    string
+	Env    string
+	Value  string
+	Source string // "default", "profile <name>", a config file, or "env", with " via <provider>" for references
+	Secret bool
+}
+
+// layer is one source of settings, keyed by setting key
+type layer struct {
+	name   string
+	values map[string]interface{}
+}
+
+// resolved is a setting's value and the layer it came from
+type resolved struct {
+	value  interface{}
+	source string
+}
+
+// resolve picks each setting's value from the last layer that sets it
+func resolve(layers []layer) map[string]resolved {
+	values := map[string]resolved{}
+	for _, s := range settings {
+		values[s.key] = resolved{value: s.def, source: "default"}
+	}
+	for _, l := range layers {
+		for key, value := range l.values {
+			values[key] = resolved{value: value, source: l.name}
+		}
+	}
+	return values
+}
+
+// envLayer holds the settings whose environment variables are set and not empty
+func envLayer() layer {
+	l := layer{name: "env", values: map[string]interface{}{}}
+	for _, s := range settings {
+		if value := os.Getenv(s.env); value != "" {
+			l.values[s.key] = value
+		}
+	}
+	return l
+}
+
+// fileLayers read settings from the files named by their _FILE variables, such as
+// OPENAI_API_KEY_FILE, so credentials can be mounted as files and rotated without a restart
+func fileLayers() ([]layer, []string, error) {
+	var layers []layer
+	var files []string
+	for _, s := range settings {
+		path := os.Getenv(s.env + "_FILE")
+		if path == "" {
+			continue
+		}
+		if os.Getenv(s.env) != "" {
+			return nil, nil, fmt.Errorf("set %s or %s_FILE, not both", s.env, s.env)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read %s_FILE: %v", s.env, err)
+		}
+		layers = append(layers, layer{name: path, values: map[string]interface{}{s.key: strings.TrimRight(string(data), "\r\n")}})
+		files = append(files, path)
+	}
+	return layers, files, nil
+}
+
+// parser converts resolved values to typed fields, collecting every invalid value
+type parser struct {
+	values   map[string]resolved
+	errs     []string
+	keystore *secrets.Keystore // Set when encryption.keystore is configured
+	files    []string          // Files secrets were read from
+}
+
+func (p *parser) fail(key, format string, args ...interface{}) {
+	s := lookupSetting(key)
+	p.errs = append(p.errs, fmt.Sprintf("%s (%s, from %s): %s", key, s.env, p.values[key].source, fmt.Sprintf(format, args...)))
+}
+
+func (p *parser) string(key string) string {
+	return scalarString(p.values[key].value)
+}
+
+func (p *parser) bool(key string) bool {
+	value, err := strconv.ParseBool(p.string(key))
+	if err != nil {
+		p.fail(key, "%q is not true or false", p.string(key))
+	}
+	return value
+}
+
+func (p *parser) int(key string) int {
+	value, err := strconv.Atoi(p.string(key))
+	if err != nil || value < 0 {
+		p.fail(key, "%q is not a whole number of at least zero", p.string(key))
+	}
+	return value
+}
+
+func (p *parser) float(key string) float64 {
+	value, err := strconv.ParseFloat(p.string(key), 64)
+	if err != nil {
+		p.fail(key, "%q is not a number", p.string(key))
+	}
+	return value
+}
+
+func (p *parser) duration(key string) time.Duration {
+	value, err := ParseDuration(p.string(key))
+	if err != nil || value < 0 {
+		p.fail(key, "%q is not a duration such as 90m or 30d", p.string(key))
+	}
+	return value
+}
+
+// list accepts a list from a config file or a comma-separated string, dropping blank entries
+func (p *parser) list(key string) []string {
+	var items []string
+	switch value := p.values[key].value.(type) {
+	case []interface{}:
+		for _, item := range value {
+			items = append(items, scalarString(item))
+		}
+	default:
+		items = strings.Split(scalarString(value), ",")
+	}
+
+	var values []string
+	for _, item := range items {
+		if item = strings.TrimSpace(item); item != "" {
+			values = append(values, item)
+		}
+	}
+	return values
+}
+
+// oneOf checks a value against the allowed ones
+func (p *parser) oneOf(key string, allowed ...string) string {
+	value := p.string(key)
+	for _, a := range allowed {
+		if value == a {
+			return value
+		}
+	}
+	p.fail(key, "unknown value %q, expected %s", value, strings.Join(allowed, ", "))
+	return value
+}
+
+func scalarString(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case time.Time:
+		// YAML reads unquoted dates such as an API version of 2024-10-21 as timestamps
+		if v.Equal(v.Truncate(24 * time.Hour)) {
+			return v.Format("2006-01-02")
+		}
+		return v.Format(time.RFC3339)
+	case []interface{}:
+		items := make([]string, len(v))
+		for i, item := range v {
+			items[i] = scalarString(item)
+		}
+		return strings.Join(items, ",")
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// effective lists every setting with its value and source, sorted by key
+func effective(values map[string]resolved) []Setting {
+	out := make([]Setting, 0, len(settings))
+	for _, s := range settings {
+		r := values[s.key]
+		out = append(out, Setting{Key: s.key, Env: s.env, Value: scalarString(r.value), Source: r.source, Secret: s.secret})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Key < out[j].Key })
+	return out
+}
@@ -0,0 +1,39 @@
+package config
+
+import (
+	"github.com/BurntSushi/toml"
+)
+
+// parseTOML decodes a TOML config file into nested maps, as YAML decodes them
+func parseTOML(data string) (map[string]interface{}, error) {
+	raw := map[string]interface{}{}
+	if _, err := toml.Decode(data, &raw); err != nil {
+		return nil, err
+	}
+	return normalizeTOML(raw).(map[string]interface{}), nil
+}
+
+// normalizeTOML turns arrays of tables, which the decoder returns as []map[string]interface{}, into
+// []interface{} like YAML lists, so both formats read the same way
+func normalizeTOML(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			v[key] = normalizeTOML(item)
+		}
+		return v
+	case []map[string]interface{}:
+		items := make([]interface{}, len(v))
+		for i, item := range v {
+			items[i] = normalizeTOML(item)
+		}
+		return items
+	case []interface{}:
+		for i, item := range v {
+			v[i] = normalizeTOML(item)
+		}
+		return v
+	default:
+		return v
+	}
+}
@@ -0,0 +1,113 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+
+	"promptforge/internal/models"
+)
+
+var auditPagination = paginationSpec{
+	sorts: map[string]sortColumn{
+		"created_at": {expr: "a.created_at", time: true, defaultDesc: true},
+	},
+	defaultSort:  "created_at",
+	idExpr:       "a.id",
+	numericID:    true,
+	defaultLimit: 50,
+	maxLimit:     500,
+}
+
+const auditColumns = `a.id, a.user_id, a.username, a.workspace_id, a.method, a.route, a.path, a.entity_id,
+	a.status, a.before_summary, a.after_summary, a.created_at`
+
+// AppendAudit records an entry in the audit log, which is never updated or deleted
+func (d *Database) AppendAudit(entry models.AuditEntry) error {
+	_, err := d.db.Exec(`
+		INSERT INTO audit_log (user_id, username, workspace_id, method, route, path, entity_id, status, before_summary, after_summary)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, entry.UserID, entry.Username, entry.WorkspaceID, entry.Method, entry.Route, entry.Path, entry.EntityID,
+		entry.Status, entry.Before, entry.After)
+	if err != nil {
+		return fmt.Errorf("failed to write audit log: %v", err)
+	}
+	return nil
+}
+
+// GetAuditLog lists audit entries, newest first by default. It is not limited by scope.
+func (d *Database) GetAuditLog(filter models.AuditFilter) ([]models.AuditEntry, *models.PageInfo, error) {
+	page, err := newPagination(filter.PageOptions, auditPagination, d.db.dialect)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	where := &whereBuilder{}
+	if filter.Username != "" {
+		where.add("a.username = ?", filter.Username)
+	}
+	if filter.WorkspaceID != 0 {
+		where.add("a.workspace_id = ?", filter.WorkspaceID)
+	}
+	if filter.Method != "" {
+		where.add("a.method = ?", filter.Method)
+	}
+	if filter.Route != "" {
+		where.add("a.route = ?", filter.Route)
+	}
+	if filter.EntityID != "" {
+		where.add("a.entity_id = ?", filter.EntityID)
+	}
+	d.addDateRange(where, "a.created_at", filter.From, filter.To)
+
+	info := &models.PageInfo{}
+	if err := d.db.QueryRow(`SELECT COUNT(*) FROM audit_log a`+where.sql(), where.args...).Scan(&info.Total); err != nil {
+		return nil, nil, fmt.Errorf("failed to count audit log: %v", err)
+	}
+
+	if err := page.applyCursor(where); err != nil {
+		return nil, nil, err
+	}
+
+	query := `
+		SELECT ` + auditColumns + `, ` + page.sortKeySQL() + `
+		FROM audit_log a` + where.sql() + page.orderBySQL() + ` LIMIT ?`
+
+	rows, err := d.db.Query(query, append(where.args, page.limitArg())...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to query audit log: %v", err)
+	}
+	defer rows.Close()
+
+	var entries []models.AuditEntry
+	var sortKeys []string
+	for rows.Next() {
+		var entry models.AuditEntry
+		var userID, workspaceID sql.NullInt64
+		var sortKey string
+		if err := rows.Scan(&entry.ID, &userID, &entry.Username, &workspaceID, &entry.Method, &entry.Route, &entry.Path,
+			&entry.EntityID, &entry.Status, &entry.Before, &entry.After, &entry.CreatedAt, &sortKey); err != nil {
+			return nil, nil, fmt.Errorf("failed to scan audit log row: %v", err)
+		}
+		if userID.Valid {
+			entry.UserID = &userID.Int64
+		}
+		if workspaceID.Valid {
+			entry.WorkspaceID = &workspaceID.Int64
+		}
+		entries = append(entries, entry)
+		sortKeys = append(sortKeys, sortKey)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("error iterating audit log rows: %v", err)
+	}
+
+	if len(entries) > page.limit {
+		last := page.limit - 1
+		info.NextCursor = page.nextCursor(len(entries), sortKeys[last], strconv.FormatInt(entries[last].ID, 10))
+		entries = entries[:page.limit]
+	}
+
+	return entries, info, nil
+}
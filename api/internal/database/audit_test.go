@@ -0,0 +1,26 @@
+package database
+
+import (
+	"testing"
+
+	"promptforge/internal/models"
+)
+
+func TestAuditLogAppendOnly(t *testing.T) {
+	db := setupTestDB(t)
+
+	if err := db.AppendAudit(models.AuditEntry{Method: "DELETE", Route: "/api/history", Path: "/api/history", Status: 200}); err != nil {
+		t.Fatalf("Failed to append audit entry: %v", err)
+	}
+	if _, err := db.db.Exec(`UPDATE audit_log SET status = 500`); err == nil {
+		t.Error("Expected audit entries to be immutable")
+	}
+	if _, err := db.db.Exec(`DELETE FROM audit_log`); err == nil {
+		t.Error("Expected audit entries to be undeletable")
+	}
+
+	entries, page, err := db.GetAuditLog(models.AuditFilter{Route: "/api/history"})
+	if err != nil || page.Total != 1 || entries[0].Status != 200 || entries[0].UserID != nil {
+		t.Errorf("Expected the anonymous entry to be kept, got %+v %v", entries, err)
+	}
+}
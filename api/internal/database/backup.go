@@ -0,0 +1,196 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+
+	"promptforge/internal/models"
+)
+
+const (
+	backupPrefix     = "promptforge-"
+	backupExt        = ".db"
+	backupTimeLayout = "20060102-150405"
+)
+
+// Backup writes a snapshot of a SQLite database to a timestamped file in dir with SQLite's online
+// backup API, so the server keeps serving requests while it runs. PostgreSQL databases are backed
+// up with pg_dump instead.
+func (d *Database) Backup(dir string) (*models.Backup, error) {
+	if d.db.dialect.name != DriverSQLite {
+		return nil, fmt.Errorf("backups are only supported for SQLite databases, use pg_dump for %s", d.db.dialect.name)
+	}
+
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create backup directory: %v", err)
+	}
+
+	createdAt := time.Now().UTC()
+	name := backupPrefix + createdAt.Format(backupTimeLayout) + backupExt
+	path := filepath.Join(dir, name)
+	if _, err := os.Stat(path); err == nil {
+		return nil, fmt.Errorf("backup %s already exists", name)
+	}
+
+	// Write to a temporary file first so an interrupted backup never looks complete
+	tmpPath := path + ".tmp"
+	os.Remove(tmpPath)
+	if err := copySQLite(tmpPath, d.db.DB); err != nil {
+		os.Remove(tmpPath)
+		return nil, err
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return nil, fmt.Errorf("failed to finish backup: %v", err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat backup: %v", err)
+	}
+
+	return &models.Backup{Name: name, Size: info.Size(), CreatedAt: createdAt.Truncate(time.Second)}, nil
+}
+
+// ListBackups returns the backups in dir, newest first. Other files are ignored.
+func ListBackups(dir string) ([]models.Backup, error) {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return []models.Backup{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read backup directory: %v", err)
+	}
+
+	backups := []models.Backup{}
+	for _, entry := range entries {
+		stamp, ok := strings.CutPrefix(entry.Name(), backupPrefix)
+		if !ok || entry.IsDir() {
+			continue
+		}
+		stamp, ok = strings.CutSuffix(stamp, backupExt)
+		if !ok {
+			continue
+		}
+		createdAt, err := time.Parse(backupTimeLayout, stamp)
+		if err != nil {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return nil, fmt.Errorf("failed to stat backup: %v", err)
+		}
+		backups = append(backups, models.Backup{Name: entry.Name(), Size: info.Size(), CreatedAt: createdAt})
+	}
+
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].CreatedAt.After(backups[j].CreatedAt)
+	})
+	return backups, nil
+}
+
+// PruneBackups deletes all but the newest keep backups in dir and returns the names it deleted
+func PruneBackups(dir string, keep int) ([]string, error) {
+	backups, err := ListBackups(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var deleted []string
+	for i := keep; i < len(backups); i++ {
+		if err := os.Remove(filepath.Join(dir, backups[i].Name)); err != nil {
+			return deleted, fmt.Errorf("failed to delete backup %s: %v", backups[i].Name, err)
+		}
+		deleted = append(deleted, backups[i].Name)
+	}
+	return deleted, nil
+}
+
+// Restore replaces the SQLite database at dbPath with the backup at backupPath. The backup is
+// checked for integrity first. Stop the server before restoring; open connections keep reading
+// the replaced pages.
+func Restore(backupPath, dbPath string) error {
+	if _, err := os.Stat(backupPath); err != nil {
+		return fmt.Errorf("failed to open backup: %v", err)
+	}
+
+	// Opened read-write because checking FTS5 indexes needs write access, though nothing is written
+	backup, err := sql.Open(dialects[DriverSQLite].driverName, backupPath)
+	if err != nil {
+		return fmt.Errorf("failed to open backup: %v", err)
+	}
+	defer backup.Close()
+
+	var integrity string
+	if err := backup.QueryRow(`PRAGMA integrity_check`).Scan(&integrity); err != nil {
+		return fmt.Errorf("failed to check backup: %v", err)
+	}
+	if integrity != "ok" {
+		return fmt.Errorf("backup %s is corrupt: %s", backupPath, integrity)
+	}
+
+	var migrated bool
+	if err := backup.QueryRow(`SELECT EXISTS(SELECT 1 FROM sqlite_master WHERE type = 'table' AND name = 'schema_migrations')`).Scan(&migrated); err != nil {
+		return fmt.Errorf("failed to check backup: %v", err)
+	}
+	if !migrated {
+		return fmt.Errorf("%s is not a PromptForge database", backupPath)
+	}
+
+	return copySQLite(dbPath, backup)
+}
+
+// copySQLite copies every page of src into the SQLite database at destPath, creating it if needed
+func copySQLite(destPath string, src *sql.DB) error {
+	dest, err := sql.Open(dialects[DriverSQLite].driverName, destPath)
+	if err != nil {
+		return fmt.Errorf("failed to open backup destination: %v", err)
+	}
+	defer dest.Close()
+
+	ctx := context.Background()
+	destConn, err := dest.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to open backup destination: %v", err)
+	}
+	defer destConn.Close()
+
+	srcConn, err := src.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to open backup source: %v", err)
+	}
+	defer srcConn.Close()
+
+	err = destConn.Raw(func(destDriver interface{}) error {
+		return srcConn.Raw(func(srcDriver interface{}) error {
+			destSQLite, ok := destDriver.(*sqlite3.SQLiteConn)
+			srcSQLite, ok2 := srcDriver.(*sqlite3.SQLiteConn)
+			if !ok || !ok2 {
+				return fmt.Errorf("backups need SQLite connections")
+			}
+
+			b, err := destSQLite.Backup("main", srcSQLite, "main")
+			if err != nil {
+				return err
+			}
+			// A single step copies a consistent snapshot; writers wait until it finishes
+			if _, err := b.Step(-1); err != nil {
+				b.Finish()
+				return err
+			}
+			return b.Finish()
+		})
+	})
+	if err != nil {
+		return fmt.Errorf("failed to copy database: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package database
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"promptforge/internal/models"
+)
+
+func TestBackupAndRestore(t *testing.T) {
+	dir := t.TempDir()
+	dbPath := filepath.Join(dir, "live.db")
+	db, err := Open(DriverSQLite, dbPath)
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+	if err := db.InitSchema(true); err != nil {
+		t.Fatalf("Failed to initialize schema: %v", err)
+	}
+
+	if _, err := db.SaveHistory(models.SaveHistoryRequest{Prompt: "Before backup", Model: "gpt-4", Success: true}); err != nil {
+		t.Fatalf("Failed to save history: %v", err)
+	}
+
+	backupDir := filepath.Join(dir, "backups")
+	backup, err := db.Backup(backupDir)
+	if err != nil {
+		t.Fatalf("Failed to back up database: %v", err)
+	}
+	if backup.Size == 0 {
+		t.Errorf("Expected a non-empty backup, got %+v", backup)
+	}
+
+	// Other files in the backup directory are not listed
+	os.WriteFile(filepath.Join(backupDir, "notes.txt"), []byte("keep"), 0o600)
+	backups, err := ListBackups(backupDir)
+	if err != nil || len(backups) != 1 || backups[0].Name != backup.Name {
+		t.Fatalf("Expected the backup to be listed, got %+v (%v)", backups, err)
+	}
+
+	if err := db.ClearHistory(); err != nil {
+		t.Fatalf("Failed to clear history: %v", err)
+	}
+	db.Close()
+
+	if err := Restore(filepath.Join(backupDir, backup.Name), dbPath); err != nil {
+		t.Fatalf("Failed to restore backup: %v", err)
+	}
+
+	restored, err := Open(DriverSQLite, dbPath)
+	if err != nil {
+		t.Fatalf("Failed to open restored database: %v", err)
+	}
+	defer restored.Close()
+	history, _, err := restored.GetHistory(models.HistoryFilter{})
+	if err != nil || len(history) != 1 || history[0].Prompt != "Before backup" {
+		t.Errorf("Expected the backed up history after restoring, got %+v (%v)", history, err)
+	}
+
+	if err := Restore(filepath.Join(backupDir, "notes.txt"), dbPath); err == nil {
+		t.Error("Expected restoring a file that is not a database to fail")
+	}
+}
+
+func TestPruneBackups(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"promptforge-20260101-000000.db", "promptforge-20260301-000000.db", "promptforge-20260201-000000.db", "other.db"} {
+		os.WriteFile(filepath.Join(dir, name), []byte("x"), 0o600)
+	}
+
+	deleted, err := PruneBackups(dir, 2)
+	if err != nil || len(deleted) != 1 || deleted[0] != "promptforge-20260101-000000.db" {
+		t.Fatalf("Expected the oldest backup to be deleted, got %v (%v)", deleted, err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "other.db")); err != nil {
+		t.Errorf("Expected files that are not backups to be kept: %v", err)
+	}
+}
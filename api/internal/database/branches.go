@@ -0,0 +1,206 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+
+	"promptforge/internal/models"
+)
+
+// messageTree indexes a conversation's messages by ID and by parent. Children are kept in insertion
+// order, so the first child of a message continues the thread it was written in and later ones are forks.
+type messageTree struct {
+	byID     map[int64]*models.ConversationMessage
+	children map[int64][]int64 // Keyed by parent ID; 0 holds first messages
+	order    []int64
+}
+
+// loadConversationTree returns the conversation (without messages) and all of its messages, or nil if
+// the conversation does not exist or belongs to another user
+func loadConversationTree(q queryer, conversationID string) (*models.Conversation, *messageTree, error) {
+	owned, ownedArgs := q.andOwned("")
+	query := `SELECT id, title, revision, head_message_id, created_at, updated_at FROM conversations WHERE id = ?` + owned
+
+	var conv models.Conversation
+	var head sql.NullInt64
+	err := q.QueryRow(query, append([]interface{}{conversationID}, ownedArgs...)...).Scan(&conv.ID, &conv.Title, &conv.Revision, &head, &conv.CreatedAt, &conv.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil, nil // Conversation not found
+		}
+		return nil, nil, fmt.Errorf("failed to get conversation: %v", err)
+	}
+	if head.Valid {
+		conv.HeadMessageID = &head.Int64
+	}
+
+	rows, err := q.Query(`
+		SELECT id, conversation_id, parent_id, role, content, timestamp, edited_at
+		FROM conversation_messages
+		WHERE conversation_id = ?
+		ORDER BY id ASC
+	`, conversationID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to query conversation messages: %v", err)
+	}
+	defer rows.Close()
+
+	tree := &messageTree{
+		byID:     make(map[int64]*models.ConversationMessage),
+		children: make(map[int64][]int64),
+	}
+	for rows.Next() {
+		var msg models.ConversationMessage
+		var parentID sql.NullInt64
+		var editedAt sql.NullTime
+		err := rows.Scan(&msg.ID, &msg.ConversationID, &parentID, &msg.Role, &msg.Content, &msg.Timestamp, &editedAt)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to scan message row: %v", err)
+		}
+		if parentID.Valid {
+			msg.ParentID = &parentID.Int64
+		}
+		if editedAt.Valid {
+			msg.EditedAt = &editedAt.Time
+		}
+
+		tree.byID[msg.ID] = &msg
+		tree.children[parentID.Int64] = append(tree.children[parentID.Int64], msg.ID)
+		tree.order = append(tree.order, msg.ID)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("error iterating message rows: %v", err)
+	}
+
+	return &conv, tree, nil
+}
+
+// path returns the messages from the start of the conversation up to and including messageID
+func (t *messageTree) path(messageID int64) []models.ConversationMessage {
+	var reversed []models.ConversationMessage
+	for msg := t.byID[messageID]; msg != nil; {
+		reversed = append(reversed, *msg)
+		if msg.ParentID == nil {
+			break
+		}
+		msg = t.byID[*msg.ParentID]
+	}
+
+	messages := make([]models.ConversationMessage, len(reversed))
+	for i, msg := range reversed {
+		messages[len(reversed)-1-i] = msg
+	}
+	return messages
+}
+
+// forkPoint returns the last message a branch shares with the thread it forked from, or nil when the
+// path only ever follows first children
+func (t *messageTree) forkPoint(path []models.ConversationMessage) *int64 {
+	for i := len(path) - 1; i >= 0; i-- {
+		var parentID int64
+		if path[i].ParentID != nil {
+			parentID = *path[i].ParentID
+		}
+		if t.children[parentID][0] != path[i].ID {
+			if i == 0 {
+				return nil // A different first message has nothing to share
+			}
+			return path[i].ParentID
+		}
+	}
+	return nil
+}
+
+// branches lists every leaf message as a branch, oldest first
+func (t *messageTree) branches(head *int64) []models.ConversationBranch {
+	branches := []models.ConversationBranch{}
+	for _, id := range t.order {
+		if len(t.children[id]) > 0 {
+			continue
+		}
+
+		path := t.path(id)
+		branches = append(branches, models.ConversationBranch{
+			LeafMessageID: id,
+			ForkMessageID: t.forkPoint(path),
+			MessageCount:  len(path),
+			LastMessage:   *t.byID[id],
+			Active:        head != nil && *head == id,
+		})
+	}
+	return branches
+}
+
+// GetConversationBranches lists the branches of a conversation. The branch ending at the head is marked
+// active; right after forking without new messages the head is an inner message and none is.
+func (d *Database) GetConversationBranches(conversationID string) ([]models.ConversationBranch, error) {
+	conv, tree, err := loadConversationTree(d.db, conversationID)
+	if err != nil {
+		return nil, err
+	}
+	if conv == nil {
+		return nil, ErrConversationNotFound
+	}
+
+	return tree.branches(conv.HeadMessageID), nil
+}
+
+// GetConversationBranch returns the conversation with Messages set to the path ending at messageID,
+// without changing the active branch
+func (d *Database) GetConversationBranch(conversationID string, messageID int64) (*models.Conversation, error) {
+	conv, tree, err := loadConversationTree(d.db, conversationID)
+	if err != nil {
+		return nil, err
+	}
+	if conv == nil {
+		return nil, ErrConversationNotFound
+	}
+	if tree.byID[messageID] == nil {
+		return nil, ErrMessageNotFound
+	}
+
+	conv.Messages = tree.path(messageID)
+	return conv, nil
+}
+
+// ForkConversation makes messageID the head of the conversation and appends req.Messages after it,
+// leaving the messages that already followed it on their own branch
+func (d *Database) ForkConversation(conversationID string, req models.ForkConversationRequest) (*models.Conversation, error) {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	revision, err := currentRevision(tx, conversationID, req.Revision)
+	if err != nil {
+		return nil, err
+	}
+
+	var exists bool
+	err = tx.QueryRow(`SELECT EXISTS (SELECT 1 FROM conversation_messages WHERE id = ? AND conversation_id = ?)`,
+		req.MessageID, conversationID).Scan(&exists)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up message: %v", err)
+	}
+	if !exists {
+		return nil, ErrMessageNotFound
+	}
+
+	if err := setHead(tx, conversationID, req.MessageID); err != nil {
+		return nil, err
+	}
+	if err := insertMessages(tx, conversationID, &req.MessageID, req.Messages); err != nil {
+		return nil, err
+	}
+	if err := bumpRevision(tx, conversationID, revision, ""); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit fork: %v", err)
+	}
+
+	return d.GetConversation(conversationID)
+}
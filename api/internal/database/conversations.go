@@ -0,0 +1,200 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"promptforge/internal/models"
+)
+
+var (
+	// ErrRevisionConflict is returned when a conversation changed since the revision the client saw
+	ErrRevisionConflict = errors.New("conversation was changed by another client")
+	// ErrConversationNotFound is returned when changing a conversation that does not exist
+	ErrConversationNotFound = errors.New("conversation not found")
+	// ErrMessageNotFound is returned when editing a message that is not part of the conversation
+	ErrMessageNotFound = errors.New("message not found")
+)
+
+// ensureConversation returns the conversation's current revision, creating it at revision 0 if it does
+// not exist. A non-nil expected revision must match. A conversation owned by another user is reported
+// as ErrConversationNotFound, as is one outside the current workspace.
+func ensureConversation(tx *txn, conversationID, title string, expected *int) (int, error) {
+	if title == "" {
+		title = "New Conversation"
+	}
+
+	// A concurrent save may create the same conversation; the loser sees it as existing
+	_, err := tx.Exec(`INSERT INTO conversations (id, title, user_id, workspace_id) VALUES (?, ?, ?, ?) ON CONFLICT (id) DO NOTHING`,
+		conversationID, title, tx.owner(), tx.workspace())
+	if err != nil {
+		return 0, fmt.Errorf("failed to create conversation: %v", err)
+	}
+
+	return currentRevision(tx, conversationID, expected)
+}
+
+// currentRevision returns an existing conversation's revision, checking it against the expected one
+func currentRevision(tx *txn, conversationID string, expected *int) (int, error) {
+	owned, ownedArgs := tx.andOwned("")
+	var revision int
+	err := tx.QueryRow(`SELECT revision FROM conversations WHERE id = ?`+owned, append([]interface{}{conversationID}, ownedArgs...)...).Scan(&revision)
+	if err == sql.ErrNoRows {
+		return 0, ErrConversationNotFound
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to get conversation revision: %v", err)
+	}
+
+	if expected != nil && *expected != revision {
+		return 0, ErrRevisionConflict
+	}
+	return revision, nil
+}
+
+// bumpRevision moves the conversation from revision to revision+1. The conditional update makes a
+// concurrent writer that read the same revision fail with ErrRevisionConflict.
+func bumpRevision(tx *txn, conversationID string, revision int, title string) error {
+	query := `UPDATE conversations SET revision = revision + 1, updated_at = CURRENT_TIMESTAMP`
+	args := []interface{}{}
+	if title != "" {
+		query += `, title = ?`
+		args = append(args, title)
+	}
+	query += ` WHERE id = ? AND revision = ?`
+	args = append(args, conversationID, revision)
+
+	result, err := tx.Exec(query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to update conversation: %v", err)
+	}
+
+	if affected, err := result.RowsAffected(); err != nil {
+		return fmt.Errorf("failed to check updated rows: %v", err)
+	} else if affected == 0 {
+		return ErrRevisionConflict
+	}
+	return nil
+}
+
+// insertMessages chains messages after parentID (nil starts a new thread) and makes the last one the head
+func insertMessages(tx *txn, conversationID string, parentID *int64, messages []models.ConversationMessage) error {
+	if len(messages) == 0 {
+		return nil
+	}
+
+	for _, msg := range messages {
+		timestamp := msg.Timestamp
+		if timestamp.IsZero() {
+			timestamp = time.Now()
+		}
+
+		var id int64
+		err := tx.QueryRow(
+			"INSERT INTO conversation_messages (conversation_id, parent_id, role, content, timestamp) VALUES (?, ?, ?, ?, ?) RETURNING id",
+			conversationID, parentID, msg.Role, msg.Content, tx.dialect.timeArg(timestamp),
+		).Scan(&id)
+		if err != nil {
+			return fmt.Errorf("failed to save message: %v", err)
+		}
+		parentID = &id
+	}
+
+	return setHead(tx, conversationID, *parentID)
+}
+
+// conversationHead returns the last message of the active branch, or nil for an empty conversation
+func conversationHead(tx *txn, conversationID string) (*int64, error) {
+	var head sql.NullInt64
+	if err := tx.QueryRow(`SELECT head_message_id FROM conversations WHERE id = ?`, conversationID).Scan(&head); err != nil {
+		return nil, fmt.Errorf("failed to get conversation head: %v", err)
+	}
+	if !head.Valid {
+		return nil, nil
+	}
+	return &head.Int64, nil
+}
+
+func setHead(tx *txn, conversationID string, messageID int64) error {
+	if _, err := tx.Exec(`UPDATE conversations SET head_message_id = ? WHERE id = ?`, messageID, conversationID); err != nil {
+		return fmt.Errorf("failed to update conversation head: %v", err)
+	}
+	return nil
+}
+
+// AppendMessages adds messages to the end of the active branch, creating the conversation if needed
+func (d *Database) AppendMessages(conversationID string, req models.AppendMessagesRequest) (*models.Conversation, error) {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	revision, err := ensureConversation(tx, conversationID, req.Title, req.Revision)
+	if err != nil {
+		return nil, err
+	}
+
+	head, err := conversationHead(tx, conversationID)
+	if err != nil {
+		return nil, err
+	}
+	if err := insertMessages(tx, conversationID, head, req.Messages); err != nil {
+		return nil, err
+	}
+	if err := bumpRevision(tx, conversationID, revision, req.Title); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit messages: %v", err)
+	}
+
+	return d.GetConversation(conversationID)
+}
+
+// EditMessage replaces the content of one message in place, keeping its ID and position
+func (d *Database) EditMessage(conversationID string, messageID int64, req models.EditMessageRequest) (*models.Conversation, error) {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	revision, err := currentRevision(tx, conversationID, req.Revision)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := tx.Exec(`
+		UPDATE conversation_messages
+		SET content = ?, edited_at = CURRENT_TIMESTAMP
+		WHERE id = ? AND conversation_id = ?
+	`, req.Content, messageID, conversationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to edit message: %v", err)
+	}
+
+	if affected, err := result.RowsAffected(); err != nil {
+		return nil, fmt.Errorf("failed to check updated rows: %v", err)
+	} else if affected == 0 {
+		return nil, ErrMessageNotFound
+	}
+
+	// Message IDs grow along every branch, so these are exactly the summaries that may cover the edit
+	if _, err := tx.Exec(`DELETE FROM conversation_summaries WHERE conversation_id = ? AND through_message_id >= ?`, conversationID, messageID); err != nil {
+		return nil, fmt.Errorf("failed to invalidate conversation summaries: %v", err)
+	}
+
+	if err := bumpRevision(tx, conversationID, revision, ""); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit message edit: %v", err)
+	}
+
+	return d.GetConversation(conversationID)
+}
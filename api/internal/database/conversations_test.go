@@ -0,0 +1,232 @@
+package database
+
+import (
+	"testing"
+
+	"promptforge/internal/models"
+)
+
+func intPtr(v int) *int {
+	return &v
+}
+
+func TestSaveConversationAppendsMessages(t *testing.T) {
+	db := setupTestDB(t)
+
+	messages := []models.ConversationMessage{
+		{Role: "user", Content: "Help me write a prompt"},
+		{Role: "assistant", Content: "What is it for?"},
+	}
+	saved, err := db.SaveConversation(models.SaveConversationRequest{ConversationID: "conv-1", Title: "Draft", Messages: messages})
+	if err != nil {
+		t.Fatalf("Failed to save conversation: %v", err)
+	}
+	if saved.Revision != 1 || len(saved.Messages) != 2 {
+		t.Fatalf("Expected revision 1 with 2 messages, got revision %d with %d", saved.Revision, len(saved.Messages))
+	}
+	firstID := saved.Messages[0].ID
+
+	// Saving the full history again only appends the new turn and keeps existing message IDs
+	messages = append(messages, models.ConversationMessage{Role: "user", Content: "Code review"})
+	saved, err = db.SaveConversation(models.SaveConversationRequest{ConversationID: "conv-1", Messages: messages, Revision: intPtr(1)})
+	if err != nil {
+		t.Fatalf("Failed to save conversation: %v", err)
+	}
+	if saved.Revision != 2 || len(saved.Messages) != 3 || saved.Messages[0].ID != firstID {
+		t.Errorf("Expected the turn to be appended at revision 2, got %+v", saved)
+	}
+	if saved.Title != "Draft" {
+		t.Errorf("Expected the title to be kept, got %q", saved.Title)
+	}
+
+	// An unchanged save is a no-op
+	unchanged, err := db.SaveConversation(models.SaveConversationRequest{ConversationID: "conv-1", Messages: messages})
+	if err != nil || unchanged.Revision != 2 {
+		t.Errorf("Expected an unchanged save to keep revision 2, got %+v (%v)", unchanged, err)
+	}
+}
+
+func TestSaveConversationConflicts(t *testing.T) {
+	db := setupTestDB(t)
+
+	messages := []models.ConversationMessage{{Role: "user", Content: "First"}}
+	if _, err := db.SaveConversation(models.SaveConversationRequest{ConversationID: "conv-1", Messages: messages}); err != nil {
+		t.Fatalf("Failed to save conversation: %v", err)
+	}
+
+	more := append(messages, models.ConversationMessage{Role: "assistant", Content: "Reply"})
+	if _, err := db.SaveConversation(models.SaveConversationRequest{ConversationID: "conv-1", Messages: more, Revision: intPtr(0)}); err != ErrRevisionConflict {
+		t.Errorf("Expected a stale revision to conflict, got %v", err)
+	}
+
+	// A client whose history diverged cannot overwrite the stored messages
+	diverged := []models.ConversationMessage{{Role: "user", Content: "Something else"}, {Role: "assistant", Content: "Reply"}}
+	if _, err := db.SaveConversation(models.SaveConversationRequest{ConversationID: "conv-1", Messages: diverged}); err != ErrRevisionConflict {
+		t.Errorf("Expected a mismatched history to conflict, got %v", err)
+	}
+	if _, err := db.SaveConversation(models.SaveConversationRequest{ConversationID: "conv-1", Messages: nil}); err != ErrRevisionConflict {
+		t.Errorf("Expected a shorter history to conflict, got %v", err)
+	}
+
+	conversation, _ := db.GetConversation("conv-1")
+	if conversation.Revision != 1 || len(conversation.Messages) != 1 || conversation.Messages[0].Content != "First" {
+		t.Errorf("Expected the stored conversation to be unchanged, got %+v", conversation)
+	}
+}
+
+func TestAppendAndEditMessages(t *testing.T) {
+	db := setupTestDB(t)
+
+	conversation, err := db.AppendMessages("conv-1", models.AppendMessagesRequest{
+		Title:    "Appended",
+		Messages: []models.ConversationMessage{{Role: "user", Content: "Hello"}, {Role: "assistant", Content: "Hi"}},
+	})
+	if err != nil {
+		t.Fatalf("Failed to append messages: %v", err)
+	}
+	if conversation.Title != "Appended" || conversation.Revision != 1 || len(conversation.Messages) != 2 {
+		t.Fatalf("Expected a new conversation at revision 1, got %+v", conversation)
+	}
+
+	reply := conversation.Messages[1]
+	edited, err := db.EditMessage("conv-1", reply.ID, models.EditMessageRequest{Content: "Hi there", Revision: intPtr(1)})
+	if err != nil {
+		t.Fatalf("Failed to edit message: %v", err)
+	}
+	if edited.Revision != 2 || edited.Messages[1].ID != reply.ID || edited.Messages[1].Content != "Hi there" {
+		t.Errorf("Expected the message to be edited in place at revision 2, got %+v", edited)
+	}
+	if edited.Messages[1].EditedAt == nil || edited.Messages[0].EditedAt != nil {
+		t.Errorf("Expected only the edited message to have edited_at set, got %+v", edited.Messages)
+	}
+
+	if _, err := db.EditMessage("conv-1", reply.ID, models.EditMessageRequest{Content: "Stale", Revision: intPtr(1)}); err != ErrRevisionConflict {
+		t.Errorf("Expected a stale edit to conflict, got %v", err)
+	}
+	if _, err := db.EditMessage("conv-1", reply.ID+100, models.EditMessageRequest{Content: "Missing"}); err != ErrMessageNotFound {
+		t.Errorf("Expected ErrMessageNotFound, got %v", err)
+	}
+	if _, err := db.EditMessage("missing", reply.ID, models.EditMessageRequest{Content: "Missing"}); err != ErrConversationNotFound {
+		t.Errorf("Expected ErrConversationNotFound, got %v", err)
+	}
+	if _, err := db.AppendMessages("conv-1", models.AppendMessagesRequest{Revision: intPtr(1)}); err != ErrRevisionConflict {
+		t.Errorf("Expected a stale append to conflict, got %v", err)
+	}
+}
+
+func TestForkConversation(t *testing.T) {
+	db := setupTestDB(t)
+
+	original, err := db.AppendMessages("conv-1", models.AppendMessagesRequest{Messages: []models.ConversationMessage{
+		{Role: "user", Content: "Write a summary prompt"},
+		{Role: "assistant", Content: "Draft 1"},
+		{Role: "user", Content: "Make it shorter"},
+		{Role: "assistant", Content: "Draft 2"},
+	}})
+	if err != nil {
+		t.Fatalf("Failed to append messages: %v", err)
+	}
+	forkFrom := original.Messages[1]
+	originalLeaf := original.Messages[3].ID
+
+	forked, err := db.ForkConversation("conv-1", models.ForkConversationRequest{
+		MessageID: forkFrom.ID,
+		Messages:  []models.ConversationMessage{{Role: "user", Content: "Make it more formal"}},
+		Revision:  intPtr(original.Revision),
+	})
+	if err != nil {
+		t.Fatalf("Failed to fork conversation: %v", err)
+	}
+	if len(forked.Messages) != 3 || forked.Messages[1].ID != forkFrom.ID || forked.Messages[2].Content != "Make it more formal" {
+		t.Fatalf("Expected the active branch to continue from the fork point, got %+v", forked.Messages)
+	}
+	if *forked.HeadMessageID != forked.Messages[2].ID || *forked.Messages[2].ParentID != forkFrom.ID {
+		t.Errorf("Expected the new message to be the head and follow the fork point, got %+v", forked)
+	}
+
+	// Saving the whole active branch appends to it, not to the original thread
+	messages := append(forked.Messages, models.ConversationMessage{Role: "assistant", Content: "Formal draft"})
+	saved, err := db.SaveConversation(models.SaveConversationRequest{ConversationID: "conv-1", Messages: messages, Revision: intPtr(forked.Revision)})
+	if err != nil || len(saved.Messages) != 4 {
+		t.Fatalf("Expected the save to extend the forked branch, got %+v (%v)", saved, err)
+	}
+
+	branches, err := db.GetConversationBranches("conv-1")
+	if err != nil {
+		t.Fatalf("Failed to list branches: %v", err)
+	}
+	if len(branches) != 2 {
+		t.Fatalf("Expected 2 branches, got %+v", branches)
+	}
+	if branches[0].LeafMessageID != originalLeaf || branches[0].ForkMessageID != nil || branches[0].Active {
+		t.Errorf("Expected the original thread first and inactive, got %+v", branches[0])
+	}
+	if branches[1].ForkMessageID == nil || *branches[1].ForkMessageID != forkFrom.ID || !branches[1].Active ||
+		branches[1].MessageCount != 4 || branches[1].LastMessage.Content != "Formal draft" {
+		t.Errorf("Expected the fork to be active and record its fork point, got %+v", branches[1])
+	}
+
+	// The original thread is still intact and can be read or switched back to
+	branch, err := db.GetConversationBranch("conv-1", originalLeaf)
+	if err != nil || len(branch.Messages) != 4 || branch.Messages[3].Content != "Draft 2" {
+		t.Fatalf("Expected the original thread, got %+v (%v)", branch, err)
+	}
+	switched, err := db.ForkConversation("conv-1", models.ForkConversationRequest{MessageID: originalLeaf})
+	if err != nil || len(switched.Messages) != 4 || switched.Messages[3].ID != originalLeaf {
+		t.Errorf("Expected forking without messages to switch branches, got %+v (%v)", switched, err)
+	}
+
+	if _, err := db.ForkConversation("conv-1", models.ForkConversationRequest{MessageID: originalLeaf + 100}); err != ErrMessageNotFound {
+		t.Errorf("Expected ErrMessageNotFound, got %v", err)
+	}
+	if _, err := db.GetConversationBranches("missing"); err != ErrConversationNotFound {
+		t.Errorf("Expected ErrConversationNotFound, got %v", err)
+	}
+}
+
+func TestConversationSummaries(t *testing.T) {
+	db := setupTestDB(t)
+
+	conversation, err := db.AppendMessages("conv-1", models.AppendMessagesRequest{Messages: []models.ConversationMessage{
+		{Role: "user", Content: "First"},
+		{Role: "assistant", Content: "Second"},
+		{Role: "user", Content: "Third"},
+	}})
+	if err != nil {
+		t.Fatalf("Failed to append messages: %v", err)
+	}
+	first, second := conversation.Messages[0], conversation.Messages[1]
+
+	for _, summary := range []models.ConversationSummary{
+		{ConversationID: "conv-1", ThroughMessageID: first.ID, MessageCount: 1, Content: "Draft", Model: "gpt-4.1-mini"},
+		{ConversationID: "conv-1", ThroughMessageID: first.ID, MessageCount: 1, Content: "Replaced", Model: "gpt-4.1-mini", TokenCount: 2},
+		{ConversationID: "conv-1", ThroughMessageID: second.ID, MessageCount: 2, Content: "Longer", Model: "gpt-4.1-mini"},
+	} {
+		if err := db.SaveConversationSummary(summary); err != nil {
+			t.Fatalf("Failed to save summary: %v", err)
+		}
+	}
+
+	summaries, err := db.GetConversationSummaries("conv-1")
+	if err != nil {
+		t.Fatalf("Failed to get summaries: %v", err)
+	}
+	if len(summaries) != 2 || summaries[0].Content != "Replaced" || summaries[0].TokenCount != 2 || summaries[1].MessageCount != 2 {
+		t.Fatalf("Expected a summary per message with the later one replacing the earlier, got %+v", summaries)
+	}
+
+	// Editing a message drops the summaries that cover it
+	if _, err := db.EditMessage("conv-1", second.ID, models.EditMessageRequest{Content: "Edited"}); err != nil {
+		t.Fatalf("Failed to edit message: %v", err)
+	}
+	if summaries, _ = db.GetConversationSummaries("conv-1"); len(summaries) != 1 || summaries[0].ThroughMessageID != first.ID {
+		t.Errorf("Expected only the summary before the edit to remain, got %+v", summaries)
+	}
+
+	if err := db.DeleteConversation("conv-1"); err != nil {
+		t.Fatalf("Failed to delete conversation: %v", err)
+	}
+	if summaries, _ = db.GetConversationSummaries("conv-1"); len(summaries) != 0 {
+		t.Errorf("Expected summaries to be deleted with the conversation, got %+v", summaries)
+	}
+}
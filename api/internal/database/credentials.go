@@ -0,0 +1,71 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+
+	"promptforge/internal/models"
+)
+
+// ErrCredentialNotFound is returned when a workspace has no credential for a provider
+var ErrCredentialNotFound = errors.New("credential not found")
+
+// ListWorkspaceCredentials returns workspaceID's credentials, ordered by provider
+func (d *Database) ListWorkspaceCredentials(workspaceID int64) ([]models.WorkspaceCredential, error) {
+	rows, err := d.db.Query(`
+		SELECT provider, api_key, base_url, updated_by, updated_at
+		FROM workspace_credentials
+		WHERE workspace_id = ?
+		ORDER BY provider ASC
+	`, workspaceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query workspace credentials: %v", err)
+	}
+	defer rows.Close()
+
+	credentials := []models.WorkspaceCredential{}
+	for rows.Next() {
+		var credential models.WorkspaceCredential
+		if err := rows.Scan(&credential.Provider, &credential.APIKey, &credential.BaseURL, &credential.UpdatedBy, &credential.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan workspace credential row: %v", err)
+		}
+		credentials = append(credentials, credential)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating workspace credential rows: %v", err)
+	}
+
+	return credentials, nil
+}
+
+// SetWorkspaceCredential stores workspaceID's credential for its provider, replacing any previous one
+func (d *Database) SetWorkspaceCredential(workspaceID int64, credential models.WorkspaceCredential) (*models.WorkspaceCredential, error) {
+	err := d.db.QueryRow(`
+		INSERT INTO workspace_credentials (workspace_id, provider, api_key, base_url, updated_by) VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT (workspace_id, provider) DO UPDATE SET
+			api_key = excluded.api_key,
+			base_url = excluded.base_url,
+			updated_by = excluded.updated_by,
+			updated_at = CURRENT_TIMESTAMP
+		RETURNING updated_at
+	`, workspaceID, credential.Provider, credential.APIKey, credential.BaseURL, credential.UpdatedBy).Scan(&credential.UpdatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set workspace credential: %v", err)
+	}
+	return &credential, nil
+}
+
+// DeleteWorkspaceCredential removes workspaceID's credential for provider
+func (d *Database) DeleteWorkspaceCredential(workspaceID int64, provider string) error {
+	result, err := d.db.Exec(`DELETE FROM workspace_credentials WHERE workspace_id = ? AND provider = ?`, workspaceID, provider)
+	if err != nil {
+		return fmt.Errorf("failed to delete workspace credential: %v", err)
+	}
+	if affected, err := result.RowsAffected(); err != nil {
+		return fmt.Errorf("failed to check deleted rows: %v", err)
+	} else if affected == 0 {
+		return ErrCredentialNotFound
+	}
+	return nil
+}
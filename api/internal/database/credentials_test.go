@@ -0,0 +1,45 @@
+package database
+
+import (
+	"errors"
+	"testing"
+
+	"promptforge/internal/models"
+)
+
+func TestWorkspaceCredentials(t *testing.T) {
+	db := setupTestDB(t)
+
+	alice, _ := db.CreateUser("alice", "", false)
+	workspace, _ := db.CreateWorkspace("Team", alice.ID)
+	other, _ := db.CreateWorkspace("Other", alice.ID)
+
+	if _, err := db.SetWorkspaceCredential(workspace.ID, models.WorkspaceCredential{Provider: "openai", APIKey: "sealed-1", UpdatedBy: &alice.ID}); err != nil {
+		t.Fatalf("Failed to set credential: %v", err)
+	}
+	if _, err := db.SetWorkspaceCredential(workspace.ID, models.WorkspaceCredential{Provider: "anthropic", APIKey: "sealed-2"}); err != nil {
+		t.Fatalf("Failed to set credential: %v", err)
+	}
+	saved, err := db.SetWorkspaceCredential(workspace.ID, models.WorkspaceCredential{Provider: "openai", APIKey: "sealed-3", BaseURL: "https://proxy.example.com/v1"})
+	if err != nil || saved.UpdatedAt.IsZero() {
+		t.Fatalf("Failed to replace credential: %+v %v", saved, err)
+	}
+
+	credentials, err := db.ListWorkspaceCredentials(workspace.ID)
+	if err != nil || len(credentials) != 2 {
+		t.Fatalf("Expected two credentials, got %+v %v", credentials, err)
+	}
+	if credentials[1].Provider != "openai" || credentials[1].APIKey != "sealed-3" || credentials[1].BaseURL != "https://proxy.example.com/v1" || credentials[1].UpdatedBy != nil {
+		t.Errorf("Expected the replaced openai credential, got %+v", credentials[1])
+	}
+	if credentials, _ := db.ListWorkspaceCredentials(other.ID); len(credentials) != 0 {
+		t.Errorf("Expected credentials to belong to their workspace, got %+v", credentials)
+	}
+
+	if err := db.DeleteWorkspaceCredential(workspace.ID, "anthropic"); err != nil {
+		t.Fatalf("Failed to delete credential: %v", err)
+	}
+	if err := db.DeleteWorkspaceCredential(workspace.ID, "anthropic"); !errors.Is(err, ErrCredentialNotFound) {
+		t.Errorf("Expected ErrCredentialNotFound, got %v", err)
+	}
+}
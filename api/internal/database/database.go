@@ -2,171 +2,231 @@ package database
 
 import (
 	"database/sql"
-	"encoding/json"
 	"fmt"
+	"strconv"
 
 	"promptforge/internal/models"
-
-	_ "github.com/mattn/go-sqlite3" // SQLite driver
 )
 
 type Database struct {
-	db *sql.DB
+	db   *conn
+	fts5 bool // FTS5 search indexes are available
 }
 
-func NewDatabase() (*Database, error) {
-	db, err := sql.Open("sqlite3", "./promptforge.db")
+// Open connects to a database without touching its schema. driver is DriverSQLite (dsn is a
+// file path) or DriverPostgres (dsn is a connection string).
+func Open(driver, dsn string) (*Database, error) {
+	dialect, err := lookupDialect(driver)
+	if err != nil {
+		return nil, err
+	}
+
+	if dsn == "" {
+		return nil, fmt.Errorf("no data source configured for %s database", dialect.name)
+	}
+
+	db, err := sql.Open(dialect.driverName, dsn)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %v", err)
 	}
 
 	// Test connection
 	if err = db.Ping(); err != nil {
+		db.Close()
 		return nil, fmt.Errorf("failed to ping database: %v", err)
 	}
 
-	database := &Database{db: db}
-
-	// Initialize tables
-	if err := database.initTables(); err != nil {
-		return nil, err
-	}
+	return &Database{db: &conn{DB: db, dialect: dialect}}, nil
+}
 
-	return database, nil
+// Driver reports which backend the database uses
+func (d *Database) Driver() string {
+	return d.db.dialect.name
 }
 
 func (d *Database) Close() error {
 	return d.db.Close()
 }
 
-func (d *Database) initTables() error {
-	createTableSQL := `
-	CREATE TABLE IF NOT EXISTS history (
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		timestamp DATETIME DEFAULT CURRENT_TIMESTAMP,
-		prompt TEXT NOT NULL,
-		model TEXT NOT NULL,
-		temperature REAL NOT NULL,
-		max_tokens INTEGER,
-		success BOOLEAN NOT NULL,
-		response TEXT,
-		error_msg TEXT
-	);
-	`
+// InitSchema prepares the schema for use. Pending migrations are applied when autoMigrate
+// is set; otherwise they are reported as an error so the server never runs on a stale schema.
+func (d *Database) InitSchema(autoMigrate bool) error {
+	if autoMigrate {
+		if _, err := d.Migrate(false); err != nil {
+			return err
+		}
+	} else {
+		pending, err := d.PendingMigrations()
+		if err != nil {
+			return err
+		}
+		if len(pending) > 0 {
+			return fmt.Errorf("database schema is out of date: %d pending migration(s), run the 'migrate up' command", len(pending))
+		}
+	}
 
-	_, err := d.db.Exec(createTableSQL)
+	// The search indexes exist once their migration ran, which needs FTS5 in the SQLite build
+	// (go build -tags sqlite_fts5). Without it search falls back to LIKE matching.
+	fts5, err := d.hasModule("fts5")
 	if err != nil {
-		return fmt.Errorf("failed to create history table: %v", err)
+		return err
 	}
+	d.fts5 = fts5
+	return nil
+}
 
-	// Create conversations table
-	createConversationsSQL := `
-	CREATE TABLE IF NOT EXISTS conversations (
-		id TEXT PRIMARY KEY,
-		title TEXT NOT NULL,
-		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
-	);
-	`
+var historyPagination = paginationSpec{
+	sorts: map[string]sortColumn{
+		"timestamp": {expr: "h.timestamp", time: true, defaultDesc: true},
+		"model":     {expr: "h.model"},
+	},
+	defaultSort:  "timestamp",
+	idExpr:       "h.id",
+	numericID:    true,
+	defaultLimit: 50,
+	maxLimit:     200,
+}
 
-	_, err = d.db.Exec(createConversationsSQL)
+func (d *Database) GetHistory(filter models.HistoryFilter) ([]models.HistoryItem, *models.PageInfo, error) {
+	page, err := newPagination(filter.PageOptions, historyPagination, d.db.dialect)
 	if err != nil {
-		return fmt.Errorf("failed to create conversations table: %v", err)
-	}
-
-	// Create conversation_messages table
-	createMessagesSQL := `
-	CREATE TABLE IF NOT EXISTS conversation_messages (
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		conversation_id TEXT NOT NULL,
-		role TEXT NOT NULL,
-		content TEXT NOT NULL,
-		timestamp DATETIME DEFAULT CURRENT_TIMESTAMP,
-		FOREIGN KEY(conversation_id) REFERENCES conversations(id) ON DELETE CASCADE
-	);
-	`
+		return nil, nil, err
+	}
 
-	_, err = d.db.Exec(createMessagesSQL)
-	if err != nil {
-		return fmt.Errorf("failed to create conversation_messages table: %v", err)
-	}
-
-	// Create saved_prompts table
-	createPromptsSQL := `
-	CREATE TABLE IF NOT EXISTS saved_prompts (
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		title TEXT NOT NULL,
-		content TEXT NOT NULL,
-		description TEXT DEFAULT '',
-		category TEXT DEFAULT 'General',
-		tags TEXT DEFAULT '[]',
-		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-		usage_count INTEGER DEFAULT 0
-	);
-	`
+	where := &whereBuilder{}
+	d.db.restrictOwner(where, "h.")
+	if filter.Model != "" {
+		where.add("h.model = ?", filter.Model)
+	}
+	if filter.Provider != "" {
+		where.add("h.provider = ?", filter.Provider)
+	}
+	if filter.PromptID != 0 {
+		where.add("h.prompt_id = ?", filter.PromptID)
+	}
+	if filter.RunID != "" {
+		where.add("h.run_id = ?", filter.RunID)
+	}
+	if filter.Success != nil {
+		where.add("h.success = ?", *filter.Success)
+	}
+	d.addDateRange(where, "h.timestamp", filter.From, filter.To)
 
-	_, err = d.db.Exec(createPromptsSQL)
-	if err != nil {
-		return fmt.Errorf("failed to create saved_prompts table: %v", err)
+	info := &models.PageInfo{}
+	if err := d.db.QueryRow(`SELECT COUNT(*) FROM history h`+where.sql(), where.args...).Scan(&info.Total); err != nil {
+		return nil, nil, fmt.Errorf("failed to count history: %v", err)
 	}
 
-	return nil
-}
+	if err := page.applyCursor(where); err != nil {
+		return nil, nil, err
+	}
 
-func (d *Database) GetHistory() ([]models.HistoryItem, error) {
 	query := `
-		SELECT id, timestamp, prompt, model, temperature, max_tokens, success, response, COALESCE(error_msg, '') as error_msg
-		FROM history 
-		ORDER BY timestamp DESC 
-		LIMIT 50
-	`
+		SELECT ` + historyColumns + `, ` + page.sortKeySQL() + `
+		FROM history h` + where.sql() + page.orderBySQL() + ` LIMIT ?`
 
-	rows, err := d.db.Query(query)
+	rows, err := d.db.Query(query, append(where.args, page.limitArg())...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to query history: %v", err)
+		return nil, nil, fmt.Errorf("failed to query history: %v", err)
 	}
 	defer rows.Close()
 
 	var history []models.HistoryItem
+	var sortKeys []string
 	for rows.Next() {
-		var item models.HistoryItem
-		err := rows.Scan(
-			&item.ID, &item.Timestamp, &item.Prompt, &item.Model,
-			&item.Temperature, &item.MaxTokens, &item.Success,
-			&item.Response, &item.ErrorMsg,
-		)
+		var sortKey string
+		item, err := scanHistoryItem(rows, &sortKey)
 		if err != nil {
-			return nil, fmt.Errorf("failed to scan history row: %v", err)
+			return nil, nil, fmt.Errorf("failed to scan history row: %v", err)
 		}
-		history = append(history, item)
+		history = append(history, *item)
+		sortKeys = append(sortKeys, sortKey)
 	}
 
 	if err = rows.Err(); err != nil {
-		return nil, fmt.Errorf("error iterating history rows: %v", err)
+		return nil, nil, fmt.Errorf("error iterating history rows: %v", err)
 	}
 
-	return history, nil
+	if len(history) > page.limit {
+		last := page.limit - 1
+		info.NextCursor = page.nextCursor(len(history), sortKeys[last], strconv.FormatInt(history[last].ID, 10))
+		history = history[:page.limit]
+	}
+
+	return history, info, nil
 }
 
-func (d *Database) SaveHistory(req models.SaveHistoryRequest) error {
+// SaveHistory records an execution and returns its ID
+func (d *Database) SaveHistory(req models.SaveHistoryRequest) (int64, error) {
 	query := `
-		INSERT INTO history (prompt, model, temperature, max_tokens, success, response, error_msg)
-		VALUES (?, ?, ?, ?, ?, ?, ?)
+		INSERT INTO history (prompt, system_prompt, model, provider, temperature, max_tokens, success, response, error_msg,
+			latency_ms, prompt_tokens, completion_tokens, total_tokens, cost, prompt_id, prompt_version, run_id, user_id)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		RETURNING id
 	`
 
-	_, err := d.db.Exec(query, req.Prompt, req.Model, req.Temperature, req.MaxTokens, req.Success, req.Response, req.ErrorMsg)
+	var promptTokens, completionTokens, totalTokens sql.NullInt64
+	if usage := req.TokenUsage; usage != nil {
+		promptTokens = sql.NullInt64{Int64: int64(usage.PromptTokens), Valid: true}
+		completionTokens = sql.NullInt64{Int64: int64(usage.CompletionTokens), Valid: true}
+		totalTokens = sql.NullInt64{Int64: int64(usage.TotalTokens), Valid: true}
+	}
+
+	var id int64
+	err := d.db.QueryRow(query,
+		req.Prompt, req.SystemPrompt, req.Model, req.Provider, req.Temperature, req.MaxTokens,
+		req.Success, req.Response, req.ErrorMsg, req.LatencyMs, promptTokens, completionTokens, totalTokens, req.Cost,
+		req.PromptID, req.PromptVersion, req.RunID, d.db.owner(),
+	).Scan(&id)
 	if err != nil {
-		return fmt.Errorf("failed to save history: %v", err)
+		return 0, fmt.Errorf("failed to save history: %v", err)
 	}
 
-	return nil
+	return id, nil
+}
+
+const historyColumns = `h.id, h.timestamp, h.prompt, COALESCE(h.system_prompt, ''), h.model, COALESCE(h.provider, ''),
+	h.temperature, COALESCE(h.max_tokens, 0), h.success, COALESCE(h.response, ''), COALESCE(h.error_msg, ''),
+	COALESCE(h.latency_ms, 0), h.prompt_tokens, h.completion_tokens, h.total_tokens, COALESCE(h.cost, 0),
+	h.prompt_id, h.prompt_version, COALESCE(h.run_id, '')`
+
+// scanHistoryItem scans historyColumns followed by any extra destinations
+func scanHistoryItem(rows *sql.Rows, extra ...interface{}) (*models.HistoryItem, error) {
+	var item models.HistoryItem
+	var promptTokens, completionTokens, totalTokens, promptID, promptVersion sql.NullInt64
+	dest := []interface{}{
+		&item.ID, &item.Timestamp, &item.Prompt, &item.SystemPrompt, &item.Model, &item.Provider,
+		&item.Temperature, &item.MaxTokens, &item.Success, &item.Response, &item.ErrorMsg,
+		&item.LatencyMs, &promptTokens, &completionTokens, &totalTokens, &item.Cost,
+		&promptID, &promptVersion, &item.RunID,
+	}
+	if err := rows.Scan(append(dest, extra...)...); err != nil {
+		return nil, err
+	}
+
+	if totalTokens.Valid {
+		item.TokenUsage = &models.TokenUsage{
+			PromptTokens:     int(promptTokens.Int64),
+			CompletionTokens: int(completionTokens.Int64),
+			TotalTokens:      int(totalTokens.Int64),
+		}
+	}
+	if promptID.Valid {
+		item.PromptID = &promptID.Int64
+	}
+	if promptVersion.Valid {
+		version := int(promptVersion.Int64)
+		item.PromptVersion = &version
+	}
+	return &item, nil
 }
 
 func (d *Database) ClearHistory() error {
-	query := `DELETE FROM history`
+	where := &whereBuilder{}
+	d.db.restrictOwner(where, "")
+	query := `DELETE FROM history` + where.sql()
 
-	_, err := d.db.Exec(query)
+	_, err := d.db.Exec(query, where.args...)
 	if err != nil {
 		return fmt.Errorf("failed to clear history: %v", err)
 	}
@@ -175,192 +235,278 @@ func (d *Database) ClearHistory() error {
 }
 
 // Conversation methods
-func (d *Database) GetConversations() ([]models.Conversation, error) {
+var conversationPagination = paginationSpec{
+	sorts: map[string]sortColumn{
+		"updated_at": {expr: "c.updated_at", time: true, defaultDesc: true},
+		"created_at": {expr: "c.created_at", time: true, defaultDesc: true},
+		"title":      {expr: "c.title"},
+	},
+	defaultSort:  "updated_at",
+	idExpr:       "c.id",
+	defaultLimit: 100,
+	maxLimit:     500,
+}
+
+func (d *Database) GetConversations(filter models.ConversationFilter) ([]models.Conversation, *models.PageInfo, error) {
+	page, err := newPagination(filter.PageOptions, conversationPagination, d.db.dialect)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	where := &whereBuilder{}
+	d.db.restrict(where, "c.")
+	d.addDateRange(where, "c.updated_at", filter.From, filter.To)
+
+	info := &models.PageInfo{}
+	if err := d.db.QueryRow(`SELECT COUNT(*) FROM conversations c`+where.sql(), where.args...).Scan(&info.Total); err != nil {
+		return nil, nil, fmt.Errorf("failed to count conversations: %v", err)
+	}
+
+	if err := page.applyCursor(where); err != nil {
+		return nil, nil, err
+	}
+
 	query := `
-		SELECT id, title, created_at, updated_at
-		FROM conversations 
-		ORDER BY updated_at DESC
-	`
+		SELECT c.id, c.title, c.revision, c.created_at, c.updated_at, ` + page.sortKeySQL() + `
+		FROM conversations c` + where.sql() + page.orderBySQL() + ` LIMIT ?`
 
-	rows, err := d.db.Query(query)
+	rows, err := d.db.Query(query, append(where.args, page.limitArg())...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to query conversations: %v", err)
+		return nil, nil, fmt.Errorf("failed to query conversations: %v", err)
 	}
 	defer rows.Close()
 
 	var conversations []models.Conversation
+	var sortKeys []string
 	for rows.Next() {
 		var conv models.Conversation
-		err := rows.Scan(&conv.ID, &conv.Title, &conv.CreatedAt, &conv.UpdatedAt)
+		var sortKey string
+		err := rows.Scan(&conv.ID, &conv.Title, &conv.Revision, &conv.CreatedAt, &conv.UpdatedAt, &sortKey)
 		if err != nil {
-			return nil, fmt.Errorf("failed to scan conversation row: %v", err)
+			return nil, nil, fmt.Errorf("failed to scan conversation row: %v", err)
 		}
 		conversations = append(conversations, conv)
+		sortKeys = append(sortKeys, sortKey)
 	}
 
 	if err = rows.Err(); err != nil {
-		return nil, fmt.Errorf("error iterating conversation rows: %v", err)
+		return nil, nil, fmt.Errorf("error iterating conversation rows: %v", err)
+	}
+
+	if len(conversations) > page.limit {
+		last := page.limit - 1
+		info.NextCursor = page.nextCursor(len(conversations), sortKeys[last], conversations[last].ID)
+		conversations = conversations[:page.limit]
 	}
 
-	return conversations, nil
+	return conversations, info, nil
 }
 
 func (d *Database) GetConversation(conversationID string) (*models.Conversation, error) {
-	// Get conversation details
-	query := `SELECT id, title, created_at, updated_at FROM conversations WHERE id = ?`
+	return getConversation(d.db, conversationID)
+}
 
-	var conv models.Conversation
-	err := d.db.QueryRow(query, conversationID).Scan(&conv.ID, &conv.Title, &conv.CreatedAt, &conv.UpdatedAt)
+func getConversation(q queryer, conversationID string) (*models.Conversation, error) {
+	conv, tree, err := loadConversationTree(q, conversationID)
+	if err != nil || conv == nil {
+		return nil, err
+	}
+
+	if conv.HeadMessageID != nil {
+		conv.Messages = tree.path(*conv.HeadMessageID)
+	}
+	return conv, nil
+}
+
+// SaveConversation stores a whole conversation without rewriting it: the messages on the active branch
+// must match the start of req.Messages and only the rest are appended to it. A client whose copy is missing
+// stored messages, or whose revision is stale, gets ErrRevisionConflict instead of clobbering them.
+func (d *Database) SaveConversation(req models.SaveConversationRequest) (*models.Conversation, error) {
+	tx, err := d.db.Begin()
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, nil // Conversation not found
-		}
-		return nil, fmt.Errorf("failed to get conversation: %v", err)
+		return nil, fmt.Errorf("failed to begin transaction: %v", err)
 	}
+	defer tx.Rollback()
 
-	// Get conversation messages
-	messagesQuery := `
-		SELECT id, conversation_id, role, content, timestamp
-		FROM conversation_messages 
-		WHERE conversation_id = ? 
-		ORDER BY timestamp ASC
-	`
+	revision, err := ensureConversation(tx, req.ConversationID, req.Title, req.Revision)
+	if err != nil {
+		return nil, err
+	}
 
-	rows, err := d.db.Query(messagesQuery, conversationID)
+	stored, err := getConversation(tx, req.ConversationID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to query conversation messages: %v", err)
+		return nil, err
 	}
-	defer rows.Close()
 
-	var messages []models.ConversationMessage
-	for rows.Next() {
-		var msg models.ConversationMessage
-		err := rows.Scan(&msg.ID, &msg.ConversationID, &msg.Role, &msg.Content, &msg.Timestamp)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan message row: %v", err)
+	if len(req.Messages) < len(stored.Messages) {
+		return nil, ErrRevisionConflict
+	}
+	for i, msg := range stored.Messages {
+		if req.Messages[i].Role != msg.Role || req.Messages[i].Content != msg.Content {
+			return nil, ErrRevisionConflict
 		}
-		messages = append(messages, msg)
 	}
 
-	if err = rows.Err(); err != nil {
-		return nil, fmt.Errorf("error iterating message rows: %v", err)
+	newMessages := req.Messages[len(stored.Messages):]
+	titleChanged := req.Title != "" && req.Title != stored.Title
+	if len(newMessages) == 0 && !titleChanged && stored.Revision > 0 {
+		return stored, nil // Nothing to save
 	}
 
-	conv.Messages = messages
-	return &conv, nil
+	if err := insertMessages(tx, req.ConversationID, stored.HeadMessageID, newMessages); err != nil {
+		return nil, err
+	}
+	if err := bumpRevision(tx, req.ConversationID, revision, req.Title); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit conversation: %v", err)
+	}
+
+	return d.GetConversation(req.ConversationID)
 }
 
-func (d *Database) SaveConversation(req models.SaveConversationRequest) error {
+func (d *Database) DeleteConversation(conversationID string) error {
 	tx, err := d.db.Begin()
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %v", err)
 	}
 	defer tx.Rollback()
 
-	// Check if conversation exists
-	var exists bool
-	err = tx.QueryRow("SELECT EXISTS(SELECT 1 FROM conversations WHERE id = ?)", req.ConversationID).Scan(&exists)
-	if err != nil {
-		return fmt.Errorf("failed to check conversation existence: %v", err)
+	if ok, err := visible(tx, "conversations", conversationID); err != nil || !ok {
+		return err
 	}
 
-	if exists {
-		// Update existing conversation
-		_, err = tx.Exec("UPDATE conversations SET title = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?",
-			req.Title, req.ConversationID)
-		if err != nil {
-			return fmt.Errorf("failed to update conversation: %v", err)
-		}
-
-		// Clear existing messages
-		_, err = tx.Exec("DELETE FROM conversation_messages WHERE conversation_id = ?", req.ConversationID)
-		if err != nil {
-			return fmt.Errorf("failed to clear existing messages: %v", err)
-		}
-	} else {
-		// Create new conversation
-		title := req.Title
-		if title == "" {
-			title = "New Conversation"
-		}
+	// Foreign keys are not enforced by default in SQLite, so remove dependents explicitly
+	if _, err := tx.Exec(`DELETE FROM conversation_summaries WHERE conversation_id = ?`, conversationID); err != nil {
+		return fmt.Errorf("failed to delete conversation summaries: %v", err)
+	}
 
-		_, err = tx.Exec("INSERT INTO conversations (id, title) VALUES (?, ?)", req.ConversationID, title)
-		if err != nil {
-			return fmt.Errorf("failed to create conversation: %v", err)
-		}
+	if _, err := tx.Exec(`DELETE FROM conversation_messages WHERE conversation_id = ?`, conversationID); err != nil {
+		return fmt.Errorf("failed to delete conversation messages: %v", err)
 	}
 
-	// Insert messages
-	for _, msg := range req.Messages {
-		_, err = tx.Exec(
-			"INSERT INTO conversation_messages (conversation_id, role, content, timestamp) VALUES (?, ?, ?, ?)",
-			req.ConversationID, msg.Role, msg.Content, msg.Timestamp,
-		)
-		if err != nil {
-			return fmt.Errorf("failed to save message: %v", err)
-		}
+	if _, err := tx.Exec(`DELETE FROM conversations WHERE id = ?`, conversationID); err != nil {
+		return fmt.Errorf("failed to delete conversation: %v", err)
 	}
 
 	return tx.Commit()
 }
 
-func (d *Database) DeleteConversation(conversationID string) error {
-	query := `DELETE FROM conversations WHERE id = ?`
+// Prompt Library methods
+var promptPagination = paginationSpec{
+	sorts: map[string]sortColumn{
+		"updated_at":  {expr: "p.updated_at", time: true, defaultDesc: true},
+		"created_at":  {expr: "p.created_at", time: true, defaultDesc: true},
+		"title":       {expr: "p.title"},
+		"category":    {expr: "p.category"},
+		"usage_count": {expr: "p.usage_count", numeric: true, defaultDesc: true},
+	},
+	defaultSort:  "updated_at",
+	idExpr:       "p.id",
+	numericID:    true,
+	defaultLimit: 100,
+	maxLimit:     500,
+}
 
-	_, err := d.db.Exec(query, conversationID)
+func (d *Database) GetSavedPrompts(filter models.PromptFilter) ([]models.SavedPrompt, *models.PageInfo, error) {
+	page, err := newPagination(filter.PageOptions, promptPagination, d.db.dialect)
 	if err != nil {
-		return fmt.Errorf("failed to delete conversation: %v", err)
+		return nil, nil, err
 	}
 
-	return nil
-}
+	where := &whereBuilder{}
+	d.db.restrict(where, "p.")
+	if filter.Category != "" {
+		where.add("p.category = ?", filter.Category)
+	}
+	addTagFilter(where, filter.Tags, filter.MatchAnyTag)
+	d.addDateRange(where, "p.updated_at", filter.From, filter.To)
+
+	info := &models.PageInfo{}
+	if err := d.db.QueryRow(`SELECT COUNT(*) FROM saved_prompts p`+where.sql(), where.args...).Scan(&info.Total); err != nil {
+		return nil, nil, fmt.Errorf("failed to count saved prompts: %v", err)
+	}
+
+	if err := page.applyCursor(where); err != nil {
+		return nil, nil, err
+	}
 
-// Prompt Library methods
-func (d *Database) GetSavedPrompts() ([]models.SavedPrompt, error) {
 	query := `
-		SELECT id, title, content, description, category, tags, created_at, updated_at, usage_count
-		FROM saved_prompts 
-		ORDER BY updated_at DESC
-	`
+		SELECT p.id, p.title, p.content, p.description, p.category, p.created_at, p.updated_at, p.usage_count, ` + page.sortKeySQL() + `
+		FROM saved_prompts p` + where.sql() + page.orderBySQL() + ` LIMIT ?`
 
-	rows, err := d.db.Query(query)
+	rows, err := d.db.Query(query, append(where.args, page.limitArg())...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to query saved prompts: %v", err)
+		return nil, nil, fmt.Errorf("failed to query saved prompts: %v", err)
 	}
 	defer rows.Close()
 
 	var prompts []models.SavedPrompt
+	var sortKeys []string
 	for rows.Next() {
 		var prompt models.SavedPrompt
+		var sortKey string
 		err := rows.Scan(
 			&prompt.ID, &prompt.Title, &prompt.Content, &prompt.Description,
-			&prompt.Category, &prompt.Tags, &prompt.CreatedAt, &prompt.UpdatedAt,
-			&prompt.UsageCount,
+			&prompt.Category, &prompt.CreatedAt, &prompt.UpdatedAt,
+			&prompt.UsageCount, &sortKey,
 		)
 		if err != nil {
-			return nil, fmt.Errorf("failed to scan saved prompt row: %v", err)
+			return nil, nil, fmt.Errorf("failed to scan saved prompt row: %v", err)
 		}
 		prompts = append(prompts, prompt)
+		sortKeys = append(sortKeys, sortKey)
 	}
 
 	if err = rows.Err(); err != nil {
-		return nil, fmt.Errorf("error iterating saved prompt rows: %v", err)
+		return nil, nil, fmt.Errorf("error iterating saved prompt rows: %v", err)
+	}
+
+	if len(prompts) > page.limit {
+		last := page.limit - 1
+		info.NextCursor = page.nextCursor(len(prompts), sortKeys[last], strconv.FormatInt(prompts[last].ID, 10))
+		prompts = prompts[:page.limit]
 	}
 
-	return prompts, nil
+	ids := make([]int64, len(prompts))
+	for i := range prompts {
+		ids[i] = prompts[i].ID
+	}
+	variables, err := d.getVariablesForPrompts(ids)
+	if err != nil {
+		return nil, nil, err
+	}
+	tags, err := d.getTagsForPrompts(ids)
+	if err != nil {
+		return nil, nil, err
+	}
+	for i := range prompts {
+		prompts[i].Variables = variables[prompts[i].ID]
+		if prompts[i].Variables == nil {
+			prompts[i].Variables = []models.TemplateVariable{}
+		}
+		if prompts[i].Tags, err = marshalTags(tags[prompts[i].ID]); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return prompts, info, nil
 }
 
 func (d *Database) GetSavedPrompt(promptID int64) (*models.SavedPrompt, error) {
 	query := `
-		SELECT id, title, content, description, category, tags, created_at, updated_at, usage_count
+		SELECT id, title, content, description, category, created_at, updated_at, usage_count
 		FROM saved_prompts 
 		WHERE id = ?
 	`
+	owned, ownedArgs := d.db.andOwned("")
 
 	var prompt models.SavedPrompt
-	err := d.db.QueryRow(query, promptID).Scan(
+	err := d.db.QueryRow(query+owned, append([]interface{}{promptID}, ownedArgs...)...).Scan(
 		&prompt.ID, &prompt.Title, &prompt.Content, &prompt.Description,
-		&prompt.Category, &prompt.Tags, &prompt.CreatedAt, &prompt.UpdatedAt,
+		&prompt.Category, &prompt.CreatedAt, &prompt.UpdatedAt,
 		&prompt.UsageCount,
 	)
 	if err != nil {
@@ -370,33 +516,55 @@ func (d *Database) GetSavedPrompt(promptID int64) (*models.SavedPrompt, error) {
 		return nil, fmt.Errorf("failed to get saved prompt: %v", err)
 	}
 
+	prompt.Variables, err = getPromptVariables(d.db, promptID)
+	if err != nil {
+		return nil, err
+	}
+
+	tags, err := getPromptTags(d.db, promptID)
+	if err != nil {
+		return nil, err
+	}
+	if prompt.Tags, err = marshalTags(tags); err != nil {
+		return nil, err
+	}
+
 	return &prompt, nil
 }
 
 func (d *Database) SavePrompt(req models.SavePromptRequest) (*models.SavedPrompt, error) {
-	// Convert tags to JSON string
-	tagsJSON := "[]"
-	if len(req.Tags) > 0 {
-		tagsBytes, err := json.Marshal(req.Tags)
-		if err != nil {
-			return nil, fmt.Errorf("failed to marshal tags: %v", err)
-		}
-		tagsJSON = string(tagsBytes)
+	tx, err := d.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %v", err)
 	}
+	defer tx.Rollback()
 
 	query := `
-		INSERT INTO saved_prompts (title, content, description, category, tags)
-		VALUES (?, ?, ?, ?, ?)
+		INSERT INTO saved_prompts (title, content, description, category, user_id, workspace_id)
+		VALUES (?, ?, ?, ?, ?, ?)
+		RETURNING id
 	`
 
-	result, err := d.db.Exec(query, req.Title, req.Content, req.Description, req.Category, tagsJSON)
+	var promptID int64
+	err = tx.QueryRow(query, req.Title, req.Content, req.Description, req.Category, tx.owner(), tx.workspace()).Scan(&promptID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to save prompt: %v", err)
 	}
 
-	promptID, err := result.LastInsertId()
-	if err != nil {
-		return nil, fmt.Errorf("failed to get last insert ID: %v", err)
+	if err := replacePromptVariables(tx, promptID, req.Variables); err != nil {
+		return nil, err
+	}
+
+	if err := replacePromptTags(tx, promptID, req.Tags); err != nil {
+		return nil, err
+	}
+
+	if _, err := snapshotPromptVersion(tx, promptID, "Initial version"); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit prompt: %v", err)
 	}
 
 	// Return the saved prompt
@@ -404,46 +572,107 @@ func (d *Database) SavePrompt(req models.SavePromptRequest) (*models.SavedPrompt
 }
 
 func (d *Database) UpdatePrompt(req models.UpdatePromptRequest) (*models.SavedPrompt, error) {
-	// Convert tags to JSON string
-	tagsJSON := "[]"
-	if len(req.Tags) > 0 {
-		tagsBytes, err := json.Marshal(req.Tags)
-		if err != nil {
-			return nil, fmt.Errorf("failed to marshal tags: %v", err)
-		}
-		tagsJSON = string(tagsBytes)
+	tx, err := d.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	if ok, err := visible(tx, "saved_prompts", req.ID); err != nil || !ok {
+		return nil, err // Prompt not found
+	}
+
+	// Prompts saved before versioning existed get their current state preserved first
+	if err := ensureBaselineVersion(tx, req.ID); err != nil {
+		return nil, err
 	}
 
 	query := `
 		UPDATE saved_prompts 
-		SET title = ?, content = ?, description = ?, category = ?, tags = ?, updated_at = CURRENT_TIMESTAMP
+		SET title = ?, content = ?, description = ?, category = ?, updated_at = CURRENT_TIMESTAMP
 		WHERE id = ?
 	`
 
-	_, err := d.db.Exec(query, req.Title, req.Content, req.Description, req.Category, tagsJSON, req.ID)
+	result, err := tx.Exec(query, req.Title, req.Content, req.Description, req.Category, req.ID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to update prompt: %v", err)
 	}
 
+	if affected, err := result.RowsAffected(); err != nil {
+		return nil, fmt.Errorf("failed to check updated rows: %v", err)
+	} else if affected == 0 {
+		return nil, nil // Prompt not found
+	}
+
+	if err := replacePromptVariables(tx, req.ID, req.Variables); err != nil {
+		return nil, err
+	}
+
+	if err := replacePromptTags(tx, req.ID, req.Tags); err != nil {
+		return nil, err
+	}
+
+	if _, err := snapshotPromptVersion(tx, req.ID, req.Note); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit prompt update: %v", err)
+	}
+
 	// Return the updated prompt
 	return d.GetSavedPrompt(req.ID)
 }
 
 func (d *Database) DeletePrompt(promptID int64) error {
-	query := `DELETE FROM saved_prompts WHERE id = ?`
-
-	_, err := d.db.Exec(query, promptID)
+	tx, err := d.db.Begin()
 	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	if ok, err := visible(tx, "saved_prompts", promptID); err != nil || !ok {
+		return err
+	}
+
+	// Foreign keys are not enforced by default in SQLite, so remove dependents explicitly
+	if _, err := tx.Exec(`DELETE FROM prompt_variables WHERE prompt_id = ?`, promptID); err != nil {
+		return fmt.Errorf("failed to delete prompt variables: %v", err)
+	}
+
+	if _, err := tx.Exec(`DELETE FROM prompt_versions WHERE prompt_id = ?`, promptID); err != nil {
+		return fmt.Errorf("failed to delete prompt versions: %v", err)
+	}
+
+	if _, err := tx.Exec(`DELETE FROM prompt_tags WHERE prompt_id = ?`, promptID); err != nil {
+		return fmt.Errorf("failed to delete prompt tags: %v", err)
+	}
+
+	// History keeps its runs of the prompt without the link
+	if _, err := tx.Exec(`UPDATE history SET prompt_id = NULL WHERE prompt_id = ?`, promptID); err != nil {
+		return fmt.Errorf("failed to unlink prompt history: %v", err)
+	}
+
+	if _, err := tx.Exec(`UPDATE eval_suites SET prompt_id = NULL WHERE prompt_id = ?`, promptID); err != nil {
+		return fmt.Errorf("failed to unlink prompt eval suites: %v", err)
+	}
+
+	if _, err := tx.Exec(`DELETE FROM saved_prompts WHERE id = ?`, promptID); err != nil {
 		return fmt.Errorf("failed to delete prompt: %v", err)
 	}
 
-	return nil
+	if err := deleteOrphanTags(tx); err != nil {
+		return err
+	}
+
+	return tx.Commit()
 }
 
 func (d *Database) IncrementPromptUsage(promptID int64) error {
 	query := `UPDATE saved_prompts SET usage_count = usage_count + 1 WHERE id = ?`
+	owned, ownedArgs := d.db.andOwned("")
 
-	_, err := d.db.Exec(query, promptID)
+	_, err := d.db.Exec(query+owned, append([]interface{}{promptID}, ownedArgs...)...)
 	if err != nil {
 		return fmt.Errorf("failed to increment prompt usage: %v", err)
 	}
@@ -2,62 +2,107 @@ package database
 
 import (
 	"database/sql"
+	"fmt"
+	"net/url"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
 	"promptforge/internal/models"
-
-	_ "github.com/mattn/go-sqlite3"
 )
 
+// setupTestDB returns a migrated, empty database. Tests run against SQLite by default; set
+// TEST_DATABASE_DRIVER=postgres and TEST_DATABASE_URL to run the same suite on PostgreSQL.
 func setupTestDB(t *testing.T) *Database {
-	// Create temporary database file
-	tmpfile := "test_" + time.Now().Format("20060102150405") + ".db"
-
-	db, err := sql.Open("sqlite3", tmpfile)
-	if err != nil {
-		t.Fatalf("Failed to create test database: %v", err)
+	t.Helper()
+
+	var database *Database
+	if os.Getenv("TEST_DATABASE_DRIVER") == DriverPostgres {
+		database = openPostgresTestDB(t)
+	} else {
+		var err error
+		database, err = Open(DriverSQLite, filepath.Join(t.TempDir(), "test.db"))
+		if err != nil {
+			t.Fatalf("Failed to create test database: %v", err)
+		}
+		t.Cleanup(func() { database.Close() })
 	}
 
-	database := &Database{db: db}
-
 	// Initialize tables
-	if err := database.initTables(); err != nil {
+	if err := database.InitSchema(true); err != nil {
 		t.Fatalf("Failed to initialize test database: %v", err)
 	}
 
-	// Clean up function
+	return database
+}
+
+// openPostgresTestDB isolates each test in its own schema, dropped when the test ends
+func openPostgresTestDB(t *testing.T) *Database {
+	t.Helper()
+
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Fatal("TEST_DATABASE_URL must be set when TEST_DATABASE_DRIVER=postgres")
+	}
+
+	admin, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatalf("Failed to connect to PostgreSQL: %v", err)
+	}
+
+	schema := fmt.Sprintf("test_%d", time.Now().UnixNano())
+	if _, err := admin.Exec("CREATE SCHEMA " + schema); err != nil {
+		admin.Close()
+		t.Fatalf("Failed to create test schema: %v", err)
+	}
 	t.Cleanup(func() {
-		database.Close()
-		os.Remove(tmpfile)
+		admin.Exec("DROP SCHEMA " + schema + " CASCADE")
+		admin.Close()
 	})
 
+	database, err := Open(DriverPostgres, withSearchPath(t, dsn, schema))
+	if err != nil {
+		t.Fatalf("Failed to open test database: %v", err)
+	}
+	t.Cleanup(func() { database.Close() })
+
 	return database
 }
 
-func TestNewDatabase(t *testing.T) {
-	// Test with a temporary database
-	tmpfile := "test_new_db.db"
-	defer os.Remove(tmpfile)
+// withSearchPath adds a search_path run-time parameter to a URL or key=value connection string
+func withSearchPath(t *testing.T, dsn, schema string) string {
+	if !strings.Contains(dsn, "://") {
+		return dsn + " search_path=" + schema
+	}
 
-	// Mock the database path temporarily
-	originalPath := "./promptforge.db"
+	u, err := url.Parse(dsn)
+	if err != nil {
+		t.Fatalf("Invalid TEST_DATABASE_URL: %v", err)
+	}
+	query := u.Query()
+	query.Set("search_path", schema)
+	u.RawQuery = query.Encode()
+	return u.String()
+}
 
-	// Create a new database instance
-	db, err := sql.Open("sqlite3", tmpfile)
+func TestOpen(t *testing.T) {
+	database, err := Open(DriverSQLite, filepath.Join(t.TempDir(), "open.db"))
 	if err != nil {
 		t.Fatalf("Failed to open test database: %v", err)
 	}
+	defer database.Close()
+
+	if database.Driver() != DriverSQLite {
+		t.Errorf("Expected driver %q, got %q", DriverSQLite, database.Driver())
+	}
 
-	database := &Database{db: db}
-	err = database.initTables()
+	err = database.InitSchema(true)
 	if err != nil {
 		t.Fatalf("Failed to initialize tables: %v", err)
 	}
 
-	defer database.Close()
-
 	// Test that tables were created by trying to insert data
 	_, err = database.db.Exec("INSERT INTO history (prompt, model, temperature, max_tokens, success, response) VALUES (?, ?, ?, ?, ?, ?)",
 		"test", "gpt-4", 0.7, 100, true, "test response")
@@ -65,7 +110,12 @@ func TestNewDatabase(t *testing.T) {
 		t.Errorf("Failed to insert into history table: %v", err)
 	}
 
-	_ = originalPath // Avoid unused variable warning
+	if _, err := Open("mysql", "whatever"); err == nil {
+		t.Error("Expected an error for an unsupported driver")
+	}
+	if _, err := Open(DriverPostgres, ""); err == nil {
+		t.Error("Expected an error for a missing connection string")
+	}
 }
 
 func TestHistoryOperations(t *testing.T) {
@@ -82,13 +132,13 @@ func TestHistoryOperations(t *testing.T) {
 		ErrorMsg:    "",
 	}
 
-	err := db.SaveHistory(saveReq)
+	_, err := db.SaveHistory(saveReq)
 	if err != nil {
 		t.Fatalf("Failed to save history: %v", err)
 	}
 
 	// Test GetHistory
-	history, err := db.GetHistory()
+	history, _, err := db.GetHistory(models.HistoryFilter{})
 	if err != nil {
 		t.Fatalf("Failed to get history: %v", err)
 	}
@@ -117,7 +167,7 @@ func TestHistoryOperations(t *testing.T) {
 		t.Fatalf("Failed to clear history: %v", err)
 	}
 
-	history, err = db.GetHistory()
+	history, _, err = db.GetHistory(models.HistoryFilter{})
 	if err != nil {
 		t.Fatalf("Failed to get history after clear: %v", err)
 	}
@@ -154,7 +204,7 @@ func TestSavedPromptOperations(t *testing.T) {
 	promptID := savedPrompt.ID
 
 	// Test GetSavedPrompts
-	prompts, err := db.GetSavedPrompts()
+	prompts, _, err := db.GetSavedPrompts(models.PromptFilter{})
 	if err != nil {
 		t.Fatalf("Failed to get saved prompts: %v", err)
 	}
@@ -246,13 +296,13 @@ func TestConversationOperations(t *testing.T) {
 		},
 	}
 
-	err := db.SaveConversation(saveReq)
+	_, err := db.SaveConversation(saveReq)
 	if err != nil {
 		t.Fatalf("Failed to save conversation: %v", err)
 	}
 
 	// Test GetConversations
-	conversations, err := db.GetConversations()
+	conversations, _, err := db.GetConversations(models.ConversationFilter{})
 	if err != nil {
 		t.Fatalf("Failed to get conversations: %v", err)
 	}
@@ -309,14 +359,14 @@ func TestHistoryLimit(t *testing.T) {
 			Response:    "Test response " + string(rune(i)),
 		}
 
-		err := db.SaveHistory(saveReq)
+		_, err := db.SaveHistory(saveReq)
 		if err != nil {
 			t.Fatalf("Failed to save history item %d: %v", i, err)
 		}
 	}
 
 	// Get history and verify limit
-	history, err := db.GetHistory()
+	history, _, err := db.GetHistory(models.HistoryFilter{})
 	if err != nil {
 		t.Fatalf("Failed to get history: %v", err)
 	}
@@ -407,3 +457,122 @@ func containsAt(s, substr string) bool {
 	}
 	return false
 }
+
+func TestPromptVariableOperations(t *testing.T) {
+	db := setupTestDB(t)
+
+	saveReq := models.SavePromptRequest{
+		Title:   "Support reply",
+		Content: "Reply to {{customer_name}} in a {{tone}} tone",
+		Variables: []models.TemplateVariable{
+			{Name: "customer_name", Type: models.VariableTypeString, Required: true, Description: "Customer's name"},
+			{Name: "tone", Type: models.VariableTypeEnum, Default: "formal", Options: []string{"formal", "casual"}},
+		},
+	}
+
+	savedPrompt, err := db.SavePrompt(saveReq)
+	if err != nil {
+		t.Fatalf("Failed to save prompt with variables: %v", err)
+	}
+
+	if len(savedPrompt.Variables) != 2 {
+		t.Fatalf("Expected 2 variables, got %d", len(savedPrompt.Variables))
+	}
+	if savedPrompt.Variables[0].Name != "customer_name" || savedPrompt.Variables[0].Description != "Customer's name" {
+		t.Errorf("Unexpected first variable: %+v", savedPrompt.Variables[0])
+	}
+	if len(savedPrompt.Variables[1].Options) != 2 || savedPrompt.Variables[1].Default != "formal" {
+		t.Errorf("Unexpected enum variable: %+v", savedPrompt.Variables[1])
+	}
+
+	// Updating replaces the declarations
+	updated, err := db.UpdatePrompt(models.UpdatePromptRequest{
+		ID:        savedPrompt.ID,
+		Title:     saveReq.Title,
+		Content:   "Reply to {{customer_name}}",
+		Variables: saveReq.Variables[:1],
+	})
+	if err != nil {
+		t.Fatalf("Failed to update prompt variables: %v", err)
+	}
+	if len(updated.Variables) != 1 {
+		t.Errorf("Expected 1 variable after update, got %d", len(updated.Variables))
+	}
+
+	prompts, _, err := db.GetSavedPrompts(models.PromptFilter{})
+	if err != nil {
+		t.Fatalf("Failed to get saved prompts: %v", err)
+	}
+	if len(prompts) != 1 || len(prompts[0].Variables) != 1 {
+		t.Errorf("Expected listed prompt to carry its variables, got %+v", prompts)
+	}
+
+	// Deleting the prompt removes its variables
+	if err := db.DeletePrompt(savedPrompt.ID); err != nil {
+		t.Fatalf("Failed to delete prompt: %v", err)
+	}
+
+	var count int
+	if err := db.db.QueryRow("SELECT COUNT(*) FROM prompt_variables").Scan(&count); err != nil {
+		t.Fatalf("Failed to count prompt variables: %v", err)
+	}
+	if count != 0 {
+		t.Errorf("Expected prompt variables to be deleted, found %d", count)
+	}
+}
+
+func TestHistoryExecutionDetails(t *testing.T) {
+	db := setupTestDB(t)
+
+	prompt, err := db.SavePrompt(models.SavePromptRequest{Title: "Greeting", Content: "Say hello"})
+	if err != nil {
+		t.Fatalf("Failed to save prompt: %v", err)
+	}
+	version := 1
+	id, err := db.SaveHistory(models.SaveHistoryRequest{
+		Prompt:        "Say hello",
+		SystemPrompt:  "Be brief",
+		Model:         "gpt-4",
+		Provider:      "openai",
+		Temperature:   0.7,
+		Success:       true,
+		Response:      "Hello",
+		LatencyMs:     120,
+		TokenUsage:    &models.TokenUsage{PromptTokens: 10, CompletionTokens: 2, TotalTokens: 12},
+		Cost:          0.00042,
+		PromptID:      &prompt.ID,
+		PromptVersion: &version,
+		RunID:         "run-1",
+	})
+	if err != nil || id == 0 {
+		t.Fatalf("Failed to save history: %v", err)
+	}
+	if _, err := db.SaveHistory(models.SaveHistoryRequest{Prompt: "Inline", Model: "gpt-4", Success: true}); err != nil {
+		t.Fatalf("Failed to save history: %v", err)
+	}
+
+	history, _, err := db.GetHistory(models.HistoryFilter{PromptID: prompt.ID})
+	if err != nil || len(history) != 1 {
+		t.Fatalf("Expected one run of the prompt, got %d (%v)", len(history), err)
+	}
+	item := history[0]
+	if item.ID != id || item.SystemPrompt != "Be brief" || item.Provider != "openai" || item.LatencyMs != 120 || item.Cost != 0.00042 || item.RunID != "run-1" {
+		t.Errorf("Unexpected execution details %+v", item)
+	}
+	if item.TokenUsage == nil || item.TokenUsage.TotalTokens != 12 || item.PromptVersion == nil || *item.PromptVersion != 1 {
+		t.Errorf("Expected token usage and the prompt version, got %+v", item)
+	}
+
+	if history, _, _ = db.GetHistory(models.HistoryFilter{RunID: "run-1", Provider: "openai"}); len(history) != 1 {
+		t.Errorf("Expected to filter by run and provider, got %d items", len(history))
+	}
+
+	// Deleting the prompt keeps its runs without the link
+	if err := db.DeletePrompt(prompt.ID); err != nil {
+		t.Fatalf("Failed to delete prompt: %v", err)
+	}
+	history, _, _ = db.GetHistory(models.HistoryFilter{RunID: "run-1"})
+	if len(history) != 1 || history[0].PromptID != nil {
+		t.Errorf("Expected the run to remain without its prompt link, got %+v", history)
+	}
+}
@@ -0,0 +1,185 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	_ "github.com/lib/pq"           // PostgreSQL driver
+	_ "github.com/mattn/go-sqlite3" // SQLite driver
+)
+
+// Supported storage backends
+const (
+	DriverSQLite   = "sqlite"
+	DriverPostgres = "postgres"
+)
+
+// dialect captures the SQL differences between the supported backends.
+// Queries are written once with ? placeholders and SQLite-compatible syntax.
+type dialect struct {
+	name          string
+	driverName    string // database/sql driver
+	system        string // db.system in trace spans
+	numbered      bool   // Placeholders are $1, $2, ... instead of ?
+	like          string // Case-insensitive LIKE operator
+	migrationsDir string
+	// migrationsTableSQL creates the schema_migrations bookkeeping table
+	migrationsTableSQL string
+	// migrationLockSQL serializes migrations between replicas sharing one database
+	migrationLockSQL string
+}
+
+var dialects = map[string]*dialect{
+	DriverSQLite: {
+		name:          DriverSQLite,
+		driverName:    "sqlite3",
+		system:        "sqlite",
+		like:          "LIKE",
+		migrationsDir: "migrations/sqlite",
+		migrationsTableSQL: `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version INTEGER PRIMARY KEY,
+			name TEXT NOT NULL,
+			applied_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		);
+		`,
+	},
+	DriverPostgres: {
+		name:          DriverPostgres,
+		driverName:    "postgres",
+		system:        "postgresql",
+		numbered:      true,
+		like:          "ILIKE",
+		migrationsDir: "migrations/postgres",
+		migrationsTableSQL: `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version INTEGER PRIMARY KEY,
+			name TEXT NOT NULL,
+			applied_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+		);
+		`,
+		migrationLockSQL: `SELECT pg_advisory_xact_lock(4242001)`,
+	},
+}
+
+// timeExpr makes a timestamp column comparable and sortable. SQLite stores timestamps
+// as text in more than one format, so they are normalized with datetime().
+func (d *dialect) timeExpr(column string) string {
+	if d.name == DriverSQLite {
+		return "datetime(" + column + ")"
+	}
+	return column
+}
+
+// timeArg converts a time for comparison against timeExpr
+func (d *dialect) timeArg(t time.Time) interface{} {
+	if d.name == DriverSQLite {
+		return t.UTC().Format(sqliteTimeLayout)
+	}
+	return t
+}
+
+// rebind rewrites ? placeholders outside string literals into the dialect's style
+func (d *dialect) rebind(query string) string {
+	if !d.numbered || !strings.Contains(query, "?") {
+		return query
+	}
+
+	var b strings.Builder
+	b.Grow(len(query) + 16)
+	n := 0
+	inString := false
+	for i := 0; i < len(query); i++ {
+		ch := query[i]
+		switch {
+		case ch == '\'':
+			inString = !inString
+			b.WriteByte(ch)
+		case ch == '?' && !inString:
+			n++
+			b.WriteByte('$')
+			b.WriteString(strconv.Itoa(n))
+		default:
+			b.WriteByte(ch)
+		}
+	}
+	return b.String()
+}
+
+// conn is a *sql.DB that rebinds placeholders for its dialect. ctx, when set, is the request the
+// statements run for; it parents their trace spans but does not cancel them.
+type conn struct {
+	*sql.DB
+	dialect *dialect
+	scope
+	ctx context.Context
+}
+
+func (c *conn) Exec(query string, args ...interface{}) (result sql.Result, err error) {
+	done := c.dialect.startQuery(c.ctx, query)
+	defer func() { done(err) }()
+	return c.DB.Exec(c.dialect.rebind(query), args...)
+}
+
+func (c *conn) Query(query string, args ...interface{}) (rows *sql.Rows, err error) {
+	done := c.dialect.startQuery(c.ctx, query)
+	defer func() { done(err) }()
+	return c.DB.Query(c.dialect.rebind(query), args...)
+}
+
+func (c *conn) QueryRow(query string, args ...interface{}) *sql.Row {
+	done := c.dialect.startQuery(c.ctx, query)
+	row := c.DB.QueryRow(c.dialect.rebind(query), args...)
+	done(row.Err())
+	return row
+}
+
+func (c *conn) Begin() (*txn, error) {
+	tx, err := c.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	return &txn{Tx: tx, dialect: c.dialect, scope: c.scope, ctx: c.ctx}, nil
+}
+
+// txn is a *sql.Tx that rebinds placeholders for its dialect
+type txn struct {
+	*sql.Tx
+	dialect *dialect
+	scope
+	ctx context.Context
+}
+
+func (t *txn) Exec(query string, args ...interface{}) (result sql.Result, err error) {
+	done := t.dialect.startQuery(t.ctx, query)
+	defer func() { done(err) }()
+	return t.Tx.Exec(t.dialect.rebind(query), args...)
+}
+
+func (t *txn) Query(query string, args ...interface{}) (rows *sql.Rows, err error) {
+	done := t.dialect.startQuery(t.ctx, query)
+	defer func() { done(err) }()
+	return t.Tx.Query(t.dialect.rebind(query), args...)
+}
+
+func (t *txn) QueryRow(query string, args ...interface{}) *sql.Row {
+	done := t.dialect.startQuery(t.ctx, query)
+	row := t.Tx.QueryRow(t.dialect.rebind(query), args...)
+	done(row.Err())
+	return row
+}
+
+func lookupDialect(driver string) (*dialect, error) {
+	if driver == "" {
+		driver = DriverSQLite
+	}
+	d, ok := dialects[driver]
+	if !ok {
+		return nil, fmt.Errorf("unsupported database driver %q (expected %q or %q)", driver, DriverSQLite, DriverPostgres)
+	}
+	return d, nil
+}
@@ -0,0 +1,41 @@
+package database
+
+import "testing"
+
+func TestRebind(t *testing.T) {
+	postgres := dialects[DriverPostgres]
+
+	tests := []struct {
+		query    string
+		expected string
+	}{
+		{"SELECT * FROM history WHERE id = ?", "SELECT * FROM history WHERE id = $1"},
+		{"INSERT INTO t (a, b) VALUES (?, ?)", "INSERT INTO t (a, b) VALUES ($1, $2)"},
+		{`SELECT 1 WHERE tags LIKE ? ESCAPE '\' AND note = 'why?' AND id = ?`, `SELECT 1 WHERE tags LIKE $1 ESCAPE '\' AND note = 'why?' AND id = $2`},
+		{"SELECT 'it''s ?' WHERE x = ?", "SELECT 'it''s ?' WHERE x = $1"},
+		{"SELECT 1", "SELECT 1"},
+	}
+
+	for _, test := range tests {
+		if result := postgres.rebind(test.query); result != test.expected {
+			t.Errorf("For query %q, expected %q, got %q", test.query, test.expected, result)
+		}
+	}
+
+	query := "SELECT * FROM history WHERE id = ?"
+	if result := dialects[DriverSQLite].rebind(query); result != query {
+		t.Errorf("Expected SQLite queries to be unchanged, got %q", result)
+	}
+}
+
+func TestLookupDialect(t *testing.T) {
+	for _, driver := range []string{"", DriverSQLite, DriverPostgres} {
+		if _, err := lookupDialect(driver); err != nil {
+			t.Errorf("Expected driver %q to be supported: %v", driver, err)
+		}
+	}
+
+	if _, err := lookupDialect("oracle"); err == nil {
+		t.Error("Expected an error for an unsupported driver")
+	}
+}
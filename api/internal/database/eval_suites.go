@@ -0,0 +1,142 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"promptforge/internal/models"
+)
+
+// ErrPromptNotFound is returned when an eval suite links to a prompt outside its scope
+var ErrPromptNotFound = errors.New("prompt not found")
+
+const evalSuiteColumns = `id, name, prompt_id, data, created_at, updated_at`
+
+func scanEvalSuite(row rowScanner) (*models.EvalSuite, error) {
+	var suite models.EvalSuite
+	var promptID sql.NullInt64
+	var data string
+	if err := row.Scan(&suite.ID, &suite.Name, &promptID, &data, &suite.CreatedAt, &suite.UpdatedAt); err != nil {
+		return nil, err
+	}
+	if promptID.Valid {
+		suite.PromptID = &promptID.Int64
+	}
+	if err := json.Unmarshal([]byte(data), &suite.Data); err != nil {
+		return nil, fmt.Errorf("failed to decode eval suite %d: %v", suite.ID, err)
+	}
+	return &suite, nil
+}
+
+// ListEvalSuites returns the saved eval suites, newest first. A promptID above zero keeps only that prompt's.
+func (d *Database) ListEvalSuites(promptID int64) ([]models.EvalSuite, error) {
+	where := &whereBuilder{}
+	d.db.restrict(where, "")
+	if promptID > 0 {
+		where.add("prompt_id = ?", promptID)
+	}
+
+	rows, err := d.db.Query(`SELECT `+evalSuiteColumns+` FROM eval_suites`+where.sql()+` ORDER BY updated_at DESC, id DESC`, where.args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query eval suites: %v", err)
+	}
+	defer rows.Close()
+
+	suites := []models.EvalSuite{}
+	for rows.Next() {
+		suite, err := scanEvalSuite(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan eval suite row: %v", err)
+		}
+		suites = append(suites, *suite)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating eval suite rows: %v", err)
+	}
+
+	return suites, nil
+}
+
+// GetEvalSuite returns nil when the suite does not exist in this scope
+func (d *Database) GetEvalSuite(suiteID int64) (*models.EvalSuite, error) {
+	owned, args := d.db.andOwned("")
+	suite, err := scanEvalSuite(d.db.QueryRow(`SELECT `+evalSuiteColumns+` FROM eval_suites WHERE id = ?`+owned,
+		append([]interface{}{suiteID}, args...)...))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get eval suite: %v", err)
+	}
+	return suite, nil
+}
+
+func (d *Database) SaveEvalSuite(req models.SaveEvalSuiteRequest) (*models.EvalSuite, error) {
+	data, err := evalSuiteData(d.db, req)
+	if err != nil {
+		return nil, err
+	}
+
+	suite, err := scanEvalSuite(d.db.QueryRow(`
+		INSERT INTO eval_suites (name, prompt_id, data, user_id, workspace_id) VALUES (?, ?, ?, ?, ?)
+		RETURNING `+evalSuiteColumns, strings.TrimSpace(req.Name), req.PromptID, data, d.db.owner(), d.db.workspace()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to save eval suite: %v", err)
+	}
+	return suite, nil
+}
+
+// UpdateEvalSuite replaces a suite's name, prompt link and contents. It returns nil when the suite
+// does not exist in this scope.
+func (d *Database) UpdateEvalSuite(suiteID int64, req models.SaveEvalSuiteRequest) (*models.EvalSuite, error) {
+	data, err := evalSuiteData(d.db, req)
+	if err != nil {
+		return nil, err
+	}
+
+	owned, args := d.db.andOwned("")
+	suite, err := scanEvalSuite(d.db.QueryRow(`
+		UPDATE eval_suites SET name = ?, prompt_id = ?, data = ?, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ?`+owned+`
+		RETURNING `+evalSuiteColumns,
+		append([]interface{}{strings.TrimSpace(req.Name), req.PromptID, data, suiteID}, args...)...))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to update eval suite: %v", err)
+	}
+	return suite, nil
+}
+
+func (d *Database) DeleteEvalSuite(suiteID int64) error {
+	owned, args := d.db.andOwned("")
+	if _, err := d.db.Exec(`DELETE FROM eval_suites WHERE id = ?`+owned, append([]interface{}{suiteID}, args...)...); err != nil {
+		return fmt.Errorf("failed to delete eval suite: %v", err)
+	}
+	return nil
+}
+
+// evalSuiteData validates a suite and encodes its contents for storage
+func evalSuiteData(q queryer, req models.SaveEvalSuiteRequest) (string, error) {
+	if strings.TrimSpace(req.Name) == "" {
+		return "", fmt.Errorf("eval suite name is required")
+	}
+	if req.PromptID != nil {
+		if ok, err := visible(q, "saved_prompts", *req.PromptID); err != nil {
+			return "", err
+		} else if !ok {
+			return "", ErrPromptNotFound
+		}
+	}
+
+	data, err := json.Marshal(req.Data)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode eval suite: %v", err)
+	}
+	return string(data), nil
+}
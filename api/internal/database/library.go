@@ -0,0 +1,330 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"promptforge/internal/models"
+)
+
+// ExportLibrary returns every saved prompt with its tags, variables, usage stats and version history
+func (d *Database) ExportLibrary() (*models.Library, error) {
+	where := &whereBuilder{}
+	d.db.restrict(where, "")
+	rows, err := d.db.Query(`
+		SELECT id, title, content, description, category, created_at, updated_at, usage_count
+		FROM saved_prompts`+where.sql()+`
+		ORDER BY id ASC
+	`, where.args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query saved prompts: %v", err)
+	}
+	defer rows.Close()
+
+	var ids []int64
+	var prompts []models.LibraryPrompt
+	for rows.Next() {
+		var id int64
+		var prompt models.LibraryPrompt
+		err := rows.Scan(&id, &prompt.Title, &prompt.Content, &prompt.Description, &prompt.Category,
+			&prompt.CreatedAt, &prompt.UpdatedAt, &prompt.UsageCount)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan saved prompt row: %v", err)
+		}
+		ids = append(ids, id)
+		prompts = append(prompts, prompt)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating saved prompt rows: %v", err)
+	}
+	rows.Close()
+
+	tags, err := d.getTagsForPrompts(ids)
+	if err != nil {
+		return nil, err
+	}
+	variables, err := d.getVariablesForPrompts(ids)
+	if err != nil {
+		return nil, err
+	}
+	versions, err := d.getVersionsForExport()
+	if err != nil {
+		return nil, err
+	}
+
+	for i, id := range ids {
+		prompts[i].Tags = tags[id]
+		prompts[i].Variables = variables[id]
+		prompts[i].Versions = versions[id]
+	}
+
+	return &models.Library{
+		SchemaVersion: models.LibrarySchemaVersion,
+		ExportedAt:    time.Now().UTC(),
+		Prompts:       prompts,
+	}, nil
+}
+
+// getVersionsForExport loads the scope's prompt versions in portable form, keyed by prompt ID and oldest first
+func (d *Database) getVersionsForExport() (map[int64][]models.LibraryPromptVersion, error) {
+	owned, args := d.db.andOwnedIn("prompt_id", "saved_prompts")
+	rows, err := d.db.Query(`SELECT `+promptVersionColumns+` FROM prompt_versions WHERE 1 = 1`+owned+` ORDER BY prompt_id, version ASC`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query prompt versions: %v", err)
+	}
+	defer rows.Close()
+
+	versions := make(map[int64][]models.LibraryPromptVersion)
+	for rows.Next() {
+		version, err := scanPromptVersion(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		var tags []string
+		if err := json.Unmarshal([]byte(version.Tags), &tags); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal version tags: %v", err)
+		}
+
+		versions[version.PromptID] = append(versions[version.PromptID], models.LibraryPromptVersion{
+			Version:     version.Version,
+			Title:       version.Title,
+			Description: version.Description,
+			Category:    version.Category,
+			Tags:        tags,
+			Variables:   version.Variables,
+			Note:        version.Note,
+			CreatedAt:   version.CreatedAt,
+			Content:     version.Content,
+		})
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating prompt version rows: %v", err)
+	}
+
+	return versions, nil
+}
+
+// ValidateLibrary checks an import document before anything is written
+func ValidateLibrary(library *models.Library) error {
+	if library.SchemaVersion > models.LibrarySchemaVersion {
+		return fmt.Errorf("library schema version %d is newer than supported version %d", library.SchemaVersion, models.LibrarySchemaVersion)
+	}
+
+	for i, prompt := range library.Prompts {
+		if strings.TrimSpace(prompt.Title) == "" {
+			return fmt.Errorf("prompt %d has no title", i+1)
+		}
+		if strings.TrimSpace(prompt.Content) == "" {
+			return fmt.Errorf("prompt %q has no content", prompt.Title)
+		}
+		seen := make(map[int]bool, len(prompt.Versions))
+		for _, version := range prompt.Versions {
+			if version.Version < 1 || seen[version.Version] {
+				return fmt.Errorf("prompt %q has an invalid or duplicate version number %d", prompt.Title, version.Version)
+			}
+			seen[version.Version] = true
+		}
+	}
+
+	return nil
+}
+
+// ImportLibrary adds the library's prompts in a single transaction. Prompts whose title already exists
+// are skipped, overwritten in place (keeping local history and recording a new version) or imported
+// under a new title, depending on strategy.
+func (d *Database) ImportLibrary(library *models.Library, strategy string) (*models.ImportResult, error) {
+	switch strategy {
+	case models.ConflictSkip, models.ConflictOverwrite, models.ConflictRename:
+	default:
+		return nil, fmt.Errorf("unknown conflict strategy %q (expected skip, overwrite or rename)", strategy)
+	}
+
+	if err := ValidateLibrary(library); err != nil {
+		return nil, err
+	}
+
+	tx, err := d.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	result := &models.ImportResult{Prompts: []models.ImportOutcome{}}
+	for i := range library.Prompts {
+		prompt := &library.Prompts[i]
+		outcome := models.ImportOutcome{Title: prompt.Title}
+
+		existingID, err := findPromptByTitle(tx, prompt.Title)
+		if err != nil {
+			return nil, err
+		}
+
+		switch {
+		case existingID == 0:
+			outcome.Action = "created"
+			outcome.PromptID, err = importPrompt(tx, prompt, prompt.Title)
+			result.Created++
+		case strategy == models.ConflictSkip:
+			outcome.Action = "skipped"
+			outcome.PromptID = existingID
+			result.Skipped++
+		case strategy == models.ConflictOverwrite:
+			outcome.Action = "overwritten"
+			outcome.PromptID = existingID
+			err = overwritePrompt(tx, existingID, prompt)
+			result.Overwritten++
+		default:
+			outcome.Action = "renamed"
+			if outcome.NewTitle, err = uniquePromptTitle(tx, prompt.Title); err == nil {
+				outcome.PromptID, err = importPrompt(tx, prompt, outcome.NewTitle)
+			}
+			result.Renamed++
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		result.Prompts = append(result.Prompts, outcome)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit library import: %v", err)
+	}
+
+	return result, nil
+}
+
+// findPromptByTitle returns the oldest prompt with exactly this title, or 0 if there is none
+func findPromptByTitle(tx *txn, title string) (int64, error) {
+	owned, args := tx.andOwned("")
+	var id int64
+	err := tx.QueryRow(`SELECT id FROM saved_prompts WHERE title = ?`+owned+` ORDER BY id LIMIT 1`, append([]interface{}{title}, args...)...).Scan(&id)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to look up prompt %q: %v", title, err)
+	}
+	return id, nil
+}
+
+// uniquePromptTitle appends " (2)", " (3)", ... until the title is free
+func uniquePromptTitle(tx *txn, title string) (string, error) {
+	for n := 2; ; n++ {
+		candidate := fmt.Sprintf("%s (%d)", title, n)
+		id, err := findPromptByTitle(tx, candidate)
+		if err != nil {
+			return "", err
+		}
+		if id == 0 {
+			return candidate, nil
+		}
+	}
+}
+
+// importPrompt inserts a prompt with its original timestamps, usage count and version history
+func importPrompt(tx *txn, prompt *models.LibraryPrompt, title string) (int64, error) {
+	now := time.Now()
+	createdAt, updatedAt := prompt.CreatedAt, prompt.UpdatedAt
+	if createdAt.IsZero() {
+		createdAt = now
+	}
+	if updatedAt.IsZero() {
+		updatedAt = createdAt
+	}
+
+	var promptID int64
+	err := tx.QueryRow(`
+		INSERT INTO saved_prompts (title, content, description, category, created_at, updated_at, usage_count, user_id, workspace_id)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		RETURNING id
+	`, title, prompt.Content, prompt.Description, prompt.Category,
+		tx.dialect.timeArg(createdAt), tx.dialect.timeArg(updatedAt), prompt.UsageCount, tx.owner(), tx.workspace()).Scan(&promptID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to import prompt %q: %v", prompt.Title, err)
+	}
+
+	if err := replacePromptVariables(tx, promptID, prompt.Variables); err != nil {
+		return 0, err
+	}
+	if err := replacePromptTags(tx, promptID, prompt.Tags); err != nil {
+		return 0, err
+	}
+
+	if len(prompt.Versions) == 0 {
+		_, err = snapshotPromptVersion(tx, promptID, "Imported")
+		return promptID, err
+	}
+
+	for i := range prompt.Versions {
+		if err := importPromptVersion(tx, promptID, &prompt.Versions[i], createdAt); err != nil {
+			return 0, err
+		}
+	}
+
+	return promptID, nil
+}
+
+func importPromptVersion(tx *txn, promptID int64, version *models.LibraryPromptVersion, fallbackTime time.Time) error {
+	tagsJSON, err := marshalTags(normalizeTags(version.Tags))
+	if err != nil {
+		return err
+	}
+
+	variables := version.Variables
+	if variables == nil {
+		variables = []models.TemplateVariable{}
+	}
+	variablesJSON, err := json.Marshal(variables)
+	if err != nil {
+		return fmt.Errorf("failed to marshal prompt variables: %v", err)
+	}
+
+	createdAt := version.CreatedAt
+	if createdAt.IsZero() {
+		createdAt = fallbackTime
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO prompt_versions (prompt_id, version, title, content, description, category, tags, variables, note, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, promptID, version.Version, version.Title, version.Content, version.Description, version.Category,
+		tagsJSON, string(variablesJSON), version.Note, tx.dialect.timeArg(createdAt))
+	if err != nil {
+		return fmt.Errorf("failed to import version %d: %v", version.Version, err)
+	}
+
+	return nil
+}
+
+// overwritePrompt replaces an existing prompt's fields with the imported ones and records the change as a new version
+func overwritePrompt(tx *txn, promptID int64, prompt *models.LibraryPrompt) error {
+	if err := ensureBaselineVersion(tx, promptID); err != nil {
+		return err
+	}
+
+	_, err := tx.Exec(`
+		UPDATE saved_prompts
+		SET content = ?, description = ?, category = ?, usage_count = ?, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ?
+	`, prompt.Content, prompt.Description, prompt.Category, prompt.UsageCount, promptID)
+	if err != nil {
+		return fmt.Errorf("failed to overwrite prompt %q: %v", prompt.Title, err)
+	}
+
+	if err := replacePromptVariables(tx, promptID, prompt.Variables); err != nil {
+		return err
+	}
+	if err := replacePromptTags(tx, promptID, prompt.Tags); err != nil {
+		return err
+	}
+
+	_, err = snapshotPromptVersion(tx, promptID, "Overwritten by library import")
+	return err
+}
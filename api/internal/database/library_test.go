@@ -0,0 +1,123 @@
+package database
+
+import (
+	"testing"
+
+	"promptforge/internal/models"
+)
+
+func TestExportImportLibraryRoundTrip(t *testing.T) {
+	source := setupTestDB(t)
+
+	saved, err := source.SavePrompt(models.SavePromptRequest{
+		Title:     "Translate",
+		Content:   "Translate {{text}} into French",
+		Category:  "Translation",
+		Tags:      []string{"french", "i18n"},
+		Variables: []models.TemplateVariable{{Name: "text", Type: models.VariableTypeString, Required: true}},
+	})
+	if err != nil {
+		t.Fatalf("Failed to save prompt: %v", err)
+	}
+	_, err = source.UpdatePrompt(models.UpdatePromptRequest{
+		ID:       saved.ID,
+		Title:    "Translate",
+		Content:  "Translate {{text}} into Spanish",
+		Category: "Translation",
+		Tags:     []string{"spanish"},
+		Note:     "Switch language",
+	})
+	if err != nil {
+		t.Fatalf("Failed to update prompt: %v", err)
+	}
+	if err := source.IncrementPromptUsage(saved.ID); err != nil {
+		t.Fatalf("Failed to increment usage: %v", err)
+	}
+
+	library, err := source.ExportLibrary()
+	if err != nil {
+		t.Fatalf("Failed to export library: %v", err)
+	}
+	if len(library.Prompts) != 1 || len(library.Prompts[0].Versions) != 2 {
+		t.Fatalf("Expected one prompt with two versions, got %+v", library.Prompts)
+	}
+
+	target := setupTestDB(t)
+	result, err := target.ImportLibrary(library, models.ConflictSkip)
+	if err != nil {
+		t.Fatalf("Failed to import library: %v", err)
+	}
+	if result.Created != 1 || result.Prompts[0].Action != "created" {
+		t.Fatalf("Expected the prompt to be created, got %+v", result)
+	}
+
+	imported, err := target.GetSavedPrompt(result.Prompts[0].PromptID)
+	if err != nil || imported == nil {
+		t.Fatalf("Failed to get imported prompt: %v", err)
+	}
+	if imported.Content != "Translate {{text}} into Spanish" || imported.Tags != `["spanish"]` || imported.UsageCount != 1 {
+		t.Errorf("Expected prompt fields to survive the round trip, got %+v", imported)
+	}
+	if !imported.CreatedAt.Equal(saved.CreatedAt) {
+		t.Errorf("Expected created_at %v to be preserved, got %v", saved.CreatedAt, imported.CreatedAt)
+	}
+
+	versions, err := target.GetPromptVersions(imported.ID)
+	if err != nil {
+		t.Fatalf("Failed to get versions: %v", err)
+	}
+	if len(versions) != 2 || versions[0].Note != "Switch language" || versions[1].Tags != `["french","i18n"]` {
+		t.Errorf("Expected version history to be imported, got %+v", versions)
+	}
+}
+
+func TestImportLibraryConflicts(t *testing.T) {
+	db := setupTestDB(t)
+
+	existing, err := db.SavePrompt(models.SavePromptRequest{Title: "Summarize", Content: "Summarize {{text}}", Tags: []string{"local"}})
+	if err != nil {
+		t.Fatalf("Failed to save prompt: %v", err)
+	}
+
+	library := &models.Library{Prompts: []models.LibraryPrompt{
+		{Title: "Summarize", Content: "Summarize {{text}} in one line", Tags: []string{"remote"}, UsageCount: 7},
+	}}
+
+	result, err := db.ImportLibrary(library, models.ConflictSkip)
+	if err != nil || result.Skipped != 1 {
+		t.Fatalf("Expected the prompt to be skipped, got %+v (%v)", result, err)
+	}
+	if prompt, _ := db.GetSavedPrompt(existing.ID); prompt.Content != "Summarize {{text}}" {
+		t.Errorf("Expected skip to leave the prompt unchanged, got %q", prompt.Content)
+	}
+
+	result, err = db.ImportLibrary(library, models.ConflictRename)
+	if err != nil || result.Renamed != 1 || result.Prompts[0].NewTitle != "Summarize (2)" {
+		t.Fatalf("Expected the prompt to be renamed, got %+v (%v)", result, err)
+	}
+	result, err = db.ImportLibrary(library, models.ConflictRename)
+	if err != nil || result.Prompts[0].NewTitle != "Summarize (3)" {
+		t.Fatalf("Expected the next free title, got %+v (%v)", result, err)
+	}
+
+	result, err = db.ImportLibrary(library, models.ConflictOverwrite)
+	if err != nil || result.Overwritten != 1 || result.Prompts[0].PromptID != existing.ID {
+		t.Fatalf("Expected the original prompt to be overwritten, got %+v (%v)", result, err)
+	}
+	prompt, _ := db.GetSavedPrompt(existing.ID)
+	if prompt.Content != "Summarize {{text}} in one line" || prompt.Tags != `["remote"]` || prompt.UsageCount != 7 {
+		t.Errorf("Expected imported fields to replace the prompt, got %+v", prompt)
+	}
+	versions, _ := db.GetPromptVersions(existing.ID)
+	if len(versions) != 2 || versions[1].Content != "Summarize {{text}}" {
+		t.Errorf("Expected overwrite to keep local history and add a version, got %+v", versions)
+	}
+
+	if _, err := db.ImportLibrary(library, "merge"); err == nil {
+		t.Error("Expected an error for an unknown conflict strategy")
+	}
+	invalid := &models.Library{Prompts: []models.LibraryPrompt{{Title: "No content"}}}
+	if _, err := db.ImportLibrary(invalid, models.ConflictSkip); err == nil {
+		t.Error("Expected an error for a prompt without content")
+	}
+}
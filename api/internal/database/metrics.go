@@ -0,0 +1,69 @@
+package database
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"promptforge/internal/metrics"
+)
+
+const instrumentationName = "promptforge/internal/database"
+
+var queryDuration = metrics.Factory.NewHistogramVec(prometheus.HistogramOpts{
+	Name:    "promptforge_db_query_duration_seconds",
+	Help:    "Time taken by database statements until their results are available, by statement type.",
+	Buckets: []float64{0.0005, 0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5},
+}, []string{"driver", "operation"})
+
+// startQuery times a statement and, when ctx is a traced request, records a span for it. Call the
+// returned function with the statement's error once its results are available.
+func (d *dialect) startQuery(ctx context.Context, query string) func(error) {
+	start := time.Now()
+	op := operation(query)
+
+	var span trace.Span
+	if ctx != nil && trace.SpanFromContext(ctx).IsRecording() {
+		_, span = otel.Tracer(instrumentationName).Start(ctx, "db."+op,
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(
+				attribute.String("db.system", d.system),
+				attribute.String("db.operation.name", op),
+				attribute.String("db.query.text", strings.Join(strings.Fields(query), " ")),
+			),
+		)
+	}
+
+	return func(err error) {
+		queryDuration.WithLabelValues(d.name, op).Observe(time.Since(start).Seconds())
+		if span != nil {
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+			}
+			span.End()
+		}
+	}
+}
+
+// operation is the lower-cased first keyword of a statement, such as select or insert
+func operation(query string) string {
+	fields := strings.FieldsFunc(query, func(r rune) bool {
+		return r == ' ' || r == '\t' || r == '\n' || r == '\r' || r == '('
+	})
+	if len(fields) == 0 {
+		return "unknown"
+	}
+	switch op := strings.ToLower(fields[0]); op {
+	case "select", "insert", "update", "delete", "with":
+		return op
+	default:
+		return "other"
+	}
+}
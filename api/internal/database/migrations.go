@@ -0,0 +1,325 @@
+package database
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Each driver has its own scripts under migrations/<driver>, numbered in lockstep
+//
+//go:embed migrations
+var migrationFiles embed.FS
+
+// migrationFilePattern matches files such as 0002_prompt_variables.up.sql
+var migrationFilePattern = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+// requiresPattern matches a "-- requires: fts5" line naming a SQLite module an up script needs
+var requiresPattern = regexp.MustCompile(`(?m)^-- requires: ([a-z0-9_]+)$`)
+
+// Migration is one versioned schema change with its rollback script
+type Migration struct {
+	Version  int
+	Name     string
+	Up       string
+	Down     string
+	Requires string // Optional SQLite module; without it the migration is skipped and left pending
+}
+
+type MigrationStatus struct {
+	Version   int
+	Name      string
+	Applied   bool
+	AppliedAt *time.Time
+}
+
+// loadMigrations reads the embedded scripts in dir and returns them ordered by version
+func loadMigrations(dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(migrationFiles, dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations: %v", err)
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, entry := range entries {
+		match := migrationFilePattern.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("unexpected migration file name: %s", entry.Name())
+		}
+
+		version, _ := strconv.Atoi(match[1])
+		contents, err := migrationFiles.ReadFile(path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %s: %v", entry.Name(), err)
+		}
+
+		migration, exists := byVersion[version]
+		if !exists {
+			migration = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = migration
+		} else if migration.Name != match[2] {
+			return nil, fmt.Errorf("migration %d has conflicting names %q and %q", version, migration.Name, match[2])
+		}
+
+		if match[3] == "up" {
+			migration.Up = string(contents)
+			if requires := requiresPattern.FindStringSubmatch(migration.Up); requires != nil {
+				migration.Requires = requires[1]
+			}
+		} else {
+			migration.Down = string(contents)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" || migration.Down == "" {
+			return nil, fmt.Errorf("migration %04d_%s needs both up and down scripts", migration.Version, migration.Name)
+		}
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+// migrations returns the driver's migrations this build can apply. One that needs a module the
+// build lacks is left out without being recorded, so it runs once a build has the module.
+func (d *Database) migrations() ([]Migration, error) {
+	all, err := loadMigrations(d.db.dialect.migrationsDir)
+	if err != nil {
+		return nil, err
+	}
+
+	migrations := all[:0]
+	for _, migration := range all {
+		if migration.Requires != "" {
+			ok, err := d.hasModule(migration.Requires)
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				continue
+			}
+		}
+		migrations = append(migrations, migration)
+	}
+	return migrations, nil
+}
+
+// hasModule reports whether the SQLite build includes an optional module such as fts5
+func (d *Database) hasModule(name string) (bool, error) {
+	if d.db.dialect.name != DriverSQLite {
+		return false, nil
+	}
+	var used bool
+	if err := d.db.QueryRow(`SELECT sqlite_compileoption_used(?)`, "ENABLE_"+strings.ToUpper(name)).Scan(&used); err != nil {
+		return false, fmt.Errorf("failed to check for the %s module: %v", name, err)
+	}
+	return used, nil
+}
+
+func (d *Database) ensureMigrationsTable() error {
+	_, err := d.db.Exec(d.db.dialect.migrationsTableSQL)
+	if err != nil {
+		return fmt.Errorf("failed to create schema_migrations table: %v", err)
+	}
+	return nil
+}
+
+func (d *Database) appliedMigrations() (map[int]time.Time, error) {
+	if err := d.ensureMigrationsTable(); err != nil {
+		return nil, err
+	}
+
+	rows, err := d.db.Query(`SELECT version, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query schema_migrations: %v", err)
+	}
+	defer rows.Close()
+
+	applied := make(map[int]time.Time)
+	for rows.Next() {
+		var version int
+		var appliedAt time.Time
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan schema_migrations row: %v", err)
+		}
+		applied[version] = appliedAt
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating schema_migrations rows: %v", err)
+	}
+
+	return applied, nil
+}
+
+// MigrationStatus lists every known migration and whether it has been applied
+func (d *Database) MigrationStatus() ([]MigrationStatus, error) {
+	migrations, err := d.migrations()
+	if err != nil {
+		return nil, err
+	}
+
+	applied, err := d.appliedMigrations()
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]MigrationStatus, len(migrations))
+	for i, migration := range migrations {
+		statuses[i] = MigrationStatus{Version: migration.Version, Name: migration.Name}
+		if appliedAt, ok := applied[migration.Version]; ok {
+			statuses[i].Applied = true
+			statuses[i].AppliedAt = &appliedAt
+		}
+	}
+
+	return statuses, nil
+}
+
+// PendingMigrations returns the migrations Migrate would apply, in order
+func (d *Database) PendingMigrations() ([]Migration, error) {
+	migrations, err := d.migrations()
+	if err != nil {
+		return nil, err
+	}
+
+	applied, err := d.appliedMigrations()
+	if err != nil {
+		return nil, err
+	}
+
+	var pending []Migration
+	for _, migration := range migrations {
+		if _, ok := applied[migration.Version]; !ok {
+			pending = append(pending, migration)
+		}
+	}
+
+	// Migrations skipped for a missing module may have been applied by another build
+	all, err := loadMigrations(d.db.dialect.migrationsDir)
+	if err != nil {
+		return nil, err
+	}
+	known := make(map[int]bool, len(all))
+	for _, migration := range all {
+		known[migration.Version] = true
+	}
+
+	for version := range applied {
+		if !known[version] {
+			return nil, fmt.Errorf("database has migration %d applied, which this build does not know about; upgrade PromptForge", version)
+		}
+	}
+
+	return pending, nil
+}
+
+// Migrate applies every pending migration in its own transaction. With dryRun it only reports them.
+func (d *Database) Migrate(dryRun bool) ([]Migration, error) {
+	pending, err := d.PendingMigrations()
+	if err != nil {
+		return nil, err
+	}
+
+	if dryRun {
+		return pending, nil
+	}
+
+	for i, migration := range pending {
+		if err := d.runMigration(migration, true); err != nil {
+			return pending[:i], fmt.Errorf("migration %04d_%s failed: %v", migration.Version, migration.Name, err)
+		}
+	}
+
+	return pending, nil
+}
+
+// MigrateDown rolls back the most recent steps migrations, newest first. With dryRun it only reports them.
+func (d *Database) MigrateDown(steps int, dryRun bool) ([]Migration, error) {
+	statuses, err := d.MigrationStatus()
+	if err != nil {
+		return nil, err
+	}
+
+	migrations, err := loadMigrations(d.db.dialect.migrationsDir)
+	if err != nil {
+		return nil, err
+	}
+	byVersion := make(map[int]Migration, len(migrations))
+	for _, migration := range migrations {
+		byVersion[migration.Version] = migration
+	}
+
+	var rollback []Migration
+	for i := len(statuses) - 1; i >= 0 && len(rollback) < steps; i-- {
+		if statuses[i].Applied {
+			rollback = append(rollback, byVersion[statuses[i].Version])
+		}
+	}
+
+	if dryRun {
+		return rollback, nil
+	}
+
+	for i, migration := range rollback {
+		if err := d.runMigration(migration, false); err != nil {
+			return rollback[:i], fmt.Errorf("rollback of %04d_%s failed: %v", migration.Version, migration.Name, err)
+		}
+	}
+
+	return rollback, nil
+}
+
+// runMigration applies (up) or rolls back one migration together with its bookkeeping row.
+// Where the dialect supports it a lock is held and the state re-checked, so replicas
+// starting at the same time never run the same migration twice.
+func (d *Database) runMigration(migration Migration, up bool) error {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	if d.db.dialect.migrationLockSQL != "" {
+		if _, err := tx.Exec(d.db.dialect.migrationLockSQL); err != nil {
+			return fmt.Errorf("failed to acquire migration lock: %v", err)
+		}
+	}
+
+	var applied bool
+	err = tx.QueryRow(`SELECT EXISTS(SELECT 1 FROM schema_migrations WHERE version = ?)`, migration.Version).Scan(&applied)
+	if err != nil {
+		return fmt.Errorf("failed to check migration state: %v", err)
+	}
+	if applied == up {
+		return nil // Another process got here first
+	}
+
+	script, record := migration.Up, `INSERT INTO schema_migrations (version, name) VALUES (?, ?)`
+	args := []interface{}{migration.Version, migration.Name}
+	if !up {
+		script, record = migration.Down, `DELETE FROM schema_migrations WHERE version = ?`
+		args = args[:1]
+	}
+
+	if _, err := tx.Exec(script); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(record, args...); err != nil {
+		return fmt.Errorf("failed to record migration: %v", err)
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,38 @@
+CREATE TABLE IF NOT EXISTS history (
+	id BIGSERIAL PRIMARY KEY,
+	timestamp TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+	prompt TEXT NOT NULL,
+	model TEXT NOT NULL,
+	temperature DOUBLE PRECISION NOT NULL,
+	max_tokens INTEGER,
+	success BOOLEAN NOT NULL,
+	response TEXT,
+	error_msg TEXT
+);
+
+CREATE TABLE IF NOT EXISTS conversations (
+	id TEXT PRIMARY KEY,
+	title TEXT NOT NULL,
+	created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS conversation_messages (
+	id BIGSERIAL PRIMARY KEY,
+	conversation_id TEXT NOT NULL REFERENCES conversations(id) ON DELETE CASCADE,
+	role TEXT NOT NULL,
+	content TEXT NOT NULL,
+	timestamp TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS saved_prompts (
+	id BIGSERIAL PRIMARY KEY,
+	title TEXT NOT NULL,
+	content TEXT NOT NULL,
+	description TEXT DEFAULT '',
+	category TEXT DEFAULT 'General',
+	tags TEXT DEFAULT '[]',
+	created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+	usage_count INTEGER DEFAULT 0
+);
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS prompt_variables (
+	id BIGSERIAL PRIMARY KEY,
+	prompt_id BIGINT NOT NULL REFERENCES saved_prompts(id) ON DELETE CASCADE,
+	position INTEGER NOT NULL,
+	name TEXT NOT NULL,
+	type TEXT NOT NULL DEFAULT 'string',
+	default_value TEXT DEFAULT '',
+	description TEXT DEFAULT '',
+	required BOOLEAN NOT NULL DEFAULT TRUE,
+	options TEXT DEFAULT '[]',
+	UNIQUE(prompt_id, name)
+);
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS prompt_versions (
+	id BIGSERIAL PRIMARY KEY,
+	prompt_id BIGINT NOT NULL REFERENCES saved_prompts(id) ON DELETE CASCADE,
+	version INTEGER NOT NULL,
+	title TEXT NOT NULL,
+	content TEXT NOT NULL,
+	description TEXT DEFAULT '',
+	category TEXT DEFAULT 'General',
+	tags TEXT DEFAULT '[]',
+	variables TEXT DEFAULT '[]',
+	note TEXT DEFAULT '',
+	created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+	UNIQUE(prompt_id, version)
+);
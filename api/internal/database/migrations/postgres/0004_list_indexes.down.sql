@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_saved_prompts_updated_at;
+DROP INDEX IF EXISTS idx_conversation_messages_conversation;
+DROP INDEX IF EXISTS idx_conversations_updated_at;
+DROP INDEX IF EXISTS idx_history_timestamp;
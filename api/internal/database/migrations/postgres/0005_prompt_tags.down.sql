@@ -0,0 +1,11 @@
+ALTER TABLE saved_prompts ADD COLUMN tags TEXT DEFAULT '[]';
+
+UPDATE saved_prompts p SET tags = COALESCE((
+	SELECT json_agg(t.name ORDER BY pt.position)::text
+	FROM prompt_tags pt
+	JOIN tags t ON t.id = pt.tag_id
+	WHERE pt.prompt_id = p.id
+), '[]');
+
+DROP TABLE IF EXISTS prompt_tags;
+DROP TABLE IF EXISTS tags;
@@ -0,0 +1,31 @@
+-- Tags move from the JSON array in saved_prompts.tags into their own tables
+CREATE TABLE IF NOT EXISTS tags (
+	id BIGSERIAL PRIMARY KEY,
+	name TEXT NOT NULL UNIQUE
+);
+
+CREATE TABLE IF NOT EXISTS prompt_tags (
+	prompt_id BIGINT NOT NULL REFERENCES saved_prompts(id) ON DELETE CASCADE,
+	tag_id BIGINT NOT NULL REFERENCES tags(id) ON DELETE CASCADE,
+	position INTEGER NOT NULL DEFAULT 0,
+	PRIMARY KEY (prompt_id, tag_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_prompt_tags_tag ON prompt_tags(tag_id);
+
+INSERT INTO tags (name)
+SELECT DISTINCT btrim(j.value)
+FROM saved_prompts p
+CROSS JOIN LATERAL json_array_elements_text(COALESCE(NULLIF(p.tags, ''), '[]')::json) AS j(value)
+WHERE btrim(j.value) <> ''
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO prompt_tags (prompt_id, tag_id, position)
+SELECT p.id, t.id, MIN(j.ordinality) - 1
+FROM saved_prompts p
+CROSS JOIN LATERAL json_array_elements_text(COALESCE(NULLIF(p.tags, ''), '[]')::json) WITH ORDINALITY AS j(value, ordinality)
+JOIN tags t ON t.name = btrim(j.value)
+GROUP BY p.id, t.id
+ON CONFLICT DO NOTHING;
+
+ALTER TABLE saved_prompts DROP COLUMN tags;
@@ -0,0 +1,9 @@
+-- Last synced state of each prompt file in the git-backed library.
+-- No foreign key: a prompt deleted while sync was off must still be traced to its file.
+CREATE TABLE IF NOT EXISTS prompt_sync (
+	path TEXT PRIMARY KEY,
+	prompt_id BIGINT NOT NULL UNIQUE,
+	file_hash TEXT NOT NULL,
+	prompt_hash TEXT NOT NULL,
+	synced_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,4 @@
+-- Optimistic concurrency for conversations: every change bumps the revision,
+-- and a save based on an older revision is rejected instead of overwriting.
+ALTER TABLE conversations ADD COLUMN revision INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE conversation_messages ADD COLUMN edited_at TIMESTAMPTZ;
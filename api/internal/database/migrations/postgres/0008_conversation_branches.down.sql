@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_conversation_messages_parent;
+ALTER TABLE conversations DROP COLUMN head_message_id;
+ALTER TABLE conversation_messages DROP COLUMN parent_id;
@@ -0,0 +1,15 @@
+-- Conversations are trees: every message points at the message it follows, so a thread can be forked
+-- from any message. head_message_id is the last message of the active branch.
+ALTER TABLE conversation_messages ADD COLUMN parent_id BIGINT REFERENCES conversation_messages(id) ON DELETE CASCADE;
+ALTER TABLE conversations ADD COLUMN head_message_id BIGINT;
+
+-- Existing conversations are a single thread
+UPDATE conversation_messages SET parent_id = (
+	SELECT MAX(p.id) FROM conversation_messages p
+	WHERE p.conversation_id = conversation_messages.conversation_id AND p.id < conversation_messages.id
+);
+UPDATE conversations SET head_message_id = (
+	SELECT MAX(m.id) FROM conversation_messages m WHERE m.conversation_id = conversations.id
+);
+
+CREATE INDEX IF NOT EXISTS idx_conversation_messages_parent ON conversation_messages(parent_id);
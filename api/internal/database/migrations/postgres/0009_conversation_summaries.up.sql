@@ -0,0 +1,14 @@
+-- Summaries of the start of long conversations, sent in place of the messages they cover. A summary
+-- written up to through_message_id applies to every branch that contains that message.
+CREATE TABLE IF NOT EXISTS conversation_summaries (
+	id BIGSERIAL PRIMARY KEY,
+	conversation_id TEXT NOT NULL REFERENCES conversations(id) ON DELETE CASCADE,
+	through_message_id BIGINT NOT NULL,
+	message_count INTEGER NOT NULL,
+	content TEXT NOT NULL,
+	model TEXT NOT NULL,
+	token_count INTEGER NOT NULL DEFAULT 0,
+	created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_conversation_summaries_message ON conversation_summaries(conversation_id, through_message_id);
@@ -0,0 +1,12 @@
+DROP INDEX IF EXISTS idx_history_run;
+DROP INDEX IF EXISTS idx_history_prompt;
+ALTER TABLE history DROP COLUMN run_id;
+ALTER TABLE history DROP COLUMN prompt_version;
+ALTER TABLE history DROP COLUMN prompt_id;
+ALTER TABLE history DROP COLUMN cost;
+ALTER TABLE history DROP COLUMN total_tokens;
+ALTER TABLE history DROP COLUMN completion_tokens;
+ALTER TABLE history DROP COLUMN prompt_tokens;
+ALTER TABLE history DROP COLUMN latency_ms;
+ALTER TABLE history DROP COLUMN system_prompt;
+ALTER TABLE history DROP COLUMN provider;
@@ -0,0 +1,38 @@
+-- Accounts for authenticated deployments. Passwords are stored as Argon2id hashes; API keys and
+-- session tokens only as SHA-256 digests, so a copy of the database cannot be used to sign in.
+CREATE TABLE IF NOT EXISTS users (
+	id BIGSERIAL PRIMARY KEY,
+	username TEXT NOT NULL UNIQUE,
+	password_hash TEXT NOT NULL DEFAULT '',
+	is_admin BOOLEAN NOT NULL DEFAULT FALSE,
+	created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS api_keys (
+	id BIGSERIAL PRIMARY KEY,
+	user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	name TEXT NOT NULL,
+	prefix TEXT NOT NULL,
+	key_hash TEXT NOT NULL UNIQUE,
+	created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+	last_used_at TIMESTAMPTZ
+);
+
+CREATE TABLE IF NOT EXISTS sessions (
+	token_hash TEXT PRIMARY KEY,
+	user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+	expires_at TIMESTAMPTZ NOT NULL
+);
+
+-- Owner of each row. NULL rows predate authentication and are only visible with it turned off
+-- until claimed with `promptforge user create -claim`.
+ALTER TABLE history ADD COLUMN user_id BIGINT REFERENCES users(id);
+ALTER TABLE conversations ADD COLUMN user_id BIGINT REFERENCES users(id);
+ALTER TABLE saved_prompts ADD COLUMN user_id BIGINT REFERENCES users(id);
+
+CREATE INDEX IF NOT EXISTS idx_api_keys_user ON api_keys(user_id);
+CREATE INDEX IF NOT EXISTS idx_sessions_user ON sessions(user_id);
+CREATE INDEX IF NOT EXISTS idx_history_user ON history(user_id);
+CREATE INDEX IF NOT EXISTS idx_conversations_user ON conversations(user_id);
+CREATE INDEX IF NOT EXISTS idx_saved_prompts_user ON saved_prompts(user_id);
@@ -0,0 +1,36 @@
+-- Workspaces share saved prompts, conversations and eval suites between their members. Rows with a
+-- NULL workspace_id are private to the user who created them.
+CREATE TABLE IF NOT EXISTS workspaces (
+	id BIGSERIAL PRIMARY KEY,
+	name TEXT NOT NULL,
+	created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS workspace_members (
+	workspace_id BIGINT NOT NULL REFERENCES workspaces(id) ON DELETE CASCADE,
+	user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	role TEXT NOT NULL CHECK (role IN ('owner', 'editor', 'viewer')),
+	created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+	PRIMARY KEY (workspace_id, user_id)
+);
+
+-- Generated evaluation suites, kept so they can be rerun. data holds the EvalData document.
+CREATE TABLE IF NOT EXISTS eval_suites (
+	id BIGSERIAL PRIMARY KEY,
+	name TEXT NOT NULL,
+	prompt_id BIGINT REFERENCES saved_prompts(id) ON DELETE SET NULL,
+	data TEXT NOT NULL,
+	user_id BIGINT REFERENCES users(id),
+	workspace_id BIGINT REFERENCES workspaces(id),
+	created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+ALTER TABLE saved_prompts ADD COLUMN workspace_id BIGINT REFERENCES workspaces(id);
+ALTER TABLE conversations ADD COLUMN workspace_id BIGINT REFERENCES workspaces(id);
+
+CREATE INDEX IF NOT EXISTS idx_workspace_members_user ON workspace_members(user_id);
+CREATE INDEX IF NOT EXISTS idx_saved_prompts_workspace ON saved_prompts(workspace_id);
+CREATE INDEX IF NOT EXISTS idx_conversations_workspace ON conversations(workspace_id);
+CREATE INDEX IF NOT EXISTS idx_eval_suites_user ON eval_suites(user_id);
+CREATE INDEX IF NOT EXISTS idx_eval_suites_workspace ON eval_suites(workspace_id);
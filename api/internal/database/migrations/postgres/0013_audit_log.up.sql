@@ -0,0 +1,28 @@
+-- Append-only record of every mutating API request. Rows are never updated or deleted.
+CREATE TABLE IF NOT EXISTS audit_log (
+	id BIGSERIAL PRIMARY KEY,
+	user_id BIGINT REFERENCES users(id),
+	username TEXT NOT NULL DEFAULT '',
+	workspace_id BIGINT REFERENCES workspaces(id),
+	method TEXT NOT NULL,
+	route TEXT NOT NULL,
+	path TEXT NOT NULL,
+	entity_id TEXT NOT NULL DEFAULT '',
+	status INTEGER NOT NULL,
+	before_summary TEXT NOT NULL DEFAULT '',
+	after_summary TEXT NOT NULL DEFAULT '',
+	created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_log_created_at ON audit_log(created_at);
+CREATE INDEX IF NOT EXISTS idx_audit_log_user ON audit_log(user_id);
+CREATE INDEX IF NOT EXISTS idx_audit_log_route ON audit_log(route, entity_id);
+
+CREATE OR REPLACE FUNCTION audit_log_append_only() RETURNS trigger AS $$
+BEGIN
+	RAISE EXCEPTION 'audit_log is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_log_append_only BEFORE UPDATE OR DELETE ON audit_log
+	FOR EACH ROW EXECUTE FUNCTION audit_log_append_only();
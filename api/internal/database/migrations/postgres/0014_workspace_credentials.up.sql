@@ -0,0 +1,11 @@
+-- Provider credentials that a workspace uses instead of the server's. api_key is encrypted under the
+-- master key and bound to the workspace and provider.
+CREATE TABLE IF NOT EXISTS workspace_credentials (
+	workspace_id BIGINT NOT NULL REFERENCES workspaces(id) ON DELETE CASCADE,
+	provider TEXT NOT NULL,
+	api_key TEXT NOT NULL,
+	base_url TEXT NOT NULL DEFAULT '',
+	updated_by BIGINT REFERENCES users(id) ON DELETE SET NULL,
+	updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+	PRIMARY KEY (workspace_id, provider)
+);
@@ -0,0 +1,2 @@
+-- Full-text indexes are SQLite-only; PostgreSQL searches with ILIKE. Kept in lockstep with SQLite.
+SELECT 1;
@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS messages_fts;
+DROP TABLE IF EXISTS history_fts;
+DROP TABLE IF EXISTS prompts_fts;
+DROP TABLE IF EXISTS saved_prompts;
+DROP TABLE IF EXISTS conversation_messages;
+DROP TABLE IF EXISTS conversations;
+DROP TABLE IF EXISTS history;
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS prompt_variables (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	prompt_id INTEGER NOT NULL,
+	position INTEGER NOT NULL,
+	name TEXT NOT NULL,
+	type TEXT NOT NULL DEFAULT 'string',
+	default_value TEXT DEFAULT '',
+	description TEXT DEFAULT '',
+	required BOOLEAN NOT NULL DEFAULT 1,
+	options TEXT DEFAULT '[]',
+	UNIQUE(prompt_id, name),
+	FOREIGN KEY(prompt_id) REFERENCES saved_prompts(id) ON DELETE CASCADE
+);
@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS prompt_versions (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	prompt_id INTEGER NOT NULL,
+	version INTEGER NOT NULL,
+	title TEXT NOT NULL,
+	content TEXT NOT NULL,
+	description TEXT DEFAULT '',
+	category TEXT DEFAULT 'General',
+	tags TEXT DEFAULT '[]',
+	variables TEXT DEFAULT '[]',
+	note TEXT DEFAULT '',
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	UNIQUE(prompt_id, version),
+	FOREIGN KEY(prompt_id) REFERENCES saved_prompts(id) ON DELETE CASCADE
+);
@@ -0,0 +1,14 @@
+ALTER TABLE saved_prompts ADD COLUMN tags TEXT DEFAULT '[]';
+
+UPDATE saved_prompts SET tags = (
+	SELECT json_group_array(name) FROM (
+		SELECT t.name
+		FROM prompt_tags pt
+		JOIN tags t ON t.id = pt.tag_id
+		WHERE pt.prompt_id = saved_prompts.id
+		ORDER BY pt.position
+	)
+);
+
+DROP TABLE IF EXISTS prompt_tags;
+DROP TABLE IF EXISTS tags;
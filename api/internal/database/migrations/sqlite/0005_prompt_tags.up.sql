@@ -0,0 +1,30 @@
+-- Tags move from the JSON array in saved_prompts.tags into their own tables
+CREATE TABLE IF NOT EXISTS tags (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	name TEXT NOT NULL UNIQUE
+);
+
+CREATE TABLE IF NOT EXISTS prompt_tags (
+	prompt_id INTEGER NOT NULL,
+	tag_id INTEGER NOT NULL,
+	position INTEGER NOT NULL DEFAULT 0,
+	PRIMARY KEY (prompt_id, tag_id),
+	FOREIGN KEY(prompt_id) REFERENCES saved_prompts(id) ON DELETE CASCADE,
+	FOREIGN KEY(tag_id) REFERENCES tags(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_prompt_tags_tag ON prompt_tags(tag_id);
+
+INSERT OR IGNORE INTO tags (name)
+SELECT DISTINCT trim(j.value)
+FROM saved_prompts p, json_each(CASE WHEN json_valid(p.tags) THEN p.tags ELSE '[]' END) j
+WHERE j.type = 'text' AND trim(j.value) <> '';
+
+INSERT OR IGNORE INTO prompt_tags (prompt_id, tag_id, position)
+SELECT p.id, t.id, MIN(j.key)
+FROM saved_prompts p, json_each(CASE WHEN json_valid(p.tags) THEN p.tags ELSE '[]' END) j
+JOIN tags t ON t.name = trim(j.value)
+WHERE j.type = 'text'
+GROUP BY p.id, t.id;
+
+ALTER TABLE saved_prompts DROP COLUMN tags;
@@ -0,0 +1,2 @@
+ALTER TABLE conversation_messages DROP COLUMN edited_at;
+ALTER TABLE conversations DROP COLUMN revision;
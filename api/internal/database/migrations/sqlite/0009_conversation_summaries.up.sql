@@ -0,0 +1,15 @@
+-- Summaries of the start of long conversations, sent in place of the messages they cover. A summary
+-- written up to through_message_id applies to every branch that contains that message.
+CREATE TABLE IF NOT EXISTS conversation_summaries (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	conversation_id TEXT NOT NULL,
+	through_message_id INTEGER NOT NULL,
+	message_count INTEGER NOT NULL,
+	content TEXT NOT NULL,
+	model TEXT NOT NULL,
+	token_count INTEGER NOT NULL DEFAULT 0,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	FOREIGN KEY(conversation_id) REFERENCES conversations(id) ON DELETE CASCADE
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_conversation_summaries_message ON conversation_summaries(conversation_id, through_message_id);
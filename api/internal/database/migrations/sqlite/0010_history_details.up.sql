@@ -0,0 +1,15 @@
+-- Executions are recorded by the server with how they ran and what they cost. prompt_id and
+-- prompt_version link a run to the saved prompt it rendered; run_id groups the models of one comparison.
+ALTER TABLE history ADD COLUMN provider TEXT;
+ALTER TABLE history ADD COLUMN system_prompt TEXT;
+ALTER TABLE history ADD COLUMN latency_ms INTEGER;
+ALTER TABLE history ADD COLUMN prompt_tokens INTEGER;
+ALTER TABLE history ADD COLUMN completion_tokens INTEGER;
+ALTER TABLE history ADD COLUMN total_tokens INTEGER;
+ALTER TABLE history ADD COLUMN cost REAL;
+ALTER TABLE history ADD COLUMN prompt_id INTEGER;
+ALTER TABLE history ADD COLUMN prompt_version INTEGER;
+ALTER TABLE history ADD COLUMN run_id TEXT;
+
+CREATE INDEX IF NOT EXISTS idx_history_prompt ON history(prompt_id);
+CREATE INDEX IF NOT EXISTS idx_history_run ON history(run_id);
@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS idx_saved_prompts_user;
+DROP INDEX IF EXISTS idx_conversations_user;
+DROP INDEX IF EXISTS idx_history_user;
+ALTER TABLE saved_prompts DROP COLUMN user_id;
+ALTER TABLE conversations DROP COLUMN user_id;
+ALTER TABLE history DROP COLUMN user_id;
+DROP TABLE IF EXISTS sessions;
+DROP TABLE IF EXISTS api_keys;
+DROP TABLE IF EXISTS users;
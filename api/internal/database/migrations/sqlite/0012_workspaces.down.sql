@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_conversations_workspace;
+DROP INDEX IF EXISTS idx_saved_prompts_workspace;
+ALTER TABLE conversations DROP COLUMN workspace_id;
+ALTER TABLE saved_prompts DROP COLUMN workspace_id;
+DROP TABLE IF EXISTS eval_suites;
+DROP TABLE IF EXISTS workspace_members;
+DROP TABLE IF EXISTS workspaces;
@@ -0,0 +1,38 @@
+-- Workspaces share saved prompts, conversations and eval suites between their members. Rows with a
+-- NULL workspace_id are private to the user who created them.
+CREATE TABLE IF NOT EXISTS workspaces (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	name TEXT NOT NULL,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS workspace_members (
+	workspace_id INTEGER NOT NULL,
+	user_id INTEGER NOT NULL,
+	role TEXT NOT NULL CHECK (role IN ('owner', 'editor', 'viewer')),
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	PRIMARY KEY (workspace_id, user_id),
+	FOREIGN KEY(workspace_id) REFERENCES workspaces(id) ON DELETE CASCADE,
+	FOREIGN KEY(user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- Generated evaluation suites, kept so they can be rerun. data holds the EvalData document.
+CREATE TABLE IF NOT EXISTS eval_suites (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	name TEXT NOT NULL,
+	prompt_id INTEGER,
+	data TEXT NOT NULL,
+	user_id INTEGER,
+	workspace_id INTEGER,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+ALTER TABLE saved_prompts ADD COLUMN workspace_id INTEGER;
+ALTER TABLE conversations ADD COLUMN workspace_id INTEGER;
+
+CREATE INDEX IF NOT EXISTS idx_workspace_members_user ON workspace_members(user_id);
+CREATE INDEX IF NOT EXISTS idx_saved_prompts_workspace ON saved_prompts(workspace_id);
+CREATE INDEX IF NOT EXISTS idx_conversations_workspace ON conversations(workspace_id);
+CREATE INDEX IF NOT EXISTS idx_eval_suites_user ON eval_suites(user_id);
+CREATE INDEX IF NOT EXISTS idx_eval_suites_workspace ON eval_suites(workspace_id);
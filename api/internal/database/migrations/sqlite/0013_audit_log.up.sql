@@ -0,0 +1,29 @@
+-- Append-only record of every mutating API request. Rows are never updated or deleted.
+CREATE TABLE IF NOT EXISTS audit_log (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	user_id INTEGER,
+	username TEXT NOT NULL DEFAULT '',
+	workspace_id INTEGER,
+	method TEXT NOT NULL,
+	route TEXT NOT NULL,
+	path TEXT NOT NULL,
+	entity_id TEXT NOT NULL DEFAULT '',
+	status INTEGER NOT NULL,
+	before_summary TEXT NOT NULL DEFAULT '',
+	after_summary TEXT NOT NULL DEFAULT '',
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_log_created_at ON audit_log(created_at);
+CREATE INDEX IF NOT EXISTS idx_audit_log_user ON audit_log(user_id);
+CREATE INDEX IF NOT EXISTS idx_audit_log_route ON audit_log(route, entity_id);
+
+CREATE TRIGGER IF NOT EXISTS audit_log_no_update BEFORE UPDATE ON audit_log
+BEGIN
+	SELECT RAISE(ABORT, 'audit_log is append-only');
+END;
+
+CREATE TRIGGER IF NOT EXISTS audit_log_no_delete BEFORE DELETE ON audit_log
+BEGIN
+	SELECT RAISE(ABORT, 'audit_log is append-only');
+END;
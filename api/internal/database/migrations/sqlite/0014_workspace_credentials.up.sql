@@ -0,0 +1,12 @@
+-- Provider credentials that a workspace uses instead of the server's. api_key is encrypted under the
+-- master key and bound to the workspace and provider.
+CREATE TABLE IF NOT EXISTS workspace_credentials (
+	workspace_id INTEGER NOT NULL,
+	provider TEXT NOT NULL,
+	api_key TEXT NOT NULL,
+	base_url TEXT NOT NULL DEFAULT '',
+	updated_by INTEGER,
+	updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	PRIMARY KEY (workspace_id, provider),
+	FOREIGN KEY(workspace_id) REFERENCES workspaces(id) ON DELETE CASCADE
+);
@@ -0,0 +1,12 @@
+DROP TRIGGER IF EXISTS prompts_fts_insert;
+DROP TRIGGER IF EXISTS prompts_fts_delete;
+DROP TRIGGER IF EXISTS prompts_fts_update;
+DROP TABLE IF EXISTS prompts_fts;
+DROP TRIGGER IF EXISTS history_fts_insert;
+DROP TRIGGER IF EXISTS history_fts_delete;
+DROP TRIGGER IF EXISTS history_fts_update;
+DROP TABLE IF EXISTS history_fts;
+DROP TRIGGER IF EXISTS messages_fts_insert;
+DROP TRIGGER IF EXISTS messages_fts_delete;
+DROP TRIGGER IF EXISTS messages_fts_update;
+DROP TABLE IF EXISTS messages_fts;
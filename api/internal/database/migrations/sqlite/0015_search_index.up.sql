@@ -0,0 +1,45 @@
+-- requires: fts5
+-- Full-text indexes for search, kept in sync with their tables by triggers. Builds without FTS5
+-- (go build -tags sqlite_fts5) skip this migration and search with LIKE; it runs once a build has it.
+
+CREATE VIRTUAL TABLE IF NOT EXISTS prompts_fts USING fts5(title, content, description, content='saved_prompts', content_rowid='id', tokenize='porter unicode61');
+CREATE TRIGGER IF NOT EXISTS prompts_fts_insert AFTER INSERT ON saved_prompts BEGIN
+	INSERT INTO prompts_fts(rowid, title, content, description) VALUES (new.id, new.title, new.content, new.description);
+END;
+CREATE TRIGGER IF NOT EXISTS prompts_fts_delete AFTER DELETE ON saved_prompts BEGIN
+	INSERT INTO prompts_fts(prompts_fts, rowid, title, content, description) VALUES ('delete', old.id, old.title, old.content, old.description);
+END;
+CREATE TRIGGER IF NOT EXISTS prompts_fts_update AFTER UPDATE ON saved_prompts BEGIN
+	INSERT INTO prompts_fts(prompts_fts, rowid, title, content, description) VALUES ('delete', old.id, old.title, old.content, old.description);
+	INSERT INTO prompts_fts(rowid, title, content, description) VALUES (new.id, new.title, new.content, new.description);
+END;
+-- Index rows written before the index existed
+INSERT INTO prompts_fts(prompts_fts) VALUES ('rebuild');
+
+CREATE VIRTUAL TABLE IF NOT EXISTS history_fts USING fts5(prompt, response, content='history', content_rowid='id', tokenize='porter unicode61');
+CREATE TRIGGER IF NOT EXISTS history_fts_insert AFTER INSERT ON history BEGIN
+	INSERT INTO history_fts(rowid, prompt, response) VALUES (new.id, new.prompt, new.response);
+END;
+CREATE TRIGGER IF NOT EXISTS history_fts_delete AFTER DELETE ON history BEGIN
+	INSERT INTO history_fts(history_fts, rowid, prompt, response) VALUES ('delete', old.id, old.prompt, old.response);
+END;
+CREATE TRIGGER IF NOT EXISTS history_fts_update AFTER UPDATE ON history BEGIN
+	INSERT INTO history_fts(history_fts, rowid, prompt, response) VALUES ('delete', old.id, old.prompt, old.response);
+	INSERT INTO history_fts(rowid, prompt, response) VALUES (new.id, new.prompt, new.response);
+END;
+-- Index rows written before the index existed
+INSERT INTO history_fts(history_fts) VALUES ('rebuild');
+
+CREATE VIRTUAL TABLE IF NOT EXISTS messages_fts USING fts5(content, content='conversation_messages', content_rowid='id', tokenize='porter unicode61');
+CREATE TRIGGER IF NOT EXISTS messages_fts_insert AFTER INSERT ON conversation_messages BEGIN
+	INSERT INTO messages_fts(rowid, content) VALUES (new.id, new.content);
+END;
+CREATE TRIGGER IF NOT EXISTS messages_fts_delete AFTER DELETE ON conversation_messages BEGIN
+	INSERT INTO messages_fts(messages_fts, rowid, content) VALUES ('delete', old.id, old.content);
+END;
+CREATE TRIGGER IF NOT EXISTS messages_fts_update AFTER UPDATE ON conversation_messages BEGIN
+	INSERT INTO messages_fts(messages_fts, rowid, content) VALUES ('delete', old.id, old.content);
+	INSERT INTO messages_fts(rowid, content) VALUES (new.id, new.content);
+END;
+-- Index rows written before the index existed
+INSERT INTO messages_fts(messages_fts) VALUES ('rebuild');
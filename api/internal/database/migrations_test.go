@@ -0,0 +1,237 @@
+package database
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"promptforge/internal/models"
+)
+
+// openLegacyFixture creates a database with the pre-migration schema and data from testdata
+func openLegacyFixture(t *testing.T) *Database {
+	fixture, err := os.ReadFile(filepath.Join("testdata", "legacy_schema.sql"))
+	if err != nil {
+		t.Fatalf("Failed to read fixture: %v", err)
+	}
+
+	database, err := Open(DriverSQLite, filepath.Join(t.TempDir(), "legacy.db"))
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	t.Cleanup(func() { database.Close() })
+
+	if _, err := database.db.Exec(string(fixture)); err != nil {
+		t.Fatalf("Failed to load fixture: %v", err)
+	}
+
+	return database
+}
+
+func TestLoadMigrations(t *testing.T) {
+	sqliteMigrations, err := loadMigrations(dialects[DriverSQLite].migrationsDir)
+	if err != nil {
+		t.Fatalf("Failed to load SQLite migrations: %v", err)
+	}
+	postgresMigrations, err := loadMigrations(dialects[DriverPostgres].migrationsDir)
+	if err != nil {
+		t.Fatalf("Failed to load PostgreSQL migrations: %v", err)
+	}
+
+	if len(sqliteMigrations) == 0 {
+		t.Fatal("Expected embedded migrations")
+	}
+
+	// Both backends must move through the same schema versions
+	if len(postgresMigrations) != len(sqliteMigrations) {
+		t.Fatalf("Expected %d PostgreSQL migrations, got %d", len(sqliteMigrations), len(postgresMigrations))
+	}
+
+	for i, migration := range sqliteMigrations {
+		if migration.Version != i+1 {
+			t.Errorf("Expected migration %d to have version %d, got %d", i, i+1, migration.Version)
+		}
+		if postgresMigrations[i].Version != migration.Version || postgresMigrations[i].Name != migration.Name {
+			t.Errorf("Expected PostgreSQL migration %04d_%s, got %04d_%s",
+				migration.Version, migration.Name, postgresMigrations[i].Version, postgresMigrations[i].Name)
+		}
+	}
+}
+
+func TestMigrateLegacyDatabase(t *testing.T) {
+	db := openLegacyFixture(t)
+
+	if err := db.InitSchema(false); err == nil {
+		t.Fatal("Expected InitSchema without auto-migrate to reject pending migrations")
+	}
+
+	applied, err := db.Migrate(false)
+	if err != nil {
+		t.Fatalf("Failed to migrate legacy database: %v", err)
+	}
+
+	migrations, _ := db.migrations()
+	if len(applied) != len(migrations) {
+		t.Errorf("Expected %d migrations to be applied, got %d", len(migrations), len(applied))
+	}
+
+	if err := db.InitSchema(false); err != nil {
+		t.Fatalf("Expected migrated schema to be current: %v", err)
+	}
+
+	statuses, err := db.MigrationStatus()
+	if err != nil {
+		t.Fatalf("Failed to get migration status: %v", err)
+	}
+	for _, status := range statuses {
+		if !status.Applied || status.AppliedAt == nil {
+			t.Errorf("Expected migration %d to be recorded as applied", status.Version)
+		}
+	}
+
+	// Existing rows survive and work with features added by later migrations
+	history, _, err := db.GetHistory(models.HistoryFilter{})
+	if err != nil {
+		t.Fatalf("Failed to get history: %v", err)
+	}
+	if len(history) != 1 || history[0].Prompt != "Summarize the quarterly report" {
+		t.Errorf("Expected legacy history to be preserved, got %+v", history)
+	}
+
+	conversation, err := db.GetConversation("legacy-conv")
+	if err != nil {
+		t.Fatalf("Failed to get conversation: %v", err)
+	}
+	if conversation == nil || len(conversation.Messages) != 2 {
+		t.Fatalf("Expected legacy conversation with 2 messages, got %+v", conversation)
+	}
+	if reply := conversation.Messages[1]; reply.ParentID == nil || *reply.ParentID != conversation.Messages[0].ID {
+		t.Errorf("Expected legacy messages to be linked into one thread, got %+v", conversation.Messages)
+	}
+
+	prompts, _, err := db.GetSavedPrompts(models.PromptFilter{})
+	if err != nil {
+		t.Fatalf("Failed to get prompts: %v", err)
+	}
+	if len(prompts) != 1 || prompts[0].UsageCount != 4 {
+		t.Fatalf("Expected legacy prompt to be preserved, got %+v", prompts)
+	}
+	if prompts[0].Tags != `["french"]` {
+		t.Errorf("Expected legacy tags to be moved into prompt_tags, got %s", prompts[0].Tags)
+	}
+
+	updated, err := db.UpdatePrompt(models.UpdatePromptRequest{
+		ID:       prompts[0].ID,
+		Title:    "Legacy prompt",
+		Content:  "Translate {{text}} into Spanish",
+		Category: "Translation",
+		Tags:     []string{"spanish"},
+	})
+	if err != nil || updated == nil {
+		t.Fatalf("Failed to update legacy prompt: %v", err)
+	}
+
+	versions, err := db.GetPromptVersions(prompts[0].ID)
+	if err != nil {
+		t.Fatalf("Failed to get versions: %v", err)
+	}
+	if len(versions) != 2 || versions[1].Content != "Translate {{text}} into French" {
+		t.Errorf("Expected baseline and updated versions, got %+v", versions)
+	}
+
+	// Running again is a no-op
+	applied, err = db.Migrate(false)
+	if err != nil {
+		t.Fatalf("Failed to re-run migrations: %v", err)
+	}
+	if len(applied) != 0 {
+		t.Errorf("Expected no pending migrations, got %d", len(applied))
+	}
+}
+
+func TestMigrateDryRun(t *testing.T) {
+	db := openLegacyFixture(t)
+
+	pending, err := db.Migrate(true)
+	if err != nil {
+		t.Fatalf("Failed to dry-run migrations: %v", err)
+	}
+	if len(pending) == 0 {
+		t.Fatal("Expected dry run to report pending migrations")
+	}
+
+	var tables int
+	err = db.db.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'prompt_versions'`).Scan(&tables)
+	if err != nil {
+		t.Fatalf("Failed to inspect schema: %v", err)
+	}
+	if tables != 0 {
+		t.Error("Expected dry run to leave the schema untouched")
+	}
+
+	stillPending, err := db.PendingMigrations()
+	if err != nil {
+		t.Fatalf("Failed to get pending migrations: %v", err)
+	}
+	if len(stillPending) != len(pending) {
+		t.Errorf("Expected %d pending migrations after dry run, got %d", len(pending), len(stillPending))
+	}
+}
+
+func TestMigrateDown(t *testing.T) {
+	db := setupTestDB(t)
+
+	migrations, _ := db.migrations()
+	latest := migrations[len(migrations)-1]
+
+	// Dry run reports the newest migration without rolling it back
+	rollback, err := db.MigrateDown(1, true)
+	if err != nil {
+		t.Fatalf("Failed to dry-run rollback: %v", err)
+	}
+	if len(rollback) != 1 || rollback[0].Version != latest.Version {
+		t.Fatalf("Expected dry run to report migration %d, got %+v", latest.Version, rollback)
+	}
+	if pending, _ := db.PendingMigrations(); len(pending) != 0 {
+		t.Errorf("Expected dry run to leave migrations applied, got %d pending", len(pending))
+	}
+
+	if _, err := db.MigrateDown(1, false); err != nil {
+		t.Fatalf("Failed to roll back: %v", err)
+	}
+	pending, err := db.PendingMigrations()
+	if err != nil {
+		t.Fatalf("Failed to get pending migrations: %v", err)
+	}
+	if len(pending) != 1 || pending[0].Version != latest.Version {
+		t.Errorf("Expected migration %d to be pending after rollback, got %+v", latest.Version, pending)
+	}
+
+	// Roll everything back, then forward again
+	if _, err := db.MigrateDown(len(migrations), false); err != nil {
+		t.Fatalf("Failed to roll back all migrations: %v", err)
+	}
+	var count int
+	if err := db.db.QueryRow(`SELECT COUNT(*) FROM saved_prompts`).Scan(&count); err == nil {
+		t.Error("Expected saved_prompts to be dropped")
+	}
+
+	if err := db.InitSchema(true); err != nil {
+		t.Fatalf("Failed to migrate back up: %v", err)
+	}
+	if _, err := db.SavePrompt(models.SavePromptRequest{Title: "After", Content: "Works again"}); err != nil {
+		t.Errorf("Failed to save prompt after re-migrating: %v", err)
+	}
+}
+
+func TestMigrateRejectsUnknownVersion(t *testing.T) {
+	db := setupTestDB(t)
+
+	if _, err := db.db.Exec(`INSERT INTO schema_migrations (version, name) VALUES (9999, 'from_the_future')`); err != nil {
+		t.Fatalf("Failed to insert migration record: %v", err)
+	}
+
+	if _, err := db.Migrate(false); err == nil {
+		t.Error("Expected an error for a database migrated by a newer build")
+	}
+}
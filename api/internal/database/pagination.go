@@ -0,0 +1,173 @@
+package database
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"promptforge/internal/models"
+)
+
+// ErrInvalidListOptions is returned for unknown sort fields, bad orders and malformed cursors
+var ErrInvalidListOptions = errors.New("invalid list options")
+
+// sortColumn is a sortable field exposed by a list endpoint
+type sortColumn struct {
+	expr        string // SQL expression producing a comparable value
+	numeric     bool   // Cursor values are compared as integers
+	time        bool   // expr is a timestamp column, normalized by the dialect
+	defaultDesc bool
+}
+
+// pageCursor marks the last row of a page; it is tied to the sort it was produced for
+type pageCursor struct {
+	Sort  string `json:"s"`
+	Order string `json:"o"`
+	Value string `json:"v"`
+	ID    string `json:"id"`
+}
+
+// pagination implements keyset pagination ordered by a sort expression with the row ID as tie-breaker
+type pagination struct {
+	sortName  string
+	column    sortColumn
+	idExpr    string
+	numericID bool
+	order     string
+	limit     int
+	after     *pageCursor
+}
+
+type paginationSpec struct {
+	sorts        map[string]sortColumn
+	defaultSort  string
+	idExpr       string
+	numericID    bool
+	defaultLimit int
+	maxLimit     int
+}
+
+func newPagination(opts models.PageOptions, spec paginationSpec, dialect *dialect) (*pagination, error) {
+	p := &pagination{
+		sortName:  opts.Sort,
+		idExpr:    spec.idExpr,
+		numericID: spec.numericID,
+		order:     opts.Order,
+		limit:     opts.Limit,
+	}
+
+	if p.sortName == "" {
+		p.sortName = spec.defaultSort
+	}
+	column, ok := spec.sorts[p.sortName]
+	if !ok {
+		return nil, fmt.Errorf("%w: unsupported sort field %q", ErrInvalidListOptions, p.sortName)
+	}
+	if column.time {
+		column.expr = dialect.timeExpr(column.expr)
+	}
+	p.column = column
+
+	switch p.order {
+	case "":
+		p.order = models.SortAsc
+		if column.defaultDesc {
+			p.order = models.SortDesc
+		}
+	case models.SortAsc, models.SortDesc:
+	default:
+		return nil, fmt.Errorf("%w: order must be %q or %q", ErrInvalidListOptions, models.SortAsc, models.SortDesc)
+	}
+
+	if p.limit <= 0 {
+		p.limit = spec.defaultLimit
+	} else if p.limit > spec.maxLimit {
+		p.limit = spec.maxLimit
+	}
+
+	if opts.Cursor != "" {
+		cursor, err := decodeCursor(opts.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		if cursor.Sort != p.sortName || cursor.Order != p.order {
+			return nil, fmt.Errorf("%w: cursor was issued for a different sort", ErrInvalidListOptions)
+		}
+		p.after = cursor
+	}
+
+	return p, nil
+}
+
+// applyCursor restricts the query to rows after the cursor
+func (p *pagination) applyCursor(where *whereBuilder) error {
+	if p.after == nil {
+		return nil
+	}
+
+	var value, id interface{} = p.after.Value, p.after.ID
+	if p.column.numeric {
+		n, err := strconv.ParseInt(p.after.Value, 10, 64)
+		if err != nil {
+			return fmt.Errorf("%w: malformed cursor", ErrInvalidListOptions)
+		}
+		value = n
+	}
+	if p.numericID {
+		n, err := strconv.ParseInt(p.after.ID, 10, 64)
+		if err != nil {
+			return fmt.Errorf("%w: malformed cursor", ErrInvalidListOptions)
+		}
+		id = n
+	}
+
+	op := ">"
+	if p.order == models.SortDesc {
+		op = "<"
+	}
+	where.add(fmt.Sprintf("(%[1]s %[3]s ? OR (%[1]s = ? AND %[2]s %[3]s ?))", p.column.expr, p.idExpr, op), value, value, id)
+	return nil
+}
+
+// sortKeySQL selects the sort value as text so it can be embedded in the next cursor
+func (p *pagination) sortKeySQL() string {
+	return "CAST(" + p.column.expr + " AS TEXT)"
+}
+
+func (p *pagination) orderBySQL() string {
+	return fmt.Sprintf(" ORDER BY %s %s, %s %s", p.column.expr, p.order, p.idExpr, p.order)
+}
+
+// limitArg over-fetches one row to learn whether another page exists
+func (p *pagination) limitArg() int {
+	return p.limit + 1
+}
+
+// nextCursor returns the cursor for the following page, or "" when fetched rows fit in this page
+func (p *pagination) nextCursor(fetched int, lastSortKey, lastID string) string {
+	if fetched <= p.limit {
+		return ""
+	}
+
+	data, err := json.Marshal(pageCursor{Sort: p.sortName, Order: p.order, Value: lastSortKey, ID: lastID})
+	if err != nil {
+		return ""
+	}
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(encoded string) (*pageCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed cursor", ErrInvalidListOptions)
+	}
+
+	var cursor pageCursor
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return nil, fmt.Errorf("%w: malformed cursor", ErrInvalidListOptions)
+	}
+
+	return &cursor, nil
+}
@@ -0,0 +1,157 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"promptforge/internal/models"
+)
+
+func TestHistoryCursorPagination(t *testing.T) {
+	db := setupTestDB(t)
+
+	for i := 0; i < 7; i++ {
+		model := "gpt-4.1"
+		if i%2 == 1 {
+			model = "o3"
+		}
+		_, err := db.SaveHistory(models.SaveHistoryRequest{
+			Prompt: fmt.Sprintf("prompt %d", i), Model: model, Temperature: 0.7, Success: i != 3,
+		})
+		if err != nil {
+			t.Fatalf("Failed to save history: %v", err)
+		}
+	}
+
+	// Walk all pages and make sure every row is returned exactly once, newest first
+	seen := map[int64]bool{}
+	var lastID int64
+	filter := models.HistoryFilter{PageOptions: models.PageOptions{Limit: 3}}
+	pages := 0
+	for {
+		items, page, err := db.GetHistory(filter)
+		if err != nil {
+			t.Fatalf("Failed to get history page: %v", err)
+		}
+		if page.Total != 7 {
+			t.Errorf("Expected total 7, got %d", page.Total)
+		}
+		for _, item := range items {
+			if seen[item.ID] {
+				t.Errorf("History item %d returned twice", item.ID)
+			}
+			if lastID != 0 && item.ID > lastID {
+				t.Errorf("Expected descending order, got %d after %d", item.ID, lastID)
+			}
+			seen[item.ID] = true
+			lastID = item.ID
+		}
+		pages++
+		if page.NextCursor == "" {
+			break
+		}
+		filter.Cursor = page.NextCursor
+	}
+
+	if len(seen) != 7 || pages != 3 {
+		t.Errorf("Expected 7 items over 3 pages, got %d items over %d pages", len(seen), pages)
+	}
+
+	failed := false
+	items, page, err := db.GetHistory(models.HistoryFilter{Model: "o3", Success: &failed})
+	if err != nil {
+		t.Fatalf("Failed to filter history: %v", err)
+	}
+	if len(items) != 1 || page.Total != 1 || items[0].Prompt != "prompt 3" {
+		t.Errorf("Expected only the failed o3 run, got %+v", items)
+	}
+}
+
+func TestSavedPromptSortingAndFilters(t *testing.T) {
+	db := setupTestDB(t)
+
+	for _, title := range []string{"Charlie", "Alpha", "Bravo"} {
+		category := "General"
+		tags := []string{"shared"}
+		if title == "Bravo" {
+			category = "Support"
+			tags = append(tags, "support")
+		}
+		if _, err := db.SavePrompt(models.SavePromptRequest{Title: title, Content: "x", Category: category, Tags: tags}); err != nil {
+			t.Fatalf("Failed to save prompt: %v", err)
+		}
+	}
+
+	first, page, err := db.GetSavedPrompts(models.PromptFilter{PageOptions: models.PageOptions{Sort: "title", Limit: 2}})
+	if err != nil {
+		t.Fatalf("Failed to list prompts: %v", err)
+	}
+	if len(first) != 2 || first[0].Title != "Alpha" || first[1].Title != "Bravo" || page.NextCursor == "" {
+		t.Fatalf("Unexpected first page: %+v (cursor %q)", first, page.NextCursor)
+	}
+	titleCursor := page.NextCursor
+
+	second, page, err := db.GetSavedPrompts(models.PromptFilter{PageOptions: models.PageOptions{Sort: "title", Limit: 2, Cursor: page.NextCursor}})
+	if err != nil {
+		t.Fatalf("Failed to list second page: %v", err)
+	}
+	if len(second) != 1 || second[0].Title != "Charlie" || page.NextCursor != "" {
+		t.Errorf("Unexpected second page: %+v", second)
+	}
+
+	filtered, page, err := db.GetSavedPrompts(models.PromptFilter{Category: "Support", Tags: []string{"shared", "support"}})
+	if err != nil {
+		t.Fatalf("Failed to filter prompts: %v", err)
+	}
+	if len(filtered) != 1 || filtered[0].Title != "Bravo" || page.Total != 1 {
+		t.Errorf("Expected only Bravo, got %+v", filtered)
+	}
+
+	invalid := []models.PageOptions{
+		{Sort: "content"},
+		{Order: "sideways"},
+		{Cursor: "not-a-cursor"},
+		{Sort: "created_at", Cursor: titleCursor}, // Cursor issued for another sort
+	}
+	for _, opts := range invalid {
+		_, _, err := db.GetSavedPrompts(models.PromptFilter{PageOptions: opts})
+		if !errors.Is(err, ErrInvalidListOptions) {
+			t.Errorf("Expected ErrInvalidListOptions for %+v, got %v", opts, err)
+		}
+	}
+}
+
+func TestConversationPagination(t *testing.T) {
+	db := setupTestDB(t)
+
+	for i := 0; i < 3; i++ {
+		_, err := db.SaveConversation(models.SaveConversationRequest{
+			ConversationID: fmt.Sprintf("conv-%d", i),
+			Title:          fmt.Sprintf("Conversation %d", i),
+		})
+		if err != nil {
+			t.Fatalf("Failed to save conversation: %v", err)
+		}
+	}
+
+	conversations, page, err := db.GetConversations(models.ConversationFilter{
+		PageOptions: models.PageOptions{Sort: "title", Order: models.SortDesc, Limit: 2},
+	})
+	if err != nil {
+		t.Fatalf("Failed to list conversations: %v", err)
+	}
+	if len(conversations) != 2 || conversations[0].ID != "conv-2" || page.Total != 3 || page.NextCursor == "" {
+		t.Fatalf("Unexpected first page: %+v", conversations)
+	}
+
+	conversations, page, err = db.GetConversations(models.ConversationFilter{
+		PageOptions: models.PageOptions{Sort: "title", Order: models.SortDesc, Limit: 2, Cursor: page.NextCursor},
+	})
+	if err != nil {
+		t.Fatalf("Failed to list second page: %v", err)
+	}
+	if len(conversations) != 1 || conversations[0].ID != "conv-0" || page.NextCursor != "" {
+		t.Errorf("Unexpected second page: %+v", conversations)
+	}
+}
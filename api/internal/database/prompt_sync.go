@@ -0,0 +1,89 @@
+package database
+
+import (
+	"fmt"
+
+	"promptforge/internal/models"
+)
+
+// ListPromptIDs returns the ID of every saved prompt in the scope, oldest first
+func (d *Database) ListPromptIDs() ([]int64, error) {
+	where := &whereBuilder{}
+	d.db.restrict(where, "")
+	rows, err := d.db.Query(`SELECT id FROM saved_prompts`+where.sql()+` ORDER BY id ASC`, where.args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query prompt IDs: %v", err)
+	}
+	defer rows.Close()
+
+	ids := []int64{}
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan prompt ID: %v", err)
+		}
+		ids = append(ids, id)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating prompt ID rows: %v", err)
+	}
+
+	return ids, nil
+}
+
+func (d *Database) GetPromptSyncStates() ([]models.PromptSyncState, error) {
+	rows, err := d.db.Query(`SELECT path, prompt_id, file_hash, prompt_hash, synced_at FROM prompt_sync ORDER BY path ASC`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query prompt sync state: %v", err)
+	}
+	defer rows.Close()
+
+	states := []models.PromptSyncState{}
+	for rows.Next() {
+		var state models.PromptSyncState
+		if err := rows.Scan(&state.Path, &state.PromptID, &state.FileHash, &state.PromptHash, &state.SyncedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan prompt sync row: %v", err)
+		}
+		states = append(states, state)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating prompt sync rows: %v", err)
+	}
+
+	return states, nil
+}
+
+// SavePromptSyncState records the agreed state of a file. A prompt maps to at most one file.
+func (d *Database) SavePromptSyncState(state models.PromptSyncState) error {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM prompt_sync WHERE path = ? OR prompt_id = ?`, state.Path, state.PromptID); err != nil {
+		return fmt.Errorf("failed to clear prompt sync state: %v", err)
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO prompt_sync (path, prompt_id, file_hash, prompt_hash, synced_at)
+		VALUES (?, ?, ?, ?, CURRENT_TIMESTAMP)
+	`, state.Path, state.PromptID, state.FileHash, state.PromptHash)
+	if err != nil {
+		return fmt.Errorf("failed to save prompt sync state: %v", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit prompt sync state: %v", err)
+	}
+	return nil
+}
+
+func (d *Database) DeletePromptSyncState(path string) error {
+	if _, err := d.db.Exec(`DELETE FROM prompt_sync WHERE path = ?`, path); err != nil {
+		return fmt.Errorf("failed to delete prompt sync state: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"promptforge/internal/models"
+)
+
+const promptVariableColumns = `prompt_id, name, type, default_value, description, required, options`
+
+// queryer is satisfied by both conn and txn so helpers can run inside or outside a transaction
+type queryer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+	andOwned(prefix string) (string, []interface{})
+}
+
+func getPromptVariables(q queryer, promptID int64) ([]models.TemplateVariable, error) {
+	query := `SELECT ` + promptVariableColumns + ` FROM prompt_variables WHERE prompt_id = ? ORDER BY position ASC`
+
+	rows, err := q.Query(query, promptID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query prompt variables: %v", err)
+	}
+	defer rows.Close()
+
+	variables := []models.TemplateVariable{}
+	for rows.Next() {
+		_, variable, err := scanPromptVariable(rows)
+		if err != nil {
+			return nil, err
+		}
+		variables = append(variables, variable)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating prompt variable rows: %v", err)
+	}
+
+	return variables, nil
+}
+
+// getVariablesForPrompts loads the variables of several prompts in one query, keyed by prompt ID
+func (d *Database) getVariablesForPrompts(promptIDs []int64) (map[int64][]models.TemplateVariable, error) {
+	variables := make(map[int64][]models.TemplateVariable)
+	if len(promptIDs) == 0 {
+		return variables, nil
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(promptIDs)), ", ")
+	args := make([]interface{}, len(promptIDs))
+	for i, id := range promptIDs {
+		args[i] = id
+	}
+
+	query := `SELECT ` + promptVariableColumns + ` FROM prompt_variables WHERE prompt_id IN (` + placeholders + `) ORDER BY prompt_id, position ASC`
+
+	rows, err := d.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query prompt variables: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		promptID, variable, err := scanPromptVariable(rows)
+		if err != nil {
+			return nil, err
+		}
+		variables[promptID] = append(variables[promptID], variable)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating prompt variable rows: %v", err)
+	}
+
+	return variables, nil
+}
+
+func scanPromptVariable(rows *sql.Rows) (int64, models.TemplateVariable, error) {
+	var promptID int64
+	var variable models.TemplateVariable
+	var optionsJSON string
+
+	err := rows.Scan(
+		&promptID, &variable.Name, &variable.Type, &variable.Default,
+		&variable.Description, &variable.Required, &optionsJSON,
+	)
+	if err != nil {
+		return 0, variable, fmt.Errorf("failed to scan prompt variable row: %v", err)
+	}
+
+	if err := json.Unmarshal([]byte(optionsJSON), &variable.Options); err != nil {
+		return 0, variable, fmt.Errorf("failed to unmarshal variable options: %v", err)
+	}
+
+	return promptID, variable, nil
+}
+
+// replacePromptVariables swaps the stored variable declarations for a prompt within a transaction
+func replacePromptVariables(tx *txn, promptID int64, variables []models.TemplateVariable) error {
+	if _, err := tx.Exec(`DELETE FROM prompt_variables WHERE prompt_id = ?`, promptID); err != nil {
+		return fmt.Errorf("failed to clear prompt variables: %v", err)
+	}
+
+	for i, variable := range variables {
+		optionsJSON := "[]"
+		if len(variable.Options) > 0 {
+			optionsBytes, err := json.Marshal(variable.Options)
+			if err != nil {
+				return fmt.Errorf("failed to marshal variable options: %v", err)
+			}
+			optionsJSON = string(optionsBytes)
+		}
+
+		_, err := tx.Exec(
+			`INSERT INTO prompt_variables (`+promptVariableColumns+`, position) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+			promptID, variable.Name, variable.Type, variable.Default, variable.Description, variable.Required, optionsJSON, i,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to save prompt variable %q: %v", variable.Name, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,221 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"promptforge/internal/models"
+)
+
+const promptVersionColumns = `id, prompt_id, version, title, content, description, category, tags, variables, note, created_at`
+
+// snapshotPromptVersion records the prompt's current state as the next version number
+func snapshotPromptVersion(tx *txn, promptID int64, note string) (int, error) {
+	var version int
+	err := tx.QueryRow(`SELECT COALESCE(MAX(version), 0) + 1 FROM prompt_versions WHERE prompt_id = ?`, promptID).Scan(&version)
+	if err != nil {
+		return 0, fmt.Errorf("failed to determine next prompt version: %v", err)
+	}
+
+	variables, err := getPromptVariables(tx, promptID)
+	if err != nil {
+		return 0, err
+	}
+
+	variablesJSON, err := json.Marshal(variables)
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal prompt variables: %v", err)
+	}
+
+	tags, err := getPromptTags(tx, promptID)
+	if err != nil {
+		return 0, err
+	}
+	tagsJSON, err := marshalTags(tags)
+	if err != nil {
+		return 0, err
+	}
+
+	query := `
+		INSERT INTO prompt_versions (prompt_id, version, title, content, description, category, tags, variables, note)
+		SELECT id, CAST(? AS INTEGER), title, content, description, category, CAST(? AS TEXT), CAST(? AS TEXT), CAST(? AS TEXT)
+		FROM saved_prompts
+		WHERE id = ?
+	`
+
+	_, err = tx.Exec(query, version, tagsJSON, string(variablesJSON), note, promptID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to snapshot prompt version: %v", err)
+	}
+
+	return version, nil
+}
+
+// ensureBaselineVersion snapshots a prompt that has no history yet so an update never loses its original state
+func ensureBaselineVersion(tx *txn, promptID int64) error {
+	var hasVersions, promptExists bool
+	err := tx.QueryRow(`
+		SELECT EXISTS(SELECT 1 FROM prompt_versions WHERE prompt_id = ?),
+		       EXISTS(SELECT 1 FROM saved_prompts WHERE id = ?)
+	`, promptID, promptID).Scan(&hasVersions, &promptExists)
+	if err != nil {
+		return fmt.Errorf("failed to check prompt versions: %v", err)
+	}
+
+	if hasVersions || !promptExists {
+		return nil
+	}
+
+	_, err = snapshotPromptVersion(tx, promptID, "Initial version")
+	return err
+}
+
+func (d *Database) GetPromptVersions(promptID int64) ([]models.PromptVersion, error) {
+	if ok, err := visible(d.db, "saved_prompts", promptID); err != nil || !ok {
+		return nil, err
+	}
+
+	query := `SELECT ` + promptVersionColumns + ` FROM prompt_versions WHERE prompt_id = ? ORDER BY version DESC`
+
+	rows, err := d.db.Query(query, promptID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query prompt versions: %v", err)
+	}
+	defer rows.Close()
+
+	var versions []models.PromptVersion
+	for rows.Next() {
+		version, err := scanPromptVersion(rows)
+		if err != nil {
+			return nil, err
+		}
+		versions = append(versions, *version)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating prompt version rows: %v", err)
+	}
+
+	return versions, nil
+}
+
+func (d *Database) GetPromptVersion(promptID int64, version int) (*models.PromptVersion, error) {
+	if ok, err := visible(d.db, "saved_prompts", promptID); err != nil || !ok {
+		return nil, err // Prompt not found
+	}
+
+	query := `SELECT ` + promptVersionColumns + ` FROM prompt_versions WHERE prompt_id = ? AND version = ?`
+
+	result, err := scanPromptVersion(d.db.QueryRow(query, promptID, version))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // Version not found
+		}
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// GetLatestPromptVersion returns the newest snapshot, or nil if the prompt has no history
+func (d *Database) GetLatestPromptVersion(promptID int64) (*models.PromptVersion, error) {
+	if ok, err := visible(d.db, "saved_prompts", promptID); err != nil || !ok {
+		return nil, err
+	}
+
+	query := `SELECT ` + promptVersionColumns + ` FROM prompt_versions WHERE prompt_id = ? ORDER BY version DESC LIMIT 1`
+
+	result, err := scanPromptVersion(d.db.QueryRow(query, promptID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// RestorePromptVersion copies a snapshot back onto the prompt and records the restore as a new version
+func (d *Database) RestorePromptVersion(promptID int64, version int, note string) (*models.SavedPrompt, error) {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	if ok, err := visible(tx, "saved_prompts", promptID); err != nil || !ok {
+		return nil, err // Prompt not found
+	}
+
+	query := `SELECT ` + promptVersionColumns + ` FROM prompt_versions WHERE prompt_id = ? AND version = ?`
+	snapshot, err := scanPromptVersion(tx.QueryRow(query, promptID, version))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // Version not found
+		}
+		return nil, err
+	}
+
+	_, err = tx.Exec(`
+		UPDATE saved_prompts
+		SET title = ?, content = ?, description = ?, category = ?, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ?
+	`, snapshot.Title, snapshot.Content, snapshot.Description, snapshot.Category, promptID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to restore prompt: %v", err)
+	}
+
+	var tags []string
+	if err := json.Unmarshal([]byte(snapshot.Tags), &tags); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal version tags: %v", err)
+	}
+	if err := replacePromptTags(tx, promptID, tags); err != nil {
+		return nil, err
+	}
+
+	if err := replacePromptVariables(tx, promptID, snapshot.Variables); err != nil {
+		return nil, err
+	}
+
+	if note == "" {
+		note = fmt.Sprintf("Restored from version %d", version)
+	}
+	if _, err := snapshotPromptVersion(tx, promptID, note); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit prompt restore: %v", err)
+	}
+
+	return d.GetSavedPrompt(promptID)
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanPromptVersion(row rowScanner) (*models.PromptVersion, error) {
+	var version models.PromptVersion
+	var variablesJSON string
+
+	err := row.Scan(
+		&version.ID, &version.PromptID, &version.Version, &version.Title, &version.Content,
+		&version.Description, &version.Category, &version.Tags, &variablesJSON,
+		&version.Note, &version.CreatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to scan prompt version row: %v", err)
+	}
+
+	if err := json.Unmarshal([]byte(variablesJSON), &version.Variables); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal version variables: %v", err)
+	}
+
+	return &version, nil
+}
@@ -0,0 +1,100 @@
+package database
+
+import (
+	"testing"
+
+	"promptforge/internal/models"
+)
+
+func TestPromptVersionHistory(t *testing.T) {
+	db := setupTestDB(t)
+
+	saved, err := db.SavePrompt(models.SavePromptRequest{
+		Title:   "Summarizer",
+		Content: "Summarize the text.\nUse bullet points.",
+	})
+	if err != nil {
+		t.Fatalf("Failed to save prompt: %v", err)
+	}
+
+	_, err = db.UpdatePrompt(models.UpdatePromptRequest{
+		ID:      saved.ID,
+		Title:   "Summarizer",
+		Content: "Summarize the text.\nUse numbered points.",
+		Note:    "Switch to numbered list",
+	})
+	if err != nil {
+		t.Fatalf("Failed to update prompt: %v", err)
+	}
+
+	versions, err := db.GetPromptVersions(saved.ID)
+	if err != nil {
+		t.Fatalf("Failed to get prompt versions: %v", err)
+	}
+	if len(versions) != 2 {
+		t.Fatalf("Expected 2 versions, got %d", len(versions))
+	}
+	if versions[0].Version != 2 || versions[0].Note != "Switch to numbered list" {
+		t.Errorf("Expected newest version first with its note, got %+v", versions[0])
+	}
+	if versions[1].Content != "Summarize the text.\nUse bullet points." {
+		t.Errorf("Expected first version to keep original content, got %q", versions[1].Content)
+	}
+
+	// Restore the original and check it is recorded as a new version
+	restored, err := db.RestorePromptVersion(saved.ID, 1, "")
+	if err != nil {
+		t.Fatalf("Failed to restore version: %v", err)
+	}
+	if restored.Content != "Summarize the text.\nUse bullet points." {
+		t.Errorf("Expected restored content, got %q", restored.Content)
+	}
+
+	latest, err := db.GetLatestPromptVersion(saved.ID)
+	if err != nil {
+		t.Fatalf("Failed to get latest version: %v", err)
+	}
+	if latest.Version != 3 || latest.Note != "Restored from version 1" {
+		t.Errorf("Expected restore to create version 3, got %+v", latest)
+	}
+
+	missing, err := db.RestorePromptVersion(saved.ID, 42, "")
+	if err != nil {
+		t.Fatalf("Unexpected error restoring missing version: %v", err)
+	}
+	if missing != nil {
+		t.Error("Expected nil when restoring a missing version")
+	}
+}
+
+func TestBaselineVersionForLegacyPrompt(t *testing.T) {
+	db := setupTestDB(t)
+
+	// Simulate a prompt saved before version history existed
+	var promptID int64
+	err := db.db.QueryRow(`INSERT INTO saved_prompts (title, content) VALUES (?, ?) RETURNING id`, "Legacy", "Old content").Scan(&promptID)
+	if err != nil {
+		t.Fatalf("Failed to insert legacy prompt: %v", err)
+	}
+
+	_, err = db.UpdatePrompt(models.UpdatePromptRequest{ID: promptID, Title: "Legacy", Content: "New content"})
+	if err != nil {
+		t.Fatalf("Failed to update legacy prompt: %v", err)
+	}
+
+	original, err := db.GetPromptVersion(promptID, 1)
+	if err != nil {
+		t.Fatalf("Failed to get baseline version: %v", err)
+	}
+	if original == nil || original.Content != "Old content" {
+		t.Errorf("Expected baseline version with original content, got %+v", original)
+	}
+
+	updated, err := db.UpdatePrompt(models.UpdatePromptRequest{ID: 99999, Title: "Missing", Content: "x"})
+	if err != nil {
+		t.Fatalf("Unexpected error updating missing prompt: %v", err)
+	}
+	if updated != nil {
+		t.Error("Expected nil when updating a missing prompt")
+	}
+}
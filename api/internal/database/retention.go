@@ -0,0 +1,76 @@
+package database
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"promptforge/internal/models"
+)
+
+// ApplyRetention deletes history entries and conversations beyond the policy's limits, oldest first
+func (d *Database) ApplyRetention(policy models.RetentionPolicy) (*models.RetentionResult, error) {
+	result := &models.RetentionResult{}
+	now := time.Now()
+
+	historyWhere, historyArgs := d.retentionWhere("history", "timestamp", policy.HistoryMaxAge, policy.HistoryMaxRows, now)
+	if historyWhere != "" {
+		res, err := d.db.Exec(`DELETE FROM history WHERE `+historyWhere, historyArgs...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to apply history retention: %v", err)
+		}
+		if result.HistoryDeleted, err = res.RowsAffected(); err != nil {
+			return nil, fmt.Errorf("failed to apply history retention: %v", err)
+		}
+	}
+
+	conversationWhere, conversationArgs := d.retentionWhere("conversations", "updated_at", policy.ConversationMaxAge, policy.ConversationMaxRows, now)
+	if conversationWhere == "" {
+		return result, nil
+	}
+
+	tx, err := d.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	// Foreign keys are not enforced by default in SQLite, so remove dependents explicitly
+	expired := `SELECT id FROM conversations WHERE ` + conversationWhere
+	if _, err := tx.Exec(`DELETE FROM conversation_summaries WHERE conversation_id IN (`+expired+`)`, conversationArgs...); err != nil {
+		return nil, fmt.Errorf("failed to delete expired conversation summaries: %v", err)
+	}
+	if _, err := tx.Exec(`DELETE FROM conversation_messages WHERE conversation_id IN (`+expired+`)`, conversationArgs...); err != nil {
+		return nil, fmt.Errorf("failed to delete expired conversation messages: %v", err)
+	}
+	res, err := tx.Exec(`DELETE FROM conversations WHERE `+conversationWhere, conversationArgs...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete expired conversations: %v", err)
+	}
+	if result.ConversationsDeleted, err = res.RowsAffected(); err != nil {
+		return nil, fmt.Errorf("failed to delete expired conversations: %v", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit retention: %v", err)
+	}
+	return result, nil
+}
+
+// retentionWhere matches rows of table older than maxAge by column or beyond the newest maxRows.
+// It returns an empty condition when neither limit is set.
+func (d *Database) retentionWhere(table, column string, maxAge time.Duration, maxRows int, now time.Time) (string, []interface{}) {
+	var conditions []string
+	var args []interface{}
+
+	if maxAge > 0 {
+		conditions = append(conditions, d.db.dialect.timeExpr(column)+" < ?")
+		args = append(args, d.db.dialect.timeArg(now.Add(-maxAge)))
+	}
+	if maxRows > 0 {
+		conditions = append(conditions, fmt.Sprintf("id NOT IN (SELECT id FROM %s ORDER BY %s DESC, id DESC LIMIT ?)", table, d.db.dialect.timeExpr(column)))
+		args = append(args, maxRows)
+	}
+
+	return strings.Join(conditions, " OR "), args
+}
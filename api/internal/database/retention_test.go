@@ -0,0 +1,61 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"promptforge/internal/models"
+)
+
+func TestApplyRetention(t *testing.T) {
+	db := setupTestDB(t)
+	now := time.Now()
+
+	for i, age := range []time.Duration{0, time.Hour, 48 * time.Hour, 72 * time.Hour} {
+		_, err := db.db.Exec(`INSERT INTO history (timestamp, prompt, model, temperature, max_tokens, success, response) VALUES (?, ?, 'gpt-4', 0.7, 100, ?, '')`,
+			db.db.dialect.timeArg(now.Add(-age)), string(rune('a'+i)), true)
+		if err != nil {
+			t.Fatalf("Failed to insert history: %v", err)
+		}
+	}
+	for _, id := range []string{"old", "recent", "newest"} {
+		if _, err := db.SaveConversation(models.SaveConversationRequest{ConversationID: id, Title: id, Messages: []models.ConversationMessage{{Role: "user", Content: "Hi"}}}); err != nil {
+			t.Fatalf("Failed to save conversation: %v", err)
+		}
+	}
+	db.SaveConversationSummary(models.ConversationSummary{ConversationID: "old", ThroughMessageID: 1, MessageCount: 1, Content: "Summary"})
+	db.db.Exec(`UPDATE conversations SET updated_at = ? WHERE id = 'old'`, db.db.dialect.timeArg(now.Add(-40*24*time.Hour)))
+	db.db.Exec(`UPDATE conversations SET updated_at = ? WHERE id = 'recent'`, db.db.dialect.timeArg(now.Add(-time.Hour)))
+
+	result, err := db.ApplyRetention(models.RetentionPolicy{HistoryMaxAge: 60 * time.Hour, HistoryMaxRows: 2, ConversationMaxAge: 30 * 24 * time.Hour})
+	if err != nil {
+		t.Fatalf("Failed to apply retention: %v", err)
+	}
+	if result.HistoryDeleted != 2 || result.ConversationsDeleted != 1 {
+		t.Errorf("Expected 2 history entries and 1 conversation to be deleted, got %+v", result)
+	}
+
+	history, _, err := db.GetHistory(models.HistoryFilter{})
+	if err != nil {
+		t.Fatalf("Failed to get history: %v", err)
+	}
+	if len(history) != 2 || history[0].Prompt != "a" || history[1].Prompt != "b" {
+		t.Errorf("Expected the two newest history entries to remain, got %+v", history)
+	}
+	if conv, _ := db.GetConversation("old"); conv != nil {
+		t.Error("Expected the expired conversation to be deleted")
+	}
+	var orphans int
+	db.db.QueryRow(`SELECT (SELECT COUNT(*) FROM conversation_messages WHERE conversation_id = 'old') + (SELECT COUNT(*) FROM conversation_summaries WHERE conversation_id = 'old')`).Scan(&orphans)
+	if orphans != 0 {
+		t.Errorf("Expected the expired conversation's messages and summaries to be deleted, got %d rows", orphans)
+	}
+
+	// Row limits keep the most recently updated conversations
+	if result, err = db.ApplyRetention(models.RetentionPolicy{ConversationMaxRows: 1}); err != nil || result.ConversationsDeleted != 1 || result.HistoryDeleted != 0 {
+		t.Fatalf("Expected one more conversation to be deleted, got %+v (%v)", result, err)
+	}
+	if conv, _ := db.GetConversation("newest"); conv == nil {
+		t.Error("Expected the newest conversation to remain")
+	}
+}
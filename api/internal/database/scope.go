@@ -0,0 +1,111 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// scope limits queries to the rows one user may see and makes new rows theirs. History is always
+// personal. Saved prompts, conversations and eval suites are shared by the members of a workspace
+// when one is selected and otherwise private to the user. The zero scope sees every row, as when
+// authentication is off.
+type scope struct {
+	userID      *int64
+	workspaceID *int64
+}
+
+// ForUser returns a view of the database limited to userID's history and to the saved prompts,
+// conversations and eval suites they keep outside any workspace. Rows created through it belong to userID.
+func (d *Database) ForUser(userID int64) Store {
+	return d.withScope(scope{userID: &userID})
+}
+
+// ForWorkspace returns a view of the database limited to userID's history and to the saved prompts,
+// conversations and eval suites of workspaceID. Membership is not checked here.
+func (d *Database) ForWorkspace(userID, workspaceID int64) Store {
+	return d.withScope(scope{userID: &userID, workspaceID: &workspaceID})
+}
+
+// WithContext returns a view of the database whose statements are traced as part of ctx's request.
+// Statements are not cancelled with ctx.
+func (d *Database) WithContext(ctx context.Context) Store {
+	traced := *d
+	traced.db = &conn{DB: d.db.DB, dialect: d.db.dialect, scope: d.db.scope, ctx: ctx}
+	return &traced
+}
+
+func (d *Database) withScope(s scope) Store {
+	scoped := *d
+	scoped.db = &conn{DB: d.db.DB, dialect: d.db.dialect, scope: s, ctx: d.db.ctx}
+	return &scoped
+}
+
+// scoped reports whether queries are limited at all
+func (s scope) scoped() bool {
+	return s.userID != nil
+}
+
+// owner is the user_id for new rows, nil when unscoped
+func (s scope) owner() *int64 {
+	return s.userID
+}
+
+// workspace is the workspace_id for new shared rows, nil outside a workspace
+func (s scope) workspace() *int64 {
+	return s.workspaceID
+}
+
+// restrictOwner limits a personal table such as history, with columns qualified by prefix, to the user's rows
+func (s scope) restrictOwner(where *whereBuilder, prefix string) {
+	if s.userID != nil {
+		where.add(prefix+"user_id = ?", *s.userID)
+	}
+}
+
+// restrict limits a shared table, with columns qualified by prefix, to the scope's rows
+func (s scope) restrict(where *whereBuilder, prefix string) {
+	if clause, args := s.shared(prefix); clause != "" {
+		where.add(clause, args...)
+	}
+}
+
+// andOwned returns a condition to append to a WHERE clause limiting a shared table to the scope's rows
+func (s scope) andOwned(prefix string) (string, []interface{}) {
+	clause, args := s.shared(prefix)
+	if clause == "" {
+		return "", nil
+	}
+	return " AND " + clause, args
+}
+
+// andOwnedIn returns a condition limiting column, which holds ids of the shared table, to the scope's rows of table
+func (s scope) andOwnedIn(column, table string) (string, []interface{}) {
+	clause, args := s.shared("")
+	if clause == "" {
+		return "", nil
+	}
+	return " AND " + column + " IN (SELECT id FROM " + table + " WHERE " + clause + ")", args
+}
+
+func (s scope) shared(prefix string) (string, []interface{}) {
+	switch {
+	case s.workspaceID != nil:
+		return prefix + "workspace_id = ?", []interface{}{*s.workspaceID}
+	case s.userID != nil:
+		return prefix + "user_id = ? AND " + prefix + "workspace_id IS NULL", []interface{}{*s.userID}
+	default:
+		return "", nil
+	}
+}
+
+// visible reports whether the row of a shared table with this id exists within q's scope
+func visible(q queryer, table string, id interface{}) (bool, error) {
+	owned, args := q.andOwned("")
+	var exists bool
+	err := q.QueryRow(`SELECT EXISTS(SELECT 1 FROM `+table+` WHERE id = ?`+owned+`)`, append([]interface{}{id}, args...)...).Scan(&exists)
+	if err != nil && err != sql.ErrNoRows {
+		return false, fmt.Errorf("failed to look up %s: %v", table, err)
+	}
+	return exists, nil
+}
@@ -0,0 +1,433 @@
+package database
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"promptforge/internal/models"
+)
+
+const (
+	defaultSearchLimit = 20
+	maxSearchLimit     = 100
+	snippetRadius      = 60
+	sqliteTimeLayout   = "2006-01-02 15:04:05"
+)
+
+// Search runs a ranked full-text query across prompts, history and conversation messages.
+// Filters only apply to the types that carry the field, so a category filter limits results to prompts.
+func (d *Database) Search(req models.SearchRequest) ([]models.SearchResult, error) {
+	terms := searchTerms(req.Query)
+	if len(terms) == 0 {
+		return []models.SearchResult{}, nil
+	}
+
+	limit := req.Limit
+	if limit <= 0 {
+		limit = defaultSearchLimit
+	} else if limit > maxSearchLimit {
+		limit = maxSearchLimit
+	}
+
+	results := []models.SearchResult{}
+
+	if searchIncludes(req, models.SearchTypePrompt) && req.Model == "" {
+		prompts, err := d.searchPrompts(req, terms, limit)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, prompts...)
+	}
+
+	if searchIncludes(req, models.SearchTypeHistory) && req.Category == "" && req.Tag == "" {
+		history, err := d.searchHistory(req, terms, limit)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, history...)
+	}
+
+	if searchIncludes(req, models.SearchTypeConversation) && req.Category == "" && req.Tag == "" && req.Model == "" {
+		conversations, err := d.searchConversations(req, terms, limit)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, conversations...)
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Score > results[j].Score
+	})
+
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	for i := range results {
+		results[i].Snippet = highlight(results[i].Snippet)
+	}
+
+	return results, nil
+}
+
+func (d *Database) searchPrompts(req models.SearchRequest, terms []string, limit int) ([]models.SearchResult, error) {
+	where := &whereBuilder{}
+	d.db.restrict(where, "p.")
+	if req.Category != "" {
+		where.add("p.category = ?", req.Category)
+	}
+	if req.Tag != "" {
+		addTagFilter(where, []string{req.Tag}, false)
+	}
+	d.addDateRange(where, "p.updated_at", req.From, req.To)
+
+	var query string
+	if d.fts5 {
+		where.add("prompts_fts MATCH ?", ftsQuery(terms))
+		query = `
+			SELECT p.id, p.title, p.category, p.updated_at,
+				snippet(prompts_fts, -1, char(2), char(3), '…', 16),
+				-bm25(prompts_fts, 10.0, 1.0, 3.0)
+			FROM prompts_fts
+			JOIN saved_prompts p ON p.id = prompts_fts.rowid
+		` + where.sql() + ` ORDER BY bm25(prompts_fts, 10.0, 1.0, 3.0) LIMIT ?`
+	} else {
+		d.addLikeTerms(where, terms, "p.title", "p.content", "p.description")
+		query = `
+			SELECT p.id, p.title, p.category, p.updated_at, p.title, p.content, COALESCE(p.description, '')
+			FROM saved_prompts p
+		` + where.sql() + ` ORDER BY p.updated_at DESC LIMIT ?`
+	}
+
+	rows, err := d.db.Query(query, append(where.args, limit)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search prompts: %v", err)
+	}
+	defer rows.Close()
+
+	var results []models.SearchResult
+	for rows.Next() {
+		var id int64
+		result := models.SearchResult{Type: models.SearchTypePrompt}
+		if d.fts5 {
+			err = rows.Scan(&id, &result.Title, &result.Category, &result.Timestamp, &result.Snippet, &result.Score)
+		} else {
+			var title, content, description string
+			err = rows.Scan(&id, &result.Title, &result.Category, &result.Timestamp, &title, &content, &description)
+			result.Snippet, result.Score = likeMatch(terms, []string{title, content, description}, []float64{10, 1, 3})
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan prompt search row: %v", err)
+		}
+		result.ID = strconv.FormatInt(id, 10)
+		results = append(results, result)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating prompt search rows: %v", err)
+	}
+
+	return results, nil
+}
+
+func (d *Database) searchHistory(req models.SearchRequest, terms []string, limit int) ([]models.SearchResult, error) {
+	where := &whereBuilder{}
+	d.db.restrictOwner(where, "h.")
+	if req.Model != "" {
+		where.add("h.model = ?", req.Model)
+	}
+	d.addDateRange(where, "h.timestamp", req.From, req.To)
+
+	var query string
+	if d.fts5 {
+		where.add("history_fts MATCH ?", ftsQuery(terms))
+		query = `
+			SELECT h.id, h.prompt, h.model, h.timestamp,
+				snippet(history_fts, -1, char(2), char(3), '…', 16),
+				-bm25(history_fts, 2.0, 1.0)
+			FROM history_fts
+			JOIN history h ON h.id = history_fts.rowid
+		` + where.sql() + ` ORDER BY bm25(history_fts, 2.0, 1.0) LIMIT ?`
+	} else {
+		d.addLikeTerms(where, terms, "h.prompt", "h.response")
+		query = `
+			SELECT h.id, h.prompt, h.model, h.timestamp, COALESCE(h.response, '')
+			FROM history h
+		` + where.sql() + ` ORDER BY h.timestamp DESC LIMIT ?`
+	}
+
+	rows, err := d.db.Query(query, append(where.args, limit)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search history: %v", err)
+	}
+	defer rows.Close()
+
+	var results []models.SearchResult
+	for rows.Next() {
+		var id int64
+		var prompt string
+		result := models.SearchResult{Type: models.SearchTypeHistory}
+		if d.fts5 {
+			err = rows.Scan(&id, &prompt, &result.Model, &result.Timestamp, &result.Snippet, &result.Score)
+		} else {
+			var response string
+			err = rows.Scan(&id, &prompt, &result.Model, &result.Timestamp, &response)
+			result.Snippet, result.Score = likeMatch(terms, []string{prompt, response}, []float64{2, 1})
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan history search row: %v", err)
+		}
+		result.ID = strconv.FormatInt(id, 10)
+		result.Title = truncateTitle(prompt)
+		results = append(results, result)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating history search rows: %v", err)
+	}
+
+	return results, nil
+}
+
+// searchConversations matches individual messages and keeps the best hit per conversation
+func (d *Database) searchConversations(req models.SearchRequest, terms []string, limit int) ([]models.SearchResult, error) {
+	where := &whereBuilder{}
+	d.db.restrict(where, "c.")
+	d.addDateRange(where, "m.timestamp", req.From, req.To)
+
+	var query string
+	if d.fts5 {
+		where.add("messages_fts MATCH ?", ftsQuery(terms))
+		query = `
+			SELECT m.id, c.id, c.title, m.timestamp,
+				snippet(messages_fts, 0, char(2), char(3), '…', 16),
+				-bm25(messages_fts)
+			FROM messages_fts
+			JOIN conversation_messages m ON m.id = messages_fts.rowid
+			JOIN conversations c ON c.id = m.conversation_id
+		` + where.sql() + ` ORDER BY bm25(messages_fts) LIMIT ?`
+	} else {
+		d.addLikeTerms(where, terms, "m.content")
+		query = `
+			SELECT m.id, c.id, c.title, m.timestamp, m.content
+			FROM conversation_messages m
+			JOIN conversations c ON c.id = m.conversation_id
+		` + where.sql() + ` ORDER BY m.timestamp DESC LIMIT ?`
+	}
+
+	// Over-fetch because several messages can belong to the same conversation
+	rows, err := d.db.Query(query, append(where.args, limit*3)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search conversations: %v", err)
+	}
+	defer rows.Close()
+
+	best := make(map[string]int)
+	var results []models.SearchResult
+	for rows.Next() {
+		result := models.SearchResult{Type: models.SearchTypeConversation}
+		if d.fts5 {
+			err = rows.Scan(&result.MessageID, &result.ID, &result.Title, &result.Timestamp, &result.Snippet, &result.Score)
+		} else {
+			var content string
+			err = rows.Scan(&result.MessageID, &result.ID, &result.Title, &result.Timestamp, &content)
+			result.Snippet, result.Score = likeMatch(terms, []string{content}, []float64{1})
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan conversation search row: %v", err)
+		}
+
+		if i, seen := best[result.ID]; seen {
+			if result.Score > results[i].Score {
+				results[i] = result
+			}
+			continue
+		}
+		best[result.ID] = len(results)
+		results = append(results, result)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating conversation search rows: %v", err)
+	}
+
+	return results, nil
+}
+
+func searchIncludes(req models.SearchRequest, searchType string) bool {
+	if len(req.Types) == 0 {
+		return true
+	}
+	for _, t := range req.Types {
+		if t == searchType {
+			return true
+		}
+	}
+	return false
+}
+
+// searchTerms splits the query into words; a trailing * requests a prefix match
+func searchTerms(query string) []string {
+	var terms []string
+	for _, field := range strings.Fields(query) {
+		if strings.Trim(field, `*"`) != "" {
+			terms = append(terms, field)
+		}
+	}
+	return terms
+}
+
+// ftsQuery quotes each term so user input can never be parsed as FTS5 syntax
+func ftsQuery(terms []string) string {
+	parts := make([]string, len(terms))
+	for i, term := range terms {
+		prefix := strings.HasSuffix(term, "*")
+		term = strings.Trim(term, `*"`)
+		parts[i] = `"` + strings.ReplaceAll(term, `"`, `""`) + `"`
+		if prefix {
+			parts[i] += "*"
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// whereBuilder accumulates AND-ed conditions and their arguments
+type whereBuilder struct {
+	clauses []string
+	args    []interface{}
+}
+
+func (w *whereBuilder) add(clause string, args ...interface{}) {
+	w.clauses = append(w.clauses, clause)
+	w.args = append(w.args, args...)
+}
+
+func (w *whereBuilder) sql() string {
+	if len(w.clauses) == 0 {
+		return ""
+	}
+	return " WHERE " + strings.Join(w.clauses, " AND ")
+}
+
+func (d *Database) addDateRange(where *whereBuilder, column string, from, to *time.Time) {
+	dialect := d.db.dialect
+	if from != nil {
+		where.add(dialect.timeExpr(column)+" >= ?", dialect.timeArg(*from))
+	}
+	if to != nil {
+		where.add(dialect.timeExpr(column)+" <= ?", dialect.timeArg(*to))
+	}
+}
+
+// addLikeTerms requires every term to appear in at least one of the columns
+func (d *Database) addLikeTerms(where *whereBuilder, terms []string, columns ...string) {
+	for _, term := range terms {
+		pattern := "%" + escapeLike(strings.Trim(term, `*"`)) + "%"
+		var alternatives []string
+		var args []interface{}
+		for _, column := range columns {
+			alternatives = append(alternatives, column+" "+d.db.dialect.like+" ? ESCAPE '\\'")
+			args = append(args, pattern)
+		}
+		where.add("("+strings.Join(alternatives, " OR ")+")", args...)
+	}
+}
+
+func escapeLike(value string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+	return replacer.Replace(value)
+}
+
+// likeMatch scores weighted term occurrences and builds a highlighted snippet from the best field
+func likeMatch(terms []string, fields []string, weights []float64) (string, float64) {
+	alternatives := make([]string, len(terms))
+	for i, term := range terms {
+		alternatives[i] = regexp.QuoteMeta(strings.Trim(term, `*"`))
+	}
+	pattern := regexp.MustCompile("(?i)" + strings.Join(alternatives, "|"))
+
+	var score, bestScore float64
+	snippetSource := ""
+	for i, field := range fields {
+		fieldScore := float64(len(pattern.FindAllStringIndex(field, -1))) * weights[i]
+		score += fieldScore
+		if fieldScore > bestScore {
+			bestScore = fieldScore
+			snippetSource = field
+		}
+	}
+
+	return makeSnippet(snippetSource, pattern), score
+}
+
+// makeSnippet cuts text around its first match, with every match delimited for highlight
+func makeSnippet(text string, pattern *regexp.Regexp) string {
+	loc := pattern.FindStringIndex(text)
+	if loc == nil {
+		return ""
+	}
+
+	start := loc[0] - snippetRadius
+	if start < 0 {
+		start = 0
+	}
+	for start > 0 && !utf8.RuneStart(text[start]) {
+		start--
+	}
+
+	end := loc[1] + snippetRadius
+	if end > len(text) {
+		end = len(text)
+	}
+	for end < len(text) && !utf8.RuneStart(text[end]) {
+		end++
+	}
+
+	var marked strings.Builder
+	last := 0
+	for _, match := range pattern.FindAllStringIndex(text[start:end], -1) {
+		marked.WriteString(text[start+last : start+match[0]])
+		marked.WriteString(markStart + text[start+match[0]:start+match[1]] + markEnd)
+		last = match[1]
+	}
+	marked.WriteString(text[start+last : end])
+
+	snippet := marked.String()
+	if start > 0 {
+		snippet = "…" + snippet
+	}
+	if end < len(text) {
+		snippet += "…"
+	}
+	return snippet
+}
+
+// markStart and markEnd delimit matches in a snippet until highlight turns them into <mark> tags.
+// SQLite's snippet() is given the same characters.
+const (
+	markStart = "\x02"
+	markEnd   = "\x03"
+)
+
+// highlight HTML-escapes a snippet, so stored text cannot inject markup, and then wraps the
+// delimited matches in <mark> tags
+func highlight(snippet string) string {
+	return markReplacer.Replace(html.EscapeString(snippet))
+}
+
+var markReplacer = strings.NewReplacer(markStart, "<mark>", markEnd, "</mark>")
+
+func truncateTitle(text string) string {
+	const maxRunes = 80
+	text = strings.Join(strings.Fields(text), " ")
+	if utf8.RuneCountInString(text) <= maxRunes {
+		return text
+	}
+	runes := []rune(text)
+	return string(runes[:maxRunes]) + "…"
+}
@@ -0,0 +1,184 @@
+package database
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"promptforge/internal/models"
+)
+
+func seedSearchData(t *testing.T, db *Database) {
+	t.Helper()
+
+	prompts := []models.SavePromptRequest{
+		{Title: "Refund policy reply", Content: "Explain our refund policy to the customer", Category: "Support", Tags: []string{"support", "billing"}},
+		{Title: "Release notes", Content: "Write release notes mentioning the refund bug fix", Category: "Engineering", Tags: []string{"docs"}},
+		{Title: "Haiku", Content: "Write a haiku about autumn", Category: "Creative"},
+	}
+	for _, p := range prompts {
+		if _, err := db.SavePrompt(p); err != nil {
+			t.Fatalf("Failed to save prompt: %v", err)
+		}
+	}
+
+	history := []models.SaveHistoryRequest{
+		{Prompt: "How do refunds work?", Model: "gpt-4.1", Temperature: 0.7, Success: true, Response: "Refunds take 5 days"},
+		{Prompt: "Tell me a joke", Model: "o3", Temperature: 0.7, Success: true, Response: "No refunds on jokes"},
+	}
+	for _, h := range history {
+		if _, err := db.SaveHistory(h); err != nil {
+			t.Fatalf("Failed to save history: %v", err)
+		}
+	}
+
+	_, err := db.SaveConversation(models.SaveConversationRequest{
+		ConversationID: "conv-search",
+		Title:          "Refund prompt design",
+		Messages: []models.ConversationMessage{
+			{Role: "user", Content: "Help me design a refund prompt", Timestamp: time.Now()},
+			{Role: "assistant", Content: "Sure, start with the refund window", Timestamp: time.Now()},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to save conversation: %v", err)
+	}
+}
+
+func TestSearchAcrossTypes(t *testing.T) {
+	db := setupTestDB(t)
+	seedSearchData(t, db)
+
+	results, err := db.Search(models.SearchRequest{Query: "refund"})
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+
+	counts := map[string]int{}
+	for _, result := range results {
+		counts[result.Type]++
+		if !strings.Contains(strings.ToLower(result.Snippet), "<mark>refund") {
+			t.Errorf("Expected highlighted snippet, got %q", result.Snippet)
+		}
+	}
+
+	if counts[models.SearchTypePrompt] != 2 {
+		t.Errorf("Expected 2 prompt hits, got %d", counts[models.SearchTypePrompt])
+	}
+	if counts[models.SearchTypeHistory] != 2 {
+		t.Errorf("Expected 2 history hits, got %d", counts[models.SearchTypeHistory])
+	}
+	if counts[models.SearchTypeConversation] != 1 {
+		t.Errorf("Expected messages to collapse into 1 conversation hit, got %d", counts[models.SearchTypeConversation])
+	}
+
+	// The prompt with "refund" in its title should outrank the one that only mentions it in passing
+	var promptOrder []string
+	for _, result := range results {
+		if result.Type == models.SearchTypePrompt {
+			promptOrder = append(promptOrder, result.Title)
+		}
+	}
+	if len(promptOrder) == 2 && promptOrder[0] != "Refund policy reply" {
+		t.Errorf("Expected title match to rank first, got %v", promptOrder)
+	}
+}
+
+func TestSearchFilters(t *testing.T) {
+	db := setupTestDB(t)
+	seedSearchData(t, db)
+
+	tests := []struct {
+		name     string
+		req      models.SearchRequest
+		expected int
+		onlyType string
+	}{
+		{"Category limits to prompts", models.SearchRequest{Query: "refund", Category: "Support"}, 1, models.SearchTypePrompt},
+		{"Tag limits to prompts", models.SearchRequest{Query: "refund", Tag: "docs"}, 1, models.SearchTypePrompt},
+		{"Model limits to history", models.SearchRequest{Query: "refund", Model: "o3"}, 1, models.SearchTypeHistory},
+		{"Type filter", models.SearchRequest{Query: "refund", Types: []string{models.SearchTypeConversation}}, 1, models.SearchTypeConversation},
+		{"All terms required", models.SearchRequest{Query: "refund haiku"}, 0, ""},
+		{"Prefix match", models.SearchRequest{Query: "hai*", Types: []string{models.SearchTypePrompt}}, 1, models.SearchTypePrompt},
+		{"Syntax characters are literal", models.SearchRequest{Query: `refund" OR (`}, 0, ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			results, err := db.Search(test.req)
+			if err != nil {
+				t.Fatalf("Search failed: %v", err)
+			}
+			if len(results) != test.expected {
+				t.Fatalf("Expected %d results, got %d: %+v", test.expected, len(results), results)
+			}
+			for _, result := range results {
+				if result.Type != test.onlyType {
+					t.Errorf("Expected only %s results, got %s", test.onlyType, result.Type)
+				}
+			}
+		})
+	}
+
+	future := time.Now().Add(24 * time.Hour)
+	results, err := db.Search(models.SearchRequest{Query: "refund", From: &future})
+	if err != nil {
+		t.Fatalf("Search with date filter failed: %v", err)
+	}
+	if len(results) != 0 {
+		t.Errorf("Expected no results after a future date, got %d", len(results))
+	}
+}
+
+func TestSearchIndexTracksUpdates(t *testing.T) {
+	db := setupTestDB(t)
+
+	saved, err := db.SavePrompt(models.SavePromptRequest{Title: "Greeting", Content: "Say hello"})
+	if err != nil {
+		t.Fatalf("Failed to save prompt: %v", err)
+	}
+
+	_, err = db.UpdatePrompt(models.UpdatePromptRequest{ID: saved.ID, Title: "Greeting", Content: "Say goodbye"})
+	if err != nil {
+		t.Fatalf("Failed to update prompt: %v", err)
+	}
+
+	results, err := db.Search(models.SearchRequest{Query: "hello"})
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	if len(results) != 0 {
+		t.Errorf("Expected stale content to be gone from the index, got %+v", results)
+	}
+
+	results, err = db.Search(models.SearchRequest{Query: "goodbye"})
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	if len(results) != 1 {
+		t.Errorf("Expected updated content to be indexed, got %d results", len(results))
+	}
+}
+
+func TestSearchSnippetsEscapeStoredText(t *testing.T) {
+	db := setupTestDB(t)
+	if _, err := db.SavePrompt(models.SavePromptRequest{Title: "Injected", Content: `Quote <script>alert("x")</script> in the invoice`}); err != nil {
+		t.Fatalf("Failed to save prompt: %v", err)
+	}
+
+	results, err := db.Search(models.SearchRequest{Query: "invoice"})
+	if err != nil || len(results) != 1 {
+		t.Fatalf("Expected one result, got %+v (%v)", results, err)
+	}
+	snippet := results[0].Snippet
+	if strings.Contains(snippet, "<script>") || !strings.Contains(snippet, "&lt;script&gt;") || !strings.Contains(snippet, "<mark>invoice</mark>") {
+		t.Errorf("Expected escaped text with highlighted matches, got %q", snippet)
+	}
+
+	// The FTS5 indexes come from a migration, applied only when the build has FTS5
+	var indexed bool
+	db.db.QueryRow(`SELECT EXISTS(SELECT 1 FROM sqlite_master WHERE name = 'prompts_fts')`).Scan(&indexed)
+	if indexed != db.fts5 {
+		t.Errorf("Expected the search index to exist only with FTS5 (fts5=%v, indexed=%v)", db.fts5, indexed)
+	}
+}
@@ -0,0 +1,161 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"promptforge/internal/models"
+)
+
+// HistoryStore persists prompt execution history
+type HistoryStore interface {
+	GetHistory(filter models.HistoryFilter) ([]models.HistoryItem, *models.PageInfo, error)
+	SaveHistory(req models.SaveHistoryRequest) (int64, error)
+	ClearHistory() error
+}
+
+// ConversationStore persists prompt engineer conversations
+type ConversationStore interface {
+	GetConversations(filter models.ConversationFilter) ([]models.Conversation, *models.PageInfo, error)
+	GetConversation(conversationID string) (*models.Conversation, error)
+	SaveConversation(req models.SaveConversationRequest) (*models.Conversation, error)
+	AppendMessages(conversationID string, req models.AppendMessagesRequest) (*models.Conversation, error)
+	EditMessage(conversationID string, messageID int64, req models.EditMessageRequest) (*models.Conversation, error)
+	GetConversationBranches(conversationID string) ([]models.ConversationBranch, error)
+	GetConversationBranch(conversationID string, messageID int64) (*models.Conversation, error)
+	ForkConversation(conversationID string, req models.ForkConversationRequest) (*models.Conversation, error)
+	GetConversationSummaries(conversationID string) ([]models.ConversationSummary, error)
+	SaveConversationSummary(summary models.ConversationSummary) error
+	DeleteConversation(conversationID string) error
+}
+
+// PromptStore persists the saved prompt library and its version history
+type PromptStore interface {
+	GetSavedPrompts(filter models.PromptFilter) ([]models.SavedPrompt, *models.PageInfo, error)
+	GetSavedPrompt(promptID int64) (*models.SavedPrompt, error)
+	SavePrompt(req models.SavePromptRequest) (*models.SavedPrompt, error)
+	UpdatePrompt(req models.UpdatePromptRequest) (*models.SavedPrompt, error)
+	DeletePrompt(promptID int64) error
+	IncrementPromptUsage(promptID int64) error
+
+	GetPromptVersions(promptID int64) ([]models.PromptVersion, error)
+	GetPromptVersion(promptID int64, version int) (*models.PromptVersion, error)
+	GetLatestPromptVersion(promptID int64) (*models.PromptVersion, error)
+	RestorePromptVersion(promptID int64, version int, note string) (*models.SavedPrompt, error)
+}
+
+// TagStore manages the tags and categories used to organize saved prompts
+type TagStore interface {
+	ListTags() ([]models.LabelCount, error)
+	RenameTag(from, to string) (*models.RelabelResult, error)
+	MergeTags(sources []string, target string) (*models.RelabelResult, error)
+
+	ListCategories() ([]models.LabelCount, error)
+	RenameCategory(from, to string) (*models.RelabelResult, error)
+	MergeCategories(sources []string, target string) (*models.RelabelResult, error)
+}
+
+// LibraryStore exports and imports the whole prompt library
+type LibraryStore interface {
+	ExportLibrary() (*models.Library, error)
+	ImportLibrary(library *models.Library, strategy string) (*models.ImportResult, error)
+}
+
+// SyncStore records what the git-backed library last agreed on for each prompt file
+type SyncStore interface {
+	ListPromptIDs() ([]int64, error)
+	GetPromptSyncStates() ([]models.PromptSyncState, error)
+	SavePromptSyncState(state models.PromptSyncState) error
+	DeletePromptSyncState(path string) error
+}
+
+// SearchStore runs ranked search across the other stores
+type SearchStore interface {
+	Search(req models.SearchRequest) ([]models.SearchResult, error)
+}
+
+// MaintenanceStore backs up the database and enforces retention limits
+type MaintenanceStore interface {
+	Backup(dir string) (*models.Backup, error)
+	ApplyRetention(policy models.RetentionPolicy) (*models.RetentionResult, error)
+}
+
+// UserStore manages accounts and the API keys and sessions they authenticate with. Keys and session
+// tokens are looked up by their hashes.
+type UserStore interface {
+	CreateUser(username, passwordHash string, isAdmin bool) (*models.User, error)
+	GetUser(userID int64) (*models.User, error)
+	GetUserByName(username string) (*models.User, error)
+	GetUserCredentials(username string) (*models.User, string, error)
+	ListUsers() ([]models.User, error)
+	ClaimUnownedData(userID int64) (int64, error)
+
+	CreateAPIKey(userID int64, name, prefix, keyHash string) (*models.APIKey, error)
+	ListAPIKeys(userID int64) ([]models.APIKey, error)
+	DeleteAPIKey(userID, keyID int64) error
+	GetUserByAPIKey(keyHash string) (*models.User, error)
+
+	CreateSession(userID int64, tokenHash string, expiresAt time.Time) error
+	GetUserBySession(tokenHash string) (*models.User, error)
+	DeleteSession(tokenHash string) error
+}
+
+// WorkspaceStore manages workspaces and their members' roles
+type WorkspaceStore interface {
+	CreateWorkspace(name string, ownerID int64) (*models.Workspace, error)
+	ListWorkspaces(userID int64) ([]models.Workspace, error)
+	GetWorkspaceRole(workspaceID, userID int64) (string, error)
+	ListWorkspaceMembers(workspaceID int64) ([]models.WorkspaceMember, error)
+	SetWorkspaceMember(workspaceID, userID int64, role string) error
+	RemoveWorkspaceMember(workspaceID, userID int64) error
+}
+
+// CredentialStore keeps workspaces' provider credentials. API keys are stored as given, so callers
+// encrypt them first.
+type CredentialStore interface {
+	ListWorkspaceCredentials(workspaceID int64) ([]models.WorkspaceCredential, error)
+	SetWorkspaceCredential(workspaceID int64, credential models.WorkspaceCredential) (*models.WorkspaceCredential, error)
+	DeleteWorkspaceCredential(workspaceID int64, provider string) error
+}
+
+// EvalSuiteStore persists generated eval suites
+type EvalSuiteStore interface {
+	ListEvalSuites(promptID int64) ([]models.EvalSuite, error)
+	GetEvalSuite(suiteID int64) (*models.EvalSuite, error)
+	SaveEvalSuite(req models.SaveEvalSuiteRequest) (*models.EvalSuite, error)
+	UpdateEvalSuite(suiteID int64, req models.SaveEvalSuiteRequest) (*models.EvalSuite, error)
+	DeleteEvalSuite(suiteID int64) error
+}
+
+// AuditStore appends to and reads the audit log of mutating API requests
+type AuditStore interface {
+	AppendAudit(entry models.AuditEntry) error
+	GetAuditLog(filter models.AuditFilter) ([]models.AuditEntry, *models.PageInfo, error)
+}
+
+// Store is the storage used by the API. Database implements it for every supported driver.
+type Store interface {
+	HistoryStore
+	ConversationStore
+	PromptStore
+	TagStore
+	LibraryStore
+	SyncStore
+	SearchStore
+	MaintenanceStore
+	UserStore
+	WorkspaceStore
+	CredentialStore
+	EvalSuiteStore
+	AuditStore
+	// ForUser limits history to userID's and saved prompts, conversations and eval suites to those
+	// userID keeps outside any workspace
+	ForUser(userID int64) Store
+	// ForWorkspace limits history to userID's and saved prompts, conversations and eval suites to workspaceID's
+	ForWorkspace(userID, workspaceID int64) Store
+	// WithContext traces statements as part of ctx's request
+	WithContext(ctx context.Context) Store
+	Close() error
+}
+
+var _ Store = (*Database)(nil)
@@ -0,0 +1,70 @@
+package database
+
+import (
+	"fmt"
+
+	"promptforge/internal/models"
+)
+
+// GetConversationSummaries returns every stored summary of a conversation, oldest first
+func (d *Database) GetConversationSummaries(conversationID string) ([]models.ConversationSummary, error) {
+	visible, err := visible(d.db, "conversations", conversationID)
+	if err != nil || !visible {
+		return nil, err
+	}
+
+	rows, err := d.db.Query(`
+		SELECT id, conversation_id, through_message_id, message_count, content, model, token_count, created_at
+		FROM conversation_summaries
+		WHERE conversation_id = ?
+		ORDER BY id ASC
+	`, conversationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query conversation summaries: %v", err)
+	}
+	defer rows.Close()
+
+	var summaries []models.ConversationSummary
+	for rows.Next() {
+		var summary models.ConversationSummary
+		err := rows.Scan(&summary.ID, &summary.ConversationID, &summary.ThroughMessageID, &summary.MessageCount,
+			&summary.Content, &summary.Model, &summary.TokenCount, &summary.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan conversation summary row: %v", err)
+		}
+		summaries = append(summaries, summary)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating conversation summary rows: %v", err)
+	}
+
+	return summaries, nil
+}
+
+// SaveConversationSummary stores a summary, replacing any earlier one written up to the same message
+func (d *Database) SaveConversationSummary(summary models.ConversationSummary) error {
+	visible, err := visible(d.db, "conversations", summary.ConversationID)
+	if err != nil {
+		return err
+	}
+	if !visible {
+		return ErrConversationNotFound
+	}
+
+	_, err = d.db.Exec(`
+		INSERT INTO conversation_summaries (conversation_id, through_message_id, message_count, content, model, token_count)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT (conversation_id, through_message_id) DO UPDATE SET
+			message_count = excluded.message_count,
+			content = excluded.content,
+			model = excluded.model,
+			token_count = excluded.token_count,
+			created_at = CURRENT_TIMESTAMP
+	`, summary.ConversationID, summary.ThroughMessageID, summary.MessageCount, summary.Content, summary.Model, summary.TokenCount)
+	if err != nil {
+		return fmt.Errorf("failed to save conversation summary: %v", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,529 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"promptforge/internal/models"
+)
+
+var (
+	// ErrTagNotFound is returned when a rename or merge names a tag no prompt carries
+	ErrTagNotFound = errors.New("tag not found")
+	// ErrTagExists is returned when renaming a tag onto another existing tag; merge them instead
+	ErrTagExists = errors.New("tag already exists")
+	// ErrCategoryNotFound is returned when a rename or merge names a category no prompt uses
+	ErrCategoryNotFound = errors.New("category not found")
+)
+
+// normalizeTags trims tag names and drops blanks and case-insensitive duplicates, keeping order
+func normalizeTags(tags []string) []string {
+	seen := make(map[string]bool, len(tags))
+	normalized := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		key := strings.ToLower(tag)
+		if tag == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		normalized = append(normalized, tag)
+	}
+	return normalized
+}
+
+// marshalTags encodes tags as the JSON array exposed on SavedPrompt.Tags
+func marshalTags(tags []string) (string, error) {
+	if len(tags) == 0 {
+		return "[]", nil
+	}
+	data, err := json.Marshal(tags)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal tags: %v", err)
+	}
+	return string(data), nil
+}
+
+// findTag looks a tag up case-insensitively, returning sql.ErrNoRows when it does not exist
+func findTag(q queryer, name string) (int64, string, error) {
+	var id int64
+	var stored string
+	err := q.QueryRow(`SELECT id, name FROM tags WHERE LOWER(name) = LOWER(?) ORDER BY id LIMIT 1`, name).Scan(&id, &stored)
+	return id, stored, err
+}
+
+// findOrCreateTag reuses an existing tag regardless of case so "Support" and "support" stay one tag
+func findOrCreateTag(tx *txn, name string) (int64, error) {
+	id, _, err := findTag(tx, name)
+	if err == nil {
+		return id, nil
+	}
+	if err != sql.ErrNoRows {
+		return 0, fmt.Errorf("failed to look up tag %q: %v", name, err)
+	}
+
+	if err := tx.QueryRow(`INSERT INTO tags (name) VALUES (?) RETURNING id`, name).Scan(&id); err != nil {
+		return 0, fmt.Errorf("failed to create tag %q: %v", name, err)
+	}
+	return id, nil
+}
+
+func getPromptTags(q queryer, promptID int64) ([]string, error) {
+	rows, err := q.Query(`
+		SELECT t.name
+		FROM prompt_tags pt
+		JOIN tags t ON t.id = pt.tag_id
+		WHERE pt.prompt_id = ?
+		ORDER BY pt.position ASC
+	`, promptID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query prompt tags: %v", err)
+	}
+	defer rows.Close()
+
+	tags := []string{}
+	for rows.Next() {
+		var tag string
+		if err := rows.Scan(&tag); err != nil {
+			return nil, fmt.Errorf("failed to scan prompt tag row: %v", err)
+		}
+		tags = append(tags, tag)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating prompt tag rows: %v", err)
+	}
+
+	return tags, nil
+}
+
+// getTagsForPrompts loads the tags of several prompts in one query, keyed by prompt ID
+func (d *Database) getTagsForPrompts(promptIDs []int64) (map[int64][]string, error) {
+	tags := make(map[int64][]string)
+	if len(promptIDs) == 0 {
+		return tags, nil
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(promptIDs)), ", ")
+	args := make([]interface{}, len(promptIDs))
+	for i, id := range promptIDs {
+		args[i] = id
+	}
+
+	rows, err := d.db.Query(`
+		SELECT pt.prompt_id, t.name
+		FROM prompt_tags pt
+		JOIN tags t ON t.id = pt.tag_id
+		WHERE pt.prompt_id IN (`+placeholders+`)
+		ORDER BY pt.prompt_id, pt.position ASC
+	`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query prompt tags: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var promptID int64
+		var tag string
+		if err := rows.Scan(&promptID, &tag); err != nil {
+			return nil, fmt.Errorf("failed to scan prompt tag row: %v", err)
+		}
+		tags[promptID] = append(tags[promptID], tag)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating prompt tag rows: %v", err)
+	}
+
+	return tags, nil
+}
+
+// replacePromptTags swaps the tags linked to a prompt within a transaction
+func replacePromptTags(tx *txn, promptID int64, tags []string) error {
+	if _, err := tx.Exec(`DELETE FROM prompt_tags WHERE prompt_id = ?`, promptID); err != nil {
+		return fmt.Errorf("failed to clear prompt tags: %v", err)
+	}
+
+	for i, tag := range normalizeTags(tags) {
+		tagID, err := findOrCreateTag(tx, tag)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(`INSERT INTO prompt_tags (prompt_id, tag_id, position) VALUES (?, ?, ?)`, promptID, tagID, i)
+		if err != nil {
+			return fmt.Errorf("failed to tag prompt with %q: %v", tag, err)
+		}
+	}
+
+	return deleteOrphanTags(tx)
+}
+
+// deleteOrphanTags removes tags no prompt carries any more so they drop out of ListTags
+func deleteOrphanTags(tx *txn) error {
+	if _, err := tx.Exec(`DELETE FROM tags WHERE id NOT IN (SELECT tag_id FROM prompt_tags)`); err != nil {
+		return fmt.Errorf("failed to delete unused tags: %v", err)
+	}
+	return nil
+}
+
+// addTagFilter restricts prompts (aliased p) to those carrying every tag, or with matchAny at least one
+func addTagFilter(where *whereBuilder, tags []string, matchAny bool) {
+	tags = normalizeTags(tags)
+	if len(tags) == 0 {
+		return
+	}
+
+	const hasTag = `EXISTS (SELECT 1 FROM prompt_tags pt JOIN tags t ON t.id = pt.tag_id WHERE pt.prompt_id = p.id AND LOWER(t.name) = LOWER(?))`
+	if !matchAny {
+		for _, tag := range tags {
+			where.add(hasTag, tag)
+		}
+		return
+	}
+
+	clauses := make([]string, len(tags))
+	args := make([]interface{}, len(tags))
+	for i, tag := range tags {
+		clauses[i] = hasTag
+		args[i] = tag
+	}
+	where.add("("+strings.Join(clauses, " OR ")+")", args...)
+}
+
+// ListTags returns every tag in use with the number of prompts carrying it, most used first
+func (d *Database) ListTags() ([]models.LabelCount, error) {
+	owned, args := d.db.andOwnedIn("pt.prompt_id", "saved_prompts")
+	return d.listLabels(`
+		SELECT t.name, COUNT(pt.prompt_id)
+		FROM tags t
+		JOIN prompt_tags pt ON pt.tag_id = t.id
+		WHERE 1 = 1`+owned+`
+		GROUP BY t.id, t.name
+		ORDER BY COUNT(pt.prompt_id) DESC, t.name ASC
+	`, "tags", args...)
+}
+
+// ListCategories returns every category in use with its prompt count, most used first
+func (d *Database) ListCategories() ([]models.LabelCount, error) {
+	owned, args := d.db.andOwned("")
+	return d.listLabels(`
+		SELECT category, COUNT(*)
+		FROM saved_prompts
+		WHERE category IS NOT NULL AND category <> ''`+owned+`
+		GROUP BY category
+		ORDER BY COUNT(*) DESC, category ASC
+	`, "categories", args...)
+}
+
+func (d *Database) listLabels(query, kind string, args ...interface{}) ([]models.LabelCount, error) {
+	rows, err := d.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query %s: %v", kind, err)
+	}
+	defer rows.Close()
+
+	labels := []models.LabelCount{}
+	for rows.Next() {
+		var label models.LabelCount
+		if err := rows.Scan(&label.Name, &label.Count); err != nil {
+			return nil, fmt.Errorf("failed to scan %s row: %v", kind, err)
+		}
+		labels = append(labels, label)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating %s rows: %v", kind, err)
+	}
+
+	return labels, nil
+}
+
+// RenameTag renames a tag on every prompt that carries it. Changing only the case of a name is allowed.
+// Tag names are shared between users and workspaces, so a scoped rename moves only the scope's prompts to
+// a tag named to instead of renaming the tag under everyone else.
+func (d *Database) RenameTag(from, to string) (*models.RelabelResult, error) {
+	from, to = strings.TrimSpace(from), strings.TrimSpace(to)
+	if from == "" || to == "" {
+		return nil, fmt.Errorf("both the current and new tag names are required")
+	}
+
+	tx, err := d.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	tagID, _, err := findTag(tx, from)
+	if err == nil && tx.scoped() {
+		err = ownedTag(tx, tagID)
+	}
+	if err == sql.ErrNoRows {
+		return nil, ErrTagNotFound
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to look up tag: %v", err)
+	}
+
+	existingID, _, err := findTag(tx, to)
+	if err == nil && existingID != tagID && tx.scoped() {
+		err = ownedTag(tx, existingID)
+	}
+	if err == nil && existingID != tagID {
+		return nil, ErrTagExists
+	} else if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("failed to look up tag: %v", err)
+	}
+
+	if tx.scoped() && existingID != tagID {
+		targetID, err := findOrCreateTag(tx, to)
+		if err != nil {
+			return nil, err
+		}
+		updated, err := moveTaggedPrompts(tx, []int64{tagID}, targetID)
+		if err != nil {
+			return nil, err
+		}
+		if err := snapshotPromptVersions(tx, updated, fmt.Sprintf("Renamed tag %s to %s", from, to)); err != nil {
+			return nil, err
+		}
+		if err := tx.Commit(); err != nil {
+			return nil, fmt.Errorf("failed to commit tag rename: %v", err)
+		}
+		return &models.RelabelResult{Name: to, PromptsUpdated: len(updated)}, nil
+	}
+
+	updated, err := touchTaggedPrompts(tx, []int64{tagID})
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := tx.Exec(`UPDATE tags SET name = ? WHERE id = ?`, to, tagID); err != nil {
+		return nil, fmt.Errorf("failed to rename tag: %v", err)
+	}
+	if err := snapshotPromptVersions(tx, updated, fmt.Sprintf("Renamed tag %s to %s", from, to)); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit tag rename: %v", err)
+	}
+
+	return &models.RelabelResult{Name: to, PromptsUpdated: len(updated)}, nil
+}
+
+// MergeTags folds the source tags into target, creating target if needed. Prompts keep the
+// position of the first merged tag they carried.
+func (d *Database) MergeTags(sources []string, target string) (*models.RelabelResult, error) {
+	target = strings.TrimSpace(target)
+	sources = normalizeTags(sources)
+	if target == "" || len(sources) == 0 {
+		return nil, fmt.Errorf("a target tag and at least one source tag are required")
+	}
+
+	tx, err := d.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	targetID, err := findOrCreateTag(tx, target)
+	if err != nil {
+		return nil, err
+	}
+
+	var sourceIDs []int64
+	for _, source := range sources {
+		sourceID, _, err := findTag(tx, source)
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("%w: %s", ErrTagNotFound, source)
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to look up tag: %v", err)
+		}
+		if sourceID != targetID {
+			sourceIDs = append(sourceIDs, sourceID)
+		}
+	}
+
+	updated, err := moveTaggedPrompts(tx, sourceIDs, targetID)
+	if err != nil {
+		return nil, err
+	}
+
+	var name string
+	if err := tx.QueryRow(`SELECT name FROM tags WHERE id = ?`, targetID).Scan(&name); err != nil {
+		return nil, fmt.Errorf("failed to read merged tag: %v", err)
+	}
+	if err := snapshotPromptVersions(tx, updated, fmt.Sprintf("Merged tags %s into %s", strings.Join(sources, ", "), name)); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit tag merge: %v", err)
+	}
+
+	return &models.RelabelResult{Name: name, PromptsUpdated: len(updated)}, nil
+}
+
+// ownedTag returns sql.ErrNoRows unless one of the scope's prompts carries the tag
+func ownedTag(tx *txn, tagID int64) error {
+	owned, args := tx.andOwnedIn("prompt_id", "saved_prompts")
+	var exists bool
+	err := tx.QueryRow(`SELECT EXISTS(SELECT 1 FROM prompt_tags WHERE tag_id = ?`+owned+`)`, append([]interface{}{tagID}, args...)...).Scan(&exists)
+	if err == nil && !exists {
+		return sql.ErrNoRows
+	}
+	return err
+}
+
+// moveTaggedPrompts retags the scope's prompts carrying any of the source tags with target and returns
+// the prompts that changed
+func moveTaggedPrompts(tx *txn, sourceIDs []int64, targetID int64) ([]int64, error) {
+	updated, err := touchTaggedPrompts(tx, sourceIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	owned, ownedArgs := tx.andOwnedIn("prompt_id", "saved_prompts")
+	for _, sourceID := range sourceIDs {
+		_, err := tx.Exec(`
+			INSERT INTO prompt_tags (prompt_id, tag_id, position)
+			SELECT prompt_id, CAST(? AS BIGINT), position FROM prompt_tags
+			WHERE tag_id = ? AND prompt_id NOT IN (SELECT prompt_id FROM prompt_tags WHERE tag_id = ?)`+owned,
+			append([]interface{}{targetID, sourceID, targetID}, ownedArgs...)...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to move prompts to merged tag: %v", err)
+		}
+
+		if _, err := tx.Exec(`DELETE FROM prompt_tags WHERE tag_id = ?`+owned, append([]interface{}{sourceID}, ownedArgs...)...); err != nil {
+			return nil, fmt.Errorf("failed to remove merged tag: %v", err)
+		}
+	}
+
+	if err := deleteOrphanTags(tx); err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+// touchTaggedPrompts bumps updated_at on the scope's prompts carrying any of the tags and returns them
+func touchTaggedPrompts(tx *txn, tagIDs []int64) ([]int64, error) {
+	if len(tagIDs) == 0 {
+		return nil, nil
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(tagIDs)), ", ")
+	args := make([]interface{}, len(tagIDs))
+	for i, id := range tagIDs {
+		args[i] = id
+	}
+	owned, ownedArgs := tx.andOwned("")
+	condition := `id IN (SELECT prompt_id FROM prompt_tags WHERE tag_id IN (` + placeholders + `))` + owned
+	args = append(args, ownedArgs...)
+
+	ids, err := promptIDs(tx, condition, args)
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range ids {
+		if err := ensureBaselineVersion(tx, id); err != nil {
+			return nil, err
+		}
+	}
+	if _, err := tx.Exec(`UPDATE saved_prompts SET updated_at = CURRENT_TIMESTAMP WHERE `+condition, args...); err != nil {
+		return nil, fmt.Errorf("failed to update tagged prompts: %v", err)
+	}
+	return ids, nil
+}
+
+// promptIDs lists the saved prompts matching condition
+func promptIDs(tx *txn, condition string, args []interface{}) ([]int64, error) {
+	rows, err := tx.Query(`SELECT id FROM saved_prompts WHERE `+condition+` ORDER BY id ASC`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query affected prompts: %v", err)
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan prompt ID: %v", err)
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating prompt ID rows: %v", err)
+	}
+	return ids, nil
+}
+
+// snapshotPromptVersions records a version of each prompt a bulk change touched, so their history
+// ends in their current state
+func snapshotPromptVersions(tx *txn, promptIDs []int64, note string) error {
+	for _, id := range promptIDs {
+		if _, err := snapshotPromptVersion(tx, id, note); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RenameCategory moves every prompt in one category to another; renaming onto an existing category merges them
+func (d *Database) RenameCategory(from, to string) (*models.RelabelResult, error) {
+	return d.MergeCategories([]string{from}, to)
+}
+
+// MergeCategories moves every prompt in the source categories into target
+func (d *Database) MergeCategories(sources []string, target string) (*models.RelabelResult, error) {
+	target = strings.TrimSpace(target)
+	if target == "" || len(sources) == 0 {
+		return nil, fmt.Errorf("a target category and at least one source category are required")
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(sources)), ", ")
+	names := make([]string, len(sources))
+	args := make([]interface{}, len(sources))
+	for i, source := range sources {
+		names[i] = strings.TrimSpace(source)
+		args[i] = names[i]
+	}
+
+	tx, err := d.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	owned, ownedArgs := tx.andOwned("")
+	condition := `category IN (` + placeholders + `)` + owned
+	args = append(args, ownedArgs...)
+
+	updated, err := promptIDs(tx, condition, args)
+	if err != nil {
+		return nil, err
+	}
+	if len(updated) == 0 {
+		return nil, ErrCategoryNotFound
+	}
+	for _, id := range updated {
+		if err := ensureBaselineVersion(tx, id); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, err := tx.Exec(`UPDATE saved_prompts SET category = ?, updated_at = CURRENT_TIMESTAMP WHERE `+condition,
+		append([]interface{}{target}, args...)...); err != nil {
+		return nil, fmt.Errorf("failed to update categories: %v", err)
+	}
+	if err := snapshotPromptVersions(tx, updated, fmt.Sprintf("Moved from category %s to %s", strings.Join(names, ", "), target)); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit category merge: %v", err)
+	}
+
+	return &models.RelabelResult{Name: target, PromptsUpdated: len(updated)}, nil
+}
@@ -0,0 +1,230 @@
+package database
+
+import (
+	"errors"
+	"testing"
+
+	"promptforge/internal/models"
+)
+
+func seedTaggedPrompts(t *testing.T, db *Database) map[string]int64 {
+	t.Helper()
+
+	prompts := []models.SavePromptRequest{
+		{Title: "Refunds", Content: "x", Category: "Support", Tags: []string{"support", "billing"}},
+		{Title: "Escalation", Content: "x", Category: "Support", Tags: []string{"Support", "urgent"}},
+		{Title: "Release notes", Content: "x", Category: "Engineering", Tags: []string{"docs"}},
+		{Title: "Untagged", Content: "x", Category: "General"},
+	}
+
+	ids := make(map[string]int64)
+	for _, req := range prompts {
+		saved, err := db.SavePrompt(req)
+		if err != nil {
+			t.Fatalf("Failed to save prompt: %v", err)
+		}
+		ids[req.Title] = saved.ID
+	}
+	return ids
+}
+
+func TestListTags(t *testing.T) {
+	db := setupTestDB(t)
+	seedTaggedPrompts(t, db)
+
+	tags, err := db.ListTags()
+	if err != nil {
+		t.Fatalf("Failed to list tags: %v", err)
+	}
+
+	// "Support" reuses the existing "support" tag
+	expected := []models.LabelCount{{Name: "support", Count: 2}, {Name: "billing", Count: 1}, {Name: "docs", Count: 1}, {Name: "urgent", Count: 1}}
+	if len(tags) != len(expected) {
+		t.Fatalf("Expected %d tags, got %+v", len(expected), tags)
+	}
+	for i := range expected {
+		if tags[i] != expected[i] {
+			t.Errorf("Expected tag %d to be %+v, got %+v", i, expected[i], tags[i])
+		}
+	}
+
+	categories, err := db.ListCategories()
+	if err != nil {
+		t.Fatalf("Failed to list categories: %v", err)
+	}
+	if len(categories) != 3 || categories[0] != (models.LabelCount{Name: "Support", Count: 2}) {
+		t.Errorf("Unexpected categories: %+v", categories)
+	}
+}
+
+func TestFilterPromptsByTags(t *testing.T) {
+	db := setupTestDB(t)
+	seedTaggedPrompts(t, db)
+
+	all, _, err := db.GetSavedPrompts(models.PromptFilter{Tags: []string{"support", "urgent"}})
+	if err != nil {
+		t.Fatalf("Failed to filter prompts: %v", err)
+	}
+	if len(all) != 1 || all[0].Title != "Escalation" {
+		t.Errorf("Expected only Escalation to carry both tags, got %+v", all)
+	}
+
+	anyMatch, page, err := db.GetSavedPrompts(models.PromptFilter{Tags: []string{"BILLING", "docs"}, MatchAnyTag: true, PageOptions: models.PageOptions{Sort: "title"}})
+	if err != nil {
+		t.Fatalf("Failed to filter prompts: %v", err)
+	}
+	if len(anyMatch) != 2 || page.Total != 2 || anyMatch[0].Title != "Refunds" || anyMatch[1].Title != "Release notes" {
+		t.Errorf("Expected Refunds and Release notes, got %+v", anyMatch)
+	}
+	if anyMatch[0].Tags != `["support","billing"]` {
+		t.Errorf("Expected tags in saved order, got %s", anyMatch[0].Tags)
+	}
+}
+
+func TestRenameTag(t *testing.T) {
+	db := setupTestDB(t)
+	ids := seedTaggedPrompts(t, db)
+
+	result, err := db.RenameTag("billing", "payments")
+	if err != nil {
+		t.Fatalf("Failed to rename tag: %v", err)
+	}
+	if result.Name != "payments" || result.PromptsUpdated != 1 {
+		t.Errorf("Unexpected rename result: %+v", result)
+	}
+
+	prompt, _ := db.GetSavedPrompt(ids["Refunds"])
+	if prompt.Tags != `["support","payments"]` {
+		t.Errorf("Expected renamed tag on prompt, got %s", prompt.Tags)
+	}
+
+	if _, err := db.RenameTag("payments", "Support"); !errors.Is(err, ErrTagExists) {
+		t.Errorf("Expected ErrTagExists, got %v", err)
+	}
+	if _, err := db.RenameTag("missing", "anything"); !errors.Is(err, ErrTagNotFound) {
+		t.Errorf("Expected ErrTagNotFound, got %v", err)
+	}
+
+	// Changing only the case is allowed
+	if _, err := db.RenameTag("docs", "Docs"); err != nil {
+		t.Errorf("Failed to change tag case: %v", err)
+	}
+}
+
+func TestMergeTags(t *testing.T) {
+	db := setupTestDB(t)
+	ids := seedTaggedPrompts(t, db)
+
+	result, err := db.MergeTags([]string{"urgent", "billing"}, "support")
+	if err != nil {
+		t.Fatalf("Failed to merge tags: %v", err)
+	}
+	if result.Name != "support" || result.PromptsUpdated != 2 {
+		t.Errorf("Unexpected merge result: %+v", result)
+	}
+
+	for _, title := range []string{"Refunds", "Escalation"} {
+		prompt, _ := db.GetSavedPrompt(ids[title])
+		if prompt.Tags != `["support"]` {
+			t.Errorf("Expected %s to carry only the merged tag, got %s", title, prompt.Tags)
+		}
+	}
+
+	tags, _ := db.ListTags()
+	if len(tags) != 2 || tags[0] != (models.LabelCount{Name: "support", Count: 2}) {
+		t.Errorf("Expected merged tags to disappear, got %+v", tags)
+	}
+
+	// Merging into a new tag creates it
+	result, err = db.MergeTags([]string{"docs"}, "documentation")
+	if err != nil || result.PromptsUpdated != 1 {
+		t.Fatalf("Failed to merge into a new tag: %+v, %v", result, err)
+	}
+
+	if _, err := db.MergeTags([]string{"missing"}, "support"); !errors.Is(err, ErrTagNotFound) {
+		t.Errorf("Expected ErrTagNotFound, got %v", err)
+	}
+}
+
+func TestRenameAndMergeCategories(t *testing.T) {
+	db := setupTestDB(t)
+	ids := seedTaggedPrompts(t, db)
+
+	result, err := db.RenameCategory("Engineering", "Docs")
+	if err != nil || result.PromptsUpdated != 1 {
+		t.Fatalf("Failed to rename category: %+v, %v", result, err)
+	}
+
+	result, err = db.MergeCategories([]string{"Docs", "General"}, "Support")
+	if err != nil || result.PromptsUpdated != 2 {
+		t.Fatalf("Failed to merge categories: %+v, %v", result, err)
+	}
+
+	prompt, _ := db.GetSavedPrompt(ids["Release notes"])
+	if prompt.Category != "Support" {
+		t.Errorf("Expected merged category, got %s", prompt.Category)
+	}
+
+	if _, err := db.RenameCategory("Nope", "Other"); !errors.Is(err, ErrCategoryNotFound) {
+		t.Errorf("Expected ErrCategoryNotFound, got %v", err)
+	}
+}
+
+func TestRelabelingRecordsPromptVersions(t *testing.T) {
+	db := setupTestDB(t)
+	ids := seedTaggedPrompts(t, db)
+
+	if _, err := db.RenameTag("billing", "payments"); err != nil {
+		t.Fatalf("Failed to rename tag: %v", err)
+	}
+	if _, err := db.MergeTags([]string{"urgent"}, "support"); err != nil {
+		t.Fatalf("Failed to merge tags: %v", err)
+	}
+	if _, err := db.RenameCategory("Support", "Help desk"); err != nil {
+		t.Fatalf("Failed to rename category: %v", err)
+	}
+
+	// Each prompt's latest version matches its current state
+	for _, title := range []string{"Refunds", "Escalation"} {
+		prompt, _ := db.GetSavedPrompt(ids[title])
+		latest, err := db.GetLatestPromptVersion(ids[title])
+		if err != nil || latest == nil {
+			t.Fatalf("Failed to get latest version of %s: %v", title, err)
+		}
+		if latest.Tags != prompt.Tags || latest.Category != prompt.Category {
+			t.Errorf("Expected %s's latest version to match %s/%s, got %s/%s",
+				title, prompt.Category, prompt.Tags, latest.Category, latest.Tags)
+		}
+	}
+
+	// Restoring the latest version keeps the relabeled tags
+	latest, _ := db.GetLatestPromptVersion(ids["Refunds"])
+	restored, err := db.RestorePromptVersion(ids["Refunds"], latest.Version, "")
+	if err != nil {
+		t.Fatalf("Failed to restore version: %v", err)
+	}
+	if restored.Tags != `["support","payments"]` || restored.Category != "Help desk" {
+		t.Errorf("Expected restore to keep the new labels, got %s/%s", restored.Category, restored.Tags)
+	}
+
+	versions, _ := db.GetPromptVersions(ids["Release notes"])
+	if len(versions) != 1 {
+		t.Errorf("Expected untouched prompts to keep one version, got %d", len(versions))
+	}
+}
+
+func TestDeletePromptRemovesUnusedTags(t *testing.T) {
+	db := setupTestDB(t)
+	ids := seedTaggedPrompts(t, db)
+
+	if err := db.DeletePrompt(ids["Release notes"]); err != nil {
+		t.Fatalf("Failed to delete prompt: %v", err)
+	}
+
+	tags, _ := db.ListTags()
+	for _, tag := range tags {
+		if tag.Name == "docs" {
+			t.Error("Expected tag of deleted prompt to be removed")
+		}
+	}
+}
@@ -0,0 +1,55 @@
+-- A database created by PromptForge before schema migrations existed:
+-- the original four tables, some data and no schema_migrations table.
+CREATE TABLE history (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	timestamp DATETIME DEFAULT CURRENT_TIMESTAMP,
+	prompt TEXT NOT NULL,
+	model TEXT NOT NULL,
+	temperature REAL NOT NULL,
+	max_tokens INTEGER,
+	success BOOLEAN NOT NULL,
+	response TEXT,
+	error_msg TEXT
+);
+
+CREATE TABLE conversations (
+	id TEXT PRIMARY KEY,
+	title TEXT NOT NULL,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE conversation_messages (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	conversation_id TEXT NOT NULL,
+	role TEXT NOT NULL,
+	content TEXT NOT NULL,
+	timestamp DATETIME DEFAULT CURRENT_TIMESTAMP,
+	FOREIGN KEY(conversation_id) REFERENCES conversations(id) ON DELETE CASCADE
+);
+
+CREATE TABLE saved_prompts (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	title TEXT NOT NULL,
+	content TEXT NOT NULL,
+	description TEXT DEFAULT '',
+	category TEXT DEFAULT 'General',
+	tags TEXT DEFAULT '[]',
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	usage_count INTEGER DEFAULT 0
+);
+
+INSERT INTO history (timestamp, prompt, model, temperature, max_tokens, success, response, error_msg)
+VALUES ('2024-03-01 10:00:00', 'Summarize the quarterly report', 'gpt-4.1', 0.7, 500, 1, 'Revenue grew 12%.', '');
+
+INSERT INTO conversations (id, title, created_at, updated_at)
+VALUES ('legacy-conv', 'Legacy conversation', '2024-03-02 09:00:00', '2024-03-02 09:05:00');
+
+INSERT INTO conversation_messages (conversation_id, role, content, timestamp)
+VALUES ('legacy-conv', 'user', 'Help me write a prompt', '2024-03-02 09:00:00'),
+       ('legacy-conv', 'assistant', 'Sure, what is it for?', '2024-03-02 09:00:05');
+
+INSERT INTO saved_prompts (title, content, description, category, tags, created_at, updated_at, usage_count)
+VALUES ('Legacy prompt', 'Translate {{text}} into French', 'Saved before migrations', 'Translation', '["french"]',
+        '2024-03-03 08:00:00', '2024-03-03 08:00:00', 4);
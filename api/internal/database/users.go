@@ -0,0 +1,238 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"promptforge/internal/models"
+)
+
+var (
+	// ErrUserExists is returned when creating a user whose name is taken
+	ErrUserExists = errors.New("user already exists")
+	// ErrUserNotFound is returned when a username, API key or session matches no user
+	ErrUserNotFound = errors.New("user not found")
+	// ErrAPIKeyNotFound is returned when deleting a key the user does not own
+	ErrAPIKeyNotFound = errors.New("API key not found")
+)
+
+const userColumns = `u.id, u.username, u.is_admin, u.created_at`
+
+func scanUser(row rowScanner, extra ...interface{}) (*models.User, error) {
+	var user models.User
+	if err := row.Scan(append([]interface{}{&user.ID, &user.Username, &user.IsAdmin, &user.CreatedAt}, extra...)...); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrUserNotFound
+		}
+		return nil, fmt.Errorf("failed to get user: %v", err)
+	}
+	return &user, nil
+}
+
+// CreateUser adds an account. passwordHash is stored as given; an empty hash disables password login.
+func (d *Database) CreateUser(username, passwordHash string, isAdmin bool) (*models.User, error) {
+	username = strings.TrimSpace(username)
+	if username == "" {
+		return nil, fmt.Errorf("username is required")
+	}
+
+	var id int64
+	err := d.db.QueryRow(`
+		INSERT INTO users (username, password_hash, is_admin) VALUES (?, ?, ?)
+		ON CONFLICT (username) DO NOTHING
+		RETURNING id
+	`, username, passwordHash, isAdmin).Scan(&id)
+	if err == sql.ErrNoRows {
+		return nil, ErrUserExists
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create user: %v", err)
+	}
+
+	return d.GetUser(id)
+}
+
+func (d *Database) GetUser(userID int64) (*models.User, error) {
+	return scanUser(d.db.QueryRow(`SELECT `+userColumns+` FROM users u WHERE u.id = ?`, userID))
+}
+
+func (d *Database) GetUserByName(username string) (*models.User, error) {
+	return scanUser(d.db.QueryRow(`SELECT `+userColumns+` FROM users u WHERE u.username = ?`, strings.TrimSpace(username)))
+}
+
+// GetUserCredentials returns the user with this username and their password hash
+func (d *Database) GetUserCredentials(username string) (*models.User, string, error) {
+	var passwordHash string
+	user, err := scanUser(d.db.QueryRow(`SELECT `+userColumns+`, u.password_hash FROM users u WHERE u.username = ?`,
+		strings.TrimSpace(username)), &passwordHash)
+	if err != nil {
+		return nil, "", err
+	}
+	return user, passwordHash, nil
+}
+
+func (d *Database) ListUsers() ([]models.User, error) {
+	rows, err := d.db.Query(`SELECT ` + userColumns + ` FROM users u ORDER BY u.username ASC`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query users: %v", err)
+	}
+	defer rows.Close()
+
+	users := []models.User{}
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, *user)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating user rows: %v", err)
+	}
+
+	return users, nil
+}
+
+// ClaimUnownedData gives userID every history entry, conversation, saved prompt and eval suite created before
+// authentication was turned on, and returns how many rows it claimed
+func (d *Database) ClaimUnownedData(userID int64) (int64, error) {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	var claimed int64
+	for _, table := range []string{"history", "conversations", "saved_prompts", "eval_suites"} {
+		result, err := tx.Exec(`UPDATE `+table+` SET user_id = ? WHERE user_id IS NULL`, userID)
+		if err != nil {
+			return 0, fmt.Errorf("failed to claim %s: %v", table, err)
+		}
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return 0, fmt.Errorf("failed to check claimed rows: %v", err)
+		}
+		claimed += affected
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit claim: %v", err)
+	}
+	return claimed, nil
+}
+
+const apiKeyColumns = `id, name, prefix, created_at, last_used_at`
+
+func scanAPIKey(row rowScanner) (*models.APIKey, error) {
+	var key models.APIKey
+	var lastUsed sql.NullTime
+	if err := row.Scan(&key.ID, &key.Name, &key.Prefix, &key.CreatedAt, &lastUsed); err != nil {
+		return nil, err
+	}
+	if lastUsed.Valid {
+		key.LastUsedAt = &lastUsed.Time
+	}
+	return &key, nil
+}
+
+// CreateAPIKey stores a key for userID by its hash; the key itself is never stored
+func (d *Database) CreateAPIKey(userID int64, name, prefix, keyHash string) (*models.APIKey, error) {
+	key, err := scanAPIKey(d.db.QueryRow(`
+		INSERT INTO api_keys (user_id, name, prefix, key_hash) VALUES (?, ?, ?, ?)
+		RETURNING `+apiKeyColumns, userID, name, prefix, keyHash))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create API key: %v", err)
+	}
+	return key, nil
+}
+
+func (d *Database) ListAPIKeys(userID int64) ([]models.APIKey, error) {
+	rows, err := d.db.Query(`SELECT `+apiKeyColumns+` FROM api_keys WHERE user_id = ? ORDER BY id ASC`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query API keys: %v", err)
+	}
+	defer rows.Close()
+
+	keys := []models.APIKey{}
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan API key row: %v", err)
+		}
+		keys = append(keys, *key)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating API key rows: %v", err)
+	}
+
+	return keys, nil
+}
+
+// DeleteAPIKey revokes one of userID's keys
+func (d *Database) DeleteAPIKey(userID, keyID int64) error {
+	result, err := d.db.Exec(`DELETE FROM api_keys WHERE id = ? AND user_id = ?`, keyID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete API key: %v", err)
+	}
+	if affected, err := result.RowsAffected(); err != nil {
+		return fmt.Errorf("failed to check deleted rows: %v", err)
+	} else if affected == 0 {
+		return ErrAPIKeyNotFound
+	}
+	return nil
+}
+
+// GetUserByAPIKey returns the owner of the key with this hash and records that the key was used
+func (d *Database) GetUserByAPIKey(keyHash string) (*models.User, error) {
+	var keyID int64
+	user, err := scanUser(d.db.QueryRow(`
+		SELECT `+userColumns+`, k.id
+		FROM api_keys k
+		JOIN users u ON u.id = k.user_id
+		WHERE k.key_hash = ?
+	`, keyHash), &keyID)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := d.db.Exec(`UPDATE api_keys SET last_used_at = CURRENT_TIMESTAMP WHERE id = ?`, keyID); err != nil {
+		return nil, fmt.Errorf("failed to record API key use: %v", err)
+	}
+	return user, nil
+}
+
+// CreateSession stores a login session by the hash of its token. Expired sessions are removed on the way.
+func (d *Database) CreateSession(userID int64, tokenHash string, expiresAt time.Time) error {
+	if _, err := d.db.Exec(`DELETE FROM sessions WHERE `+d.db.dialect.timeExpr("expires_at")+` < ?`, d.db.dialect.timeArg(time.Now())); err != nil {
+		return fmt.Errorf("failed to delete expired sessions: %v", err)
+	}
+
+	_, err := d.db.Exec(`INSERT INTO sessions (token_hash, user_id, expires_at) VALUES (?, ?, ?)`,
+		tokenHash, userID, d.db.dialect.timeArg(expiresAt))
+	if err != nil {
+		return fmt.Errorf("failed to create session: %v", err)
+	}
+	return nil
+}
+
+// GetUserBySession returns the user of the unexpired session with this token hash
+func (d *Database) GetUserBySession(tokenHash string) (*models.User, error) {
+	return scanUser(d.db.QueryRow(`
+		SELECT `+userColumns+`
+		FROM sessions s
+		JOIN users u ON u.id = s.user_id
+		WHERE s.token_hash = ? AND `+d.db.dialect.timeExpr("s.expires_at")+` >= ?
+	`, tokenHash, d.db.dialect.timeArg(time.Now())))
+}
+
+func (d *Database) DeleteSession(tokenHash string) error {
+	if _, err := d.db.Exec(`DELETE FROM sessions WHERE token_hash = ?`, tokenHash); err != nil {
+		return fmt.Errorf("failed to delete session: %v", err)
+	}
+	return nil
+}
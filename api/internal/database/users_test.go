@@ -0,0 +1,170 @@
+package database
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"promptforge/internal/models"
+)
+
+func TestUserCredentials(t *testing.T) {
+	db := setupTestDB(t)
+
+	user, err := db.CreateUser("alice", "hash", true)
+	if err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+	if !user.IsAdmin || user.Username != "alice" {
+		t.Errorf("Unexpected user: %+v", user)
+	}
+	if _, err := db.CreateUser("alice", "other", false); !errors.Is(err, ErrUserExists) {
+		t.Errorf("Expected ErrUserExists for a duplicate username, got %v", err)
+	}
+
+	found, hash, err := db.GetUserCredentials("alice")
+	if err != nil || found.ID != user.ID || hash != "hash" {
+		t.Errorf("Expected alice's credentials, got %+v %q %v", found, hash, err)
+	}
+	if _, _, err := db.GetUserCredentials("bob"); !errors.Is(err, ErrUserNotFound) {
+		t.Errorf("Expected ErrUserNotFound, got %v", err)
+	}
+
+	key, err := db.CreateAPIKey(user.ID, "ci", "pf_abcdef", "keyhash")
+	if err != nil {
+		t.Fatalf("Failed to create API key: %v", err)
+	}
+	if key.LastUsedAt != nil {
+		t.Error("A new key should not have been used")
+	}
+	if found, err := db.GetUserByAPIKey("keyhash"); err != nil || found.ID != user.ID {
+		t.Errorf("Expected the key to authenticate alice, got %+v %v", found, err)
+	}
+	keys, err := db.ListAPIKeys(user.ID)
+	if err != nil || len(keys) != 1 || keys[0].LastUsedAt == nil {
+		t.Errorf("Expected one used key, got %+v %v", keys, err)
+	}
+	if err := db.DeleteAPIKey(user.ID+1, key.ID); !errors.Is(err, ErrAPIKeyNotFound) {
+		t.Errorf("Expected another user's key to be untouchable, got %v", err)
+	}
+	if err := db.DeleteAPIKey(user.ID, key.ID); err != nil {
+		t.Fatalf("Failed to delete API key: %v", err)
+	}
+	if _, err := db.GetUserByAPIKey("keyhash"); !errors.Is(err, ErrUserNotFound) {
+		t.Errorf("Expected a deleted key to stop working, got %v", err)
+	}
+
+	if err := db.CreateSession(user.ID, "live", time.Now().Add(time.Hour)); err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+	if err := db.CreateSession(user.ID, "expired", time.Now().Add(-time.Hour)); err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+	if found, err := db.GetUserBySession("live"); err != nil || found.ID != user.ID {
+		t.Errorf("Expected the session to authenticate alice, got %+v %v", found, err)
+	}
+	if _, err := db.GetUserBySession("expired"); !errors.Is(err, ErrUserNotFound) {
+		t.Errorf("Expected an expired session to be rejected, got %v", err)
+	}
+	if err := db.DeleteSession("live"); err != nil {
+		t.Fatalf("Failed to delete session: %v", err)
+	}
+	if _, err := db.GetUserBySession("live"); !errors.Is(err, ErrUserNotFound) {
+		t.Errorf("Expected a logged out session to be rejected, got %v", err)
+	}
+}
+
+func TestForUserScoping(t *testing.T) {
+	db := setupTestDB(t)
+
+	// Data from before authentication has no owner until it is claimed
+	legacy, err := db.SavePrompt(models.SavePromptRequest{Title: "Legacy", Content: "old", Category: "General", Tags: []string{"shared"}})
+	if err != nil {
+		t.Fatalf("Failed to save prompt: %v", err)
+	}
+
+	alice, err := db.CreateUser("alice", "", false)
+	if err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+	bob, err := db.CreateUser("bob", "", false)
+	if err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+	asAlice, asBob := db.ForUser(alice.ID), db.ForUser(bob.ID)
+
+	if claimed, err := db.ClaimUnownedData(alice.ID); err != nil || claimed != 1 {
+		t.Fatalf("Expected to claim 1 row, got %d %v", claimed, err)
+	}
+
+	bobPrompt, err := asBob.SavePrompt(models.SavePromptRequest{Title: "Bob's", Content: "mine", Category: "Private", Tags: []string{"shared"}})
+	if err != nil {
+		t.Fatalf("Failed to save prompt: %v", err)
+	}
+	if _, err := asBob.SaveHistory(models.SaveHistoryRequest{Prompt: "p", Model: "gpt-4", Success: true}); err != nil {
+		t.Fatalf("Failed to save history: %v", err)
+	}
+	if _, err := asBob.SaveConversation(models.SaveConversationRequest{
+		ConversationID: "bob-conv",
+		Messages:       []models.ConversationMessage{{Role: "user", Content: "hi"}},
+	}); err != nil {
+		t.Fatalf("Failed to save conversation: %v", err)
+	}
+
+	prompts, _, err := asAlice.GetSavedPrompts(models.PromptFilter{})
+	if err != nil || len(prompts) != 1 || prompts[0].ID != legacy.ID {
+		t.Errorf("Expected alice to see only her claimed prompt, got %+v %v", prompts, err)
+	}
+	if prompt, err := asAlice.GetSavedPrompt(bobPrompt.ID); err != nil || prompt != nil {
+		t.Errorf("Expected bob's prompt to be hidden from alice, got %+v %v", prompt, err)
+	}
+	if updated, err := asAlice.UpdatePrompt(models.UpdatePromptRequest{ID: bobPrompt.ID, Title: "Stolen"}); err != nil || updated != nil {
+		t.Errorf("Expected alice's update of bob's prompt to find nothing, got %+v %v", updated, err)
+	}
+	if err := asAlice.DeletePrompt(bobPrompt.ID); err != nil {
+		t.Fatalf("Delete should be a no-op, got %v", err)
+	}
+	if prompt, _ := asBob.GetSavedPrompt(bobPrompt.ID); prompt == nil || prompt.Title != "Bob's" {
+		t.Errorf("Expected bob's prompt to be untouched, got %+v", prompt)
+	}
+
+	history, _, err := asAlice.GetHistory(models.HistoryFilter{})
+	if err != nil || len(history) != 0 {
+		t.Errorf("Expected alice to see no history, got %d %v", len(history), err)
+	}
+	if err := asAlice.ClearHistory(); err != nil {
+		t.Fatalf("Failed to clear history: %v", err)
+	}
+	if history, _, _ := asBob.GetHistory(models.HistoryFilter{}); len(history) != 1 {
+		t.Errorf("Expected alice's clear to leave bob's history, got %d entries", len(history))
+	}
+
+	if conv, err := asAlice.GetConversation("bob-conv"); err != nil || conv != nil {
+		t.Errorf("Expected bob's conversation to be hidden from alice, got %+v %v", conv, err)
+	}
+	_, err = asAlice.SaveConversation(models.SaveConversationRequest{
+		ConversationID: "bob-conv",
+		Messages:       []models.ConversationMessage{{Role: "user", Content: "hijack"}},
+	})
+	if !errors.Is(err, ErrConversationNotFound) {
+		t.Errorf("Expected saving over bob's conversation to fail with ErrConversationNotFound, got %v", err)
+	}
+
+	// Tags are shared by name, but renaming one only moves the renaming user's prompts
+	if _, err := asBob.RenameTag("shared", "bobs"); err != nil {
+		t.Fatalf("Failed to rename tag: %v", err)
+	}
+	tags, err := asAlice.ListTags()
+	if err != nil || len(tags) != 1 || tags[0].Name != "shared" {
+		t.Errorf("Expected alice to keep her tag, got %+v %v", tags, err)
+	}
+	tags, err = asBob.ListTags()
+	if err != nil || len(tags) != 1 || tags[0].Name != "bobs" {
+		t.Errorf("Expected bob's prompt to move to the new tag, got %+v %v", tags, err)
+	}
+
+	all, _, err := db.GetSavedPrompts(models.PromptFilter{})
+	if err != nil || len(all) != 2 {
+		t.Errorf("Expected the unscoped database to see every prompt, got %d %v", len(all), err)
+	}
+}
@@ -0,0 +1,204 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"promptforge/internal/models"
+)
+
+var (
+	// ErrWorkspaceNotFound is returned when a workspace does not exist or the user is not a member
+	ErrWorkspaceNotFound = errors.New("workspace not found")
+	// ErrMemberNotFound is returned when removing a user who is not a member
+	ErrMemberNotFound = errors.New("workspace member not found")
+	// ErrLastOwner is returned when a change would leave a workspace without an owner
+	ErrLastOwner = errors.New("workspace must keep at least one owner")
+)
+
+// ValidRole reports whether role is one of the workspace roles
+func ValidRole(role string) bool {
+	switch role {
+	case models.RoleOwner, models.RoleEditor, models.RoleViewer:
+		return true
+	}
+	return false
+}
+
+// CreateWorkspace adds a workspace with ownerID as its first owner
+func (d *Database) CreateWorkspace(name string, ownerID int64) (*models.Workspace, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, fmt.Errorf("workspace name is required")
+	}
+
+	tx, err := d.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	workspace := models.Workspace{Name: name, Role: models.RoleOwner}
+	err = tx.QueryRow(`INSERT INTO workspaces (name) VALUES (?) RETURNING id, created_at`, name).
+		Scan(&workspace.ID, &workspace.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create workspace: %v", err)
+	}
+
+	if _, err := tx.Exec(`INSERT INTO workspace_members (workspace_id, user_id, role) VALUES (?, ?, ?)`,
+		workspace.ID, ownerID, models.RoleOwner); err != nil {
+		return nil, fmt.Errorf("failed to add workspace owner: %v", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit workspace: %v", err)
+	}
+	return &workspace, nil
+}
+
+// ListWorkspaces returns the workspaces userID belongs to, each with their role
+func (d *Database) ListWorkspaces(userID int64) ([]models.Workspace, error) {
+	rows, err := d.db.Query(`
+		SELECT w.id, w.name, m.role, w.created_at
+		FROM workspaces w
+		JOIN workspace_members m ON m.workspace_id = w.id
+		WHERE m.user_id = ?
+		ORDER BY w.name ASC, w.id ASC
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query workspaces: %v", err)
+	}
+	defer rows.Close()
+
+	workspaces := []models.Workspace{}
+	for rows.Next() {
+		var workspace models.Workspace
+		if err := rows.Scan(&workspace.ID, &workspace.Name, &workspace.Role, &workspace.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan workspace row: %v", err)
+		}
+		workspaces = append(workspaces, workspace)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating workspace rows: %v", err)
+	}
+
+	return workspaces, nil
+}
+
+// GetWorkspaceRole returns userID's role in workspaceID, or ErrWorkspaceNotFound when they are not a member
+func (d *Database) GetWorkspaceRole(workspaceID, userID int64) (string, error) {
+	var role string
+	err := d.db.QueryRow(`SELECT role FROM workspace_members WHERE workspace_id = ? AND user_id = ?`,
+		workspaceID, userID).Scan(&role)
+	if err == sql.ErrNoRows {
+		return "", ErrWorkspaceNotFound
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get workspace role: %v", err)
+	}
+	return role, nil
+}
+
+func (d *Database) ListWorkspaceMembers(workspaceID int64) ([]models.WorkspaceMember, error) {
+	rows, err := d.db.Query(`
+		SELECT u.id, u.username, m.role, m.created_at
+		FROM workspace_members m
+		JOIN users u ON u.id = m.user_id
+		WHERE m.workspace_id = ?
+		ORDER BY u.username ASC
+	`, workspaceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query workspace members: %v", err)
+	}
+	defer rows.Close()
+
+	members := []models.WorkspaceMember{}
+	for rows.Next() {
+		var member models.WorkspaceMember
+		if err := rows.Scan(&member.UserID, &member.Username, &member.Role, &member.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan workspace member row: %v", err)
+		}
+		members = append(members, member)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating workspace member rows: %v", err)
+	}
+
+	return members, nil
+}
+
+// SetWorkspaceMember adds userID to workspaceID with role, or changes the role of an existing member
+func (d *Database) SetWorkspaceMember(workspaceID, userID int64, role string) error {
+	if !ValidRole(role) {
+		return fmt.Errorf("invalid workspace role %q", role)
+	}
+
+	tx, err := d.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	if role != models.RoleOwner {
+		if err := keepAnOwner(tx, workspaceID, userID); err != nil {
+			return err
+		}
+	}
+
+	if _, err := tx.Exec(`
+		INSERT INTO workspace_members (workspace_id, user_id, role) VALUES (?, ?, ?)
+		ON CONFLICT (workspace_id, user_id) DO UPDATE SET role = excluded.role
+	`, workspaceID, userID, role); err != nil {
+		return fmt.Errorf("failed to set workspace member: %v", err)
+	}
+
+	return tx.Commit()
+}
+
+// RemoveWorkspaceMember takes userID out of workspaceID. The rows they created stay in the workspace.
+func (d *Database) RemoveWorkspaceMember(workspaceID, userID int64) error {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	if err := keepAnOwner(tx, workspaceID, userID); err != nil {
+		return err
+	}
+
+	result, err := tx.Exec(`DELETE FROM workspace_members WHERE workspace_id = ? AND user_id = ?`, workspaceID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to remove workspace member: %v", err)
+	}
+	if affected, err := result.RowsAffected(); err != nil {
+		return fmt.Errorf("failed to check removed rows: %v", err)
+	} else if affected == 0 {
+		return ErrMemberNotFound
+	}
+
+	return tx.Commit()
+}
+
+// keepAnOwner returns ErrLastOwner if userID is the only owner of workspaceID
+func keepAnOwner(tx *txn, workspaceID, userID int64) error {
+	var others, self int
+	err := tx.QueryRow(`
+		SELECT
+			COUNT(CASE WHEN user_id <> ? THEN 1 END),
+			COUNT(CASE WHEN user_id = ? THEN 1 END)
+		FROM workspace_members
+		WHERE workspace_id = ? AND role = ?
+	`, userID, userID, workspaceID, models.RoleOwner).Scan(&others, &self)
+	if err != nil {
+		return fmt.Errorf("failed to count workspace owners: %v", err)
+	}
+	if self > 0 && others == 0 {
+		return ErrLastOwner
+	}
+	return nil
+}
@@ -0,0 +1,138 @@
+package database
+
+import (
+	"errors"
+	"testing"
+
+	"promptforge/internal/models"
+)
+
+func TestWorkspaceMembers(t *testing.T) {
+	db := setupTestDB(t)
+
+	alice, _ := db.CreateUser("alice", "", false)
+	bob, _ := db.CreateUser("bob", "", false)
+
+	workspace, err := db.CreateWorkspace("Team", alice.ID)
+	if err != nil {
+		t.Fatalf("Failed to create workspace: %v", err)
+	}
+	if role, err := db.GetWorkspaceRole(workspace.ID, alice.ID); err != nil || role != models.RoleOwner {
+		t.Errorf("Expected the creator to own the workspace, got %q %v", role, err)
+	}
+	if _, err := db.GetWorkspaceRole(workspace.ID, bob.ID); !errors.Is(err, ErrWorkspaceNotFound) {
+		t.Errorf("Expected ErrWorkspaceNotFound for a non-member, got %v", err)
+	}
+
+	if err := db.SetWorkspaceMember(workspace.ID, bob.ID, "admin"); err == nil {
+		t.Error("Expected an unknown role to be rejected")
+	}
+	if err := db.SetWorkspaceMember(workspace.ID, bob.ID, models.RoleViewer); err != nil {
+		t.Fatalf("Failed to add member: %v", err)
+	}
+	if err := db.SetWorkspaceMember(workspace.ID, bob.ID, models.RoleEditor); err != nil {
+		t.Fatalf("Failed to change role: %v", err)
+	}
+	members, err := db.ListWorkspaceMembers(workspace.ID)
+	if err != nil || len(members) != 2 || members[1].Username != "bob" || members[1].Role != models.RoleEditor {
+		t.Errorf("Expected alice and bob as editor, got %+v %v", members, err)
+	}
+	if workspaces, err := db.ListWorkspaces(bob.ID); err != nil || len(workspaces) != 1 || workspaces[0].Role != models.RoleEditor {
+		t.Errorf("Expected bob to see the workspace as editor, got %+v %v", workspaces, err)
+	}
+
+	if err := db.SetWorkspaceMember(workspace.ID, alice.ID, models.RoleEditor); !errors.Is(err, ErrLastOwner) {
+		t.Errorf("Expected demoting the only owner to fail, got %v", err)
+	}
+	if err := db.RemoveWorkspaceMember(workspace.ID, alice.ID); !errors.Is(err, ErrLastOwner) {
+		t.Errorf("Expected removing the only owner to fail, got %v", err)
+	}
+	if err := db.SetWorkspaceMember(workspace.ID, bob.ID, models.RoleOwner); err != nil {
+		t.Fatalf("Failed to promote bob: %v", err)
+	}
+	if err := db.RemoveWorkspaceMember(workspace.ID, alice.ID); err != nil {
+		t.Errorf("Expected alice to leave once bob owns the workspace, got %v", err)
+	}
+	if err := db.RemoveWorkspaceMember(workspace.ID, alice.ID); !errors.Is(err, ErrMemberNotFound) {
+		t.Errorf("Expected ErrMemberNotFound, got %v", err)
+	}
+}
+
+func TestForWorkspaceScoping(t *testing.T) {
+	db := setupTestDB(t)
+
+	alice, _ := db.CreateUser("alice", "", false)
+	bob, _ := db.CreateUser("bob", "", false)
+	workspace, err := db.CreateWorkspace("Team", alice.ID)
+	if err != nil {
+		t.Fatalf("Failed to create workspace: %v", err)
+	}
+	if err := db.SetWorkspaceMember(workspace.ID, bob.ID, models.RoleEditor); err != nil {
+		t.Fatalf("Failed to add member: %v", err)
+	}
+	aliceTeam, bobTeam := db.ForWorkspace(alice.ID, workspace.ID), db.ForWorkspace(bob.ID, workspace.ID)
+
+	private, err := db.ForUser(alice.ID).SavePrompt(models.SavePromptRequest{Title: "Private", Content: "mine", Category: "General"})
+	if err != nil {
+		t.Fatalf("Failed to save prompt: %v", err)
+	}
+	shared, err := aliceTeam.SavePrompt(models.SavePromptRequest{Title: "Shared", Content: "ours", Category: "General"})
+	if err != nil {
+		t.Fatalf("Failed to save prompt: %v", err)
+	}
+
+	prompts, _, err := bobTeam.GetSavedPrompts(models.PromptFilter{})
+	if err != nil || len(prompts) != 1 || prompts[0].ID != shared.ID {
+		t.Errorf("Expected bob to see only the workspace prompt, got %+v %v", prompts, err)
+	}
+	if prompt, _ := bobTeam.GetSavedPrompt(private.ID); prompt != nil {
+		t.Error("Expected alice's private prompt to stay out of the workspace")
+	}
+	if updated, err := bobTeam.UpdatePrompt(models.UpdatePromptRequest{ID: shared.ID, Title: "Shared", Content: "edited"}); err != nil || updated == nil {
+		t.Errorf("Expected bob to edit the shared prompt, got %+v %v", updated, err)
+	}
+	prompts, _, _ = db.ForUser(alice.ID).GetSavedPrompts(models.PromptFilter{})
+	if len(prompts) != 1 || prompts[0].ID != private.ID {
+		t.Errorf("Expected alice's personal library to exclude workspace prompts, got %+v", prompts)
+	}
+
+	if _, err := aliceTeam.SaveHistory(models.SaveHistoryRequest{Prompt: "p", Model: "gpt-4", Success: true}); err != nil {
+		t.Fatalf("Failed to save history: %v", err)
+	}
+	if history, _, _ := bobTeam.GetHistory(models.HistoryFilter{}); len(history) != 0 {
+		t.Errorf("Expected history to stay personal inside a workspace, got %d entries", len(history))
+	}
+
+	suite, err := aliceTeam.SaveEvalSuite(models.SaveEvalSuiteRequest{
+		Name:     "Regression",
+		PromptID: &shared.ID,
+		Data:     models.EvalData{TestCases: []models.TestCase{{Input: "hello", Category: "basic"}}},
+	})
+	if err != nil {
+		t.Fatalf("Failed to save eval suite: %v", err)
+	}
+	if _, err := aliceTeam.SaveEvalSuite(models.SaveEvalSuiteRequest{Name: "Leak", PromptID: &private.ID}); !errors.Is(err, ErrPromptNotFound) {
+		t.Errorf("Expected linking a prompt outside the workspace to fail, got %v", err)
+	}
+	suites, err := bobTeam.ListEvalSuites(shared.ID)
+	if err != nil || len(suites) != 1 || suites[0].Data.TestCases[0].Input != "hello" {
+		t.Errorf("Expected bob to see the shared suite, got %+v %v", suites, err)
+	}
+	if found, _ := db.ForUser(bob.ID).GetEvalSuite(suite.ID); found != nil {
+		t.Error("Expected the suite to be hidden outside the workspace")
+	}
+	updated, err := bobTeam.UpdateEvalSuite(suite.ID, models.SaveEvalSuiteRequest{Name: "Renamed", Data: suite.Data})
+	if err != nil || updated == nil || updated.Name != "Renamed" || updated.PromptID != nil {
+		t.Errorf("Expected bob to update the suite, got %+v %v", updated, err)
+	}
+
+	if err := aliceTeam.DeletePrompt(shared.ID); err != nil {
+		t.Fatalf("Failed to delete prompt: %v", err)
+	}
+	if err := bobTeam.DeleteEvalSuite(suite.ID); err != nil {
+		t.Fatalf("Failed to delete eval suite: %v", err)
+	}
+	if suites, _ := aliceTeam.ListEvalSuites(0); len(suites) != 0 {
+		t.Errorf("Expected the suite to be deleted, got %+v", suites)
+	}
+}
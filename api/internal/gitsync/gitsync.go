@@ -0,0 +1,736 @@
+// Package gitsync mirrors the saved prompt library to a git working tree, one
+// Markdown file per prompt, so prompts can be reviewed and shared through git.
+//
+// Every file has a sync state recording the file and prompt hashes the two
+// sides last agreed on. A side whose hash moved since then has changed; when
+// both sides changed the prompt is reported as a conflict and left alone.
+package gitsync
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"promptforge/internal/database"
+	"promptforge/internal/library"
+	"promptforge/internal/models"
+)
+
+// Store is the part of the database the syncer reads and writes. A scoped store limits the synced
+// library to one user's or workspace's prompts, and makes imported prompts theirs.
+type Store interface {
+	GetSavedPrompt(promptID int64) (*models.SavedPrompt, error)
+	SavePrompt(req models.SavePromptRequest) (*models.SavedPrompt, error)
+	UpdatePrompt(req models.UpdatePromptRequest) (*models.SavedPrompt, error)
+	DeletePrompt(promptID int64) error
+	database.SyncStore
+}
+
+// Options configures where prompt files live and how commits are published
+type Options struct {
+	Dir         string // Git working tree
+	Path        string // Directory for prompt files, relative to Dir
+	Remote      string
+	Branch      string // Empty pulls the current branch's upstream
+	Push        bool   // Push after every commit
+	AuthorName  string
+	AuthorEmail string
+}
+
+const (
+	// commandTimeout bounds local git commands
+	commandTimeout = 30 * time.Second
+	// remoteTimeout bounds pulls and pushes, which wait on the remote
+	remoteTimeout = 2 * time.Minute
+)
+
+type Syncer struct {
+	store Store
+	opts  Options
+	mu    sync.Mutex
+
+	// pushes holds at most one pending push; commits made while it waits go out with it
+	pushes  chan struct{}
+	pending sync.WaitGroup
+}
+
+// New checks that opts.Dir is a git working tree and creates the prompt directory
+func New(store Store, opts Options) (*Syncer, error) {
+	if opts.Dir == "" {
+		return nil, fmt.Errorf("library sync directory is required")
+	}
+	if opts.Path == "" {
+		opts.Path = "."
+	}
+	opts.Path = filepath.Clean(opts.Path)
+	if filepath.IsAbs(opts.Path) || strings.HasPrefix(opts.Path, "..") {
+		return nil, fmt.Errorf("library sync path %q must be inside the working tree", opts.Path)
+	}
+
+	s := &Syncer{store: store, opts: opts, pushes: make(chan struct{}, 1)}
+	if _, err := s.git("rev-parse", "--is-inside-work-tree"); err != nil {
+		return nil, fmt.Errorf("%s is not a git working tree: %v", opts.Dir, err)
+	}
+	if err := os.MkdirAll(filepath.Join(opts.Dir, opts.Path), 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create prompt directory: %v", err)
+	}
+
+	if opts.Push {
+		go s.pushLoop()
+	}
+	return s, nil
+}
+
+// Status reports what a sync would change and which prompts conflict, without writing anything
+func (s *Syncer) Status() (*models.SyncResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entries, err := s.entries()
+	if err != nil {
+		return nil, err
+	}
+	return s.apply(entries, true, "")
+}
+
+// Sync reconciles every prompt with its file and commits the files that changed
+func (s *Syncer) Sync(message string) (*models.SyncResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entries, err := s.entries()
+	if err != nil {
+		return nil, err
+	}
+	return s.apply(entries, false, message)
+}
+
+// Pull rebases the working tree onto upstream and imports the prompt files that changed
+func (s *Syncer) Pull() (*models.SyncResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	args := []string{"pull", "--rebase", "--autostash"}
+	if s.opts.Branch != "" {
+		args = append(args, s.opts.Remote, s.opts.Branch)
+	}
+	if _, err := s.gitWithin(remoteTimeout, args...); err != nil {
+		if _, abortErr := s.git("rebase", "--abort"); abortErr == nil {
+			return nil, fmt.Errorf("upstream changes conflict with local commits; resolve them in %s: %v", s.opts.Dir, err)
+		}
+		return nil, err
+	}
+
+	entries, err := s.entries()
+	if err != nil {
+		return nil, err
+	}
+	return s.apply(entries, false, "Sync prompt library with upstream")
+}
+
+// PromptChanged writes, or removes, the file of one prompt after it changed through the API.
+// Prompts outside the store's scope have no file and are skipped.
+func (s *Syncer) PromptChanged(promptID int64, message string) (*models.SyncResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, err := s.promptEntry(promptID)
+	if err != nil || e == nil {
+		return newResult(), err // Deleted before it was ever synced
+	}
+	return s.apply([]*entry{e}, false, message)
+}
+
+// Resolve settles a conflict by keeping either the library's prompt or the file
+func (s *Syncer) Resolve(path, keep string) (*models.SyncResult, error) {
+	if keep != models.SyncKeepLibrary && keep != models.SyncKeepFile {
+		return nil, fmt.Errorf("keep must be %q or %q", models.SyncKeepLibrary, models.SyncKeepFile)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entries, err := s.entries()
+	if err != nil {
+		return nil, err
+	}
+
+	path = filepath.ToSlash(filepath.Clean(path))
+	for _, e := range entries {
+		if e.path != path {
+			continue
+		}
+		result := newResult()
+		if keep == models.SyncKeepLibrary {
+			err = s.exportEntry(e, result, false)
+		} else {
+			err = s.importEntry(e, result, false)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return result, s.commit(result, fmt.Sprintf("Resolve %s (keep %s)", path, keep))
+	}
+
+	return nil, fmt.Errorf("no prompt file %s", path)
+}
+
+// entry pairs a prompt file with its prompt and last sync state; any of the three may be missing
+type entry struct {
+	path       string
+	state      *models.PromptSyncState
+	prompt     *models.SavedPrompt
+	rendered   []byte
+	promptHash string
+	file       []byte
+	fileHash   string
+	parsed     *models.LibraryPrompt
+	parseErr   error
+}
+
+func (e *entry) title() string {
+	switch {
+	case e.prompt != nil:
+		return e.prompt.Title
+	case e.parsed != nil:
+		return e.parsed.Title
+	default:
+		return e.path
+	}
+}
+
+func (e *entry) promptID() int64 {
+	if e.prompt != nil {
+		return e.prompt.ID
+	}
+	if e.state != nil {
+		return e.state.PromptID
+	}
+	return 0
+}
+
+func newResult() *models.SyncResult {
+	return &models.SyncResult{Changes: []models.SyncChange{}, Conflicts: []models.SyncConflict{}}
+}
+
+// apply reconciles each entry, then commits and pushes whatever was written
+func (s *Syncer) apply(entries []*entry, dryRun bool, message string) (*models.SyncResult, error) {
+	result := newResult()
+	for _, e := range entries {
+		if err := s.reconcile(e, result, dryRun); err != nil {
+			return nil, err
+		}
+	}
+
+	if dryRun {
+		return result, nil
+	}
+	return result, s.commit(result, message)
+}
+
+func (s *Syncer) reconcile(e *entry, result *models.SyncResult, dryRun bool) error {
+	if e.parseErr != nil {
+		s.conflict(e, result, e.parseErr.Error())
+		return nil
+	}
+
+	if e.state == nil {
+		switch {
+		case e.prompt != nil && e.file == nil:
+			return s.exportEntry(e, result, dryRun)
+		case e.prompt == nil && e.file != nil:
+			return s.importEntry(e, result, dryRun)
+		case e.promptHash == e.fileHash:
+			return s.link(e, result, dryRun)
+		default:
+			s.conflict(e, result, "prompt exists in the library and the repository with different content")
+			return nil
+		}
+	}
+
+	fileChanged := e.file == nil || e.fileHash != e.state.FileHash
+	promptChanged := e.prompt == nil || e.promptHash != e.state.PromptHash
+
+	switch {
+	case !fileChanged && !promptChanged:
+		return nil
+	case promptChanged && !fileChanged:
+		return s.exportEntry(e, result, dryRun)
+	case fileChanged && !promptChanged:
+		return s.importEntry(e, result, dryRun)
+	case e.prompt == nil && e.file == nil:
+		if dryRun {
+			return nil
+		}
+		return s.store.DeletePromptSyncState(e.path)
+	case e.prompt != nil && e.file != nil && e.promptHash == e.fileHash:
+		return s.link(e, result, dryRun) // Both sides made the same change
+	case e.prompt == nil:
+		s.conflict(e, result, "prompt was deleted from the library but its file changed in the repository")
+	case e.file == nil:
+		s.conflict(e, result, "file was deleted from the repository but the prompt changed in the library")
+	default:
+		s.conflict(e, result, "prompt changed in both the library and the repository")
+	}
+	return nil
+}
+
+func (s *Syncer) conflict(e *entry, result *models.SyncResult, reason string) {
+	result.Conflicts = append(result.Conflicts, models.SyncConflict{
+		Path:     e.path,
+		PromptID: e.promptID(),
+		Title:    e.title(),
+		Reason:   reason,
+	})
+}
+
+func (s *Syncer) change(e *entry, result *models.SyncResult, action string) {
+	result.Changes = append(result.Changes, models.SyncChange{
+		Path:     e.path,
+		PromptID: e.promptID(),
+		Title:    e.title(),
+		Action:   action,
+	})
+}
+
+// exportEntry makes the file match the library, removing it when the prompt was deleted
+func (s *Syncer) exportEntry(e *entry, result *models.SyncResult, dryRun bool) error {
+	fullPath := filepath.Join(s.opts.Dir, filepath.FromSlash(e.path))
+
+	if e.prompt == nil {
+		s.change(e, result, "removed")
+		if dryRun {
+			return nil
+		}
+		if err := os.Remove(fullPath); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove %s: %v", e.path, err)
+		}
+		return s.store.DeletePromptSyncState(e.path)
+	}
+
+	s.change(e, result, "exported")
+	if dryRun {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(fullPath), 0o755); err != nil {
+		return fmt.Errorf("failed to create directory for %s: %v", e.path, err)
+	}
+	if err := os.WriteFile(fullPath, e.rendered, 0o644); err != nil {
+		return fmt.Errorf("failed to write %s: %v", e.path, err)
+	}
+	return s.record(e.path, e.prompt.ID, e.promptHash, e.promptHash)
+}
+
+// importEntry makes the library match the file, deleting the prompt when the file was removed
+func (s *Syncer) importEntry(e *entry, result *models.SyncResult, dryRun bool) error {
+	if e.file == nil {
+		if e.prompt == nil {
+			return nil
+		}
+		s.change(e, result, "deleted")
+		if dryRun {
+			return nil
+		}
+		if err := s.store.DeletePrompt(e.prompt.ID); err != nil {
+			return err
+		}
+		return s.store.DeletePromptSyncState(e.path)
+	}
+
+	if e.parsed == nil {
+		s.conflict(e, result, "file could not be parsed")
+		return nil
+	}
+
+	var saved *models.SavedPrompt
+	var err error
+	action := "imported"
+	if e.prompt == nil {
+		action = "created"
+	}
+	if dryRun {
+		s.change(e, result, action)
+		return nil
+	}
+
+	if e.prompt == nil {
+		saved, err = s.store.SavePrompt(models.SavePromptRequest{
+			Title:       e.parsed.Title,
+			Content:     e.parsed.Content,
+			Description: e.parsed.Description,
+			Category:    e.parsed.Category,
+			Tags:        e.parsed.Tags,
+			Variables:   e.parsed.Variables,
+		})
+	} else {
+		saved, err = s.store.UpdatePrompt(models.UpdatePromptRequest{
+			ID:          e.prompt.ID,
+			Title:       e.parsed.Title,
+			Content:     e.parsed.Content,
+			Description: e.parsed.Description,
+			Category:    e.parsed.Category,
+			Tags:        e.parsed.Tags,
+			Variables:   e.parsed.Variables,
+			Note:        "Synced from " + e.path,
+		})
+	}
+	if err != nil {
+		return err
+	}
+	if saved == nil {
+		return fmt.Errorf("prompt for %s disappeared during sync", e.path)
+	}
+
+	e.prompt = saved
+	s.change(e, result, action)
+
+	rendered, err := render(saved)
+	if err != nil {
+		return err
+	}
+	return s.record(e.path, saved.ID, e.fileHash, hash(rendered))
+}
+
+// link records a prompt and file that already agree
+func (s *Syncer) link(e *entry, result *models.SyncResult, dryRun bool) error {
+	if e.state == nil {
+		s.change(e, result, "linked")
+	}
+	if dryRun {
+		return nil
+	}
+	return s.record(e.path, e.prompt.ID, e.fileHash, e.promptHash)
+}
+
+func (s *Syncer) record(path string, promptID int64, fileHash, promptHash string) error {
+	return s.store.SavePromptSyncState(models.PromptSyncState{
+		Path:       path,
+		PromptID:   promptID,
+		FileHash:   fileHash,
+		PromptHash: promptHash,
+	})
+}
+
+// entries pairs every sync state, prompt and prompt file. Unlinked files and prompts are matched by title.
+func (s *Syncer) entries() ([]*entry, error) {
+	states, err := s.store.GetPromptSyncStates()
+	if err != nil {
+		return nil, err
+	}
+	ids, err := s.store.ListPromptIDs()
+	if err != nil {
+		return nil, err
+	}
+	files, err := s.listFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []*entry
+	taken := make(map[string]bool)
+	linkedPrompts := make(map[int64]bool)
+	for i := range states {
+		e, err := s.newEntry(states[i].Path, &states[i], states[i].PromptID)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+		taken[e.path] = true
+		linkedPrompts[states[i].PromptID] = true
+	}
+
+	var newFiles []*entry
+	unlinkedByTitle := make(map[string]*entry)
+	for _, path := range files {
+		if taken[path] {
+			continue
+		}
+		e, err := s.newEntry(path, nil, 0)
+		if err != nil {
+			return nil, err
+		}
+		taken[path] = true
+		newFiles = append(newFiles, e)
+		if e.parsed != nil {
+			if _, dup := unlinkedByTitle[e.parsed.Title]; !dup {
+				unlinkedByTitle[e.parsed.Title] = e
+			}
+		}
+	}
+
+	for _, id := range ids {
+		if linkedPrompts[id] {
+			continue
+		}
+		prompt, err := s.store.GetSavedPrompt(id)
+		if err != nil {
+			return nil, err
+		}
+		if prompt == nil {
+			continue
+		}
+
+		if e, ok := unlinkedByTitle[prompt.Title]; ok && e.prompt == nil {
+			if err := e.setPrompt(prompt); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		e := &entry{path: s.newPath(prompt.Title, taken)}
+		if err := e.setPrompt(prompt); err != nil {
+			return nil, err
+		}
+		taken[e.path] = true
+		entries = append(entries, e)
+	}
+
+	entries = append(entries, newFiles...)
+	sort.Slice(entries, func(i, j int) bool { return entries[i].path < entries[j].path })
+	return entries, nil
+}
+
+// promptEntry builds the entry for a single prompt, allocating a file for it if it has none
+func (s *Syncer) promptEntry(promptID int64) (*entry, error) {
+	states, err := s.store.GetPromptSyncStates()
+	if err != nil {
+		return nil, err
+	}
+	for i := range states {
+		if states[i].PromptID == promptID {
+			return s.newEntry(states[i].Path, &states[i], promptID)
+		}
+	}
+
+	prompt, err := s.store.GetSavedPrompt(promptID)
+	if err != nil || prompt == nil {
+		return nil, err
+	}
+
+	files, err := s.listFiles()
+	if err != nil {
+		return nil, err
+	}
+	taken := make(map[string]bool, len(files)+len(states))
+	for _, path := range files {
+		taken[path] = true
+	}
+	for _, state := range states {
+		taken[state.Path] = true
+	}
+
+	e := &entry{path: s.newPath(prompt.Title, taken)}
+	return e, e.setPrompt(prompt)
+}
+
+func (s *Syncer) newEntry(path string, state *models.PromptSyncState, promptID int64) (*entry, error) {
+	e := &entry{path: path, state: state}
+
+	data, err := os.ReadFile(filepath.Join(s.opts.Dir, filepath.FromSlash(path)))
+	switch {
+	case err == nil:
+		e.file = data
+		e.fileHash = hash(data)
+		e.parsed, e.parseErr = library.UnmarshalMarkdown(data, path)
+	case !os.IsNotExist(err):
+		return nil, fmt.Errorf("failed to read %s: %v", path, err)
+	}
+
+	if promptID != 0 {
+		prompt, err := s.store.GetSavedPrompt(promptID)
+		if err != nil {
+			return nil, err
+		}
+		if prompt != nil {
+			if err := e.setPrompt(prompt); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return e, nil
+}
+
+func (e *entry) setPrompt(prompt *models.SavedPrompt) error {
+	rendered, err := render(prompt)
+	if err != nil {
+		return err
+	}
+	e.prompt, e.rendered, e.promptHash = prompt, rendered, hash(rendered)
+	return nil
+}
+
+// newPath picks an unused file name for a prompt that has none yet
+func (s *Syncer) newPath(title string, taken map[string]bool) string {
+	base := filepath.ToSlash(filepath.Join(s.opts.Path, library.Slug(title)))
+	path := base + ".md"
+	for n := 2; taken[path]; n++ {
+		path = fmt.Sprintf("%s-%d.md", base, n)
+	}
+	return path
+}
+
+// listFiles returns the working-tree-relative paths of all prompt files
+func (s *Syncer) listFiles() ([]string, error) {
+	root := filepath.Join(s.opts.Dir, s.opts.Path)
+	var files []string
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && path != root && strings.HasPrefix(d.Name(), ".") {
+			return filepath.SkipDir
+		}
+		if d.IsDir() || !strings.EqualFold(filepath.Ext(path), ".md") {
+			return nil
+		}
+		rel, err := filepath.Rel(s.opts.Dir, path)
+		if err != nil {
+			return err
+		}
+		files = append(files, filepath.ToSlash(rel))
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list prompt files: %v", err)
+	}
+	return files, nil
+}
+
+// render produces the file for a prompt. Usage counts, timestamps and version history stay out of
+// git so that using a prompt does not create a commit.
+func render(prompt *models.SavedPrompt) ([]byte, error) {
+	var tags []string
+	if err := json.Unmarshal([]byte(prompt.Tags), &tags); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal prompt tags: %v", err)
+	}
+
+	return library.MarshalMarkdown(&models.LibraryPrompt{
+		Title:       prompt.Title,
+		Description: prompt.Description,
+		Category:    prompt.Category,
+		Tags:        tags,
+		Variables:   prompt.Variables,
+		Content:     prompt.Content,
+	})
+}
+
+func hash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// commit records the files the sync changed, and queues a push when configured. Files in
+// conflict are left out so a local edit is never published over an upstream one.
+func (s *Syncer) commit(result *models.SyncResult, message string) error {
+	var paths []string
+	for _, change := range result.Changes {
+		if _, err := os.Stat(filepath.Join(s.opts.Dir, filepath.FromSlash(change.Path))); err == nil {
+			if _, err := s.git("add", "--", change.Path); err != nil {
+				return err
+			}
+		} else if _, err := s.git("rm", "--cached", "--quiet", "--ignore-unmatch", "--", change.Path); err != nil {
+			return err
+		}
+		paths = append(paths, change.Path)
+	}
+	if len(paths) == 0 {
+		return nil
+	}
+
+	staged, err := s.git(append([]string{"diff", "--cached", "--name-only", "--relative", "--"}, paths...)...)
+	if err != nil || staged == "" {
+		return err
+	}
+
+	if message == "" {
+		message = "Update prompt library"
+	}
+	args := append([]string{"commit", "--quiet", "-m", message, "--"}, strings.Split(staged, "\n")...)
+	if _, err := s.git(args...); err != nil {
+		return err
+	}
+
+	commit, err := s.git("rev-parse", "HEAD")
+	if err != nil {
+		return err
+	}
+	result.Commit = commit
+
+	if s.opts.Push {
+		s.queuePush()
+		result.PushQueued = true
+	}
+
+	return nil
+}
+
+// queuePush asks the push loop to publish the current commits, so a slow remote never holds up the
+// change that made them
+func (s *Syncer) queuePush() {
+	s.pending.Add(1)
+	select {
+	case s.pushes <- struct{}{}:
+	default:
+		s.pending.Done() // A push is already waiting and will include these commits
+	}
+}
+
+// pushLoop pushes once per queued request. Failures are logged; the next push retries them.
+func (s *Syncer) pushLoop() {
+	for range s.pushes {
+		args := []string{"push", "--quiet", s.opts.Remote}
+		if s.opts.Branch != "" {
+			args = append(args, "HEAD:"+s.opts.Branch)
+		}
+		if _, err := s.gitWithin(remoteTimeout, args...); err != nil {
+			slog.Error("library sync push failed", "error", err)
+		}
+		s.pending.Done()
+	}
+}
+
+// waitForPush blocks until every queued push has finished
+func (s *Syncer) waitForPush() {
+	s.pending.Wait()
+}
+
+func (s *Syncer) git(args ...string) (string, error) {
+	return s.gitWithin(commandTimeout, args...)
+}
+
+// gitWithin runs git in the working tree, killing it after timeout. Git never prompts for
+// credentials, so a remote that needs them fails instead of hanging.
+func (s *Syncer) gitWithin(timeout time.Duration, args ...string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "git", append([]string{"-C", s.opts.Dir}, args...)...)
+	// Helpers such as ssh may outlive a killed git and hold its output open
+	cmd.WaitDelay = time.Second
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	if s.opts.AuthorName != "" && s.opts.AuthorEmail != "" {
+		cmd.Env = append(cmd.Env,
+			"GIT_AUTHOR_NAME="+s.opts.AuthorName, "GIT_AUTHOR_EMAIL="+s.opts.AuthorEmail,
+			"GIT_COMMITTER_NAME="+s.opts.AuthorName, "GIT_COMMITTER_EMAIL="+s.opts.AuthorEmail)
+	}
+	output, err := cmd.CombinedOutput()
+	if ctx.Err() == context.DeadlineExceeded {
+		return "", fmt.Errorf("git %s timed out after %s", args[0], timeout)
+	}
+	if err != nil {
+		return "", fmt.Errorf("git %s failed: %v: %s", args[0], err, strings.TrimSpace(string(output)))
+	}
+	return strings.TrimSpace(string(output)), nil
+}
@@ -0,0 +1,277 @@
+package gitsync
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"promptforge/internal/database"
+	"promptforge/internal/models"
+)
+
+func runGit(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", append([]string{"-C", dir, "-c", "user.name=Reviewer", "-c", "user.email=reviewer@example.com"}, args...)...)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %s failed: %v: %s", strings.Join(args, " "), err, output)
+	}
+	return strings.TrimSpace(string(output))
+}
+
+// setupSync creates a bare upstream, a working tree for the syncer and a second clone standing in for
+// a teammate, and returns the syncer, its database and the teammate's clone
+func setupSync(t *testing.T) (*Syncer, *database.Database, string) {
+	root := t.TempDir()
+	upstream := filepath.Join(root, "upstream.git")
+	work := filepath.Join(root, "work")
+	teammate := filepath.Join(root, "teammate")
+
+	runGit(t, root, "init", "--bare", "-b", "main", upstream)
+	runGit(t, root, "clone", upstream, work)
+	if err := os.WriteFile(filepath.Join(work, "README.md"), []byte("Prompts\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	runGit(t, work, "add", "README.md")
+	runGit(t, work, "commit", "-m", "Initial commit")
+	runGit(t, work, "push", "-u", "origin", "HEAD:main")
+	runGit(t, work, "branch", "--set-upstream-to=origin/main")
+
+	db, err := database.Open(database.DriverSQLite, filepath.Join(root, "test.db"))
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if err := db.InitSchema(true); err != nil {
+		t.Fatalf("Failed to initialize schema: %v", err)
+	}
+
+	syncer, err := New(db, Options{
+		Dir:         work,
+		Path:        "prompts",
+		Remote:      "origin",
+		Push:        true,
+		AuthorName:  "PromptForge",
+		AuthorEmail: "promptforge@localhost",
+	})
+	if err != nil {
+		t.Fatalf("Failed to create syncer: %v", err)
+	}
+	t.Cleanup(syncer.waitForPush)
+
+	return syncer, db, teammate
+}
+
+func TestNewRequiresWorkingTree(t *testing.T) {
+	if _, err := New(nil, Options{Dir: t.TempDir()}); err == nil {
+		t.Error("Expected an error for a directory that is not a git working tree")
+	}
+}
+
+func TestGitCommandsTimeOut(t *testing.T) {
+	syncer, _, _ := setupSync(t)
+
+	if _, err := syncer.gitWithin(time.Nanosecond, "status"); err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Errorf("Expected a timeout error, got %v", err)
+	}
+}
+
+func TestPromptChangesAreCommitted(t *testing.T) {
+	syncer, db, _ := setupSync(t)
+	work := syncer.opts.Dir
+
+	prompt, err := db.SavePrompt(models.SavePromptRequest{Title: "Code Review", Content: "Review {{diff}}", Tags: []string{"review"}})
+	if err != nil {
+		t.Fatalf("Failed to save prompt: %v", err)
+	}
+
+	result, err := syncer.PromptChanged(prompt.ID, "Add prompt")
+	if err != nil {
+		t.Fatalf("Failed to sync prompt: %v", err)
+	}
+	if result.Commit == "" || !result.PushQueued || len(result.Changes) != 1 || result.Changes[0].Path != "prompts/code-review.md" {
+		t.Fatalf("Expected the prompt file to be committed and pushed, got %+v", result)
+	}
+	syncer.waitForPush()
+	if upstream := runGit(t, work, "rev-parse", "origin/main"); upstream != result.Commit {
+		t.Errorf("Expected the commit to be pushed in the background, upstream is at %s", upstream)
+	}
+
+	data, err := os.ReadFile(filepath.Join(work, "prompts", "code-review.md"))
+	if err != nil {
+		t.Fatalf("Failed to read prompt file: %v", err)
+	}
+	if !strings.Contains(string(data), "title: Code Review") || !strings.HasSuffix(string(data), "Review {{diff}}\n") {
+		t.Errorf("Unexpected prompt file:\n%s", data)
+	}
+
+	// Using a prompt changes nothing that is synced
+	if err := db.IncrementPromptUsage(prompt.ID); err != nil {
+		t.Fatalf("Failed to increment usage: %v", err)
+	}
+	if result, _ := syncer.PromptChanged(prompt.ID, "Use prompt"); result.Commit != "" || len(result.Changes) != 0 {
+		t.Errorf("Expected no commit for a usage change, got %+v", result)
+	}
+
+	// A rename keeps the file so history stays in one place
+	_, err = db.UpdatePrompt(models.UpdatePromptRequest{ID: prompt.ID, Title: "Careful Code Review", Content: "Review {{diff}} carefully"})
+	if err != nil {
+		t.Fatalf("Failed to update prompt: %v", err)
+	}
+	if result, err = syncer.PromptChanged(prompt.ID, "Update prompt"); err != nil || result.Commit == "" {
+		t.Fatalf("Expected the update to be committed, got %+v (%v)", result, err)
+	}
+	data, _ = os.ReadFile(filepath.Join(work, "prompts", "code-review.md"))
+	if !strings.Contains(string(data), "title: Careful Code Review") {
+		t.Errorf("Expected the file to be updated in place:\n%s", data)
+	}
+
+	if err := db.DeletePrompt(prompt.ID); err != nil {
+		t.Fatalf("Failed to delete prompt: %v", err)
+	}
+	if result, err = syncer.PromptChanged(prompt.ID, "Delete prompt"); err != nil || result.Changes[0].Action != "removed" {
+		t.Fatalf("Expected the file to be removed, got %+v (%v)", result, err)
+	}
+	if _, err := os.Stat(filepath.Join(work, "prompts", "code-review.md")); !os.IsNotExist(err) {
+		t.Error("Expected the prompt file to be deleted")
+	}
+	if log := runGit(t, work, "log", "--format=%s"); !strings.HasPrefix(log, "Delete prompt\nUpdate prompt\nAdd prompt") {
+		t.Errorf("Unexpected commit history:\n%s", log)
+	}
+}
+
+func TestPullImportsUpstreamChanges(t *testing.T) {
+	syncer, db, teammate := setupSync(t)
+
+	prompt, _ := db.SavePrompt(models.SavePromptRequest{Title: "Summarize", Content: "Summarize {{text}}"})
+	if _, err := syncer.Sync("Initial sync"); err != nil {
+		t.Fatalf("Failed to sync: %v", err)
+	}
+	syncer.waitForPush()
+
+	// A teammate edits the prompt and adds a new one through a reviewed commit
+	runGit(t, filepath.Dir(teammate), "clone", filepath.Join(filepath.Dir(teammate), "upstream.git"), teammate)
+	edited := "---\ntitle: Summarize\ntags:\n    - reviewed\n---\n\nSummarize {{text}} in three bullet points\n"
+	if err := os.WriteFile(filepath.Join(teammate, "prompts", "summarize.md"), []byte(edited), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	added := "---\ntitle: Translate\ncategory: Translation\n---\n\nTranslate {{text}}\n"
+	if err := os.WriteFile(filepath.Join(teammate, "prompts", "translate.md"), []byte(added), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	runGit(t, teammate, "add", "-A")
+	runGit(t, teammate, "commit", "-m", "Improve prompts")
+	runGit(t, teammate, "push")
+
+	result, err := syncer.Pull()
+	if err != nil {
+		t.Fatalf("Failed to pull: %v", err)
+	}
+	if len(result.Conflicts) != 0 || len(result.Changes) != 2 {
+		t.Fatalf("Expected two imported prompts, got %+v", result)
+	}
+
+	updated, _ := db.GetSavedPrompt(prompt.ID)
+	if updated.Content != "Summarize {{text}} in three bullet points" || updated.Tags != `["reviewed"]` {
+		t.Errorf("Expected the upstream edit to be imported, got %+v", updated)
+	}
+	versions, _ := db.GetPromptVersions(prompt.ID)
+	if len(versions) != 2 || versions[0].Note != "Synced from prompts/summarize.md" {
+		t.Errorf("Expected the import to be recorded as a version, got %+v", versions)
+	}
+
+	prompts, _, _ := db.GetSavedPrompts(models.PromptFilter{})
+	if len(prompts) != 2 {
+		t.Errorf("Expected the new upstream prompt to be created, got %d prompts", len(prompts))
+	}
+
+	// Nothing left to do afterwards
+	status, err := syncer.Status()
+	if err != nil || len(status.Changes) != 0 || len(status.Conflicts) != 0 {
+		t.Errorf("Expected a clean status after pulling, got %+v (%v)", status, err)
+	}
+}
+
+func TestConflictsAreReportedNotOverwritten(t *testing.T) {
+	syncer, db, _ := setupSync(t)
+	work := syncer.opts.Dir
+
+	prompt, _ := db.SavePrompt(models.SavePromptRequest{Title: "Classify", Content: "Classify {{ticket}}"})
+	if _, err := syncer.Sync("Initial sync"); err != nil {
+		t.Fatalf("Failed to sync: %v", err)
+	}
+
+	// Both sides change the same prompt
+	path := filepath.Join(work, "prompts", "classify.md")
+	if err := os.WriteFile(path, []byte("---\ntitle: Classify\n---\n\nClassify {{ticket}} by urgency\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.UpdatePrompt(models.UpdatePromptRequest{ID: prompt.ID, Title: "Classify", Content: "Classify {{ticket}} by team"}); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := syncer.Sync("Sync")
+	if err != nil {
+		t.Fatalf("Failed to sync: %v", err)
+	}
+	if len(result.Conflicts) != 1 || result.Conflicts[0].Path != "prompts/classify.md" || result.Commit != "" {
+		t.Fatalf("Expected one conflict and no commit, got %+v", result)
+	}
+
+	current, _ := db.GetSavedPrompt(prompt.ID)
+	data, _ := os.ReadFile(path)
+	if current.Content != "Classify {{ticket}} by team" || !strings.Contains(string(data), "by urgency") {
+		t.Error("Expected neither side to be overwritten")
+	}
+
+	result, err = syncer.Resolve("prompts/classify.md", models.SyncKeepFile)
+	if err != nil || result.Commit == "" {
+		t.Fatalf("Failed to resolve conflict: %+v (%v)", result, err)
+	}
+	current, _ = db.GetSavedPrompt(prompt.ID)
+	if current.Content != "Classify {{ticket}} by urgency" {
+		t.Errorf("Expected the file to win, got %q", current.Content)
+	}
+
+	if status, _ := syncer.Status(); len(status.Conflicts) != 0 || len(status.Changes) != 0 {
+		t.Errorf("Expected a clean status after resolving, got %+v", status)
+	}
+}
+
+func TestSyncIsLimitedToScope(t *testing.T) {
+	unscoped, db, _ := setupSync(t)
+	alice, _ := db.CreateUser("alice", "", false)
+	bob, _ := db.CreateUser("bob", "", false)
+	syncer, err := New(db.ForUser(alice.ID), unscoped.opts)
+	if err != nil {
+		t.Fatalf("Failed to create syncer: %v", err)
+	}
+	t.Cleanup(syncer.waitForPush)
+
+	db.ForUser(alice.ID).SavePrompt(models.SavePromptRequest{Title: "Shared", Content: "Shared {{text}}"})
+	private, _ := db.ForUser(bob.ID).SavePrompt(models.SavePromptRequest{Title: "Private", Content: "Private {{text}}"})
+
+	if result, err := syncer.PromptChanged(private.ID, "Add prompt"); err != nil || len(result.Changes) != 0 {
+		t.Errorf("Expected another user's prompt to be skipped, got %+v (%v)", result, err)
+	}
+	result, err := syncer.Sync("Sync")
+	if err != nil || len(result.Changes) != 1 || result.Changes[0].Path != "prompts/shared.md" {
+		t.Fatalf("Expected only the synced user's prompt to be written, got %+v (%v)", result, err)
+	}
+
+	// Prompts added upstream belong to the synced user
+	added := "---\ntitle: Translate\n---\n\nTranslate {{text}}\n"
+	if err := os.WriteFile(filepath.Join(syncer.opts.Dir, "prompts", "translate.md"), []byte(added), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := syncer.Sync("Import"); err != nil {
+		t.Fatalf("Failed to sync: %v", err)
+	}
+	prompts, _, _ := db.ForUser(alice.ID).GetSavedPrompts(models.PromptFilter{})
+	if len(prompts) != 2 {
+		t.Errorf("Expected the imported prompt to belong to the synced user, got %d prompts", len(prompts))
+	}
+}
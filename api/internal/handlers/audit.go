@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+
+	"promptforge/internal/audit"
+	"promptforge/internal/database"
+	"promptforge/internal/models"
+)
+
+// GetAuditLog handles GET /api/audit (administrators only). It filters by username, workspace_id,
+// method, route, entity_id, from and to, and pages like the other list endpoints.
+func (h *Handlers) GetAuditLog(c echo.Context) error {
+	var filter models.AuditFilter
+	var err error
+	if filter.PageOptions, err = parsePageOptions(c); err != nil {
+		return c.JSON(http.StatusBadRequest, models.AuditLogResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+	}
+	if filter.From, filter.To, err = parseDateRange(c); err != nil {
+		return c.JSON(http.StatusBadRequest, models.AuditLogResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+	}
+
+	filter.Username = c.QueryParam("username")
+	filter.Method = strings.ToUpper(c.QueryParam("method"))
+	filter.Route = c.QueryParam("route")
+	filter.EntityID = c.QueryParam("entity_id")
+	if workspaceID := c.QueryParam("workspace_id"); workspaceID != "" {
+		if filter.WorkspaceID, err = strconv.ParseInt(workspaceID, 10, 64); err != nil {
+			return c.JSON(http.StatusBadRequest, models.AuditLogResponse{
+				Success: false,
+				Error:   "Invalid workspace_id filter",
+			})
+		}
+	}
+
+	entries, page, err := h.db.GetAuditLog(filter)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, database.ErrInvalidListOptions) {
+			status = http.StatusBadRequest
+		}
+		return c.JSON(status, models.AuditLogResponse{
+			Success: false,
+			Error:   fmt.Sprintf("Failed to retrieve audit log: %v", err),
+		})
+	}
+
+	return c.JSON(http.StatusOK, models.AuditLogResponse{
+		Success:    true,
+		Data:       entries,
+		Total:      page.Total,
+		NextCursor: page.NextCursor,
+	})
+}
+
+// AuditSnapshots describe what updates and deletes are about to change, for the audit log
+func (h *Handlers) AuditSnapshots() map[string]audit.Snapshot {
+	prompt := func(c echo.Context) string {
+		id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err != nil {
+			return ""
+		}
+		prompt, err := h.store(c).GetSavedPrompt(id)
+		if err != nil || prompt == nil {
+			return ""
+		}
+		return snapshotJSON(map[string]interface{}{
+			"title":    prompt.Title,
+			"category": prompt.Category,
+			"tags":     json.RawMessage(prompt.Tags),
+			"content":  prompt.Content,
+		})
+	}
+	evalSuite := func(c echo.Context) string {
+		id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err != nil {
+			return ""
+		}
+		suite, err := h.store(c).GetEvalSuite(id)
+		if err != nil || suite == nil {
+			return ""
+		}
+		return snapshotJSON(map[string]interface{}{
+			"name":       suite.Name,
+			"prompt_id":  suite.PromptID,
+			"test_cases": len(suite.Data.TestCases),
+		})
+	}
+
+	return map[string]audit.Snapshot{
+		"PUT /api/prompts/:id":                            prompt,
+		"DELETE /api/prompts/:id":                         prompt,
+		"POST /api/prompts/:id/versions/:version/restore": prompt,
+		"PUT /api/eval-suites/:id":                        evalSuite,
+		"DELETE /api/eval-suites/:id":                     evalSuite,
+		"DELETE /api/conversations/:id": func(c echo.Context) string {
+			conv, err := h.store(c).GetConversation(c.Param("id"))
+			if err != nil || conv == nil {
+				return ""
+			}
+			return snapshotJSON(map[string]interface{}{
+				"title":    conv.Title,
+				"revision": conv.Revision,
+				"messages": len(conv.Messages),
+			})
+		},
+		"DELETE /api/history": func(c echo.Context) string {
+			_, page, err := h.store(c).GetHistory(models.HistoryFilter{PageOptions: models.PageOptions{Limit: 1}})
+			if err != nil {
+				return ""
+			}
+			return snapshotJSON(map[string]interface{}{"entries": page.Total})
+		},
+	}
+}
+
+func snapshotJSON(fields map[string]interface{}) string {
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
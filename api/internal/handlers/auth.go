@@ -0,0 +1,274 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"promptforge/internal/auth"
+	"promptforge/internal/config"
+	"promptforge/internal/database"
+	"promptforge/internal/models"
+)
+
+// store returns the storage for a request, limited to the signed-in user's data, or to the selected
+// workspace's shared data, when authentication is on. Its queries are traced as part of the request.
+func (h *Handlers) store(c echo.Context) database.Store {
+	store := h.db.WithContext(c.Request().Context())
+	if user := auth.UserFrom(c); user != nil {
+		if membership := auth.WorkspaceFrom(c); membership != nil {
+			return store.ForWorkspace(user.ID, membership.WorkspaceID)
+		}
+		return store.ForUser(user.ID)
+	}
+	return store
+}
+
+// Login handles POST /api/auth/login, starting a session carried by an HttpOnly cookie
+func (h *Handlers) Login(c echo.Context) error {
+	var req models.LoginRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, models.UserResponse{
+			Success: false,
+			Error:   "Invalid request format",
+		})
+	}
+
+	user, passwordHash, err := h.db.GetUserCredentials(req.Username)
+	if errors.Is(err, database.ErrUserNotFound) {
+		auth.CheckNoPassword(req.Password)
+	} else if err != nil {
+		return c.JSON(http.StatusInternalServerError, models.UserResponse{
+			Success: false,
+			Error:   fmt.Sprintf("Failed to log in: %v", err),
+		})
+	}
+	if user == nil || !auth.CheckPassword(passwordHash, req.Password) {
+		return c.JSON(http.StatusUnauthorized, models.UserResponse{
+			Success: false,
+			Error:   "Invalid username or password",
+		})
+	}
+
+	token, tokenHash, err := auth.NewSessionToken()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, models.UserResponse{
+			Success: false,
+			Error:   fmt.Sprintf("Failed to log in: %v", err),
+		})
+	}
+	expiresAt := time.Now().Add(config.AppConfig.Auth.SessionTTL)
+	if err := h.db.CreateSession(user.ID, tokenHash, expiresAt); err != nil {
+		return c.JSON(http.StatusInternalServerError, models.UserResponse{
+			Success: false,
+			Error:   fmt.Sprintf("Failed to log in: %v", err),
+		})
+	}
+
+	c.SetCookie(sessionCookie(token, expiresAt))
+	return c.JSON(http.StatusOK, models.UserResponse{
+		Success: true,
+		Data:    user,
+	})
+}
+
+// Logout handles POST /api/auth/logout, ending the cookie's session
+func (h *Handlers) Logout(c echo.Context) error {
+	if cookie, err := c.Cookie(auth.SessionCookie); err == nil && cookie.Value != "" {
+		if err := h.db.DeleteSession(auth.HashToken(cookie.Value)); err != nil {
+			return c.JSON(http.StatusInternalServerError, models.APIResponse{
+				Success: false,
+				Error:   fmt.Sprintf("Failed to log out: %v", err),
+			})
+		}
+	}
+
+	c.SetCookie(sessionCookie("", time.Unix(0, 0)))
+	return c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    "Logged out",
+	})
+}
+
+func sessionCookie(token string, expiresAt time.Time) *http.Cookie {
+	return &http.Cookie{
+		Name:     auth.SessionCookie,
+		Value:    token,
+		Path:     "/",
+		Expires:  expiresAt,
+		HttpOnly: true,
+		Secure:   config.AppConfig.Auth.CookieSecure,
+		SameSite: http.SameSiteLaxMode,
+	}
+}
+
+// GetCurrentUser handles GET /api/auth/me
+func (h *Handlers) GetCurrentUser(c echo.Context) error {
+	return c.JSON(http.StatusOK, models.UserResponse{
+		Success: true,
+		Data:    auth.UserFrom(c),
+	})
+}
+
+// GetAPIKeys handles GET /api/auth/keys, listing the signed-in user's API keys without their secrets
+func (h *Handlers) GetAPIKeys(c echo.Context) error {
+	user := auth.UserFrom(c)
+	if user == nil {
+		return authDisabled(c)
+	}
+
+	keys, err := h.db.ListAPIKeys(user.ID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, models.APIKeysResponse{
+			Success: false,
+			Error:   fmt.Sprintf("Failed to list API keys: %v", err),
+		})
+	}
+
+	return c.JSON(http.StatusOK, models.APIKeysResponse{
+		Success: true,
+		Data:    keys,
+	})
+}
+
+// CreateAPIKey handles POST /api/auth/keys. The key is only ever returned in this response.
+func (h *Handlers) CreateAPIKey(c echo.Context) error {
+	user := auth.UserFrom(c)
+	if user == nil {
+		return authDisabled(c)
+	}
+
+	var req models.CreateAPIKeyRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, models.CreatedAPIKeyResponse{
+			Success: false,
+			Error:   "Invalid request format",
+		})
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		return c.JSON(http.StatusBadRequest, models.CreatedAPIKeyResponse{
+			Success: false,
+			Error:   "Key name is required",
+		})
+	}
+
+	key, prefix, hash, err := auth.NewAPIKey()
+	var stored *models.APIKey
+	if err == nil {
+		stored, err = h.db.CreateAPIKey(user.ID, req.Name, prefix, hash)
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, models.CreatedAPIKeyResponse{
+			Success: false,
+			Error:   fmt.Sprintf("Failed to create API key: %v", err),
+		})
+	}
+
+	return c.JSON(http.StatusCreated, models.CreatedAPIKeyResponse{
+		Success: true,
+		Data:    &models.CreatedAPIKey{APIKey: *stored, Key: key},
+	})
+}
+
+// DeleteAPIKey handles DELETE /api/auth/keys/:id, revoking one of the signed-in user's keys
+func (h *Handlers) DeleteAPIKey(c echo.Context) error {
+	user := auth.UserFrom(c)
+	if user == nil {
+		return authDisabled(c)
+	}
+
+	keyID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "Invalid API key ID",
+		})
+	}
+
+	if err := h.db.DeleteAPIKey(user.ID, keyID); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, database.ErrAPIKeyNotFound) {
+			status = http.StatusNotFound
+		}
+		return c.JSON(status, models.APIResponse{
+			Success: false,
+			Error:   fmt.Sprintf("Failed to delete API key: %v", err),
+		})
+	}
+
+	return c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    "API key deleted",
+	})
+}
+
+// GetUsers handles GET /api/users (administrators only)
+func (h *Handlers) GetUsers(c echo.Context) error {
+	users, err := h.db.ListUsers()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, models.UsersResponse{
+			Success: false,
+			Error:   fmt.Sprintf("Failed to list users: %v", err),
+		})
+	}
+
+	return c.JSON(http.StatusOK, models.UsersResponse{
+		Success: true,
+		Data:    users,
+	})
+}
+
+// CreateUser handles POST /api/users (administrators only)
+func (h *Handlers) CreateUser(c echo.Context) error {
+	var req models.CreateUserRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, models.UserResponse{
+			Success: false,
+			Error:   "Invalid request format",
+		})
+	}
+	if strings.TrimSpace(req.Username) == "" || req.Password == "" {
+		return c.JSON(http.StatusBadRequest, models.UserResponse{
+			Success: false,
+			Error:   "Username and password are required",
+		})
+	}
+
+	passwordHash, err := auth.HashPassword(req.Password)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, models.UserResponse{
+			Success: false,
+			Error:   fmt.Sprintf("Failed to create user: %v", err),
+		})
+	}
+
+	user, err := h.db.CreateUser(req.Username, passwordHash, req.IsAdmin)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, database.ErrUserExists) {
+			status = http.StatusConflict
+		}
+		return c.JSON(status, models.UserResponse{
+			Success: false,
+			Error:   fmt.Sprintf("Failed to create user: %v", err),
+		})
+	}
+
+	return c.JSON(http.StatusCreated, models.UserResponse{
+		Success: true,
+		Data:    user,
+	})
+}
+
+func authDisabled(c echo.Context) error {
+	return c.JSON(http.StatusBadRequest, models.APIResponse{
+		Success: false,
+		Error:   "Authentication is not enabled",
+	})
+}
@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"promptforge/internal/auth"
+	"promptforge/internal/config"
+	"promptforge/internal/models"
+)
+
+func TestLoginSession(t *testing.T) {
+	h, db := setupHandlers(t)
+	config.AppConfig.Auth = config.AuthConfig{Enabled: true, SessionTTL: time.Hour}
+
+	hash, err := auth.HashPassword("secret")
+	if err != nil {
+		t.Fatalf("Failed to hash password: %v", err)
+	}
+	if _, err := db.CreateUser("alice", hash, false); err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+
+	e := echo.New()
+	e.Use(auth.Middleware(db))
+	e.POST("/api/auth/login", h.Login)
+	e.POST("/api/auth/logout", h.Logout)
+	e.GET("/api/auth/me", h.GetCurrentUser)
+
+	do := func(method, path, body string, cookie *http.Cookie) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if cookie != nil {
+			req.AddCookie(cookie)
+		}
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := do(http.MethodPost, "/api/auth/login", `{"username": "alice", "password": "wrong"}`, nil); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("Expected a wrong password to get 401, got %d", rec.Code)
+	}
+	if rec := do(http.MethodPost, "/api/auth/login", `{"username": "nobody", "password": "secret"}`, nil); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("Expected an unknown user to get 401, got %d", rec.Code)
+	}
+
+	rec := do(http.MethodPost, "/api/auth/login", `{"username": "alice", "password": "secret"}`, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected login to succeed, got %d: %s", rec.Code, rec.Body.String())
+	}
+	cookies := rec.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != auth.SessionCookie || !cookies[0].HttpOnly {
+		t.Fatalf("Expected an HttpOnly session cookie, got %+v", cookies)
+	}
+	session := cookies[0]
+
+	rec = do(http.MethodGet, "/api/auth/me", "", session)
+	var me models.UserResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &me); err != nil || me.Data == nil || me.Data.Username != "alice" {
+		t.Fatalf("Expected the session to identify alice, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	if rec := do(http.MethodPost, "/api/auth/logout", "", session); rec.Code != http.StatusOK {
+		t.Fatalf("Expected logout to succeed, got %d", rec.Code)
+	}
+	if rec := do(http.MethodGet, "/api/auth/me", "", session); rec.Code != http.StatusUnauthorized {
+		t.Errorf("Expected the session to end at logout, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+
+	"promptforge/internal/config"
+	"promptforge/internal/database"
+	"promptforge/internal/models"
+)
+
+// GetBackups handles GET /api/backups, listing the backups in BACKUP_DIR newest first
+func (h *Handlers) GetBackups(c echo.Context) error {
+	backups, err := database.ListBackups(config.AppConfig.Backup.Dir)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, models.BackupsResponse{
+			Success: false,
+			Error:   fmt.Sprintf("Failed to list backups: %v", err),
+		})
+	}
+
+	return c.JSON(http.StatusOK, models.BackupsResponse{
+		Success: true,
+		Data:    backups,
+	})
+}
+
+// CreateBackup handles POST /api/backups, writing a snapshot of the running database to BACKUP_DIR
+func (h *Handlers) CreateBackup(c echo.Context) error {
+	backup, err := h.db.Backup(config.AppConfig.Backup.Dir)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, models.BackupResponse{
+			Success: false,
+			Error:   fmt.Sprintf("Failed to back up database: %v", err),
+		})
+	}
+
+	return c.JSON(http.StatusCreated, models.BackupResponse{
+		Success: true,
+		Data:    backup,
+	})
+}
@@ -0,0 +1,30 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+
+	"promptforge/internal/config"
+	"promptforge/internal/models"
+)
+
+// GetConfig handles GET /api/config (administrators only), showing the configuration in effect,
+// including reloaded settings, with the source of each value and without secrets
+func (h *Handlers) GetConfig(c echo.Context) error {
+	cfg := config.Current()
+	settings := cfg.Effective()
+	effective := &models.EffectiveConfig{
+		Profile:  cfg.Profile,
+		File:     cfg.File,
+		Settings: make([]models.ConfigSetting, len(settings)),
+	}
+	for i, s := range settings {
+		effective.Settings[i] = models.ConfigSetting{Key: s.Key, Env: s.Env, Value: s.Value, Source: s.Source, Secret: s.Secret}
+	}
+
+	return c.JSON(http.StatusOK, models.ConfigResponse{
+		Success: true,
+		Data:    effective,
+	})
+}
@@ -0,0 +1,199 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+
+	"promptforge/internal/database"
+	"promptforge/internal/models"
+)
+
+// AppendMessages handles POST /api/conversations/:id/messages, appending without rewriting earlier messages
+func (h *Handlers) AppendMessages(c echo.Context) error {
+	conversationID := c.Param("id")
+
+	var req models.AppendMessagesRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, models.ConversationDetailResponse{
+			Success: false,
+			Error:   "Invalid request format",
+		})
+	}
+
+	if len(req.Messages) == 0 {
+		return c.JSON(http.StatusBadRequest, models.ConversationDetailResponse{
+			Success: false,
+			Error:   "At least one message is required",
+		})
+	}
+	for _, msg := range req.Messages {
+		if strings.TrimSpace(msg.Role) == "" || msg.Content == "" {
+			return c.JSON(http.StatusBadRequest, models.ConversationDetailResponse{
+				Success: false,
+				Error:   "Every message needs a role and content",
+			})
+		}
+	}
+
+	conversation, err := h.store(c).AppendMessages(conversationID, req)
+	if err != nil {
+		return h.conversationError(c, conversationID, "Failed to append messages", err)
+	}
+
+	return c.JSON(http.StatusOK, models.ConversationDetailResponse{
+		Success: true,
+		Data:    conversation,
+	})
+}
+
+// EditMessage handles PUT /api/conversations/:id/messages/:messageId
+func (h *Handlers) EditMessage(c echo.Context) error {
+	conversationID := c.Param("id")
+	messageID, err := parseInt64Param(c, "messageId")
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, models.ConversationDetailResponse{
+			Success: false,
+			Error:   "Invalid message ID format",
+		})
+	}
+
+	var req models.EditMessageRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, models.ConversationDetailResponse{
+			Success: false,
+			Error:   "Invalid request format",
+		})
+	}
+
+	if req.Content == "" {
+		return c.JSON(http.StatusBadRequest, models.ConversationDetailResponse{
+			Success: false,
+			Error:   "Content is required",
+		})
+	}
+
+	conversation, err := h.store(c).EditMessage(conversationID, messageID, req)
+	if err != nil {
+		return h.conversationError(c, conversationID, "Failed to edit message", err)
+	}
+
+	return c.JSON(http.StatusOK, models.ConversationDetailResponse{
+		Success: true,
+		Data:    conversation,
+	})
+}
+
+// conversationError maps conversation write errors to responses. A stale revision gets 409 with the
+// current conversation so the client can merge its changes and retry.
+func (h *Handlers) conversationError(c echo.Context, conversationID, message string, err error) error {
+	switch {
+	case errors.Is(err, database.ErrRevisionConflict):
+		current, getErr := h.store(c).GetConversation(conversationID)
+		if getErr != nil {
+			current = nil
+		}
+		return c.JSON(http.StatusConflict, models.ConversationDetailResponse{
+			Success: false,
+			Data:    current,
+			Error:   fmt.Sprintf("%s: %v; reload it and retry", message, err),
+		})
+	case errors.Is(err, database.ErrConversationNotFound), errors.Is(err, database.ErrMessageNotFound):
+		return c.JSON(http.StatusNotFound, models.ConversationDetailResponse{
+			Success: false,
+			Error:   fmt.Sprintf("%s: %v", message, err),
+		})
+	default:
+		return c.JSON(http.StatusInternalServerError, models.ConversationDetailResponse{
+			Success: false,
+			Error:   fmt.Sprintf("%s: %v", message, err),
+		})
+	}
+}
+
+// GetConversationBranches handles GET /api/conversations/:id/branches
+func (h *Handlers) GetConversationBranches(c echo.Context) error {
+	conversationID := c.Param("id")
+
+	branches, err := h.store(c).GetConversationBranches(conversationID)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, database.ErrConversationNotFound) {
+			status = http.StatusNotFound
+		}
+		return c.JSON(status, models.ConversationBranchesResponse{
+			Success: false,
+			Error:   fmt.Sprintf("Failed to list branches: %v", err),
+		})
+	}
+
+	return c.JSON(http.StatusOK, models.ConversationBranchesResponse{
+		Success: true,
+		Data:    branches,
+	})
+}
+
+// GetConversationBranch handles GET /api/conversations/:id/branches/:messageId, returning the messages
+// leading to messageId without switching the active branch
+func (h *Handlers) GetConversationBranch(c echo.Context) error {
+	conversationID := c.Param("id")
+	messageID, err := parseInt64Param(c, "messageId")
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, models.ConversationDetailResponse{
+			Success: false,
+			Error:   "Invalid message ID format",
+		})
+	}
+
+	conversation, err := h.store(c).GetConversationBranch(conversationID, messageID)
+	if err != nil {
+		return h.conversationError(c, conversationID, "Failed to get branch", err)
+	}
+
+	return c.JSON(http.StatusOK, models.ConversationDetailResponse{
+		Success: true,
+		Data:    conversation,
+	})
+}
+
+// ForkConversation handles POST /api/conversations/:id/fork with {"message_id", "messages"?, "revision"?}.
+// Without messages it switches the active branch to the one ending at message_id.
+func (h *Handlers) ForkConversation(c echo.Context) error {
+	conversationID := c.Param("id")
+
+	var req models.ForkConversationRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, models.ConversationDetailResponse{
+			Success: false,
+			Error:   "Invalid request format",
+		})
+	}
+
+	if req.MessageID <= 0 {
+		return c.JSON(http.StatusBadRequest, models.ConversationDetailResponse{
+			Success: false,
+			Error:   "message_id is required",
+		})
+	}
+	for _, msg := range req.Messages {
+		if strings.TrimSpace(msg.Role) == "" || msg.Content == "" {
+			return c.JSON(http.StatusBadRequest, models.ConversationDetailResponse{
+				Success: false,
+				Error:   "Every message needs a role and content",
+			})
+		}
+	}
+
+	conversation, err := h.store(c).ForkConversation(conversationID, req)
+	if err != nil {
+		return h.conversationError(c, conversationID, "Failed to fork conversation", err)
+	}
+
+	return c.JSON(http.StatusOK, models.ConversationDetailResponse{
+		Success: true,
+		Data:    conversation,
+	})
+}
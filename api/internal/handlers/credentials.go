@@ -0,0 +1,188 @@
+package handlers
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+
+	"promptforge/internal/auth"
+	"promptforge/internal/config"
+	"promptforge/internal/models"
+	"promptforge/internal/services"
+)
+
+// GetWorkspaceCredentials handles GET /api/workspaces/:id/credentials, listing the providers the
+// workspace has its own keys for, without the keys (members only)
+func (h *Handlers) GetWorkspaceCredentials(c echo.Context) error {
+	workspaceID, _, err := h.workspaceRole(c)
+	if err != nil {
+		return workspaceError(c, err)
+	}
+
+	credentials, err := h.db.ListWorkspaceCredentials(workspaceID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, models.WorkspaceCredentialsResponse{
+			Success: false,
+			Error:   fmt.Sprintf("Failed to list workspace credentials: %v", err),
+		})
+	}
+
+	return c.JSON(http.StatusOK, models.WorkspaceCredentialsResponse{
+		Success: true,
+		Data:    credentials,
+	})
+}
+
+// SetWorkspaceCredential handles PUT /api/workspaces/:id/credentials/:provider, storing the
+// workspace's key for a provider encrypted under the master key (owners only). An optional base_url
+// must be https on a public address.
+func (h *Handlers) SetWorkspaceCredential(c echo.Context) error {
+	workspaceID, role, err := h.workspaceRole(c)
+	if err != nil {
+		return workspaceError(c, err)
+	}
+	if role != models.RoleOwner {
+		return workspaceOwnersOnly(c, "credentials")
+	}
+
+	provider, err := config.ParseProvider(c.Param("provider"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, models.WorkspaceCredentialResponse{
+			Success: false,
+			Error:   fmt.Sprintf("Invalid provider: %v", err),
+		})
+	}
+
+	var req models.SetWorkspaceCredentialRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, models.WorkspaceCredentialResponse{
+			Success: false,
+			Error:   "Invalid request format",
+		})
+	}
+	req.APIKey = strings.TrimSpace(req.APIKey)
+	if req.APIKey == "" {
+		return c.JSON(http.StatusBadRequest, models.WorkspaceCredentialResponse{
+			Success: false,
+			Error:   "api_key is required",
+		})
+	}
+
+	req.BaseURL = strings.TrimSpace(req.BaseURL)
+	if req.BaseURL != "" {
+		if err := services.ValidateBaseURL(c.Request().Context(), req.BaseURL); err != nil {
+			return c.JSON(http.StatusBadRequest, models.WorkspaceCredentialResponse{
+				Success: false,
+				Error:   fmt.Sprintf("Invalid base_url: %v", err),
+			})
+		}
+	}
+
+	cipher, err := config.Current().Cipher()
+	if err != nil {
+		return credentialsDisabled(c)
+	}
+	sealed, err := cipher.Encrypt(req.APIKey, credentialLabel(workspaceID, string(provider)))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, models.WorkspaceCredentialResponse{
+			Success: false,
+			Error:   fmt.Sprintf("Failed to encrypt credential: %v", err),
+		})
+	}
+
+	credential, err := h.db.SetWorkspaceCredential(workspaceID, models.WorkspaceCredential{
+		Provider:  string(provider),
+		BaseURL:   req.BaseURL,
+		APIKey:    sealed,
+		UpdatedBy: &auth.UserFrom(c).ID,
+	})
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, models.WorkspaceCredentialResponse{
+			Success: false,
+			Error:   fmt.Sprintf("Failed to save workspace credential: %v", err),
+		})
+	}
+
+	return c.JSON(http.StatusOK, models.WorkspaceCredentialResponse{
+		Success: true,
+		Data:    credential,
+	})
+}
+
+// DeleteWorkspaceCredential handles DELETE /api/workspaces/:id/credentials/:provider, returning the
+// workspace to the server's key for that provider (owners only)
+func (h *Handlers) DeleteWorkspaceCredential(c echo.Context) error {
+	workspaceID, role, err := h.workspaceRole(c)
+	if err != nil {
+		return workspaceError(c, err)
+	}
+	if role != models.RoleOwner {
+		return workspaceOwnersOnly(c, "credentials")
+	}
+
+	if err := h.db.DeleteWorkspaceCredential(workspaceID, c.Param("provider")); err != nil {
+		return workspaceError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    "Workspace credential removed",
+	})
+}
+
+// WorkspaceCredentials makes the AI calls of requests in a workspace use the workspace's own
+// provider keys. A key that cannot be decrypted fails the calls to its provider rather than letting
+// them run on the server's key.
+func (h *Handlers) WorkspaceCredentials(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		membership := auth.WorkspaceFrom(c)
+		if membership == nil {
+			return next(c)
+		}
+
+		ctx := c.Request().Context()
+		stored, err := h.db.WithContext(ctx).ListWorkspaceCredentials(membership.WorkspaceID)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, models.APIResponse{
+				Success: false,
+				Error:   fmt.Sprintf("Failed to load workspace credentials: %v", err),
+			})
+		}
+		if len(stored) == 0 {
+			return next(c)
+		}
+
+		cipher, cipherErr := config.Current().Cipher()
+		credentials := map[config.AIProvider]services.Credential{}
+		for _, credential := range stored {
+			var apiKey string
+			err := cipherErr
+			if err == nil {
+				apiKey, err = cipher.Decrypt(credential.APIKey, credentialLabel(membership.WorkspaceID, credential.Provider))
+			}
+			if err != nil {
+				slog.ErrorContext(ctx, "failed to decrypt workspace credential", "workspace_id", membership.WorkspaceID, "provider", credential.Provider, "error", err)
+				err = fmt.Errorf("the workspace's %s credential cannot be used: %v", credential.Provider, err)
+			}
+			credentials[config.AIProvider(credential.Provider)] = services.Credential{APIKey: apiKey, BaseURL: credential.BaseURL, Err: err}
+		}
+
+		c.SetRequest(c.Request().WithContext(services.WithCredentials(ctx, credentials)))
+		return next(c)
+	}
+}
+
+// credentialLabel binds an encrypted key to its workspace and provider, so it cannot be copied to another
+func credentialLabel(workspaceID int64, provider string) string {
+	return fmt.Sprintf("workspace:%d:%s", workspaceID, provider)
+}
+
+func credentialsDisabled(c echo.Context) error {
+	return c.JSON(http.StatusServiceUnavailable, models.APIResponse{
+		Success: false,
+		Error:   "Workspace credentials need a master key (set PROMPTFORGE_MASTER_KEY)",
+	})
+}
@@ -0,0 +1,173 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+
+	"promptforge/internal/database"
+	"promptforge/internal/models"
+)
+
+// GetEvalSuites handles GET /api/eval-suites. A prompt_id query parameter keeps only that prompt's suites.
+func (h *Handlers) GetEvalSuites(c echo.Context) error {
+	var promptID int64
+	if param := c.QueryParam("prompt_id"); param != "" {
+		var err error
+		if promptID, err = strconv.ParseInt(param, 10, 64); err != nil {
+			return c.JSON(http.StatusBadRequest, models.EvalSuitesResponse{
+				Success: false,
+				Error:   "Invalid prompt ID format",
+			})
+		}
+	}
+
+	suites, err := h.store(c).ListEvalSuites(promptID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, models.EvalSuitesResponse{
+			Success: false,
+			Error:   fmt.Sprintf("Failed to retrieve eval suites: %v", err),
+		})
+	}
+
+	return c.JSON(http.StatusOK, models.EvalSuitesResponse{
+		Success: true,
+		Data:    suites,
+	})
+}
+
+// GetEvalSuite handles GET /api/eval-suites/:id
+func (h *Handlers) GetEvalSuite(c echo.Context) error {
+	suiteID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, models.EvalSuiteResponse{
+			Success: false,
+			Error:   "Invalid eval suite ID format",
+		})
+	}
+
+	suite, err := h.store(c).GetEvalSuite(suiteID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, models.EvalSuiteResponse{
+			Success: false,
+			Error:   fmt.Sprintf("Failed to retrieve eval suite: %v", err),
+		})
+	}
+	if suite == nil {
+		return evalSuiteNotFound(c)
+	}
+
+	return c.JSON(http.StatusOK, models.EvalSuiteResponse{
+		Success: true,
+		Data:    suite,
+	})
+}
+
+// SaveEvalSuite handles POST /api/eval-suites, keeping a generated suite so it can be rerun
+func (h *Handlers) SaveEvalSuite(c echo.Context) error {
+	req, err := bindEvalSuite(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, models.EvalSuiteResponse{
+			Success: false,
+			Error:   fmt.Sprintf("Invalid eval suite: %v", err),
+		})
+	}
+
+	suite, err := h.store(c).SaveEvalSuite(req)
+	if err != nil {
+		return evalSuiteError(c, "Failed to save eval suite", err)
+	}
+
+	return c.JSON(http.StatusCreated, models.EvalSuiteResponse{
+		Success: true,
+		Data:    suite,
+	})
+}
+
+// UpdateEvalSuite handles PUT /api/eval-suites/:id
+func (h *Handlers) UpdateEvalSuite(c echo.Context) error {
+	suiteID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, models.EvalSuiteResponse{
+			Success: false,
+			Error:   "Invalid eval suite ID format",
+		})
+	}
+	req, err := bindEvalSuite(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, models.EvalSuiteResponse{
+			Success: false,
+			Error:   fmt.Sprintf("Invalid eval suite: %v", err),
+		})
+	}
+
+	suite, err := h.store(c).UpdateEvalSuite(suiteID, req)
+	if err != nil {
+		return evalSuiteError(c, "Failed to update eval suite", err)
+	}
+	if suite == nil {
+		return evalSuiteNotFound(c)
+	}
+
+	return c.JSON(http.StatusOK, models.EvalSuiteResponse{
+		Success: true,
+		Data:    suite,
+	})
+}
+
+// DeleteEvalSuite handles DELETE /api/eval-suites/:id
+func (h *Handlers) DeleteEvalSuite(c echo.Context) error {
+	suiteID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "Invalid eval suite ID format",
+		})
+	}
+
+	if err := h.store(c).DeleteEvalSuite(suiteID); err != nil {
+		return c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Error:   fmt.Sprintf("Failed to delete eval suite: %v", err),
+		})
+	}
+
+	return c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    "Eval suite deleted successfully",
+	})
+}
+
+// bindEvalSuite reads a suite from the request body and checks it has a name and test cases
+func bindEvalSuite(c echo.Context) (models.SaveEvalSuiteRequest, error) {
+	var req models.SaveEvalSuiteRequest
+	if err := c.Bind(&req); err != nil {
+		return req, fmt.Errorf("invalid request format")
+	}
+	if strings.TrimSpace(req.Name) == "" || len(req.Data.TestCases) == 0 {
+		return req, fmt.Errorf("a name and at least one test case are required")
+	}
+	return req, nil
+}
+
+func evalSuiteError(c echo.Context, message string, err error) error {
+	status := http.StatusInternalServerError
+	if errors.Is(err, database.ErrPromptNotFound) {
+		status = http.StatusBadRequest
+	}
+	return c.JSON(status, models.EvalSuiteResponse{
+		Success: false,
+		Error:   fmt.Sprintf("%s: %v", message, err),
+	})
+}
+
+func evalSuiteNotFound(c echo.Context) error {
+	return c.JSON(http.StatusNotFound, models.EvalSuiteResponse{
+		Success: false,
+		Error:   "Eval suite not found",
+	})
+}
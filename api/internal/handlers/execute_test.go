@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+
+	"promptforge/internal/models"
+)
+
+func TestExecuteRecordsHistory(t *testing.T) {
+	h, db, requests := setupStubHandlers(t, func(req models.OpenAIRequest) (string, bool) {
+		return "Hello from " + req.Model, req.Model != "broken"
+	})
+
+	prompt, err := db.SavePrompt(models.SavePromptRequest{Title: "Greeting", Content: "Greet {{name}}"})
+	if err != nil {
+		t.Fatalf("Failed to save prompt: %v", err)
+	}
+
+	var response models.ExecuteResponse
+	body := `{"prompt_id": ` + strconv.FormatInt(prompt.ID, 10) + `, "variables": {"name": "Ada"}, "system_prompt": "Be brief", "model": "gpt-4"}`
+	if code := postJSON(t, h.ExecutePrompt, body, &response); code != http.StatusOK || !response.Success {
+		t.Fatalf("Expected the execution to succeed, got %d: %+v", code, response)
+	}
+	if (*requests)[0].Messages[0].Role != "system" || (*requests)[0].Messages[1].Content != "Greet Ada" {
+		t.Errorf("Expected the system prompt and rendered prompt to be sent, got %+v", (*requests)[0].Messages)
+	}
+	if response.HistoryID == 0 || response.TokenUsage == nil || response.TokenUsage.TotalTokens != 1500 || response.Cost != 0.06 {
+		t.Errorf("Expected usage, cost and the history ID in the response, got %+v", response)
+	}
+
+	history, _, err := db.GetHistory(models.HistoryFilter{PromptID: prompt.ID})
+	if err != nil || len(history) != 1 {
+		t.Fatalf("Expected the execution to be recorded against the prompt, got %d (%v)", len(history), err)
+	}
+	item := history[0]
+	if item.ID != response.HistoryID || item.Prompt != "Greet Ada" || item.SystemPrompt != "Be brief" || item.Provider != "openai" || item.PromptVersion == nil || *item.PromptVersion != 1 {
+		t.Errorf("Unexpected history record %+v", item)
+	}
+
+	// Every model of a comparison is recorded under one run, failures included
+	var multi models.MultiModelExecuteResponse
+	if code := postJSON(t, h.MultiModelExecute, `{"prompt": "Hi", "models": ["gpt-4", "broken"]}`, &multi); code != http.StatusOK || multi.RunID == "" {
+		t.Fatalf("Expected a run ID, got %d: %+v", code, multi)
+	}
+	history, _, _ = db.GetHistory(models.HistoryFilter{RunID: multi.RunID})
+	if len(history) != 2 {
+		t.Fatalf("Expected both models in the run's history, got %d", len(history))
+	}
+	for _, item := range history {
+		if item.Model == "broken" && (item.Success || item.ErrorMsg == "") {
+			t.Errorf("Expected the failed model to be recorded with its error, got %+v", item)
+		}
+	}
+}
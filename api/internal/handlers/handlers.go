@@ -1,26 +1,36 @@
 package handlers
 
 import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"log/slog"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/labstack/echo/v4"
 
+	"promptforge/internal/auth"
 	"promptforge/internal/config"
 	"promptforge/internal/database"
+	"promptforge/internal/gitsync"
 	"promptforge/internal/models"
 	"promptforge/internal/services"
 )
 
 type Handlers struct {
-	db             *database.Database
+	db             database.Store
 	aiService      *services.UnifiedAIService
 	promptAnalyzer *services.PromptAnalyzer
 	evalGenerator  *services.EvalGenerator
+	promptEngineer *services.PromptEngineer
+	templateEngine *services.TemplateEngine
+	librarySync    *gitsync.Syncer // nil when git library sync is off
 }
 
-func NewHandlers(db *database.Database, aiService *services.UnifiedAIService) *Handlers {
+func NewHandlers(db database.Store, aiService *services.UnifiedAIService) *Handlers {
 	promptAnalyzer := services.NewPromptAnalyzer(aiService)
 	evalGenerator := services.NewEvalGenerator(aiService)
 
@@ -29,6 +39,8 @@ func NewHandlers(db *database.Database, aiService *services.UnifiedAIService) *H
 		aiService:      aiService,
 		promptAnalyzer: promptAnalyzer,
 		evalGenerator:  evalGenerator,
+		promptEngineer: services.NewPromptEngineer(aiService),
+		templateEngine: services.NewTemplateEngine(),
 	}
 }
 
@@ -39,19 +51,35 @@ func (h *Handlers) HealthCheck(c echo.Context) error {
 	})
 }
 
+// GetProviders handles GET /api/providers, listing providers and the Azure OpenAI deployments
+// without their keys
 func (h *Handlers) GetProviders(c echo.Context) error {
+	cfg := config.Current()
+	ctx := c.Request().Context()
+	deployments := []map[string]string{}
+	for _, d := range cfg.AzureOpenAI.Deployments {
+		deployments = append(deployments, map[string]string{
+			"model":       d.Model,
+			"deployment":  d.Deployment,
+			"region":      d.Region,
+			"endpoint":    d.Endpoint,
+			"api_version": d.APIVersion,
+		})
+	}
+
 	providers := map[string]interface{}{
-		"default": config.AppConfig.DefaultProvider,
+		"default": cfg.DefaultProvider,
 		"available": []string{
 			string(config.ProviderOpenAI),
 			string(config.ProviderAzureOpenAI),
 			string(config.ProviderAnthropic),
 		},
 		"configured": map[string]bool{
-			string(config.ProviderOpenAI):      config.AppConfig.OpenAI.APIKey != "",
-			string(config.ProviderAzureOpenAI): config.AppConfig.AzureOpenAI.APIKey != "",
-			string(config.ProviderAnthropic):   config.AppConfig.Anthropic.APIKey != "",
+			string(config.ProviderOpenAI):      cfg.OpenAI.APIKey != "" || services.HasCredential(ctx, config.ProviderOpenAI),
+			string(config.ProviderAzureOpenAI): cfg.AzureOpenAI.Configured() || services.HasCredential(ctx, config.ProviderAzureOpenAI),
+			string(config.ProviderAnthropic):   cfg.Anthropic.APIKey != "" || services.HasCredential(ctx, config.ProviderAnthropic),
 		},
+		"azure_deployments": deployments,
 	}
 
 	return c.JSON(http.StatusOK, providers)
@@ -72,7 +100,7 @@ func (h *Handlers) CritiquePrompt(c echo.Context) error {
 	}
 
 	// Use the enhanced prompt analyzer
-	response, err := h.promptAnalyzer.AnalyzePrompt(req.Prompt, model)
+	response, err := h.promptAnalyzer.AnalyzePrompt(c.Request().Context(), req.Prompt, model)
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, models.APIResponse{
 			Success: false,
@@ -89,14 +117,18 @@ func (h *Handlers) CritiquePrompt(c echo.Context) error {
 func (h *Handlers) ExecutePrompt(c echo.Context) error {
 	var req models.ExecuteRequest
 	if err := c.Bind(&req); err != nil {
-		return c.JSON(http.StatusBadRequest, models.APIResponse{
+		return c.JSON(http.StatusBadRequest, models.ExecuteResponse{
 			Success: false,
 			Error:   "Invalid request format",
 		})
 	}
 
-	messages := []models.Message{
-		{Role: "user", Content: req.Prompt},
+	prompt, status, err := h.renderRequestPrompt(c, req.PromptID, req.Prompt, req.Variables)
+	if err != nil {
+		return c.JSON(status, models.ExecuteResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
 	}
 
 	temperature := req.Temperature
@@ -109,17 +141,33 @@ func (h *Handlers) ExecutePrompt(c echo.Context) error {
 		model = models.DefaultGPTModel // Default model
 	}
 
-	response, err := h.aiService.CallWithDefaultProvider(messages, temperature, req.MaxTokens, model)
-	if err != nil {
-		return c.JSON(http.StatusInternalServerError, models.APIResponse{
-			Success: false,
-			Error:   fmt.Sprintf("Failed to execute prompt: %v", err),
+	run := execution{
+		Prompt:       prompt,
+		SystemPrompt: req.SystemPrompt,
+		Temperature:  temperature,
+		MaxTokens:    req.MaxTokens,
+	}
+	run.PromptID, run.PromptVersion = h.promptLink(c, req.PromptID)
+
+	result := h.execute(c, run, model)
+	if !result.Success {
+		return c.JSON(http.StatusInternalServerError, models.ExecuteResponse{
+			Success:   false,
+			HistoryID: result.HistoryID,
+			Provider:  result.Provider,
+			LatencyMs: result.ExecutionTime,
+			Error:     fmt.Sprintf("Failed to execute prompt: %s", result.Error),
 		})
 	}
 
-	return c.JSON(http.StatusOK, models.APIResponse{
-		Success: true,
-		Data:    response,
+	return c.JSON(http.StatusOK, models.ExecuteResponse{
+		Success:    true,
+		Data:       result.Response,
+		HistoryID:  result.HistoryID,
+		Provider:   result.Provider,
+		LatencyMs:  result.ExecutionTime,
+		TokenUsage: result.TokenUsage,
+		Cost:       result.Cost,
 	})
 }
 
@@ -139,8 +187,12 @@ func (h *Handlers) MultiModelExecute(c echo.Context) error {
 		})
 	}
 
-	messages := []models.Message{
-		{Role: "user", Content: req.Prompt},
+	prompt, status, err := h.renderRequestPrompt(c, req.PromptID, req.Prompt, req.Variables)
+	if err != nil {
+		return c.JSON(status, models.MultiModelExecuteResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
 	}
 
 	temperature := req.Temperature
@@ -153,52 +205,207 @@ func (h *Handlers) MultiModelExecute(c echo.Context) error {
 		maxTokens = 1000 // Default max tokens
 	}
 
+	run := execution{
+		Prompt:       prompt,
+		SystemPrompt: req.SystemPrompt,
+		Temperature:  temperature,
+		MaxTokens:    maxTokens,
+		RunID:        newRunID(),
+	}
+	run.PromptID, run.PromptVersion = h.promptLink(c, req.PromptID)
+
 	var results []models.ModelExecutionResult
 
 	// Execute prompt against each model
 	for _, model := range req.Models {
-		startTime := time.Now()
+		results = append(results, h.execute(c, run, model))
+	}
+
+	return c.JSON(http.StatusOK, models.MultiModelExecuteResponse{
+		Success: true,
+		Data:    results,
+		RunID:   run.RunID,
+	})
+}
 
-		response, err := h.aiService.CallWithDefaultProvider(messages, temperature, maxTokens, model)
+// execution is a prompt run against one or more models
+type execution struct {
+	Prompt        string
+	SystemPrompt  string
+	Temperature   float64
+	MaxTokens     int
+	PromptID      *int64
+	PromptVersion *int
+	RunID         string
+}
+
+// execute runs the prompt against model with the default provider and records the outcome in history.
+// A failure to record is logged so the caller still gets the model's response.
+func (h *Handlers) execute(c echo.Context, run execution, model string) models.ModelExecutionResult {
+	var messages []models.Message
+	if run.SystemPrompt != "" {
+		messages = append(messages, models.Message{Role: "system", Content: run.SystemPrompt})
+	}
+	messages = append(messages, models.Message{Role: "user", Content: run.Prompt})
 
-		executionTime := time.Since(startTime).Milliseconds()
+	startTime := time.Now()
+	completion, err := h.aiService.CompleteWithDefaultProvider(c.Request().Context(), messages, run.Temperature, run.MaxTokens, model)
+	executionTime := time.Since(startTime).Milliseconds()
+
+	result := models.ModelExecutionResult{
+		Model:         model,
+		Provider:      string(config.Current().DefaultProvider),
+		ExecutionTime: executionTime,
+	}
+
+	if err != nil {
+		result.Success = false
+		result.Error = err.Error()
+	} else {
+		result.Success = true
+		result.Response = completion.Content
+		result.TokenUsage = completion.Usage
+		result.Cost = completion.Cost
+	}
+
+	historyID, err := h.store(c).SaveHistory(models.SaveHistoryRequest{
+		Prompt:        run.Prompt,
+		SystemPrompt:  run.SystemPrompt,
+		Model:         model,
+		Provider:      result.Provider,
+		Temperature:   run.Temperature,
+		MaxTokens:     run.MaxTokens,
+		Success:       result.Success,
+		Response:      result.Response,
+		ErrorMsg:      result.Error,
+		LatencyMs:     executionTime,
+		TokenUsage:    result.TokenUsage,
+		Cost:          result.Cost,
+		PromptID:      run.PromptID,
+		PromptVersion: run.PromptVersion,
+		RunID:         run.RunID,
+	})
+	if err != nil {
+		slog.WarnContext(c.Request().Context(), "failed to record execution", "model", model, "error", err)
+	} else {
+		result.HistoryID = historyID
+	}
+
+	return result
+}
 
-		result := models.ModelExecutionResult{
-			Model:         model,
-			ExecutionTime: executionTime,
+// promptLink returns the saved prompt and its current version for history, or nils for inline prompts
+func (h *Handlers) promptLink(c echo.Context, promptID int64) (*int64, *int) {
+	if promptID == 0 {
+		return nil, nil
+	}
+
+	version, err := h.store(c).GetLatestPromptVersion(promptID)
+	if err != nil {
+		slog.WarnContext(c.Request().Context(), "failed to look up prompt version", "prompt_id", promptID, "error", err)
+		return &promptID, nil
+	}
+	if version == nil {
+		return &promptID, nil
+	}
+	return &promptID, &version.Version
+}
+
+// newRunID identifies the executions of one multi-model comparison
+func newRunID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return strconv.FormatInt(time.Now().UnixNano(), 36)
+	}
+	return hex.EncodeToString(b)
+}
+
+// renderRequestPrompt resolves the text to execute: a rendered saved prompt when promptID is set,
+// otherwise the inline prompt rendered as an ad-hoc template if variables were supplied
+func (h *Handlers) renderRequestPrompt(c echo.Context, promptID int64, prompt string, values map[string]interface{}) (string, int, error) {
+	if promptID == 0 {
+		if len(values) == 0 {
+			return prompt, http.StatusOK, nil
 		}
 
+		rendered, err := h.templateEngine.Render(prompt, nil, values)
 		if err != nil {
-			result.Success = false
-			result.Error = err.Error()
-		} else {
-			result.Success = true
-			result.Response = response
-			// Note: Token usage would need to be extracted from the AI service response
-			// This is a placeholder for future enhancement
+			return "", http.StatusBadRequest, err
 		}
+		return rendered, http.StatusOK, nil
+	}
 
-		results = append(results, result)
+	saved, err := h.store(c).GetSavedPrompt(promptID)
+	if err != nil {
+		return "", http.StatusInternalServerError, fmt.Errorf("Failed to retrieve prompt: %v", err)
+	}
+	if saved == nil {
+		return "", http.StatusNotFound, fmt.Errorf("Prompt not found")
 	}
 
-	return c.JSON(http.StatusOK, models.MultiModelExecuteResponse{
-		Success: true,
-		Data:    results,
-	})
+	rendered, err := h.templateEngine.Render(saved.Content, saved.Variables, values)
+	if err != nil {
+		return "", http.StatusBadRequest, err
+	}
+
+	return rendered, http.StatusOK, nil
 }
 
 func (h *Handlers) GetHistory(c echo.Context) error {
-	history, err := h.db.GetHistory()
+	var filter models.HistoryFilter
+	var err error
+	if filter.PageOptions, err = parsePageOptions(c); err != nil {
+		return c.JSON(http.StatusBadRequest, models.HistoryResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+	}
+	if filter.From, filter.To, err = parseDateRange(c); err != nil {
+		return c.JSON(http.StatusBadRequest, models.HistoryResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+	}
+
+	filter.Model = c.QueryParam("model")
+	filter.Provider = c.QueryParam("provider")
+	filter.RunID = c.QueryParam("run_id")
+	if promptID := c.QueryParam("prompt_id"); promptID != "" {
+		if filter.PromptID, err = strconv.ParseInt(promptID, 10, 64); err != nil {
+			return c.JSON(http.StatusBadRequest, models.HistoryResponse{
+				Success: false,
+				Error:   "Invalid prompt_id filter",
+			})
+		}
+	}
+	if success := c.QueryParam("success"); success != "" {
+		value, err := strconv.ParseBool(success)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, models.HistoryResponse{
+				Success: false,
+				Error:   "Invalid success filter, expected true or false",
+			})
+		}
+		filter.Success = &value
+	}
+
+	history, page, err := h.store(c).GetHistory(filter)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, models.HistoryResponse{
+		status := http.StatusInternalServerError
+		if errors.Is(err, database.ErrInvalidListOptions) {
+			status = http.StatusBadRequest
+		}
+		return c.JSON(status, models.HistoryResponse{
 			Success: false,
 			Error:   fmt.Sprintf("Failed to retrieve history: %v", err),
 		})
 	}
 
 	return c.JSON(http.StatusOK, models.HistoryResponse{
-		Success: true,
-		Data:    history,
+		Success:    true,
+		Data:       history,
+		Total:      page.Total,
+		NextCursor: page.NextCursor,
 	})
 }
 
@@ -211,7 +418,7 @@ func (h *Handlers) SaveHistory(c echo.Context) error {
 		})
 	}
 
-	if err := h.db.SaveHistory(req); err != nil {
+	if _, err := h.store(c).SaveHistory(req); err != nil {
 		return c.JSON(http.StatusInternalServerError, models.APIResponse{
 			Success: false,
 			Error:   fmt.Sprintf("Failed to save history: %v", err),
@@ -225,7 +432,7 @@ func (h *Handlers) SaveHistory(c echo.Context) error {
 }
 
 func (h *Handlers) ClearHistory(c echo.Context) error {
-	if err := h.db.ClearHistory(); err != nil {
+	if err := h.store(c).ClearHistory(); err != nil {
 		return c.JSON(http.StatusInternalServerError, models.APIResponse{
 			Success: false,
 			Error:   fmt.Sprintf("Failed to clear history: %v", err),
@@ -241,7 +448,7 @@ func (h *Handlers) ClearHistory(c echo.Context) error {
 func (h *Handlers) PromptEngineer(c echo.Context) error {
 	var req models.PromptEngineerRequest
 	if err := c.Bind(&req); err != nil {
-		return c.JSON(http.StatusBadRequest, models.APIResponse{
+		return c.JSON(http.StatusBadRequest, models.PromptEngineerResponse{
 			Success: false,
 			Error:   "Invalid request format",
 		})
@@ -257,15 +464,26 @@ func (h *Handlers) PromptEngineer(c echo.Context) error {
 		temperature = 0.7 // Default temperature
 	}
 
-	response, err := h.aiService.CallWithDefaultProvider(req.Messages, temperature, 2000, model)
+	if req.ConversationID != "" {
+		// Server-side sessions save both turns to the conversation
+		if !auth.CanEdit(c) {
+			return c.JSON(http.StatusForbidden, models.PromptEngineerResponse{
+				Success: false,
+				Error:   "Viewers cannot change this workspace",
+			})
+		}
+		return h.promptEngineerSession(c, req, model, temperature)
+	}
+
+	response, err := h.aiService.CallWithDefaultProvider(c.Request().Context(), req.Messages, temperature, 2000, model)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, models.APIResponse{
+		return c.JSON(http.StatusInternalServerError, models.PromptEngineerResponse{
 			Success: false,
 			Error:   fmt.Sprintf("Failed to get prompt engineering response: %v", err),
 		})
 	}
 
-	return c.JSON(http.StatusOK, models.APIResponse{
+	return c.JSON(http.StatusOK, models.PromptEngineerResponse{
 		Success: true,
 		Data:    response,
 	})
@@ -286,7 +504,7 @@ func (h *Handlers) DualCritiquePrompt(c echo.Context) error {
 	}
 
 	// Use the dual prompt analyzer
-	response, err := h.promptAnalyzer.DualAnalyzePrompt(req.Prompt, model)
+	response, err := h.promptAnalyzer.DualAnalyzePrompt(c.Request().Context(), req.Prompt, model)
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, models.DualAnalysisResponse{
 			Success: false,
@@ -302,17 +520,38 @@ func (h *Handlers) DualCritiquePrompt(c echo.Context) error {
 
 // Conversation management handlers
 func (h *Handlers) GetConversations(c echo.Context) error {
-	conversations, err := h.db.GetConversations()
+	var filter models.ConversationFilter
+	var err error
+	if filter.PageOptions, err = parsePageOptions(c); err != nil {
+		return c.JSON(http.StatusBadRequest, models.ConversationResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+	}
+	if filter.From, filter.To, err = parseDateRange(c); err != nil {
+		return c.JSON(http.StatusBadRequest, models.ConversationResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+	}
+
+	conversations, page, err := h.store(c).GetConversations(filter)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, models.ConversationResponse{
+		status := http.StatusInternalServerError
+		if errors.Is(err, database.ErrInvalidListOptions) {
+			status = http.StatusBadRequest
+		}
+		return c.JSON(status, models.ConversationResponse{
 			Success: false,
 			Error:   fmt.Sprintf("Failed to retrieve conversations: %v", err),
 		})
 	}
 
 	return c.JSON(http.StatusOK, models.ConversationResponse{
-		Success: true,
-		Data:    conversations,
+		Success:    true,
+		Data:       conversations,
+		Total:      page.Total,
+		NextCursor: page.NextCursor,
 	})
 }
 
@@ -325,7 +564,7 @@ func (h *Handlers) GetConversation(c echo.Context) error {
 		})
 	}
 
-	conversation, err := h.db.GetConversation(conversationID)
+	conversation, err := h.store(c).GetConversation(conversationID)
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, models.ConversationDetailResponse{
 			Success: false,
@@ -349,22 +588,27 @@ func (h *Handlers) GetConversation(c echo.Context) error {
 func (h *Handlers) SaveConversation(c echo.Context) error {
 	var req models.SaveConversationRequest
 	if err := c.Bind(&req); err != nil {
-		return c.JSON(http.StatusBadRequest, models.APIResponse{
+		return c.JSON(http.StatusBadRequest, models.ConversationDetailResponse{
 			Success: false,
 			Error:   "Invalid request format",
 		})
 	}
 
-	if err := h.db.SaveConversation(req); err != nil {
-		return c.JSON(http.StatusInternalServerError, models.APIResponse{
+	if req.ConversationID == "" {
+		return c.JSON(http.StatusBadRequest, models.ConversationDetailResponse{
 			Success: false,
-			Error:   fmt.Sprintf("Failed to save conversation: %v", err),
+			Error:   "Conversation ID is required",
 		})
 	}
 
-	return c.JSON(http.StatusOK, models.APIResponse{
+	conversation, err := h.store(c).SaveConversation(req)
+	if err != nil {
+		return h.conversationError(c, req.ConversationID, "Failed to save conversation", err)
+	}
+
+	return c.JSON(http.StatusOK, models.ConversationDetailResponse{
 		Success: true,
-		Data:    "Conversation saved successfully",
+		Data:    conversation,
 	})
 }
 
@@ -377,7 +621,7 @@ func (h *Handlers) DeleteConversation(c echo.Context) error {
 		})
 	}
 
-	if err := h.db.DeleteConversation(conversationID); err != nil {
+	if err := h.store(c).DeleteConversation(conversationID); err != nil {
 		return c.JSON(http.StatusInternalServerError, models.APIResponse{
 			Success: false,
 			Error:   fmt.Sprintf("Failed to delete conversation: %v", err),
@@ -392,17 +636,51 @@ func (h *Handlers) DeleteConversation(c echo.Context) error {
 
 // Prompt Library handlers
 func (h *Handlers) GetSavedPrompts(c echo.Context) error {
-	prompts, err := h.db.GetSavedPrompts()
+	var filter models.PromptFilter
+	var err error
+	if filter.PageOptions, err = parsePageOptions(c); err != nil {
+		return c.JSON(http.StatusBadRequest, models.PromptLibraryResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+	}
+	if filter.From, filter.To, err = parseDateRange(c); err != nil {
+		return c.JSON(http.StatusBadRequest, models.PromptLibraryResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+	}
+
+	filter.Category = c.QueryParam("category")
+	filter.Tags = c.QueryParams()["tag"]
+	switch c.QueryParam("tag_match") {
+	case "", "all":
+	case "any":
+		filter.MatchAnyTag = true
+	default:
+		return c.JSON(http.StatusBadRequest, models.PromptLibraryResponse{
+			Success: false,
+			Error:   "tag_match must be 'all' or 'any'",
+		})
+	}
+
+	prompts, page, err := h.store(c).GetSavedPrompts(filter)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, models.PromptLibraryResponse{
+		status := http.StatusInternalServerError
+		if errors.Is(err, database.ErrInvalidListOptions) {
+			status = http.StatusBadRequest
+		}
+		return c.JSON(status, models.PromptLibraryResponse{
 			Success: false,
 			Error:   fmt.Sprintf("Failed to retrieve saved prompts: %v", err),
 		})
 	}
 
 	return c.JSON(http.StatusOK, models.PromptLibraryResponse{
-		Success: true,
-		Data:    prompts,
+		Success:    true,
+		Data:       prompts,
+		Total:      page.Total,
+		NextCursor: page.NextCursor,
 	})
 }
 
@@ -424,7 +702,7 @@ func (h *Handlers) GetSavedPrompt(c echo.Context) error {
 		})
 	}
 
-	prompt, err := h.db.GetSavedPrompt(id)
+	prompt, err := h.store(c).GetSavedPrompt(id)
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, models.PromptResponse{
 			Success: false,
@@ -469,7 +747,16 @@ func (h *Handlers) SavePrompt(c echo.Context) error {
 		})
 	}
 
-	prompt, err := h.db.SavePrompt(req)
+	variables, err := h.templateEngine.ResolveVariables(req.Content, req.Variables)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, models.PromptResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+	}
+	req.Variables = variables
+
+	prompt, err := h.store(c).SavePrompt(req)
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, models.PromptResponse{
 			Success: false,
@@ -477,6 +764,8 @@ func (h *Handlers) SavePrompt(c echo.Context) error {
 		})
 	}
 
+	h.syncPrompt(c, prompt.ID, fmt.Sprintf("Add prompt %q", prompt.Title))
+
 	return c.JSON(http.StatusCreated, models.PromptResponse{
 		Success: true,
 		Data:    prompt,
@@ -527,7 +816,16 @@ func (h *Handlers) UpdatePrompt(c echo.Context) error {
 		})
 	}
 
-	prompt, err := h.db.UpdatePrompt(req)
+	variables, err := h.templateEngine.ResolveVariables(req.Content, req.Variables)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, models.PromptResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+	}
+	req.Variables = variables
+
+	prompt, err := h.store(c).UpdatePrompt(req)
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, models.PromptResponse{
 			Success: false,
@@ -535,6 +833,15 @@ func (h *Handlers) UpdatePrompt(c echo.Context) error {
 		})
 	}
 
+	if prompt == nil {
+		return c.JSON(http.StatusNotFound, models.PromptResponse{
+			Success: false,
+			Error:   "Prompt not found",
+		})
+	}
+
+	h.syncPrompt(c, prompt.ID, fmt.Sprintf("Update prompt %q", prompt.Title))
+
 	return c.JSON(http.StatusOK, models.PromptResponse{
 		Success: true,
 		Data:    prompt,
@@ -559,13 +866,15 @@ func (h *Handlers) DeletePrompt(c echo.Context) error {
 		})
 	}
 
-	if err := h.db.DeletePrompt(id); err != nil {
+	if err := h.store(c).DeletePrompt(id); err != nil {
 		return c.JSON(http.StatusInternalServerError, models.APIResponse{
 			Success: false,
 			Error:   fmt.Sprintf("Failed to delete prompt: %v", err),
 		})
 	}
 
+	h.syncPrompt(c, id, fmt.Sprintf("Delete prompt %d", id))
+
 	return c.JSON(http.StatusOK, models.APIResponse{
 		Success: true,
 		Data:    "Prompt deleted successfully",
@@ -590,16 +899,19 @@ func (h *Handlers) UsePrompt(c echo.Context) error {
 		})
 	}
 
-	// Increment usage count
-	if err := h.db.IncrementPromptUsage(id); err != nil {
-		return c.JSON(http.StatusInternalServerError, models.APIResponse{
-			Success: false,
-			Error:   fmt.Sprintf("Failed to increment prompt usage: %v", err),
-		})
+	// Variables are optional; a bare POST only records usage
+	var req models.UsePromptRequest
+	if c.Request().ContentLength != 0 {
+		if err := c.Bind(&req); err != nil {
+			return c.JSON(http.StatusBadRequest, models.PromptResponse{
+				Success: false,
+				Error:   "Invalid request format",
+			})
+		}
 	}
 
 	// Get the prompt to return
-	prompt, err := h.db.GetSavedPrompt(id)
+	prompt, err := h.store(c).GetSavedPrompt(id)
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, models.PromptResponse{
 			Success: false,
@@ -614,9 +926,30 @@ func (h *Handlers) UsePrompt(c echo.Context) error {
 		})
 	}
 
+	var rendered string
+	if req.Variables != nil {
+		rendered, err = h.templateEngine.Render(prompt.Content, prompt.Variables, req.Variables)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, models.PromptResponse{
+				Success: false,
+				Error:   err.Error(),
+			})
+		}
+	}
+
+	// Increment usage count
+	if err := h.store(c).IncrementPromptUsage(id); err != nil {
+		return c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Error:   fmt.Sprintf("Failed to increment prompt usage: %v", err),
+		})
+	}
+	prompt.UsageCount++
+
 	return c.JSON(http.StatusOK, models.PromptResponse{
-		Success: true,
-		Data:    prompt,
+		Success:  true,
+		Data:     prompt,
+		Rendered: rendered,
 	})
 }
 
@@ -657,7 +990,7 @@ func (h *Handlers) GenerateEval(c echo.Context) error {
 	}
 
 	// Generate evaluation suite
-	evalData, err := h.evalGenerator.GenerateEvaluationSuite(req)
+	evalData, err := h.evalGenerator.GenerateEvaluationSuite(c.Request().Context(), req)
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, models.EvalResponse{
 			Success: false,
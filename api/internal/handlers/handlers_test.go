@@ -5,13 +5,99 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/labstack/echo/v4"
 
+	"promptforge/internal/config"
+	"promptforge/internal/database"
 	"promptforge/internal/models"
+	"promptforge/internal/services"
 )
 
+// setupHandlers returns handlers backed by a fresh test database, under an empty configuration the
+// test may change
+func setupHandlers(t *testing.T) (*Handlers, *database.Database) {
+	t.Helper()
+
+	cfg := &config.Config{}
+	previousApp, previous := config.AppConfig, config.Current()
+	config.AppConfig = cfg
+	config.Set(cfg)
+	t.Cleanup(func() {
+		config.AppConfig = previousApp
+		config.Set(previous)
+	})
+
+	db, err := database.Open(database.DriverSQLite, filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if err := db.InitSchema(true); err != nil {
+		t.Fatalf("Failed to initialize schema: %v", err)
+	}
+
+	return NewHandlers(db, services.NewUnifiedAIService()), db
+}
+
+// stubReply answers a request to the stub provider, or fails it with a server error when ok is false
+type stubReply func(req models.OpenAIRequest) (content string, ok bool)
+
+// setupStubHandlers is setupHandlers with the default provider pointed at a stub OpenAI server that
+// answers with reply and reports 1000 prompt and 500 completion tokens. It returns the requests the
+// stub received.
+func setupStubHandlers(t *testing.T, reply stubReply) (*Handlers, *database.Database, *[]models.OpenAIRequest) {
+	t.Helper()
+
+	var requests []models.OpenAIRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req models.OpenAIRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("Failed to decode provider request: %v", err)
+		}
+		requests = append(requests, req)
+
+		content, ok := reply(req)
+		if !ok {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"choices": []map[string]interface{}{{"message": map[string]string{"role": "assistant", "content": content}}},
+			"usage":   map[string]int{"prompt_tokens": 1000, "completion_tokens": 500, "total_tokens": 1500},
+		})
+	}))
+	t.Cleanup(server.Close)
+
+	h, db := setupHandlers(t)
+	cfg := config.Current()
+	cfg.DefaultProvider = config.ProviderOpenAI
+	cfg.OpenAI = config.OpenAIConfig{APIKey: "test-key", BaseURL: server.URL}
+
+	return h, db, &requests
+}
+
+// postJSON posts body to handler and decodes the response into response, returning the status code
+func postJSON(t *testing.T, handler echo.HandlerFunc, body string, response interface{}) int {
+	t.Helper()
+
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+
+	if err := handler(e.NewContext(req, rec)); err != nil {
+		t.Fatalf("Handler returned error: %v", err)
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), response); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	return rec.Code
+}
+
 func TestHealthCheckEndpoint(t *testing.T) {
 	e := echo.New()
 	req := httptest.NewRequest(http.MethodGet, "/api/health", nil)
@@ -45,6 +131,36 @@ func TestHealthCheckEndpoint(t *testing.T) {
 	}
 }
 
+func TestGetConfig(t *testing.T) {
+	t.Setenv("DEFAULT_AI_PROVIDER", "openai")
+	t.Setenv("OPENAI_API_KEY", "sk-test-secret")
+	cfg, err := config.Load()
+	if err != nil {
+		t.Fatalf("Failed to load configuration: %v", err)
+	}
+	previous := config.Current()
+	config.Set(cfg)
+	defer func() { config.Set(previous) }()
+
+	rec := httptest.NewRecorder()
+	if err := (&Handlers{}).GetConfig(echo.New().NewContext(httptest.NewRequest(http.MethodGet, "/api/config", nil), rec)); err != nil {
+		t.Fatalf("GetConfig returned error: %v", err)
+	}
+	if bytes.Contains(rec.Body.Bytes(), []byte("sk-test-secret")) {
+		t.Fatal("Expected the API key to be hidden")
+	}
+
+	var response models.ConfigResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	for _, s := range response.Data.Settings {
+		if s.Key == "openai.api_key" && (s.Value != "[set]" || s.Source != "env") {
+			t.Errorf("Expected the API key to show as set from the environment, got %+v", s)
+		}
+	}
+}
+
 func TestRequestValidation(t *testing.T) {
 	// Test JSON binding directly with Echo context
 	tests := []struct {
@@ -0,0 +1,163 @@
+package handlers
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"promptforge/internal/database"
+	"promptforge/internal/library"
+	"promptforge/internal/models"
+)
+
+// maxImportSize bounds library uploads held in memory while parsing
+const maxImportSize = 32 << 20
+
+// ExportLibrary handles GET /api/library/export?format=json|yaml|markdown. Markdown is sent as a zip archive.
+func (h *Handlers) ExportLibrary(c echo.Context) error {
+	format, err := library.ParseFormat(c.QueryParam("format"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, models.LibraryResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+	}
+
+	lib, err := h.store(c).ExportLibrary()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, models.LibraryResponse{
+			Success: false,
+			Error:   fmt.Sprintf("Failed to export library: %v", err),
+		})
+	}
+
+	var buf bytes.Buffer
+	contentType, extension := "application/json", "json"
+	switch format {
+	case models.LibraryFormatMarkdown:
+		contentType, extension = "application/zip", "zip"
+		err = library.WriteZip(&buf, lib)
+	case models.LibraryFormatYAML:
+		contentType, extension = "application/yaml", "yaml"
+		err = library.Encode(&buf, format, lib)
+	default:
+		err = library.Encode(&buf, format, lib)
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, models.LibraryResponse{
+			Success: false,
+			Error:   fmt.Sprintf("Failed to export library: %v", err),
+		})
+	}
+
+	filename := fmt.Sprintf("promptforge-library-%s.%s", time.Now().UTC().Format("20060102-150405"), extension)
+	c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", filename))
+	return c.Blob(http.StatusOK, contentType, buf.Bytes())
+}
+
+// ImportLibrary handles POST /api/library/import?format=json|yaml|markdown&on_conflict=skip|overwrite|rename.
+// The library is the request body or a multipart "file" upload; Markdown libraries are uploaded as a zip archive.
+func (h *Handlers) ImportLibrary(c echo.Context) error {
+	strategy := c.QueryParam("on_conflict")
+	if strategy == "" {
+		strategy = models.ConflictSkip
+	}
+	if strategy != models.ConflictSkip && strategy != models.ConflictOverwrite && strategy != models.ConflictRename {
+		return c.JSON(http.StatusBadRequest, models.ImportResponse{
+			Success: false,
+			Error:   "on_conflict must be skip, overwrite or rename",
+		})
+	}
+
+	data, filename, err := readImportBody(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, models.ImportResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+	}
+
+	format := c.QueryParam("format")
+	if format == "" && filename != "" {
+		format = library.FormatFromPath(filename, false)
+	} else if format == "" && strings.Contains(c.Request().Header.Get(echo.HeaderContentType), "yaml") {
+		format = models.LibraryFormatYAML
+	}
+	if format, err = library.ParseFormat(format); err != nil {
+		return c.JSON(http.StatusBadRequest, models.ImportResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+	}
+
+	var lib *models.Library
+	if format == models.LibraryFormatMarkdown {
+		lib, err = library.ReadZip(data)
+	} else {
+		lib, err = library.Decode(data, format)
+	}
+	if err == nil {
+		err = database.ValidateLibrary(lib)
+	}
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, models.ImportResponse{
+			Success: false,
+			Error:   fmt.Sprintf("Invalid library: %v", err),
+		})
+	}
+
+	result, err := h.store(c).ImportLibrary(lib, strategy)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, models.ImportResponse{
+			Success: false,
+			Error:   fmt.Sprintf("Failed to import library: %v", err),
+		})
+	}
+
+	if result.Created+result.Overwritten+result.Renamed > 0 {
+		h.syncLibrary(c, "Import prompt library")
+	}
+
+	return c.JSON(http.StatusOK, models.ImportResponse{
+		Success: true,
+		Data:    result,
+	})
+}
+
+// readImportBody returns the uploaded file from a multipart form, or the raw request body
+func readImportBody(c echo.Context) ([]byte, string, error) {
+	var reader io.Reader = c.Request().Body
+	filename := ""
+
+	if strings.HasPrefix(c.Request().Header.Get(echo.HeaderContentType), echo.MIMEMultipartForm) {
+		header, err := c.FormFile("file")
+		if err != nil {
+			return nil, "", fmt.Errorf("multipart import requires a 'file' field")
+		}
+		file, err := header.Open()
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to open uploaded file: %v", err)
+		}
+		defer file.Close()
+		reader, filename = file, filepath.Base(header.Filename)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(reader, maxImportSize+1))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read library: %v", err)
+	}
+	if len(data) > maxImportSize {
+		return nil, "", fmt.Errorf("library exceeds the %d MB import limit", maxImportSize>>20)
+	}
+	if len(data) == 0 {
+		return nil, "", fmt.Errorf("library is empty")
+	}
+
+	return data, filename, nil
+}
@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+
+	"promptforge/internal/gitsync"
+	"promptforge/internal/models"
+)
+
+// SetLibrarySync enables committing prompt changes to the git-backed library
+func (h *Handlers) SetLibrarySync(syncer *gitsync.Syncer) {
+	h.librarySync = syncer
+}
+
+// syncPrompt mirrors one changed prompt to git; prompts outside the synced user's or workspace's
+// library are skipped. The change is already saved, so failures and conflicts are logged rather
+// than failing the request; GET /api/library/sync reports them.
+func (h *Handlers) syncPrompt(c echo.Context, promptID int64, message string) {
+	if h.librarySync == nil {
+		return
+	}
+	result, err := h.librarySync.PromptChanged(promptID, message)
+	logSyncResult(c, result, err)
+}
+
+// syncLibrary mirrors the whole library after a change that touched many prompts
+func (h *Handlers) syncLibrary(c echo.Context, message string) {
+	if h.librarySync == nil {
+		return
+	}
+	result, err := h.librarySync.Sync(message)
+	logSyncResult(c, result, err)
+}
+
+func logSyncResult(c echo.Context, result *models.SyncResult, err error) {
+	if err != nil {
+		slog.ErrorContext(c.Request().Context(), "library sync failed", "error", err)
+		return
+	}
+	for _, conflict := range result.Conflicts {
+		slog.WarnContext(c.Request().Context(), "library sync conflict", "path", conflict.Path, "reason", conflict.Reason)
+	}
+}
+
+func (h *Handlers) librarySyncDisabled(c echo.Context) error {
+	return c.JSON(http.StatusNotFound, models.SyncResponse{
+		Success: false,
+		Error:   "Library sync is not configured (set LIBRARY_SYNC_DIR)",
+	})
+}
+
+// GetLibrarySyncStatus handles GET /api/library/sync, reporting pending changes and conflicts without applying them
+func (h *Handlers) GetLibrarySyncStatus(c echo.Context) error {
+	if h.librarySync == nil {
+		return h.librarySyncDisabled(c)
+	}
+
+	result, err := h.librarySync.Status()
+	return syncResponse(c, result, err, "Failed to get library sync status")
+}
+
+// SyncLibrary handles POST /api/library/sync, reconciling every prompt with the working tree and committing
+func (h *Handlers) SyncLibrary(c echo.Context) error {
+	if h.librarySync == nil {
+		return h.librarySyncDisabled(c)
+	}
+
+	result, err := h.librarySync.Sync("Sync prompt library")
+	return syncResponse(c, result, err, "Failed to sync library")
+}
+
+// PullLibrary handles POST /api/library/sync/pull, importing upstream changes
+func (h *Handlers) PullLibrary(c echo.Context) error {
+	if h.librarySync == nil {
+		return h.librarySyncDisabled(c)
+	}
+
+	result, err := h.librarySync.Pull()
+	return syncResponse(c, result, err, "Failed to pull library")
+}
+
+// ResolveLibraryConflict handles POST /api/library/sync/resolve with {"path", "keep": "library"|"file"}
+func (h *Handlers) ResolveLibraryConflict(c echo.Context) error {
+	if h.librarySync == nil {
+		return h.librarySyncDisabled(c)
+	}
+
+	var req models.ResolveSyncConflictRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, models.SyncResponse{
+			Success: false,
+			Error:   "Invalid request format",
+		})
+	}
+
+	if strings.TrimSpace(req.Path) == "" || (req.Keep != models.SyncKeepLibrary && req.Keep != models.SyncKeepFile) {
+		return c.JSON(http.StatusBadRequest, models.SyncResponse{
+			Success: false,
+			Error:   "A path and keep ('library' or 'file') are required",
+		})
+	}
+
+	result, err := h.librarySync.Resolve(req.Path, req.Keep)
+	return syncResponse(c, result, err, "Failed to resolve conflict")
+}
+
+func syncResponse(c echo.Context, result *models.SyncResult, err error, message string) error {
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, models.SyncResponse{
+			Success: false,
+			Error:   fmt.Sprintf("%s: %v", message, err),
+		})
+	}
+
+	// Conflicts are part of a successful response so callers can show what needs resolving
+	return c.JSON(http.StatusOK, models.SyncResponse{
+		Success: true,
+		Data:    result,
+	})
+}
@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"promptforge/internal/models"
+)
+
+// parsePageOptions reads ?cursor=&limit=&sort=&order= shared by the list endpoints
+func parsePageOptions(c echo.Context) (models.PageOptions, error) {
+	opts := models.PageOptions{
+		Cursor: c.QueryParam("cursor"),
+		Sort:   c.QueryParam("sort"),
+		Order:  c.QueryParam("order"),
+	}
+
+	if limit := c.QueryParam("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil {
+			return opts, fmt.Errorf("invalid limit format")
+		}
+		opts.Limit = n
+	}
+
+	return opts, nil
+}
+
+// parseDateRange reads ?from=&to= as RFC 3339 timestamps or YYYY-MM-DD dates
+func parseDateRange(c echo.Context) (from, to *time.Time, err error) {
+	if from, err = parseDateParam(c.QueryParam("from"), false); err != nil {
+		return nil, nil, fmt.Errorf("invalid 'from' date, expected RFC 3339 or YYYY-MM-DD")
+	}
+	if to, err = parseDateParam(c.QueryParam("to"), true); err != nil {
+		return nil, nil, fmt.Errorf("invalid 'to' date, expected RFC 3339 or YYYY-MM-DD")
+	}
+	return from, to, nil
+}
+
+// parseDateParam accepts RFC 3339 timestamps or plain dates; a plain end date covers the whole day
+func parseDateParam(value string, endOfDay bool) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return &t, nil
+	}
+
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return nil, err
+	}
+	if endOfDay {
+		t = t.Add(24*time.Hour - time.Second)
+	}
+	return &t, nil
+}
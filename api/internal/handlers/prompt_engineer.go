@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"promptforge/internal/database"
+	"promptforge/internal/models"
+	"promptforge/internal/services"
+)
+
+// promptEngineerSession continues a stored conversation: it replies to req.Message using the active
+// branch as history, then saves the user turn and the reply together. The first exchange also names
+// the conversation.
+func (h *Handlers) promptEngineerSession(c echo.Context, req models.PromptEngineerRequest, model string, temperature float64) error {
+	userTurn := strings.TrimSpace(req.Message)
+	if userTurn == "" {
+		return c.JSON(http.StatusBadRequest, models.PromptEngineerResponse{
+			Success: false,
+			Error:   "A message is required when a conversation_id is given",
+		})
+	}
+
+	conversation, err := h.store(c).GetConversation(req.ConversationID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, models.PromptEngineerResponse{
+			Success: false,
+			Error:   fmt.Sprintf("Failed to load conversation: %v", err),
+		})
+	}
+
+	var history []models.ConversationMessage
+	revision := 0
+	firstExchange := true
+	if conversation != nil {
+		history = conversation.Messages
+		revision = conversation.Revision
+		for _, msg := range history {
+			if msg.Role == "user" {
+				firstExchange = false
+				break
+			}
+		}
+	}
+
+	// Refuse before calling the model if the client is already behind
+	if req.Revision != nil && *req.Revision != revision {
+		return c.JSON(http.StatusConflict, models.PromptEngineerResponse{
+			Success:      false,
+			Conversation: conversation,
+			Error:        fmt.Sprintf("Conversation changed since revision %d; reload it and retry", *req.Revision),
+		})
+	}
+
+	summaries, err := h.store(c).GetConversationSummaries(req.ConversationID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, models.PromptEngineerResponse{
+			Success: false,
+			Error:   fmt.Sprintf("Failed to load conversation summaries: %v", err),
+		})
+	}
+
+	userMessage := models.ConversationMessage{Role: "user", Content: userTurn, Timestamp: time.Now()}
+	reply, err := h.promptEngineer.Reply(c.Request().Context(), services.SessionTurn{
+		SystemPrompt: req.SystemPrompt,
+		History:      history,
+		Summaries:    summaries,
+		UserTurn:     userTurn,
+		Model:        model,
+		Temperature:  temperature,
+	})
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, models.PromptEngineerResponse{
+			Success: false,
+			Error:   fmt.Sprintf("Failed to get prompt engineering response: %v", err),
+		})
+	}
+
+	// A summary only depends on messages that are already stored, so it is kept even if saving the turn fails
+	if reply.Summary != nil {
+		if err := h.store(c).SaveConversationSummary(*reply.Summary); err != nil {
+			slog.WarnContext(c.Request().Context(), "failed to save conversation summary", "conversation_id", req.ConversationID, "error", err)
+		}
+	}
+
+	var title string
+	if firstExchange {
+		if title, err = h.promptEngineer.GenerateTitle(c.Request().Context(), userTurn, reply.Content, model); err != nil {
+			slog.WarnContext(c.Request().Context(), "failed to generate title", "conversation_id", req.ConversationID, "error", err)
+			title = services.TruncateTitle(userTurn)
+		}
+	}
+
+	// The revision the reply was based on: a turn saved in the meantime makes this a conflict
+	saved, err := h.store(c).AppendMessages(req.ConversationID, models.AppendMessagesRequest{
+		Title: title,
+		Messages: []models.ConversationMessage{
+			userMessage,
+			{Role: "assistant", Content: reply.Content, Timestamp: time.Now()},
+		},
+		Revision: &revision,
+	})
+	if err != nil {
+		// The reply is returned either way so the client can show it or retry the save
+		if errors.Is(err, database.ErrRevisionConflict) {
+			current, _ := h.store(c).GetConversation(req.ConversationID)
+			return c.JSON(http.StatusConflict, models.PromptEngineerResponse{
+				Success:      false,
+				Data:         reply.Content,
+				Conversation: current,
+				Context:      &reply.Context,
+				Error:        fmt.Sprintf("Failed to save prompt engineer turn: %v; reload it and retry", err),
+			})
+		}
+		return c.JSON(http.StatusInternalServerError, models.PromptEngineerResponse{
+			Success: false,
+			Data:    reply.Content,
+			Context: &reply.Context,
+			Error:   fmt.Sprintf("Failed to save prompt engineer turn: %v", err),
+		})
+	}
+
+	return c.JSON(http.StatusOK, models.PromptEngineerResponse{
+		Success:      true,
+		Data:         reply.Content,
+		Conversation: saved,
+		Context:      &reply.Context,
+	})
+}
@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"promptforge/internal/models"
+	"promptforge/internal/services"
+)
+
+// answerPromptEngineer answers title requests with a title and everything else with the number of
+// messages it received
+func answerPromptEngineer(req models.OpenAIRequest) (string, bool) {
+	if strings.Contains(req.Messages[0].Content, "You name prompt engineering sessions") {
+		return "\"Title: Support Ticket Triage.\"", true
+	}
+	return "Revised prompt after " + string(rune('0'+len(req.Messages))) + " messages", true
+}
+
+func postPromptEngineer(t *testing.T, h *Handlers, body string) (int, models.PromptEngineerResponse) {
+	t.Helper()
+
+	var response models.PromptEngineerResponse
+	code := postJSON(t, h.PromptEngineer, body, &response)
+	return code, response
+}
+
+func TestPromptEngineerSession(t *testing.T) {
+	h, db, requests := setupStubHandlers(t, answerPromptEngineer)
+
+	code, response := postPromptEngineer(t, h, `{"conversation_id": "session-1", "message": "Help me triage support tickets", "model": "gpt-4"}`)
+	if code != http.StatusOK || !response.Success {
+		t.Fatalf("Expected the first turn to succeed, got %d: %+v", code, response)
+	}
+	if response.Data != "Revised prompt after 2 messages" {
+		t.Errorf("Expected the system prompt and user turn to be sent, got %q", response.Data)
+	}
+	if response.Conversation == nil || response.Conversation.Title != "Support Ticket Triage" || len(response.Conversation.Messages) != 2 {
+		t.Fatalf("Expected both turns to be saved under a generated title, got %+v", response.Conversation)
+	}
+	if response.Context == nil || response.Context.Summarized || response.Context.PromptTokens == 0 {
+		t.Errorf("Expected a context report without summarizing, got %+v", response.Context)
+	}
+	if (*requests)[0].Messages[0].Content != services.PromptEngineerSystemPrompt {
+		t.Errorf("Expected the built-in system prompt, got %q", (*requests)[0].Messages[0].Content)
+	}
+
+	// The next turn is answered with the stored history and does not rename the conversation
+	*requests = nil
+	db.AppendMessages("session-1", models.AppendMessagesRequest{Title: "Renamed by user"})
+	code, response = postPromptEngineer(t, h, `{"conversation_id": "session-1", "message": "Add a priority field", "model": "gpt-4", "revision": 2}`)
+	if code != http.StatusOK || response.Data != "Revised prompt after 4 messages" {
+		t.Fatalf("Expected the history to be sent with the second turn, got %d: %+v", code, response)
+	}
+	if len(*requests) != 1 || response.Conversation.Title != "Renamed by user" || len(response.Conversation.Messages) != 4 {
+		t.Errorf("Expected no title request on later turns, got %d requests and %+v", len(*requests), response.Conversation)
+	}
+
+	// A client that is behind gets the current conversation back without a model call
+	*requests = nil
+	code, response = postPromptEngineer(t, h, `{"conversation_id": "session-1", "message": "Stale", "model": "gpt-4", "revision": 1}`)
+	if code != http.StatusConflict || response.Conversation == nil || response.Conversation.Revision != 3 || len(*requests) != 0 {
+		t.Errorf("Expected a 409 with the current conversation, got %d: %+v", code, response)
+	}
+
+	if code, _ := postPromptEngineer(t, h, `{"conversation_id": "session-1"}`); code != http.StatusBadRequest {
+		t.Errorf("Expected a missing message to be rejected, got %d", code)
+	}
+}
@@ -0,0 +1,235 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+
+	"promptforge/internal/models"
+	"promptforge/internal/services"
+)
+
+// parseInt64Param reads a numeric path parameter such as :id
+func parseInt64Param(c echo.Context, name string) (int64, error) {
+	value := c.Param(name)
+	if value == "" {
+		return 0, fmt.Errorf("%s is required", name)
+	}
+
+	id, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s format", name)
+	}
+
+	return id, nil
+}
+
+// Prompt version history handlers
+func (h *Handlers) GetPromptVersions(c echo.Context) error {
+	id, err := parseInt64Param(c, "id")
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, models.PromptVersionsResponse{
+			Success: false,
+			Error:   "Invalid prompt ID format",
+		})
+	}
+
+	versions, err := h.store(c).GetPromptVersions(id)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, models.PromptVersionsResponse{
+			Success: false,
+			Error:   fmt.Sprintf("Failed to retrieve prompt versions: %v", err),
+		})
+	}
+
+	if len(versions) == 0 {
+		prompt, err := h.store(c).GetSavedPrompt(id)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, models.PromptVersionsResponse{
+				Success: false,
+				Error:   fmt.Sprintf("Failed to retrieve prompt: %v", err),
+			})
+		}
+		if prompt == nil {
+			return c.JSON(http.StatusNotFound, models.PromptVersionsResponse{
+				Success: false,
+				Error:   "Prompt not found",
+			})
+		}
+	}
+
+	return c.JSON(http.StatusOK, models.PromptVersionsResponse{
+		Success: true,
+		Data:    versions,
+	})
+}
+
+func (h *Handlers) GetPromptVersion(c echo.Context) error {
+	id, err := parseInt64Param(c, "id")
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, models.PromptVersionResponse{
+			Success: false,
+			Error:   "Invalid prompt ID format",
+		})
+	}
+
+	version, err := parseInt64Param(c, "version")
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, models.PromptVersionResponse{
+			Success: false,
+			Error:   "Invalid version format",
+		})
+	}
+
+	snapshot, err := h.store(c).GetPromptVersion(id, int(version))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, models.PromptVersionResponse{
+			Success: false,
+			Error:   fmt.Sprintf("Failed to retrieve prompt version: %v", err),
+		})
+	}
+
+	if snapshot == nil {
+		return c.JSON(http.StatusNotFound, models.PromptVersionResponse{
+			Success: false,
+			Error:   "Prompt version not found",
+		})
+	}
+
+	return c.JSON(http.StatusOK, models.PromptVersionResponse{
+		Success: true,
+		Data:    snapshot,
+	})
+}
+
+// DiffPromptVersions compares ?from= and ?to= versions, defaulting to the latest version and its
+// predecessor. from=0 compares against an empty prompt.
+func (h *Handlers) DiffPromptVersions(c echo.Context) error {
+	id, err := parseInt64Param(c, "id")
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, models.PromptDiffResponse{
+			Success: false,
+			Error:   "Invalid prompt ID format",
+		})
+	}
+
+	var to *models.PromptVersion
+	if toParam := c.QueryParam("to"); toParam != "" {
+		toVersion, err := strconv.Atoi(toParam)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, models.PromptDiffResponse{
+				Success: false,
+				Error:   "Invalid 'to' version format",
+			})
+		}
+		to, err = h.store(c).GetPromptVersion(id, toVersion)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, models.PromptDiffResponse{
+				Success: false,
+				Error:   fmt.Sprintf("Failed to retrieve prompt version: %v", err),
+			})
+		}
+	} else {
+		to, err = h.store(c).GetLatestPromptVersion(id)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, models.PromptDiffResponse{
+				Success: false,
+				Error:   fmt.Sprintf("Failed to retrieve prompt version: %v", err),
+			})
+		}
+	}
+
+	if to == nil {
+		return c.JSON(http.StatusNotFound, models.PromptDiffResponse{
+			Success: false,
+			Error:   "Prompt version not found",
+		})
+	}
+
+	fromVersion := to.Version - 1
+	if fromParam := c.QueryParam("from"); fromParam != "" {
+		fromVersion, err = strconv.Atoi(fromParam)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, models.PromptDiffResponse{
+				Success: false,
+				Error:   "Invalid 'from' version format",
+			})
+		}
+	}
+
+	// Version 0 is the empty prompt before the first version, so version 1 diffs as all additions
+	from := &models.PromptVersion{PromptID: id, Tags: "[]"}
+	if fromVersion != 0 {
+		from, err = h.store(c).GetPromptVersion(id, fromVersion)
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, models.PromptDiffResponse{
+			Success: false,
+			Error:   fmt.Sprintf("Failed to retrieve prompt version: %v", err),
+		})
+	}
+
+	if from == nil {
+		return c.JSON(http.StatusNotFound, models.PromptDiffResponse{
+			Success: false,
+			Error:   fmt.Sprintf("Prompt version %d not found", fromVersion),
+		})
+	}
+
+	return c.JSON(http.StatusOK, models.PromptDiffResponse{
+		Success: true,
+		Data:    services.DiffPromptVersions(from, to),
+	})
+}
+
+func (h *Handlers) RestorePromptVersion(c echo.Context) error {
+	id, err := parseInt64Param(c, "id")
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, models.PromptResponse{
+			Success: false,
+			Error:   "Invalid prompt ID format",
+		})
+	}
+
+	version, err := parseInt64Param(c, "version")
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, models.PromptResponse{
+			Success: false,
+			Error:   "Invalid version format",
+		})
+	}
+
+	var req models.RestorePromptVersionRequest
+	if c.Request().ContentLength != 0 {
+		if err := c.Bind(&req); err != nil {
+			return c.JSON(http.StatusBadRequest, models.PromptResponse{
+				Success: false,
+				Error:   "Invalid request format",
+			})
+		}
+	}
+
+	prompt, err := h.store(c).RestorePromptVersion(id, int(version), req.Note)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, models.PromptResponse{
+			Success: false,
+			Error:   fmt.Sprintf("Failed to restore prompt version: %v", err),
+		})
+	}
+
+	if prompt == nil {
+		return c.JSON(http.StatusNotFound, models.PromptResponse{
+			Success: false,
+			Error:   "Prompt version not found",
+		})
+	}
+
+	h.syncPrompt(c, prompt.ID, fmt.Sprintf("Restore prompt %q to version %d", prompt.Title, version))
+
+	return c.JSON(http.StatusOK, models.PromptResponse{
+		Success: true,
+		Data:    prompt,
+	})
+}
@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+
+	"promptforge/internal/models"
+)
+
+func TestDiffFirstPromptVersion(t *testing.T) {
+	h, db := setupHandlers(t)
+
+	prompt, err := db.SavePrompt(models.SavePromptRequest{Title: "Greeting", Content: "Hello\nWorld"})
+	if err != nil {
+		t.Fatalf("Failed to save prompt: %v", err)
+	}
+
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetParamNames("id")
+	c.SetParamValues(strconv.FormatInt(prompt.ID, 10))
+	if err := h.DiffPromptVersions(c); err != nil {
+		t.Fatalf("Handler returned error: %v", err)
+	}
+
+	var response models.PromptDiffResponse
+	json.Unmarshal(rec.Body.Bytes(), &response)
+	if rec.Code != http.StatusOK || response.Data == nil {
+		t.Fatalf("Expected the first version to diff against an empty prompt, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if response.Data.FromVersion != 0 || response.Data.ToVersion != 1 || response.Data.Additions != 2 || response.Data.Deletions != 0 {
+		t.Errorf("Expected every line of version 1 as an addition, got %+v", response.Data)
+	}
+}
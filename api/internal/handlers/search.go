@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+
+	"promptforge/internal/models"
+)
+
+// Search handles GET /api/search?q=...&types=prompt,history,conversation&category=&tag=&model=&from=&to=&limit=
+func (h *Handlers) Search(c echo.Context) error {
+	req := models.SearchRequest{
+		Query:    strings.TrimSpace(c.QueryParam("q")),
+		Category: c.QueryParam("category"),
+		Tag:      c.QueryParam("tag"),
+		Model:    c.QueryParam("model"),
+	}
+
+	if req.Query == "" {
+		return c.JSON(http.StatusBadRequest, models.SearchResponse{
+			Success: false,
+			Error:   "Search query 'q' is required",
+		})
+	}
+
+	if types := c.QueryParam("types"); types != "" {
+		for _, t := range strings.Split(types, ",") {
+			switch strings.TrimSpace(t) {
+			case "prompt", "prompts":
+				req.Types = append(req.Types, models.SearchTypePrompt)
+			case "history":
+				req.Types = append(req.Types, models.SearchTypeHistory)
+			case "conversation", "conversations":
+				req.Types = append(req.Types, models.SearchTypeConversation)
+			default:
+				return c.JSON(http.StatusBadRequest, models.SearchResponse{
+					Success: false,
+					Error:   fmt.Sprintf("Unknown search type: %s", t),
+				})
+			}
+		}
+	}
+
+	var err error
+	if req.From, req.To, err = parseDateRange(c); err != nil {
+		return c.JSON(http.StatusBadRequest, models.SearchResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+	}
+
+	if limit := c.QueryParam("limit"); limit != "" {
+		if req.Limit, err = strconv.Atoi(limit); err != nil {
+			return c.JSON(http.StatusBadRequest, models.SearchResponse{
+				Success: false,
+				Error:   "Invalid limit format",
+			})
+		}
+	}
+
+	results, err := h.store(c).Search(req)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, models.SearchResponse{
+			Success: false,
+			Error:   fmt.Sprintf("Failed to search: %v", err),
+		})
+	}
+
+	return c.JSON(http.StatusOK, models.SearchResponse{
+		Success: true,
+		Data:    results,
+	})
+}
@@ -0,0 +1,177 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+
+	"promptforge/internal/database"
+	"promptforge/internal/models"
+)
+
+// Tag and category management handlers
+func (h *Handlers) GetTags(c echo.Context) error {
+	tags, err := h.store(c).ListTags()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, models.LabelCountsResponse{
+			Success: false,
+			Error:   fmt.Sprintf("Failed to retrieve tags: %v", err),
+		})
+	}
+
+	return c.JSON(http.StatusOK, models.LabelCountsResponse{
+		Success: true,
+		Data:    tags,
+	})
+}
+
+func (h *Handlers) RenameTag(c echo.Context) error {
+	var req models.RenameLabelRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, models.RelabelResponse{
+			Success: false,
+			Error:   "Invalid request format",
+		})
+	}
+
+	if strings.TrimSpace(req.From) == "" || strings.TrimSpace(req.To) == "" {
+		return c.JSON(http.StatusBadRequest, models.RelabelResponse{
+			Success: false,
+			Error:   "Both 'from' and 'to' tag names are required",
+		})
+	}
+
+	result, err := h.store(c).RenameTag(req.From, req.To)
+	if err != nil {
+		return relabelError(c, "Failed to rename tag", err)
+	}
+
+	h.syncLibrary(c, fmt.Sprintf("Rename tag %q to %q", req.From, req.To))
+
+	return c.JSON(http.StatusOK, models.RelabelResponse{
+		Success: true,
+		Data:    result,
+	})
+}
+
+func (h *Handlers) MergeTags(c echo.Context) error {
+	var req models.MergeLabelsRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, models.RelabelResponse{
+			Success: false,
+			Error:   "Invalid request format",
+		})
+	}
+
+	if strings.TrimSpace(req.Target) == "" || len(req.Sources) == 0 {
+		return c.JSON(http.StatusBadRequest, models.RelabelResponse{
+			Success: false,
+			Error:   "A target tag and at least one source tag are required",
+		})
+	}
+
+	result, err := h.store(c).MergeTags(req.Sources, req.Target)
+	if err != nil {
+		return relabelError(c, "Failed to merge tags", err)
+	}
+
+	h.syncLibrary(c, fmt.Sprintf("Merge tags into %q", req.Target))
+
+	return c.JSON(http.StatusOK, models.RelabelResponse{
+		Success: true,
+		Data:    result,
+	})
+}
+
+func (h *Handlers) GetCategories(c echo.Context) error {
+	categories, err := h.store(c).ListCategories()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, models.LabelCountsResponse{
+			Success: false,
+			Error:   fmt.Sprintf("Failed to retrieve categories: %v", err),
+		})
+	}
+
+	return c.JSON(http.StatusOK, models.LabelCountsResponse{
+		Success: true,
+		Data:    categories,
+	})
+}
+
+func (h *Handlers) RenameCategory(c echo.Context) error {
+	var req models.RenameLabelRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, models.RelabelResponse{
+			Success: false,
+			Error:   "Invalid request format",
+		})
+	}
+
+	if strings.TrimSpace(req.From) == "" || strings.TrimSpace(req.To) == "" {
+		return c.JSON(http.StatusBadRequest, models.RelabelResponse{
+			Success: false,
+			Error:   "Both 'from' and 'to' category names are required",
+		})
+	}
+
+	result, err := h.store(c).RenameCategory(req.From, req.To)
+	if err != nil {
+		return relabelError(c, "Failed to rename category", err)
+	}
+
+	h.syncLibrary(c, fmt.Sprintf("Rename category %q to %q", req.From, req.To))
+
+	return c.JSON(http.StatusOK, models.RelabelResponse{
+		Success: true,
+		Data:    result,
+	})
+}
+
+func (h *Handlers) MergeCategories(c echo.Context) error {
+	var req models.MergeLabelsRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, models.RelabelResponse{
+			Success: false,
+			Error:   "Invalid request format",
+		})
+	}
+
+	if strings.TrimSpace(req.Target) == "" || len(req.Sources) == 0 {
+		return c.JSON(http.StatusBadRequest, models.RelabelResponse{
+			Success: false,
+			Error:   "A target category and at least one source category are required",
+		})
+	}
+
+	result, err := h.store(c).MergeCategories(req.Sources, req.Target)
+	if err != nil {
+		return relabelError(c, "Failed to merge categories", err)
+	}
+
+	h.syncLibrary(c, fmt.Sprintf("Merge categories into %q", req.Target))
+
+	return c.JSON(http.StatusOK, models.RelabelResponse{
+		Success: true,
+		Data:    result,
+	})
+}
+
+// relabelError maps tag and category errors to 404 for unknown names and 409 for rename clashes
+func relabelError(c echo.Context, message string, err error) error {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, database.ErrTagNotFound), errors.Is(err, database.ErrCategoryNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, database.ErrTagExists):
+		status = http.StatusConflict
+		err = fmt.Errorf("%v; merge the tags instead", err)
+	}
+
+	return c.JSON(status, models.RelabelResponse{
+		Success: false,
+		Error:   fmt.Sprintf("%s: %v", message, err),
+	})
+}
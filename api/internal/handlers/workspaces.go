@@ -0,0 +1,196 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+
+	"promptforge/internal/auth"
+	"promptforge/internal/database"
+	"promptforge/internal/models"
+)
+
+// GetWorkspaces handles GET /api/workspaces, listing the signed-in user's workspaces and their role in each
+func (h *Handlers) GetWorkspaces(c echo.Context) error {
+	user := auth.UserFrom(c)
+	if user == nil {
+		return authDisabled(c)
+	}
+
+	workspaces, err := h.db.ListWorkspaces(user.ID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, models.WorkspacesResponse{
+			Success: false,
+			Error:   fmt.Sprintf("Failed to list workspaces: %v", err),
+		})
+	}
+
+	return c.JSON(http.StatusOK, models.WorkspacesResponse{
+		Success: true,
+		Data:    workspaces,
+	})
+}
+
+// CreateWorkspace handles POST /api/workspaces. The signed-in user becomes its owner.
+func (h *Handlers) CreateWorkspace(c echo.Context) error {
+	user := auth.UserFrom(c)
+	if user == nil {
+		return authDisabled(c)
+	}
+
+	var req models.CreateWorkspaceRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, models.WorkspaceResponse{
+			Success: false,
+			Error:   "Invalid request format",
+		})
+	}
+
+	workspace, err := h.db.CreateWorkspace(req.Name, user.ID)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if req.Name == "" {
+			status = http.StatusBadRequest
+		}
+		return c.JSON(status, models.WorkspaceResponse{
+			Success: false,
+			Error:   fmt.Sprintf("Failed to create workspace: %v", err),
+		})
+	}
+
+	return c.JSON(http.StatusCreated, models.WorkspaceResponse{
+		Success: true,
+		Data:    workspace,
+	})
+}
+
+// GetWorkspaceMembers handles GET /api/workspaces/:id/members (members only)
+func (h *Handlers) GetWorkspaceMembers(c echo.Context) error {
+	workspaceID, _, err := h.workspaceRole(c)
+	if err != nil {
+		return workspaceError(c, err)
+	}
+
+	members, err := h.db.ListWorkspaceMembers(workspaceID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, models.WorkspaceMembersResponse{
+			Success: false,
+			Error:   fmt.Sprintf("Failed to list workspace members: %v", err),
+		})
+	}
+
+	return c.JSON(http.StatusOK, models.WorkspaceMembersResponse{
+		Success: true,
+		Data:    members,
+	})
+}
+
+// SetWorkspaceMember handles PUT /api/workspaces/:id/members, adding a user or changing their role (owners only)
+func (h *Handlers) SetWorkspaceMember(c echo.Context) error {
+	workspaceID, role, err := h.workspaceRole(c)
+	if err != nil {
+		return workspaceError(c, err)
+	}
+	if role != models.RoleOwner {
+		return workspaceOwnersOnly(c, "members")
+	}
+
+	var req models.SetWorkspaceMemberRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "Invalid request format",
+		})
+	}
+	if !database.ValidRole(req.Role) {
+		return c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "Role must be owner, editor or viewer",
+		})
+	}
+
+	member, err := h.db.GetUserByName(req.Username)
+	if err == nil {
+		err = h.db.SetWorkspaceMember(workspaceID, member.ID, req.Role)
+	}
+	if err != nil {
+		return workspaceError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    fmt.Sprintf("%s is now a workspace %s", member.Username, req.Role),
+	})
+}
+
+// RemoveWorkspaceMember handles DELETE /api/workspaces/:id/members/:userId. Owners can remove anyone;
+// other members can only leave.
+func (h *Handlers) RemoveWorkspaceMember(c echo.Context) error {
+	workspaceID, role, err := h.workspaceRole(c)
+	if err != nil {
+		return workspaceError(c, err)
+	}
+
+	userID, err := strconv.ParseInt(c.Param("userId"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "Invalid user ID",
+		})
+	}
+	if role != models.RoleOwner && userID != auth.UserFrom(c).ID {
+		return workspaceOwnersOnly(c, "members")
+	}
+
+	if err := h.db.RemoveWorkspaceMember(workspaceID, userID); err != nil {
+		return workspaceError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    "Workspace member removed",
+	})
+}
+
+// workspaceRole returns the workspace named by the :id parameter and the signed-in user's role in it
+func (h *Handlers) workspaceRole(c echo.Context) (int64, string, error) {
+	user := auth.UserFrom(c)
+	if user == nil {
+		return 0, "", errAuthDisabled
+	}
+	workspaceID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return 0, "", database.ErrWorkspaceNotFound
+	}
+	role, err := h.db.GetWorkspaceRole(workspaceID, user.ID)
+	return workspaceID, role, err
+}
+
+var errAuthDisabled = errors.New("authentication is not enabled")
+
+func workspaceError(c echo.Context, err error) error {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, errAuthDisabled):
+		return authDisabled(c)
+	case errors.Is(err, database.ErrWorkspaceNotFound), errors.Is(err, database.ErrMemberNotFound),
+		errors.Is(err, database.ErrUserNotFound), errors.Is(err, database.ErrCredentialNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, database.ErrLastOwner):
+		status = http.StatusConflict
+	}
+	return c.JSON(status, models.APIResponse{
+		Success: false,
+		Error:   fmt.Sprintf("Workspace request failed: %v", err),
+	})
+}
+
+func workspaceOwnersOnly(c echo.Context, what string) error {
+	return c.JSON(http.StatusForbidden, models.APIResponse{
+		Success: false,
+		Error:   "Only workspace owners can manage " + what,
+	})
+}
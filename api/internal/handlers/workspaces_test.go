@@ -0,0 +1,181 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+
+	"promptforge/internal/auth"
+	"promptforge/internal/config"
+	"promptforge/internal/models"
+)
+
+func TestWorkspaceRoles(t *testing.T) {
+	h, db := setupHandlers(t)
+	config.AppConfig.Auth.Enabled = true
+
+	keys := map[string]string{}
+	for _, name := range []string{"alice", "bob", "carol"} {
+		user, err := db.CreateUser(name, "", false)
+		if err != nil {
+			t.Fatalf("Failed to create user: %v", err)
+		}
+		key, prefix, hash, err := auth.NewAPIKey()
+		if err != nil {
+			t.Fatalf("Failed to generate key: %v", err)
+		}
+		if _, err := db.CreateAPIKey(user.ID, "test", prefix, hash); err != nil {
+			t.Fatalf("Failed to store key: %v", err)
+		}
+		keys[name] = key
+	}
+
+	e := echo.New()
+	e.Use(auth.Middleware(db))
+	e.POST("/api/workspaces", h.CreateWorkspace)
+	e.PUT("/api/workspaces/:id/members", h.SetWorkspaceMember)
+	e.GET("/api/prompts", h.GetSavedPrompts)
+	e.POST("/api/prompts", h.SavePrompt, auth.RequireEditor)
+
+	do := func(user, workspace, method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-API-Key", keys[user])
+		if workspace != "" {
+			req.Header.Set(auth.WorkspaceHeader, workspace)
+		}
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := do("alice", "", http.MethodPost, "/api/workspaces", `{"name": "Team"}`)
+	var created models.WorkspaceResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &created); err != nil || rec.Code != http.StatusCreated {
+		t.Fatalf("Expected the workspace to be created, got %d: %s", rec.Code, rec.Body.String())
+	}
+	workspace := strconv.FormatInt(created.Data.ID, 10)
+	members := "/api/workspaces/" + workspace + "/members"
+
+	if rec := do("alice", "", http.MethodPut, members, `{"username": "bob", "role": "viewer"}`); rec.Code != http.StatusOK {
+		t.Fatalf("Expected the owner to add a viewer, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if rec := do("bob", "", http.MethodPut, members, `{"username": "carol", "role": "editor"}`); rec.Code != http.StatusForbidden {
+		t.Errorf("Expected a viewer not to manage members, got %d", rec.Code)
+	}
+
+	prompt := `{"title": "Shared", "content": "Hello", "category": "General"}`
+	if rec := do("bob", workspace, http.MethodPost, "/api/prompts", prompt); rec.Code != http.StatusForbidden {
+		t.Errorf("Expected a viewer not to save prompts, got %d", rec.Code)
+	}
+	if rec := do("alice", workspace, http.MethodPost, "/api/prompts", prompt); rec.Code != http.StatusCreated {
+		t.Fatalf("Expected the owner to save a prompt, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if rec := do("bob", "", http.MethodPost, "/api/prompts", prompt); rec.Code != http.StatusCreated {
+		t.Errorf("Expected a viewer to keep editing their own library, got %d", rec.Code)
+	}
+
+	var library models.PromptLibraryResponse
+	rec = do("bob", workspace, http.MethodGet, "/api/prompts", "")
+	if err := json.Unmarshal(rec.Body.Bytes(), &library); err != nil || len(library.Data) != 1 || library.Data[0].Content != "Hello" {
+		t.Errorf("Expected the viewer to read the workspace prompt, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if rec := do("carol", workspace, http.MethodGet, "/api/prompts", ""); rec.Code != http.StatusForbidden {
+		t.Errorf("Expected a non-member to be refused, got %d", rec.Code)
+	}
+}
+
+func TestWorkspaceCredentials(t *testing.T) {
+	h, db := setupHandlers(t)
+	config.AppConfig.Auth.Enabled = true
+
+	var keysUsed []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		keysUsed = append(keysUsed, r.Header.Get("Authorization"))
+		w.Write([]byte(`{"choices": [{"message": {"role": "assistant", "content": "Hello"}}]}`))
+	}))
+	defer server.Close()
+	config.Current().DefaultProvider = config.ProviderOpenAI
+	config.Current().OpenAI = config.OpenAIConfig{APIKey: "test-key", BaseURL: server.URL}
+
+	keys := map[string]string{}
+	for _, name := range []string{"alice", "bob"} {
+		user, _ := db.CreateUser(name, "", false)
+		key, prefix, hash, _ := auth.NewAPIKey()
+		db.CreateAPIKey(user.ID, "test", prefix, hash)
+		keys[name] = key
+	}
+
+	e := echo.New()
+	e.Use(auth.Middleware(db))
+	e.Use(h.WorkspaceCredentials)
+	e.POST("/api/workspaces", h.CreateWorkspace)
+	e.PUT("/api/workspaces/:id/members", h.SetWorkspaceMember)
+	e.GET("/api/workspaces/:id/credentials", h.GetWorkspaceCredentials)
+	e.PUT("/api/workspaces/:id/credentials/:provider", h.SetWorkspaceCredential)
+	e.POST("/api/execute", h.ExecutePrompt)
+
+	do := func(user, workspace, method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-API-Key", keys[user])
+		if workspace != "" {
+			req.Header.Set(auth.WorkspaceHeader, workspace)
+		}
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	var created models.WorkspaceResponse
+	json.Unmarshal(do("alice", "", http.MethodPost, "/api/workspaces", `{"name": "Team"}`).Body.Bytes(), &created)
+	workspace := strconv.FormatInt(created.Data.ID, 10)
+	credentials := "/api/workspaces/" + workspace + "/credentials"
+	do("alice", "", http.MethodPut, "/api/workspaces/"+workspace+"/members", `{"username": "bob", "role": "editor"}`)
+
+	if rec := do("alice", "", http.MethodPut, credentials+"/openai", `{"api_key": "sk-workspace"}`); rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected credentials to need a master key, got %d: %s", rec.Code, rec.Body.String())
+	}
+	config.Current().Encryption.MasterKey = "MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY="
+
+	if rec := do("bob", "", http.MethodPut, credentials+"/openai", `{"api_key": "sk-bob"}`); rec.Code != http.StatusForbidden {
+		t.Errorf("Expected an editor not to manage credentials, got %d", rec.Code)
+	}
+	if rec := do("alice", "", http.MethodPut, credentials+"/vault", `{"api_key": "sk-workspace"}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected an unknown provider to be rejected, got %d", rec.Code)
+	}
+	for _, baseURL := range []string{"http://8.8.8.8", "https://127.0.0.1:8080", "https://169.254.169.254"} {
+		if rec := do("alice", "", http.MethodPut, credentials+"/openai", `{"api_key": "sk-workspace", "base_url": "`+baseURL+`"}`); rec.Code != http.StatusBadRequest {
+			t.Errorf("Expected base URL %s to be rejected, got %d", baseURL, rec.Code)
+		}
+	}
+	if rec := do("alice", "", http.MethodPut, credentials+"/openai", `{"api_key": "sk-workspace"}`); rec.Code != http.StatusOK || strings.Contains(rec.Body.String(), "sk-workspace") {
+		t.Fatalf("Expected the owner to store the key without it being returned, got %d: %s", rec.Code, rec.Body.String())
+	}
+	stored, _ := db.ListWorkspaceCredentials(created.Data.ID)
+	if len(stored) != 1 || stored[0].APIKey == "sk-workspace" {
+		t.Errorf("Expected the key to be stored encrypted, got %+v", stored)
+	}
+	if rec := do("bob", "", http.MethodGet, credentials, ""); rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"provider":"openai"`) || strings.Contains(rec.Body.String(), "api_key") {
+		t.Errorf("Expected members to see which providers have keys, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	run := `{"prompt": "Hi", "model": "gpt-4"}`
+	if rec := do("bob", workspace, http.MethodPost, "/api/execute", run); rec.Code != http.StatusOK {
+		t.Fatalf("Expected the execution to succeed, got %d: %s", rec.Code, rec.Body.String())
+	}
+	do("bob", "", http.MethodPost, "/api/execute", run)
+	if strings.Join(keysUsed, ",") != "Bearer sk-workspace,Bearer test-key" {
+		t.Errorf("Expected the workspace's key in the workspace and the server's outside it, got %v", keysUsed)
+	}
+
+	config.Current().Encryption.MasterKey = "ZmVkY2JhOTg3NjU0MzIxMGZlZGNiYTk4NzY1NDMyMTA="
+	if rec := do("bob", workspace, http.MethodPost, "/api/execute", run); rec.Code == http.StatusOK || len(keysUsed) != 2 {
+		t.Errorf("Expected a key that cannot be decrypted not to fall back to the server's, got %d and %v", rec.Code, keysUsed)
+	}
+}
@@ -0,0 +1,90 @@
+// Package library converts the portable prompt library between JSON, YAML and
+// Markdown files with YAML frontmatter.
+package library
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"promptforge/internal/models"
+)
+
+// ParseFormat normalizes a format name, accepting common aliases such as "yml" and "md"
+func ParseFormat(format string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(format)) {
+	case "", models.LibraryFormatJSON:
+		return models.LibraryFormatJSON, nil
+	case models.LibraryFormatYAML, "yml":
+		return models.LibraryFormatYAML, nil
+	case models.LibraryFormatMarkdown, "md", "zip":
+		return models.LibraryFormatMarkdown, nil
+	default:
+		return "", fmt.Errorf("unknown library format %q (expected json, yaml or markdown)", format)
+	}
+}
+
+// FormatFromPath guesses the format of an import source from its extension. Directories hold Markdown files.
+func FormatFromPath(path string, isDir bool) string {
+	if isDir {
+		return models.LibraryFormatMarkdown
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return models.LibraryFormatYAML
+	case ".zip", ".md":
+		return models.LibraryFormatMarkdown
+	default:
+		return models.LibraryFormatJSON
+	}
+}
+
+// Encode writes the library as a single JSON or YAML document
+func Encode(w io.Writer, format string, library *models.Library) error {
+	switch format {
+	case models.LibraryFormatJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(library); err != nil {
+			return fmt.Errorf("failed to encode library as JSON: %v", err)
+		}
+		return nil
+	case models.LibraryFormatYAML:
+		encoder := yaml.NewEncoder(w)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(library); err != nil {
+			return fmt.Errorf("failed to encode library as YAML: %v", err)
+		}
+		return encoder.Close()
+	default:
+		return fmt.Errorf("format %q is not a single-document format", format)
+	}
+}
+
+// Decode parses a JSON or YAML library document
+func Decode(data []byte, format string) (*models.Library, error) {
+	var library models.Library
+	switch format {
+	case models.LibraryFormatJSON:
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&library); err != nil {
+			return nil, fmt.Errorf("failed to parse JSON library: %v", err)
+		}
+	case models.LibraryFormatYAML:
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		if err := decoder.Decode(&library); err != nil && err != io.EOF {
+			return nil, fmt.Errorf("failed to parse YAML library: %v", err)
+		}
+	default:
+		return nil, fmt.Errorf("format %q is not a single-document format", format)
+	}
+
+	return &library, nil
+}
@@ -0,0 +1,124 @@
+package library
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"promptforge/internal/models"
+)
+
+func sampleLibrary() *models.Library {
+	created := time.Date(2024, 3, 1, 9, 30, 0, 0, time.UTC)
+	return &models.Library{
+		SchemaVersion: models.LibrarySchemaVersion,
+		ExportedAt:    created,
+		Prompts: []models.LibraryPrompt{
+			{
+				Title:      "Code Review",
+				Category:   "Engineering",
+				Tags:       []string{"review", "go"},
+				Variables:  []models.TemplateVariable{{Name: "diff", Type: models.VariableTypeString, Required: true}},
+				UsageCount: 3,
+				CreatedAt:  created,
+				UpdatedAt:  created,
+				Content:    "Review this diff:\n\n---\n{{diff}}\n",
+				Versions: []models.LibraryPromptVersion{
+					{Version: 1, Title: "Code Review", Tags: []string{"review"}, CreatedAt: created, Content: "Review {{diff}}", Note: "Initial version"},
+				},
+			},
+			{Title: "Code review", Content: "Same slug, different title", CreatedAt: created, UpdatedAt: created},
+		},
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	for input, expected := range map[string]string{"": "json", "YML": "yaml", "md": "markdown", "markdown": "markdown"} {
+		if format, err := ParseFormat(input); err != nil || format != expected {
+			t.Errorf("ParseFormat(%q) = %q, %v; expected %q", input, format, err, expected)
+		}
+	}
+	if _, err := ParseFormat("csv"); err == nil {
+		t.Error("Expected an error for an unknown format")
+	}
+}
+
+func TestEncodeDecode(t *testing.T) {
+	for _, format := range []string{models.LibraryFormatJSON, models.LibraryFormatYAML} {
+		t.Run(format, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := Encode(&buf, format, sampleLibrary()); err != nil {
+				t.Fatalf("Failed to encode: %v", err)
+			}
+
+			decoded, err := Decode(buf.Bytes(), format)
+			if err != nil {
+				t.Fatalf("Failed to decode: %v", err)
+			}
+			if !reflect.DeepEqual(decoded, sampleLibrary()) {
+				t.Errorf("Expected round trip to preserve the library, got %+v", decoded)
+			}
+		})
+	}
+
+	if _, err := Decode([]byte(`{"prompts": [], "unexpected": true}`), models.LibraryFormatJSON); err == nil {
+		t.Error("Expected unknown fields to be rejected")
+	}
+}
+
+func TestMarkdownDirectoryRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	original := sampleLibrary()
+	if err := WriteDir(dir, original); err != nil {
+		t.Fatalf("Failed to write directory: %v", err)
+	}
+
+	for _, name := range []string{"code-review.md", "code-review-2.md"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Errorf("Expected %s to be written: %v", name, err)
+		}
+	}
+
+	read, err := ReadDir(dir)
+	if err != nil {
+		t.Fatalf("Failed to read directory: %v", err)
+	}
+	// Files are read in path order, so code-review-2.md comes first
+	if len(read.Prompts) != 2 || !reflect.DeepEqual(read.Prompts[1], original.Prompts[0]) {
+		t.Errorf("Expected prompts to survive the round trip, got %+v", read.Prompts)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteZip(&buf, original); err != nil {
+		t.Fatalf("Failed to write zip: %v", err)
+	}
+	zipped, err := ReadZip(buf.Bytes())
+	if err != nil {
+		t.Fatalf("Failed to read zip: %v", err)
+	}
+	if !reflect.DeepEqual(zipped.Prompts, read.Prompts) {
+		t.Errorf("Expected zip and directory layouts to match, got %+v", zipped.Prompts)
+	}
+}
+
+func TestUnmarshalHandWrittenMarkdown(t *testing.T) {
+	data := []byte("---\r\ncategory: Writing\r\ntags: [blog]\r\n---\r\n\r\nWrite a post about {{topic}}\r\n")
+
+	prompt, err := UnmarshalMarkdown(data, "prompts/blog-post.md")
+	if err != nil {
+		t.Fatalf("Failed to parse markdown: %v", err)
+	}
+	if prompt.Title != "blog-post" || prompt.Category != "Writing" || prompt.Content != "Write a post about {{topic}}" {
+		t.Errorf("Unexpected prompt: %+v", prompt)
+	}
+
+	if _, err := UnmarshalMarkdown([]byte("No frontmatter"), "x.md"); err == nil {
+		t.Error("Expected an error for a file without frontmatter")
+	}
+	if _, err := UnmarshalMarkdown([]byte("---\nowner: someone\n---\nBody"), "x.md"); err == nil {
+		t.Error("Expected unknown frontmatter fields to be rejected")
+	}
+}
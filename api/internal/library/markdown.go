@@ -0,0 +1,268 @@
+package library
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"promptforge/internal/models"
+)
+
+const frontmatterDelimiter = "---\n"
+
+// frontmatter holds every prompt field except the content, which is the Markdown body.
+// Usage stats and timestamps are omitted when zero so git-synced files only hold reviewable fields.
+type frontmatter struct {
+	Title       string                        `yaml:"title"`
+	Description string                        `yaml:"description,omitempty"`
+	Category    string                        `yaml:"category,omitempty"`
+	Tags        []string                      `yaml:"tags,omitempty"`
+	Variables   []models.TemplateVariable     `yaml:"variables,omitempty"`
+	UsageCount  int                           `yaml:"usage_count,omitempty"`
+	CreatedAt   time.Time                     `yaml:"created_at,omitempty"`
+	UpdatedAt   time.Time                     `yaml:"updated_at,omitempty"`
+	Versions    []models.LibraryPromptVersion `yaml:"versions,omitempty"`
+}
+
+// MarshalMarkdown renders a prompt as YAML frontmatter followed by its content
+func MarshalMarkdown(prompt *models.LibraryPrompt) ([]byte, error) {
+	meta, err := yaml.Marshal(frontmatter{
+		Title:       prompt.Title,
+		Description: prompt.Description,
+		Category:    prompt.Category,
+		Tags:        prompt.Tags,
+		Variables:   prompt.Variables,
+		UsageCount:  prompt.UsageCount,
+		CreatedAt:   prompt.CreatedAt,
+		UpdatedAt:   prompt.UpdatedAt,
+		Versions:    prompt.Versions,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode frontmatter for %q: %v", prompt.Title, err)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(frontmatterDelimiter)
+	buf.Write(meta)
+	buf.WriteString(frontmatterDelimiter)
+	buf.WriteString("\n")
+	buf.WriteString(prompt.Content)
+	buf.WriteString("\n")
+	return buf.Bytes(), nil
+}
+
+// UnmarshalMarkdown parses a prompt file. The title falls back to the file name when the frontmatter has none.
+func UnmarshalMarkdown(data []byte, name string) (*models.LibraryPrompt, error) {
+	text := strings.ReplaceAll(string(data), "\r\n", "\n")
+	if !strings.HasPrefix(text, frontmatterDelimiter) {
+		return nil, fmt.Errorf("%s: missing YAML frontmatter", name)
+	}
+
+	rest := text[len(frontmatterDelimiter):]
+	end := strings.Index(rest, "\n"+frontmatterDelimiter)
+	var meta, body string
+	switch {
+	case strings.HasPrefix(rest, frontmatterDelimiter):
+		body = rest[len(frontmatterDelimiter):]
+	case end >= 0:
+		meta, body = rest[:end+1], rest[end+1+len(frontmatterDelimiter):]
+	default:
+		return nil, fmt.Errorf("%s: unterminated YAML frontmatter", name)
+	}
+
+	var fm frontmatter
+	decoder := yaml.NewDecoder(strings.NewReader(meta))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&fm); err != nil && err != io.EOF {
+		return nil, fmt.Errorf("%s: invalid frontmatter: %v", name, err)
+	}
+
+	if fm.Title == "" {
+		fm.Title = strings.TrimSuffix(filepath.Base(name), filepath.Ext(name))
+	}
+
+	return &models.LibraryPrompt{
+		Title:       fm.Title,
+		Description: fm.Description,
+		Category:    fm.Category,
+		Tags:        fm.Tags,
+		Variables:   fm.Variables,
+		UsageCount:  fm.UsageCount,
+		CreatedAt:   fm.CreatedAt,
+		UpdatedAt:   fm.UpdatedAt,
+		Content:     strings.TrimSuffix(strings.TrimPrefix(body, "\n"), "\n"),
+		Versions:    fm.Versions,
+	}, nil
+}
+
+// Filenames assigns each prompt a stable, unique Markdown file name derived from its title
+func Filenames(prompts []models.LibraryPrompt) []string {
+	used := make(map[string]bool, len(prompts))
+	names := make([]string, len(prompts))
+	for i := range prompts {
+		base := Slug(prompts[i].Title)
+		name := base + ".md"
+		for n := 2; used[name]; n++ {
+			name = fmt.Sprintf("%s-%d.md", base, n)
+		}
+		used[name] = true
+		names[i] = name
+	}
+	return names
+}
+
+// Slug turns a title into a lowercase, dash-separated file name stem
+func Slug(title string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(title) {
+		if r >= 'a' && r <= 'z' || r >= '0' && r <= '9' {
+			b.WriteRune(r)
+			dash = false
+		} else if !dash && b.Len() > 0 {
+			b.WriteByte('-')
+			dash = true
+		}
+	}
+
+	slug := strings.TrimSuffix(b.String(), "-")
+	if len(slug) > 80 {
+		slug = strings.TrimSuffix(slug[:80], "-")
+	}
+	if slug == "" {
+		slug = "prompt"
+	}
+	return slug
+}
+
+// WriteDir writes one Markdown file per prompt into dir, creating it if needed
+func WriteDir(dir string, library *models.Library) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create %s: %v", dir, err)
+	}
+
+	for i, name := range Filenames(library.Prompts) {
+		data, err := MarshalMarkdown(&library.Prompts[i])
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0o644); err != nil {
+			return fmt.Errorf("failed to write %s: %v", name, err)
+		}
+	}
+
+	return nil
+}
+
+// ReadDir parses every .md file below dir, in path order
+func ReadDir(dir string) (*models.Library, error) {
+	var paths []string
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() && path != dir && strings.HasPrefix(entry.Name(), ".") {
+			return filepath.SkipDir // e.g. .git
+		}
+		if !entry.IsDir() && strings.EqualFold(filepath.Ext(path), ".md") {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %v", dir, err)
+	}
+	sort.Strings(paths)
+
+	library := &models.Library{SchemaVersion: models.LibrarySchemaVersion}
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %v", path, err)
+		}
+		prompt, err := UnmarshalMarkdown(data, path)
+		if err != nil {
+			return nil, err
+		}
+		library.Prompts = append(library.Prompts, *prompt)
+	}
+
+	return library, nil
+}
+
+// WriteZip writes the Markdown directory layout as a zip archive, for download over HTTP
+func WriteZip(w io.Writer, library *models.Library) error {
+	archive := zip.NewWriter(w)
+	for i, name := range Filenames(library.Prompts) {
+		data, err := MarshalMarkdown(&library.Prompts[i])
+		if err != nil {
+			return err
+		}
+		file, err := archive.Create(name)
+		if err != nil {
+			return fmt.Errorf("failed to add %s to archive: %v", name, err)
+		}
+		if _, err := file.Write(data); err != nil {
+			return fmt.Errorf("failed to write %s to archive: %v", name, err)
+		}
+	}
+
+	if err := archive.Close(); err != nil {
+		return fmt.Errorf("failed to finish archive: %v", err)
+	}
+	return nil
+}
+
+// ReadZip parses every .md file in a zip archive produced by WriteZip or by zipping a library directory
+func ReadZip(data []byte) (*models.Library, error) {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open zip archive: %v", err)
+	}
+
+	files := make([]*zip.File, 0, len(archive.File))
+	for _, file := range archive.File {
+		if !file.FileInfo().IsDir() && strings.EqualFold(filepath.Ext(file.Name), ".md") {
+			files = append(files, file)
+		}
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Name < files[j].Name })
+
+	library := &models.Library{SchemaVersion: models.LibrarySchemaVersion}
+	for _, file := range files {
+		content, err := readZipFile(file)
+		if err != nil {
+			return nil, err
+		}
+		prompt, err := UnmarshalMarkdown(content, file.Name)
+		if err != nil {
+			return nil, err
+		}
+		library.Prompts = append(library.Prompts, *prompt)
+	}
+
+	return library, nil
+}
+
+func readZipFile(file *zip.File) ([]byte, error) {
+	reader, err := file.Open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %v", file.Name, err)
+	}
+	defer reader.Close()
+
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %v", file.Name, err)
+	}
+	return data, nil
+}
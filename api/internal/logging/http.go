@@ -0,0 +1,51 @@
+package logging
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Middleware logs one entry per request once it has been handled and makes the request ID (from
+// Echo's RequestID middleware, which must run first) available to logs written while handling it
+func Middleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			start := time.Now()
+			req := c.Request()
+			if id := c.Response().Header().Get(echo.HeaderXRequestID); id != "" {
+				c.SetRequest(req.WithContext(WithRequestID(req.Context(), id)))
+			}
+
+			err := next(c)
+			if err != nil {
+				c.Error(err)
+			}
+
+			status := c.Response().Status
+			level := slog.LevelInfo
+			switch {
+			case status >= 500:
+				level = slog.LevelError
+			case status >= 400:
+				level = slog.LevelWarn
+			}
+			attrs := []slog.Attr{
+				slog.String("method", req.Method),
+				slog.String("route", c.Path()),
+				slog.String("path", req.URL.Path),
+				slog.Int("status", status),
+				slog.Float64("duration_ms", float64(time.Since(start).Microseconds())/1000),
+				slog.Int64("bytes_out", c.Response().Size),
+				slog.String("remote_ip", c.RealIP()),
+			}
+			if err != nil {
+				attrs = append(attrs, slog.String("error", err.Error()))
+			}
+			// The handler may have replaced the request's context, e.g. to add a trace span
+			slog.LogAttrs(c.Request().Context(), level, "request", attrs...)
+			return nil
+		}
+	}
+}
@@ -0,0 +1,157 @@
+// Package logging writes structured logs with log/slog. Entries carry the request ID and trace ID of
+// the request they belong to, and credentials (and optionally prompt text) are redacted.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+
+	"go.opentelemetry.io/otel/trace"
+
+	"promptforge/internal/config"
+)
+
+// New returns a logger for cfg. secrets returns the credential values to scrub from every entry; it
+// is called per entry so changed credentials are picked up.
+func New(w io.Writer, cfg config.LoggingConfig, secrets func() []string) (*slog.Logger, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(cfg.Level)); err != nil {
+		return nil, fmt.Errorf("invalid LOG_LEVEL %q, expected debug, info, warn or error", cfg.Level)
+	}
+
+	options := &slog.HandlerOptions{Level: level}
+	var handler slog.Handler
+	switch strings.ToLower(cfg.Format) {
+	case "", "json":
+		handler = slog.NewJSONHandler(w, options)
+	case "text":
+		handler = slog.NewTextHandler(w, options)
+	default:
+		return nil, fmt.Errorf("invalid LOG_FORMAT %q, expected json or text", cfg.Format)
+	}
+
+	return slog.New(&redactingHandler{next: handler, secrets: secrets, redactPrompts: cfg.RedactPrompts}), nil
+}
+
+type requestIDKey struct{}
+
+// WithRequestID returns a context whose log entries carry id
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID returns the ID of the request ctx belongs to, or ""
+func RequestID(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// IsSecretKey matches names such as password, api_key and session_token, but not max_tokens
+func IsSecretKey(name string) bool {
+	name = strings.ToLower(name)
+	switch name[strings.LastIndex(name, "_")+1:] {
+	case "password", "secret", "token", "key", "apikey", "authorization":
+		return true
+	}
+	return false
+}
+
+// promptKeys hold prompt or model output text, redacted when LOG_REDACT_PROMPTS is set
+var promptKeys = map[string]bool{
+	"prompt":        true,
+	"system_prompt": true,
+	"messages":      true,
+	"content":       true,
+	"response":      true,
+	"response_body": true,
+}
+
+// redactingHandler adds request context to entries and redacts them before passing them on
+type redactingHandler struct {
+	next          slog.Handler
+	secrets       func() []string
+	redactPrompts bool
+}
+
+func (h *redactingHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *redactingHandler) Handle(ctx context.Context, r slog.Record) error {
+	secrets := h.secretValues()
+	out := slog.NewRecord(r.Time, r.Level, scrub(r.Message, secrets), r.PC)
+	if id := RequestID(ctx); id != "" {
+		out.AddAttrs(slog.String("request_id", id))
+	}
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		out.AddAttrs(slog.String("trace_id", sc.TraceID().String()))
+	}
+	r.Attrs(func(a slog.Attr) bool {
+		out.AddAttrs(h.redact(a, secrets))
+		return true
+	})
+	return h.next.Handle(ctx, out)
+}
+
+func (h *redactingHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	secrets := h.secretValues()
+	redacted := make([]slog.Attr, len(attrs))
+	for i, a := range attrs {
+		redacted[i] = h.redact(a, secrets)
+	}
+	return &redactingHandler{next: h.next.WithAttrs(redacted), secrets: h.secrets, redactPrompts: h.redactPrompts}
+}
+
+func (h *redactingHandler) WithGroup(name string) slog.Handler {
+	return &redactingHandler{next: h.next.WithGroup(name), secrets: h.secrets, redactPrompts: h.redactPrompts}
+}
+
+func (h *redactingHandler) secretValues() []string {
+	if h.secrets == nil {
+		return nil
+	}
+	return h.secrets()
+}
+
+func (h *redactingHandler) redact(a slog.Attr, secrets []string) slog.Attr {
+	value := a.Value.Resolve()
+	switch {
+	case value.Kind() == slog.KindGroup:
+		group := value.Group()
+		redacted := make([]any, len(group))
+		for i, member := range group {
+			redacted[i] = h.redact(member, secrets)
+		}
+		return slog.Group(a.Key, redacted...)
+	case IsSecretKey(a.Key):
+		return slog.String(a.Key, "[redacted]")
+	case h.redactPrompts && promptKeys[a.Key]:
+		return slog.String(a.Key, fmt.Sprintf("[redacted, %d bytes]", len(valueText(value))))
+	case value.Kind() == slog.KindString || value.Kind() == slog.KindAny:
+		return slog.String(a.Key, scrub(valueText(value), secrets))
+	}
+	return slog.Attr{Key: a.Key, Value: value}
+}
+
+func valueText(value slog.Value) string {
+	if err, ok := value.Any().(error); ok {
+		return err.Error()
+	}
+	return value.String()
+}
+
+// scrub replaces every occurrence of a secret in s
+func scrub(s string, secrets []string) string {
+	for _, secret := range secrets {
+		if secret != "" {
+			s = strings.ReplaceAll(s, secret, "[redacted]")
+		}
+	}
+	return s
+}
@@ -0,0 +1,86 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+
+	"promptforge/internal/config"
+)
+
+func TestRedaction(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := New(&buf, config.LoggingConfig{Level: "debug", RedactPrompts: true}, func() []string { return []string{"sk-secret"} })
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+
+	ctx := WithRequestID(context.Background(), "req-1")
+	logger.With("api_key", "sk-other").InfoContext(ctx, "calling sk-secret",
+		"error", errors.New("401: invalid key sk-secret"),
+		"prompt", "Write a poem",
+		"max_tokens", 100,
+		slog.Group("request", "authorization", "Bearer sk-other"),
+	)
+
+	line := buf.String()
+	for _, leaked := range []string{"sk-secret", "sk-other", "Write a poem"} {
+		if strings.Contains(line, leaked) {
+			t.Errorf("Expected %q to be redacted, got %s", leaked, line)
+		}
+	}
+	var entry map[string]interface{}
+	if err := json.Unmarshal([]byte(line), &entry); err != nil {
+		t.Fatalf("Expected a JSON entry, got %s", line)
+	}
+	if entry["request_id"] != "req-1" || entry["max_tokens"] != float64(100) || entry["prompt"] != "[redacted, 12 bytes]" {
+		t.Errorf("Expected the request ID and other values to be kept, got %v", entry)
+	}
+
+	if _, err := New(&buf, config.LoggingConfig{Level: "loud"}, nil); err == nil {
+		t.Error("Expected an unknown level to be rejected")
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	var buf bytes.Buffer
+	logger, _ := New(&buf, config.LoggingConfig{Level: "info"}, nil)
+	previous := slog.Default()
+	slog.SetDefault(logger)
+	defer slog.SetDefault(previous)
+
+	e := echo.New()
+	e.Use(middleware.RequestID())
+	e.Use(Middleware())
+	e.GET("/api/things/:id", func(c echo.Context) error {
+		slog.InfoContext(c.Request().Context(), "handling")
+		return echo.NewHTTPError(http.StatusNotFound, "missing")
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/things/7", nil)
+	req.Header.Set(echo.HeaderXRequestID, "abc123")
+	e.ServeHTTP(httptest.NewRecorder(), req)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected a handler entry and a request entry, got %q", lines)
+	}
+	var handled, request map[string]interface{}
+	json.Unmarshal([]byte(lines[0]), &handled)
+	json.Unmarshal([]byte(lines[1]), &request)
+	if handled["request_id"] != "abc123" || request["request_id"] != "abc123" {
+		t.Errorf("Expected both entries to carry the request ID, got %v and %v", handled, request)
+	}
+	if request["route"] != "/api/things/:id" || request["status"] != float64(404) || request["level"] != "WARN" {
+		t.Errorf("Expected the route, status and level of the request, got %v", request)
+	}
+}
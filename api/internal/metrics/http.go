@@ -0,0 +1,36 @@
+package metrics
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var httpRequestDuration = Factory.NewHistogramVec(prometheus.HistogramOpts{
+	Name:    "promptforge_http_request_duration_seconds",
+	Help:    "Time taken to handle HTTP requests, by route pattern.",
+	Buckets: DefBuckets,
+}, []string{"method", "route", "status"})
+
+// Middleware times every request by its route pattern, so IDs in paths do not multiply the series
+func Middleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			start := time.Now()
+			err := next(c)
+			if err != nil {
+				c.Error(err)
+			}
+
+			route := c.Path()
+			if route == "" {
+				route = "unmatched"
+			}
+			httpRequestDuration.WithLabelValues(c.Request().Method, route, strconv.Itoa(c.Response().Status)).
+				Observe(time.Since(start).Seconds())
+			return nil
+		}
+	}
+}
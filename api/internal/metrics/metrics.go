@@ -0,0 +1,32 @@
+// Package metrics holds the Prometheus registry that the server's metrics are registered with and
+// serves it for scraping
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// DefBuckets are latency buckets in seconds, from 5ms to 2 minutes to cover provider calls
+var DefBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 120}
+
+// Registry holds the server's metrics, along with the Go runtime and process collectors
+var Registry = newRegistry()
+
+// Factory creates metrics registered with Registry
+var Factory = promauto.With(Registry)
+
+func newRegistry() *prometheus.Registry {
+	r := prometheus.NewRegistry()
+	r.MustRegister(collectors.NewGoCollector(), collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}))
+	return r
+}
+
+// Handler serves Registry for Prometheus to scrape
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
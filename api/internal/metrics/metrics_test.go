@@ -0,0 +1,47 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+func TestMiddleware(t *testing.T) {
+	e := echo.New()
+	e.Use(Middleware())
+	e.GET("/api/prompts/:id", func(c echo.Context) error {
+		return echo.NewHTTPError(http.StatusNotFound)
+	})
+	e.GET("/metrics", echo.WrapHandler(Handler()))
+
+	series := httpRequestDuration.WithLabelValues(http.MethodGet, "/api/prompts/:id", "404")
+	before := sampleCount(t, series)
+	e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/prompts/42", nil))
+	if got := sampleCount(t, series); got != before+1 {
+		t.Errorf("Expected the request to be counted under its route pattern, got %d", got)
+	}
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body := rec.Body.String()
+	if !strings.Contains(body, `promptforge_http_request_duration_seconds_count{method="GET",route="/api/prompts/:id",status="404"}`) {
+		t.Errorf("Expected /metrics to expose the request, got:\n%s", body)
+	}
+	if !strings.Contains(body, "go_goroutines") {
+		t.Error("Expected /metrics to include the Go runtime metrics")
+	}
+}
+
+func sampleCount(t *testing.T, observer prometheus.Observer) uint64 {
+	t.Helper()
+	var m dto.Metric
+	if err := observer.(prometheus.Metric).Write(&m); err != nil {
+		t.Fatalf("Failed to read histogram: %v", err)
+	}
+	return m.GetHistogram().GetSampleCount()
+}
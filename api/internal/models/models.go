@@ -20,6 +20,16 @@ type OpenAIResponse struct {
 	Choices []struct {
 		Message Message `json:"message"`
 	} `json:"choices"`
+	Usage *TokenUsage `json:"usage,omitempty"`
+}
+
+// Completion is a model's reply with the details recorded in execution history
+type Completion struct {
+	Content  string
+	Provider string
+	Model    string
+	Usage    *TokenUsage // nil when the provider does not report usage
+	Cost     float64     // Estimated cost in USD, zero for models without known pricing
 }
 
 // API Request structures
@@ -29,16 +39,70 @@ type CritiqueRequest struct {
 }
 
 type ExecuteRequest struct {
-	Prompt      string  `json:"prompt"`
-	Model       string  `json:"model,omitempty"`
-	Temperature float64 `json:"temperature"`
-	MaxTokens   int     `json:"max_tokens,omitempty"`
-}
-
+	Prompt       string                 `json:"prompt"`
+	SystemPrompt string                 `json:"system_prompt,omitempty"`
+	Model        string                 `json:"model,omitempty"`
+	Temperature  float64                `json:"temperature"`
+	MaxTokens    int                    `json:"max_tokens,omitempty"`
+	PromptID     int64                  `json:"prompt_id,omitempty"` // Render a saved prompt instead of Prompt
+	Variables    map[string]interface{} `json:"variables,omitempty"`
+}
+
+// ExecuteResponse is the model's reply with the details recorded in history
+type ExecuteResponse struct {
+	Success    bool        `json:"success"`
+	Data       string      `json:"data,omitempty"`
+	HistoryID  int64       `json:"history_id,omitempty"`
+	Provider   string      `json:"provider,omitempty"`
+	LatencyMs  int64       `json:"latency_ms,omitempty"`
+	TokenUsage *TokenUsage `json:"token_usage,omitempty"`
+	Cost       float64     `json:"cost,omitempty"`
+	Error      string      `json:"error,omitempty"`
+}
+
+// PromptEngineerRequest either carries the whole exchange in Messages, or names a ConversationID and
+// sends only the new user Message; the server then loads the history and saves both turns.
 type PromptEngineerRequest struct {
-	Messages    []Message `json:"messages"`
-	Model       string    `json:"model,omitempty"`
-	Temperature float64   `json:"temperature"`
+	Messages       []Message `json:"messages"`
+	Model          string    `json:"model,omitempty"`
+	Temperature    float64   `json:"temperature"`
+	ConversationID string    `json:"conversation_id,omitempty"`
+	Message        string    `json:"message,omitempty"`
+	SystemPrompt   string    `json:"system_prompt,omitempty"` // Defaults to the built-in prompt engineer instructions
+	Revision       *int      `json:"revision,omitempty"`
+}
+
+// PromptEngineerResponse carries the reply in Data, and for server-side sessions the saved conversation
+// and how its history was fitted into the model's context window
+type PromptEngineerResponse struct {
+	Success      bool           `json:"success"`
+	Data         string         `json:"data,omitempty"`
+	Conversation *Conversation  `json:"conversation,omitempty"`
+	Context      *ContextReport `json:"context,omitempty"`
+	Error        string         `json:"error,omitempty"`
+}
+
+// ConversationSummary condenses the start of a conversation up to and including ThroughMessageID. It
+// applies to every branch that contains that message.
+type ConversationSummary struct {
+	ID               int64     `json:"id" db:"id"`
+	ConversationID   string    `json:"conversation_id" db:"conversation_id"`
+	ThroughMessageID int64     `json:"through_message_id" db:"through_message_id"`
+	MessageCount     int       `json:"message_count" db:"message_count"` // Messages covered, counted from the start
+	Content          string    `json:"content" db:"content"`
+	Model            string    `json:"model" db:"model"`
+	TokenCount       int       `json:"token_count" db:"token_count"`
+	CreatedAt        time.Time `json:"created_at" db:"created_at"`
+}
+
+// ContextReport describes the context sent to the model for one prompt engineer turn
+type ContextReport struct {
+	Model               string  `json:"model"`
+	ContextWindow       int     `json:"context_window"`
+	PromptTokens        int     `json:"prompt_tokens"` // Estimated for Model
+	SummaryModel        string  `json:"summary_model,omitempty"`
+	CompactedMessageIDs []int64 `json:"compacted_message_ids,omitempty"` // Messages sent only as part of the summary
+	Summarized          bool    `json:"summarized"`                      // A new summary was written for this turn
 }
 
 type APIResponse struct {
@@ -59,62 +123,154 @@ type DualAnalysisData struct {
 	DetailedReport string `json:"detailed_report"`
 }
 
+// Pagination structures
+const (
+	SortAsc  = "asc"
+	SortDesc = "desc"
+)
+
+// PageOptions controls cursor-based pagination and sorting for list endpoints
+type PageOptions struct {
+	Cursor string // Opaque cursor from a previous page's NextCursor
+	Limit  int
+	Sort   string
+	Order  string
+}
+
+type PageInfo struct {
+	Total      int    `json:"total"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
 // History structures
 type HistoryItem struct {
-	ID          int64     `json:"id" db:"id"`
-	Timestamp   time.Time `json:"timestamp" db:"timestamp"`
-	Prompt      string    `json:"prompt" db:"prompt"`
-	Model       string    `json:"model" db:"model"`
-	Temperature float64   `json:"temperature" db:"temperature"`
-	MaxTokens   int       `json:"max_tokens" db:"max_tokens"`
-	Success     bool      `json:"success" db:"success"`
-	Response    string    `json:"response" db:"response"`
-	ErrorMsg    string    `json:"error_msg,omitempty" db:"error_msg"`
+	ID            int64       `json:"id" db:"id"`
+	Timestamp     time.Time   `json:"timestamp" db:"timestamp"`
+	Prompt        string      `json:"prompt" db:"prompt"`
+	SystemPrompt  string      `json:"system_prompt,omitempty" db:"system_prompt"`
+	Model         string      `json:"model" db:"model"`
+	Provider      string      `json:"provider,omitempty" db:"provider"`
+	Temperature   float64     `json:"temperature" db:"temperature"`
+	MaxTokens     int         `json:"max_tokens" db:"max_tokens"`
+	Success       bool        `json:"success" db:"success"`
+	Response      string      `json:"response" db:"response"`
+	ErrorMsg      string      `json:"error_msg,omitempty" db:"error_msg"`
+	LatencyMs     int64       `json:"latency_ms,omitempty" db:"latency_ms"`
+	TokenUsage    *TokenUsage `json:"token_usage,omitempty"`
+	Cost          float64     `json:"cost,omitempty" db:"cost"`                     // Estimated, in USD
+	PromptID      *int64      `json:"prompt_id,omitempty" db:"prompt_id"`           // Saved prompt that was rendered
+	PromptVersion *int        `json:"prompt_version,omitempty" db:"prompt_version"` // Its version at the time
+	RunID         string      `json:"run_id,omitempty" db:"run_id"`                 // Shared by the models of one comparison
 }
 
 type SaveHistoryRequest struct {
-	Prompt      string  `json:"prompt"`
-	Model       string  `json:"model"`
-	Temperature float64 `json:"temperature"`
-	MaxTokens   int     `json:"max_tokens"`
-	Success     bool    `json:"success"`
-	Response    string  `json:"response"`
-	ErrorMsg    string  `json:"error_msg,omitempty"`
+	Prompt        string      `json:"prompt"`
+	SystemPrompt  string      `json:"system_prompt,omitempty"`
+	Model         string      `json:"model"`
+	Provider      string      `json:"provider,omitempty"`
+	Temperature   float64     `json:"temperature"`
+	MaxTokens     int         `json:"max_tokens"`
+	Success       bool        `json:"success"`
+	Response      string      `json:"response"`
+	ErrorMsg      string      `json:"error_msg,omitempty"`
+	LatencyMs     int64       `json:"latency_ms,omitempty"`
+	TokenUsage    *TokenUsage `json:"token_usage,omitempty"`
+	Cost          float64     `json:"cost,omitempty"`
+	PromptID      *int64      `json:"prompt_id,omitempty"`
+	PromptVersion *int        `json:"prompt_version,omitempty"`
+	RunID         string      `json:"run_id,omitempty"`
 }
 
 type HistoryResponse struct {
-	Success bool          `json:"success"`
-	Data    []HistoryItem `json:"data,omitempty"`
-	Error   string        `json:"error,omitempty"`
+	Success    bool          `json:"success"`
+	Data       []HistoryItem `json:"data,omitempty"`
+	Total      int           `json:"total"`
+	NextCursor string        `json:"next_cursor,omitempty"`
+	Error      string        `json:"error,omitempty"`
+}
+
+type HistoryFilter struct {
+	PageOptions
+	Model    string
+	Provider string
+	Success  *bool
+	PromptID int64
+	RunID    string
+	From     *time.Time
+	To       *time.Time
 }
 
 // Conversation structures
 type ConversationMessage struct {
-	ID             int64     `json:"id" db:"id"`
-	ConversationID string    `json:"conversation_id" db:"conversation_id"`
-	Role           string    `json:"role" db:"role"`
-	Content        string    `json:"content" db:"content"`
-	Timestamp      time.Time `json:"timestamp" db:"timestamp"`
+	ID             int64      `json:"id" db:"id"`
+	ConversationID string     `json:"conversation_id" db:"conversation_id"`
+	Role           string     `json:"role" db:"role"`
+	Content        string     `json:"content" db:"content"`
+	Timestamp      time.Time  `json:"timestamp" db:"timestamp"`
+	EditedAt       *time.Time `json:"edited_at,omitempty" db:"edited_at"`
+	ParentID       *int64     `json:"parent_id,omitempty" db:"parent_id"` // Message this one follows; nil for the first message
 }
 
 type Conversation struct {
-	ID        string                `json:"id" db:"id"`
-	Title     string                `json:"title" db:"title"`
-	CreatedAt time.Time             `json:"created_at" db:"created_at"`
-	UpdatedAt time.Time             `json:"updated_at" db:"updated_at"`
+	ID        string    `json:"id" db:"id"`
+	Title     string    `json:"title" db:"title"`
+	Revision  int       `json:"revision" db:"revision"` // Bumped by every change; send it back to detect concurrent edits
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+	// HeadMessageID is the last message of the active branch; Messages is the path leading to it
+	HeadMessageID *int64                `json:"head_message_id,omitempty" db:"head_message_id"`
+	Messages      []ConversationMessage `json:"messages,omitempty"`
+}
+
+// ConversationBranch is one path through a conversation, identified by its last message
+type ConversationBranch struct {
+	LeafMessageID int64               `json:"leaf_message_id"`
+	ForkMessageID *int64              `json:"fork_message_id,omitempty"` // Last message shared with the thread it forked from; nil for the original thread
+	MessageCount  int                 `json:"message_count"`
+	LastMessage   ConversationMessage `json:"last_message"`
+	Active        bool                `json:"active"`
+}
+
+// ForkConversationRequest continues a conversation from an earlier message. Without messages it only
+// makes that message the head, which is also how a client switches to another branch.
+type ForkConversationRequest struct {
+	MessageID int64                 `json:"message_id"`
 	Messages  []ConversationMessage `json:"messages,omitempty"`
+	Revision  *int                  `json:"revision,omitempty"`
 }
 
+// SaveConversationRequest saves a whole conversation. Messages already stored must be an unchanged
+// prefix of Messages; only the new ones are appended.
 type SaveConversationRequest struct {
 	ConversationID string                `json:"conversation_id"`
 	Title          string                `json:"title,omitempty"`
 	Messages       []ConversationMessage `json:"messages"`
+	Revision       *int                  `json:"revision,omitempty"` // Revision the client last saw; omit to skip the check
+}
+
+type AppendMessagesRequest struct {
+	Title    string                `json:"title,omitempty"` // Renames the conversation; new ones default to "New Conversation"
+	Messages []ConversationMessage `json:"messages"`
+	Revision *int                  `json:"revision,omitempty"`
+}
+
+type EditMessageRequest struct {
+	Content  string `json:"content"`
+	Revision *int   `json:"revision,omitempty"`
 }
 
 type ConversationResponse struct {
-	Success bool           `json:"success"`
-	Data    []Conversation `json:"data,omitempty"`
-	Error   string         `json:"error,omitempty"`
+	Success    bool           `json:"success"`
+	Data       []Conversation `json:"data,omitempty"`
+	Total      int            `json:"total"`
+	NextCursor string         `json:"next_cursor,omitempty"`
+	Error      string         `json:"error,omitempty"`
+}
+
+type ConversationFilter struct {
+	PageOptions
+	From *time.Time
+	To   *time.Time
 }
 
 type ConversationDetailResponse struct {
@@ -123,48 +279,586 @@ type ConversationDetailResponse struct {
 	Error   string        `json:"error,omitempty"`
 }
 
+type ConversationBranchesResponse struct {
+	Success bool                 `json:"success"`
+	Data    []ConversationBranch `json:"data,omitempty"`
+	Error   string               `json:"error,omitempty"`
+}
+
 // Prompt Library structures
 type SavedPrompt struct {
-	ID          int64     `json:"id" db:"id"`
-	Title       string    `json:"title" db:"title"`
-	Content     string    `json:"content" db:"content"`
-	Description string    `json:"description" db:"description"`
-	Category    string    `json:"category" db:"category"`
-	Tags        string    `json:"tags" db:"tags"` // JSON string of tag array
-	CreatedAt   time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
-	UsageCount  int       `json:"usage_count" db:"usage_count"`
+	ID          int64              `json:"id" db:"id"`
+	Title       string             `json:"title" db:"title"`
+	Content     string             `json:"content" db:"content"`
+	Description string             `json:"description" db:"description"`
+	Category    string             `json:"category" db:"category"`
+	Tags        string             `json:"tags" db:"tags"` // JSON string of tag array
+	CreatedAt   time.Time          `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time          `json:"updated_at" db:"updated_at"`
+	UsageCount  int                `json:"usage_count" db:"usage_count"`
+	Variables   []TemplateVariable `json:"variables"`
 }
 
 type SavePromptRequest struct {
-	Title       string   `json:"title"`
-	Content     string   `json:"content"`
-	Description string   `json:"description,omitempty"`
-	Category    string   `json:"category,omitempty"`
-	Tags        []string `json:"tags,omitempty"`
+	Title       string             `json:"title"`
+	Content     string             `json:"content"`
+	Description string             `json:"description,omitempty"`
+	Category    string             `json:"category,omitempty"`
+	Tags        []string           `json:"tags,omitempty"`
+	Variables   []TemplateVariable `json:"variables,omitempty"`
 }
 
 type UpdatePromptRequest struct {
-	ID          int64    `json:"id"`
-	Title       string   `json:"title"`
-	Content     string   `json:"content"`
-	Description string   `json:"description,omitempty"`
-	Category    string   `json:"category,omitempty"`
-	Tags        []string `json:"tags,omitempty"`
+	ID          int64              `json:"id"`
+	Title       string             `json:"title"`
+	Content     string             `json:"content"`
+	Description string             `json:"description,omitempty"`
+	Category    string             `json:"category,omitempty"`
+	Tags        []string           `json:"tags,omitempty"`
+	Variables   []TemplateVariable `json:"variables,omitempty"`
+	Note        string             `json:"note,omitempty"` // Recorded on the version snapshot
 }
 
 type PromptLibraryResponse struct {
-	Success bool          `json:"success"`
-	Data    []SavedPrompt `json:"data,omitempty"`
-	Error   string        `json:"error,omitempty"`
+	Success    bool          `json:"success"`
+	Data       []SavedPrompt `json:"data,omitempty"`
+	Total      int           `json:"total"`
+	NextCursor string        `json:"next_cursor,omitempty"`
+	Error      string        `json:"error,omitempty"`
+}
+
+type PromptFilter struct {
+	PageOptions
+	Category    string
+	Tags        []string
+	MatchAnyTag bool // Match prompts carrying any listed tag instead of all of them
+	From        *time.Time
+	To          *time.Time
 }
 
 type PromptResponse struct {
+	Success  bool         `json:"success"`
+	Data     *SavedPrompt `json:"data,omitempty"`
+	Rendered string       `json:"rendered,omitempty"`
+	Error    string       `json:"error,omitempty"`
+}
+
+// Tag and category management structures
+type LabelCount struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+type LabelCountsResponse struct {
 	Success bool         `json:"success"`
-	Data    *SavedPrompt `json:"data,omitempty"`
+	Data    []LabelCount `json:"data,omitempty"`
 	Error   string       `json:"error,omitempty"`
 }
 
+type RenameLabelRequest struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+type MergeLabelsRequest struct {
+	Sources []string `json:"sources"`
+	Target  string   `json:"target"`
+}
+
+type RelabelResult struct {
+	Name           string `json:"name"`
+	PromptsUpdated int    `json:"prompts_updated"`
+}
+
+type RelabelResponse struct {
+	Success bool           `json:"success"`
+	Data    *RelabelResult `json:"data,omitempty"`
+	Error   string         `json:"error,omitempty"`
+}
+
+// Prompt template structures
+const (
+	VariableTypeString  = "string"
+	VariableTypeNumber  = "number"
+	VariableTypeInteger = "integer"
+	VariableTypeBoolean = "boolean"
+	VariableTypeEnum    = "enum"
+)
+
+// TemplateVariable describes a {{name}} slot in a saved prompt
+type TemplateVariable struct {
+	Name        string   `json:"name" yaml:"name"`
+	Type        string   `json:"type" yaml:"type"`
+	Default     string   `json:"default,omitempty" yaml:"default,omitempty"`
+	Description string   `json:"description,omitempty" yaml:"description,omitempty"`
+	Required    bool     `json:"required" yaml:"required"`
+	Options     []string `json:"options,omitempty" yaml:"options,omitempty"` // Allowed values for enum variables
+}
+
+// Prompt version history structures
+type PromptVersion struct {
+	ID          int64              `json:"id" db:"id"`
+	PromptID    int64              `json:"prompt_id" db:"prompt_id"`
+	Version     int                `json:"version" db:"version"`
+	Title       string             `json:"title" db:"title"`
+	Content     string             `json:"content" db:"content"`
+	Description string             `json:"description" db:"description"`
+	Category    string             `json:"category" db:"category"`
+	Tags        string             `json:"tags" db:"tags"` // JSON string of tag array
+	Variables   []TemplateVariable `json:"variables"`
+	Note        string             `json:"note" db:"note"`
+	CreatedAt   time.Time          `json:"created_at" db:"created_at"`
+}
+
+type PromptVersionsResponse struct {
+	Success bool            `json:"success"`
+	Data    []PromptVersion `json:"data,omitempty"`
+	Error   string          `json:"error,omitempty"`
+}
+
+type PromptVersionResponse struct {
+	Success bool           `json:"success"`
+	Data    *PromptVersion `json:"data,omitempty"`
+	Error   string         `json:"error,omitempty"`
+}
+
+type RestorePromptVersionRequest struct {
+	Note string `json:"note,omitempty"`
+}
+
+// Library export/import structures
+const (
+	LibraryFormatJSON     = "json"
+	LibraryFormatYAML     = "yaml"
+	LibraryFormatMarkdown = "markdown" // Directory (or zip archive) of Markdown files with YAML frontmatter
+
+	ConflictSkip      = "skip"
+	ConflictOverwrite = "overwrite"
+	ConflictRename    = "rename"
+
+	// LibrarySchemaVersion is bumped whenever the exported document layout changes incompatibly
+	LibrarySchemaVersion = 1
+)
+
+// Library is the portable form of the prompt library shared between instances
+type Library struct {
+	SchemaVersion int             `json:"schema_version" yaml:"schema_version"`
+	ExportedAt    time.Time       `json:"exported_at" yaml:"exported_at"`
+	Prompts       []LibraryPrompt `json:"prompts" yaml:"prompts"`
+}
+
+// LibraryPrompt is a saved prompt without instance-specific IDs. Prompts are matched by title on import.
+type LibraryPrompt struct {
+	Title       string                 `json:"title" yaml:"title"`
+	Description string                 `json:"description,omitempty" yaml:"description,omitempty"`
+	Category    string                 `json:"category,omitempty" yaml:"category,omitempty"`
+	Tags        []string               `json:"tags,omitempty" yaml:"tags,omitempty"`
+	Variables   []TemplateVariable     `json:"variables,omitempty" yaml:"variables,omitempty"`
+	UsageCount  int                    `json:"usage_count" yaml:"usage_count"`
+	CreatedAt   time.Time              `json:"created_at" yaml:"created_at"`
+	UpdatedAt   time.Time              `json:"updated_at" yaml:"updated_at"`
+	Content     string                 `json:"content" yaml:"content"`
+	Versions    []LibraryPromptVersion `json:"versions,omitempty" yaml:"versions,omitempty"`
+}
+
+type LibraryPromptVersion struct {
+	Version     int                `json:"version" yaml:"version"`
+	Title       string             `json:"title" yaml:"title"`
+	Description string             `json:"description,omitempty" yaml:"description,omitempty"`
+	Category    string             `json:"category,omitempty" yaml:"category,omitempty"`
+	Tags        []string           `json:"tags,omitempty" yaml:"tags,omitempty"`
+	Variables   []TemplateVariable `json:"variables,omitempty" yaml:"variables,omitempty"`
+	Note        string             `json:"note,omitempty" yaml:"note,omitempty"`
+	CreatedAt   time.Time          `json:"created_at" yaml:"created_at"`
+	Content     string             `json:"content" yaml:"content"`
+}
+
+type LibraryResponse struct {
+	Success bool     `json:"success"`
+	Data    *Library `json:"data,omitempty"`
+	Error   string   `json:"error,omitempty"`
+}
+
+// ImportOutcome records what happened to one imported prompt
+type ImportOutcome struct {
+	Title    string `json:"title"`
+	Action   string `json:"action"` // created, overwritten, renamed or skipped
+	PromptID int64  `json:"prompt_id,omitempty"`
+	NewTitle string `json:"new_title,omitempty"` // Set when renamed
+}
+
+type ImportResult struct {
+	Created     int             `json:"created"`
+	Overwritten int             `json:"overwritten"`
+	Renamed     int             `json:"renamed"`
+	Skipped     int             `json:"skipped"`
+	Prompts     []ImportOutcome `json:"prompts"`
+}
+
+type ImportResponse struct {
+	Success bool          `json:"success"`
+	Data    *ImportResult `json:"data,omitempty"`
+	Error   string        `json:"error,omitempty"`
+}
+
+// Git library sync structures
+const (
+	SyncKeepLibrary = "library" // Resolve a conflict by writing the library's prompt to the file
+	SyncKeepFile    = "file"    // Resolve a conflict by importing the file into the library
+)
+
+// PromptSyncState is the last state of a prompt file that the library and the git working tree agreed on
+type PromptSyncState struct {
+	Path       string    `json:"path"` // Relative to the working tree
+	PromptID   int64     `json:"prompt_id"`
+	FileHash   string    `json:"file_hash"`
+	PromptHash string    `json:"prompt_hash"`
+	SyncedAt   time.Time `json:"synced_at"`
+}
+
+type SyncChange struct {
+	Path     string `json:"path"`
+	PromptID int64  `json:"prompt_id,omitempty"`
+	Title    string `json:"title"`
+	Action   string `json:"action"` // exported, imported, created, deleted, removed or linked
+}
+
+// SyncConflict is a prompt changed on both sides since the last sync; neither side is overwritten
+type SyncConflict struct {
+	Path     string `json:"path"`
+	PromptID int64  `json:"prompt_id,omitempty"`
+	Title    string `json:"title"`
+	Reason   string `json:"reason"`
+}
+
+type SyncResult struct {
+	Commit     string         `json:"commit,omitempty"` // Commit recording exported changes, if any
+	PushQueued bool           `json:"push_queued"`      // The commit is pushed in the background
+	Changes    []SyncChange   `json:"changes"`
+	Conflicts  []SyncConflict `json:"conflicts"`
+}
+
+type SyncResponse struct {
+	Success bool        `json:"success"`
+	Data    *SyncResult `json:"data,omitempty"`
+	Error   string      `json:"error,omitempty"`
+}
+
+type ResolveSyncConflictRequest struct {
+	Path string `json:"path"`
+	Keep string `json:"keep"` // library or file
+}
+
+// Backup and retention structures
+
+// Backup is a database snapshot file in the backup directory
+type Backup struct {
+	Name      string    `json:"name"`
+	Size      int64     `json:"size"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type BackupResponse struct {
+	Success bool    `json:"success"`
+	Data    *Backup `json:"data,omitempty"`
+	Error   string  `json:"error,omitempty"`
+}
+
+type BackupsResponse struct {
+	Success bool     `json:"success"`
+	Data    []Backup `json:"data"`
+	Error   string   `json:"error,omitempty"`
+}
+
+// RetentionPolicy limits how much history and how many conversations are kept. Zero values are not enforced.
+type RetentionPolicy struct {
+	HistoryMaxAge       time.Duration
+	HistoryMaxRows      int
+	ConversationMaxAge  time.Duration // Measured from the last update
+	ConversationMaxRows int
+}
+
+type RetentionResult struct {
+	HistoryDeleted       int64 `json:"history_deleted"`
+	ConversationsDeleted int64 `json:"conversations_deleted"`
+}
+
+// User and authentication structures
+type User struct {
+	ID        int64     `json:"id"`
+	Username  string    `json:"username"`
+	IsAdmin   bool      `json:"is_admin"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// APIKey describes a key without its secret, which is only shown once when the key is created
+type APIKey struct {
+	ID         int64      `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"` // Leading characters of the key, to tell keys apart
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+}
+
+type LoginRequest struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+type CreateUserRequest struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+	IsAdmin  bool   `json:"is_admin"`
+}
+
+type CreateAPIKeyRequest struct {
+	Name string `json:"name"`
+}
+
+// CreatedAPIKey is a new API key together with its secret
+type CreatedAPIKey struct {
+	APIKey
+	Key string `json:"key"`
+}
+
+type UserResponse struct {
+	Success bool   `json:"success"`
+	Data    *User  `json:"data,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+type UsersResponse struct {
+	Success bool   `json:"success"`
+	Data    []User `json:"data"`
+	Error   string `json:"error,omitempty"`
+}
+
+type APIKeysResponse struct {
+	Success bool     `json:"success"`
+	Data    []APIKey `json:"data"`
+	Error   string   `json:"error,omitempty"`
+}
+
+type CreatedAPIKeyResponse struct {
+	Success bool           `json:"success"`
+	Data    *CreatedAPIKey `json:"data,omitempty"`
+	Error   string         `json:"error,omitempty"`
+}
+
+// Workspace structures
+
+// Workspace roles, from most to least privileged. Owners manage members, editors change the shared
+// library and viewers can only read and run it.
+const (
+	RoleOwner  = "owner"
+	RoleEditor = "editor"
+	RoleViewer = "viewer"
+)
+
+// Workspace is a team sharing saved prompts, conversations and eval suites. Role is the requesting user's.
+type Workspace struct {
+	ID        int64     `json:"id"`
+	Name      string    `json:"name"`
+	Role      string    `json:"role,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type WorkspaceMember struct {
+	UserID    int64     `json:"user_id"`
+	Username  string    `json:"username"`
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type CreateWorkspaceRequest struct {
+	Name string `json:"name"`
+}
+
+// SetWorkspaceMemberRequest adds a user to a workspace or changes their role
+type SetWorkspaceMemberRequest struct {
+	Username string `json:"username"`
+	Role     string `json:"role"`
+}
+
+type WorkspaceResponse struct {
+	Success bool       `json:"success"`
+	Data    *Workspace `json:"data,omitempty"`
+	Error   string     `json:"error,omitempty"`
+}
+
+type WorkspacesResponse struct {
+	Success bool        `json:"success"`
+	Data    []Workspace `json:"data"`
+	Error   string      `json:"error,omitempty"`
+}
+
+type WorkspaceMembersResponse struct {
+	Success bool              `json:"success"`
+	Data    []WorkspaceMember `json:"data"`
+	Error   string            `json:"error,omitempty"`
+}
+
+// WorkspaceCredential is a provider API key that a workspace uses instead of the server's. The key is
+// stored encrypted and never returned by the API.
+type WorkspaceCredential struct {
+	Provider  string    `json:"provider"`
+	BaseURL   string    `json:"base_url,omitempty"`
+	APIKey    string    `json:"-"`
+	UpdatedBy *int64    `json:"updated_by,omitempty"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// SetWorkspaceCredentialRequest stores a workspace's key for a provider. BaseURL is optional and, for
+// Azure OpenAI, replaces the endpoint of every deployment.
+type SetWorkspaceCredentialRequest struct {
+	APIKey  string `json:"api_key"`
+	BaseURL string `json:"base_url"`
+}
+
+type WorkspaceCredentialResponse struct {
+	Success bool                 `json:"success"`
+	Data    *WorkspaceCredential `json:"data,omitempty"`
+	Error   string               `json:"error,omitempty"`
+}
+
+type WorkspaceCredentialsResponse struct {
+	Success bool                  `json:"success"`
+	Data    []WorkspaceCredential `json:"data"`
+	Error   string                `json:"error,omitempty"`
+}
+
+// Audit log structures
+
+// AuditEntry records one mutating API request. Before describes the entity it changed as it was
+// beforehand, where known, and After the change that was asked for.
+type AuditEntry struct {
+	ID          int64     `json:"id"`
+	UserID      *int64    `json:"user_id,omitempty"`
+	Username    string    `json:"username,omitempty"`
+	WorkspaceID *int64    `json:"workspace_id,omitempty"`
+	Method      string    `json:"method"`
+	Route       string    `json:"route"` // Route pattern, such as /api/prompts/:id
+	Path        string    `json:"path"`
+	EntityID    string    `json:"entity_id,omitempty"`
+	Status      int       `json:"status"`
+	Before      string    `json:"before,omitempty"`
+	After       string    `json:"after,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type AuditFilter struct {
+	PageOptions
+	Username    string
+	WorkspaceID int64
+	Method      string
+	Route       string
+	EntityID    string
+	From        *time.Time
+	To          *time.Time
+}
+
+type AuditLogResponse struct {
+	Success    bool         `json:"success"`
+	Data       []AuditEntry `json:"data,omitempty"`
+	Total      int          `json:"total"`
+	NextCursor string       `json:"next_cursor,omitempty"`
+	Error      string       `json:"error,omitempty"`
+}
+
+// Configuration structures
+
+// ConfigSetting is one effective setting and where its value came from. Secret values are shown as
+// "[set]" when set.
+type ConfigSetting struct {
+	Key    string `json:"key"`
+	Env    string `json:"env"`
+	Value  string `json:"value"`
+	Source string `json:"source"`
+	Secret bool   `json:"secret,omitempty"`
+}
+
+type EffectiveConfig struct {
+	Profile  string          `json:"profile,omitempty"`
+	File     string          `json:"file,omitempty"`
+	Settings []ConfigSetting `json:"settings"`
+}
+
+type ConfigResponse struct {
+	Success bool             `json:"success"`
+	Data    *EffectiveConfig `json:"data,omitempty"`
+	Error   string           `json:"error,omitempty"`
+}
+
+// Line-level diff structures
+const (
+	DiffOpEqual  = "equal"
+	DiffOpInsert = "insert"
+	DiffOpDelete = "delete"
+)
+
+type DiffLine struct {
+	Op      string `json:"op"`
+	Text    string `json:"text"`
+	OldLine int    `json:"old_line,omitempty"`
+	NewLine int    `json:"new_line,omitempty"`
+}
+
+type PromptDiff struct {
+	PromptID      int64      `json:"prompt_id"`
+	FromVersion   int        `json:"from_version"`
+	ToVersion     int        `json:"to_version"`
+	ChangedFields []string   `json:"changed_fields"`
+	Additions     int        `json:"additions"`
+	Deletions     int        `json:"deletions"`
+	Lines         []DiffLine `json:"lines"`
+}
+
+type PromptDiffResponse struct {
+	Success bool        `json:"success"`
+	Data    *PromptDiff `json:"data,omitempty"`
+	Error   string      `json:"error,omitempty"`
+}
+
+type UsePromptRequest struct {
+	Variables map[string]interface{} `json:"variables,omitempty"`
+}
+
+// Search structures
+const (
+	SearchTypePrompt       = "prompt"
+	SearchTypeHistory      = "history"
+	SearchTypeConversation = "conversation"
+)
+
+type SearchRequest struct {
+	Query    string
+	Types    []string // Empty means all types
+	Category string
+	Tag      string
+	Model    string
+	From     *time.Time
+	To       *time.Time
+	Limit    int
+}
+
+type SearchResult struct {
+	Type      string    `json:"type"`
+	ID        string    `json:"id"` // Prompt/history ID, or conversation ID for message hits
+	Title     string    `json:"title"`
+	Snippet   string    `json:"snippet"`
+	Score     float64   `json:"score"`
+	Timestamp time.Time `json:"timestamp"`
+	Category  string    `json:"category,omitempty"`
+	Model     string    `json:"model,omitempty"`
+	MessageID int64     `json:"message_id,omitempty"`
+}
+
+type SearchResponse struct {
+	Success bool           `json:"success"`
+	Data    []SearchResult `json:"data,omitempty"`
+	Error   string         `json:"error,omitempty"`
+}
+
 // Eval Generator structures
 type EvalGenerateRequest struct {
 	Prompt     string   `json:"prompt"`
@@ -208,21 +902,55 @@ type EvalResponse struct {
 	Error   string    `json:"error,omitempty"`
 }
 
+// EvalSuite is a saved set of generated test cases and criteria, optionally tied to the prompt it evaluates
+type EvalSuite struct {
+	ID        int64     `json:"id"`
+	Name      string    `json:"name"`
+	PromptID  *int64    `json:"prompt_id,omitempty"`
+	Data      EvalData  `json:"data"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type SaveEvalSuiteRequest struct {
+	Name     string   `json:"name"`
+	PromptID *int64   `json:"prompt_id,omitempty"`
+	Data     EvalData `json:"data"`
+}
+
+type EvalSuiteResponse struct {
+	Success bool       `json:"success"`
+	Data    *EvalSuite `json:"data,omitempty"`
+	Error   string     `json:"error,omitempty"`
+}
+
+type EvalSuitesResponse struct {
+	Success bool        `json:"success"`
+	Data    []EvalSuite `json:"data"`
+	Error   string      `json:"error,omitempty"`
+}
+
 // Multi-model execution structures
 type MultiModelExecuteRequest struct {
-	Prompt      string   `json:"prompt"`
-	Models      []string `json:"models"`
-	Temperature float64  `json:"temperature"`
-	MaxTokens   int      `json:"max_tokens,omitempty"`
+	Prompt       string                 `json:"prompt"`
+	SystemPrompt string                 `json:"system_prompt,omitempty"`
+	Models       []string               `json:"models"`
+	Temperature  float64                `json:"temperature"`
+	MaxTokens    int                    `json:"max_tokens,omitempty"`
+	PromptID     int64                  `json:"prompt_id,omitempty"` // Render a saved prompt instead of Prompt
+	Variables    map[string]interface{} `json:"variables,omitempty"`
 }
 
 type ModelExecutionResult struct {
 	Model         string      `json:"model"`
+	Provider      string      `json:"provider,omitempty"`
 	Response      string      `json:"response"`
 	Success       bool        `json:"success"`
 	Error         string      `json:"error,omitempty"`
 	ExecutionTime int64       `json:"execution_time_ms,omitempty"`
 	TokenUsage    *TokenUsage `json:"token_usage,omitempty"`
+	Cost          float64     `json:"cost,omitempty"`
+	HistoryID     int64       `json:"history_id,omitempty"`
 }
 
 type TokenUsage struct {
@@ -234,6 +962,7 @@ type TokenUsage struct {
 type MultiModelExecuteResponse struct {
 	Success bool                   `json:"success"`
 	Data    []ModelExecutionResult `json:"data,omitempty"`
+	RunID   string                 `json:"run_id,omitempty"` // Groups the results in history
 	Error   string                 `json:"error,omitempty"`
 }
 
@@ -258,4 +987,10 @@ type AnthropicContent struct {
 
 type AnthropicResponse struct {
 	Content []AnthropicContent `json:"content"`
+	Usage   *AnthropicUsage    `json:"usage,omitempty"`
+}
+
+type AnthropicUsage struct {
+	InputTokens  int `json:"input_tokens"`
+	OutputTokens int `json:"output_tokens"`
 }
@@ -0,0 +1,74 @@
+package secrets
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// KeySize is the length of a master key: 32 bytes, for AES-256
+const KeySize = 32
+
+// ErrDecrypt is returned for values that were encrypted under another key or label, or tampered with
+var ErrDecrypt = errors.New("failed to decrypt: wrong master key or corrupted value")
+
+// Cipher encrypts values with AES-256-GCM under a master key. Each value is bound to a label, such as
+// the name it is stored under, so an encrypted value copied elsewhere does not decrypt.
+type Cipher struct {
+	aead cipher.AEAD
+}
+
+// NewCipher takes a base64-encoded master key of 32 bytes, as made by GenerateKey or
+// `openssl rand -base64 32`
+func NewCipher(masterKey string) (*Cipher, error) {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(masterKey))
+	if err != nil || len(key) != KeySize {
+		return nil, fmt.Errorf("master key must be %d random bytes, base64-encoded", KeySize)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %v", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %v", err)
+	}
+	return &Cipher{aead: aead}, nil
+}
+
+// GenerateKey returns a new random master key
+func GenerateKey() (string, error) {
+	key := make([]byte, KeySize)
+	if _, err := rand.Read(key); err != nil {
+		return "", fmt.Errorf("failed to generate key: %v", err)
+	}
+	return base64.StdEncoding.EncodeToString(key), nil
+}
+
+// Encrypt returns plaintext encrypted and bound to label, base64-encoded with its nonce
+func (c *Cipher) Encrypt(plaintext, label string) (string, error) {
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %v", err)
+	}
+	sealed := c.aead.Seal(nonce, nonce, []byte(plaintext), []byte(label))
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt reverses Encrypt for the same label
+func (c *Cipher) Decrypt(ciphertext, label string) (string, error) {
+	sealed, err := base64.StdEncoding.DecodeString(ciphertext)
+	if err != nil || len(sealed) < c.aead.NonceSize() {
+		return "", ErrDecrypt
+	}
+	nonce, sealed := sealed[:c.aead.NonceSize()], sealed[c.aead.NonceSize():]
+	plaintext, err := c.aead.Open(nil, nonce, sealed, []byte(label))
+	if err != nil {
+		return "", ErrDecrypt
+	}
+	return string(plaintext), nil
+}
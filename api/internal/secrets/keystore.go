@@ -0,0 +1,140 @@
+package secrets
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// ErrNotFound is returned when the keystore has no secret of the given name
+var ErrNotFound = errors.New("secret not found")
+
+// Keystore is a local JSON file of named secrets, each encrypted under the master key. It is read on
+// every lookup, so secrets set while the server runs are seen by the next reload.
+type Keystore struct {
+	path   string
+	cipher *Cipher
+}
+
+type keystoreFile struct {
+	Version int               `json:"version"`
+	Secrets map[string]string `json:"secrets"`
+}
+
+// OpenKeystore returns the keystore at path. The file is created by the first Set.
+func OpenKeystore(path string, c *Cipher) *Keystore {
+	return &Keystore{path: path, cipher: c}
+}
+
+// Lookup decrypts the secret called name
+func (k *Keystore) Lookup(name string) (string, error) {
+	file, err := k.read()
+	if err != nil {
+		return "", err
+	}
+	sealed, ok := file.Secrets[name]
+	if !ok {
+		return "", fmt.Errorf("%w in keystore: %s", ErrNotFound, name)
+	}
+	return k.cipher.Decrypt(sealed, label(name))
+}
+
+// Source is the keystore file, so changing any secret reloads the configuration
+func (k *Keystore) Source(string) string {
+	return k.path
+}
+
+// Names lists the secrets in the keystore
+func (k *Keystore) Names() ([]string, error) {
+	file, err := k.read()
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(file.Secrets))
+	for name := range file.Secrets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// Set encrypts value and stores it as name, replacing any previous value
+func (k *Keystore) Set(name, value string) error {
+	file, err := k.read()
+	if err != nil {
+		return err
+	}
+	sealed, err := k.cipher.Encrypt(value, label(name))
+	if err != nil {
+		return err
+	}
+	file.Secrets[name] = sealed
+	return k.write(file)
+}
+
+// Delete removes the secret called name
+func (k *Keystore) Delete(name string) error {
+	file, err := k.read()
+	if err != nil {
+		return err
+	}
+	if _, ok := file.Secrets[name]; !ok {
+		return fmt.Errorf("%w in keystore: %s", ErrNotFound, name)
+	}
+	delete(file.Secrets, name)
+	return k.write(file)
+}
+
+func (k *Keystore) read() (*keystoreFile, error) {
+	file := &keystoreFile{Version: 1, Secrets: map[string]string{}}
+	data, err := os.ReadFile(k.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return file, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read keystore: %v", err)
+	}
+	if err := json.Unmarshal(data, file); err != nil {
+		return nil, fmt.Errorf("failed to parse keystore %s: %v", k.path, err)
+	}
+	if file.Secrets == nil {
+		file.Secrets = map[string]string{}
+	}
+	return file, nil
+}
+
+// write replaces the keystore through a temporary file, so readers never see a partial one
+func (k *Keystore) write(file *keystoreFile) error {
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode keystore: %v", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(k.path), ".keystore-*")
+	if err != nil {
+		return fmt.Errorf("failed to write keystore: %v", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write keystore: %v", err)
+	}
+	if err := tmp.Chmod(0o600); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write keystore: %v", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write keystore: %v", err)
+	}
+	if err := os.Rename(tmp.Name(), k.path); err != nil {
+		return fmt.Errorf("failed to write keystore: %v", err)
+	}
+	return nil
+}
+
+// label binds a keystore value to its name
+func label(name string) string {
+	return "keystore:" + name
+}
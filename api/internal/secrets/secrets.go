@@ -0,0 +1,84 @@
+// Package secrets resolves credentials from pluggable sources, such as environment variables, files
+// and a local encrypted keystore, and encrypts credentials that are stored in the database.
+package secrets
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// Provider is a source of secrets. A config value such as ${file:/run/secrets/openai} is looked up
+// in the provider registered as "file".
+type Provider interface {
+	// Lookup returns the secret ref names
+	Lookup(ref string) (string, error)
+	// Source returns the file the secret is read from, or "", so the file can be watched for changes
+	Source(ref string) string
+}
+
+var (
+	mu        sync.RWMutex
+	providers = map[string]Provider{
+		"env":  Env{},
+		"file": File{},
+	}
+)
+
+// Register makes p available in references as name, replacing any provider of that name
+func Register(name string, p Provider) {
+	mu.Lock()
+	defer mu.Unlock()
+	providers[name] = p
+}
+
+// Get returns the provider registered as name, or nil
+func Get(name string) Provider {
+	mu.RLock()
+	defer mu.RUnlock()
+	return providers[name]
+}
+
+var referencePattern = regexp.MustCompile(`^\$\{([a-z][a-z0-9_-]*):(.+)\}$`)
+
+// ParseReference splits a reference such as ${keystore:openai_api_key} into its provider and ref
+func ParseReference(value string) (provider, ref string, ok bool) {
+	match := referencePattern.FindStringSubmatch(strings.TrimSpace(value))
+	if match == nil {
+		return "", "", false
+	}
+	return match[1], match[2], true
+}
+
+// Env reads secrets from environment variables, e.g. ${env:VAULT_OPENAI_KEY}
+type Env struct{}
+
+func (Env) Lookup(ref string) (string, error) {
+	value, ok := os.LookupEnv(ref)
+	if !ok || value == "" {
+		return "", fmt.Errorf("environment variable %s is not set", ref)
+	}
+	return value, nil
+}
+
+func (Env) Source(string) string {
+	return ""
+}
+
+// File reads secrets from files, such as mounted Docker or Kubernetes secrets, e.g.
+// ${file:/run/secrets/openai_api_key}. A trailing newline is dropped.
+type File struct{}
+
+func (File) Lookup(ref string) (string, error) {
+	data, err := os.ReadFile(ref)
+	if err != nil {
+		return "", fmt.Errorf("failed to read secret file: %v", err)
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+func (File) Source(ref string) string {
+	return ref
+}
@@ -0,0 +1,111 @@
+package secrets
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCipher(t *testing.T) {
+	key, err := GenerateKey()
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	c, err := NewCipher(key)
+	if err != nil {
+		t.Fatalf("Failed to create cipher: %v", err)
+	}
+
+	sealed, err := c.Encrypt("sk-secret", "workspace:1:openai")
+	if err != nil || strings.Contains(sealed, "sk-secret") {
+		t.Fatalf("Expected an encrypted value, got %q (%v)", sealed, err)
+	}
+	if again, _ := c.Encrypt("sk-secret", "workspace:1:openai"); again == sealed {
+		t.Error("Expected a fresh nonce for every value")
+	}
+	if plaintext, err := c.Decrypt(sealed, "workspace:1:openai"); err != nil || plaintext != "sk-secret" {
+		t.Errorf("Expected the value back, got %q (%v)", plaintext, err)
+	}
+	if _, err := c.Decrypt(sealed, "workspace:2:openai"); !errors.Is(err, ErrDecrypt) {
+		t.Errorf("Expected a value moved to another label not to decrypt, got %v", err)
+	}
+
+	other, _ := GenerateKey()
+	otherCipher, _ := NewCipher(other)
+	if _, err := otherCipher.Decrypt(sealed, "workspace:1:openai"); !errors.Is(err, ErrDecrypt) {
+		t.Errorf("Expected another key not to decrypt, got %v", err)
+	}
+	if _, err := NewCipher("too-short"); err == nil {
+		t.Error("Expected a key that is not 32 bytes to be rejected")
+	}
+}
+
+func TestKeystore(t *testing.T) {
+	key, _ := GenerateKey()
+	c, _ := NewCipher(key)
+	path := filepath.Join(t.TempDir(), "keystore.json")
+	ks := OpenKeystore(path, c)
+
+	if names, err := ks.Names(); err != nil || len(names) != 0 {
+		t.Fatalf("Expected a missing keystore to be empty, got %v (%v)", names, err)
+	}
+	if err := ks.Set("openai_api_key", "sk-openai"); err != nil {
+		t.Fatalf("Failed to set secret: %v", err)
+	}
+	if err := ks.Set("anthropic_api_key", "sk-ant"); err != nil {
+		t.Fatalf("Failed to set secret: %v", err)
+	}
+
+	data, _ := os.ReadFile(path)
+	if strings.Contains(string(data), "sk-openai") {
+		t.Error("Expected the keystore file not to hold plain text")
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0o600 {
+		t.Errorf("Expected the keystore to be private, got %v (%v)", info.Mode(), err)
+	}
+
+	if value, err := OpenKeystore(path, c).Lookup("openai_api_key"); err != nil || value != "sk-openai" {
+		t.Errorf("Expected the stored secret, got %q (%v)", value, err)
+	}
+	if names, _ := ks.Names(); strings.Join(names, ",") != "anthropic_api_key,openai_api_key" {
+		t.Errorf("Expected both names, got %v", names)
+	}
+	if err := ks.Delete("anthropic_api_key"); err != nil {
+		t.Fatalf("Failed to delete secret: %v", err)
+	}
+	if _, err := ks.Lookup("anthropic_api_key"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected a deleted secret to be gone, got %v", err)
+	}
+}
+
+func TestReferences(t *testing.T) {
+	tests := []struct {
+		value    string
+		provider string
+		ref      string
+	}{
+		{"${file:/run/secrets/openai}", "file", "/run/secrets/openai"},
+		{" ${keystore:openai_api_key} ", "keystore", "openai_api_key"},
+		{"${env:VAULT_KEY}", "env", "VAULT_KEY"},
+		{"sk-plain-key", "", ""},
+		{"https://example.com/${file:x}", "", ""},
+	}
+	for _, test := range tests {
+		provider, ref, ok := ParseReference(test.value)
+		if provider != test.provider || ref != test.ref || ok != (test.provider != "") {
+			t.Errorf("For %q, expected %q %q, got %q %q", test.value, test.provider, test.ref, provider, ref)
+		}
+	}
+
+	path := filepath.Join(t.TempDir(), "key")
+	os.WriteFile(path, []byte("sk-from-file\n"), 0o600)
+	if value, err := Get("file").Lookup(path); err != nil || value != "sk-from-file" {
+		t.Errorf("Expected the file's value without its newline, got %q (%v)", value, err)
+	}
+	t.Setenv("VAULT_KEY", "sk-from-env")
+	if value, err := Get("env").Lookup("VAULT_KEY"); err != nil || value != "sk-from-env" {
+		t.Errorf("Expected the environment variable, got %q (%v)", value, err)
+	}
+}
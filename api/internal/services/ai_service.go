@@ -2,11 +2,14 @@ package services
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
 	"strings"
+	"time"
 
 	"promptforge/internal/config"
 	"promptforge/internal/models"
@@ -14,42 +17,143 @@ import (
 
 // AIService interface for all AI providers
 type AIService interface {
-	CallAI(messages []models.Message, temperature float64, maxTokens int, model string, provider config.AIProvider) (string, error)
+	CallAI(ctx context.Context, messages []models.Message, temperature float64, maxTokens int, model string, provider config.AIProvider) (string, error)
 }
 
 // UnifiedAIService implements AIService for multiple providers
 type UnifiedAIService struct {
-	client *http.Client
+	client       *http.Client
+	publicClient *http.Client // For calls to a workspace's own base URL
 }
 
 func NewUnifiedAIService() *UnifiedAIService {
 	return &UnifiedAIService{
-		client: &http.Client{},
+		client:       &http.Client{},
+		publicClient: newPublicClient(),
 	}
 }
 
+// clientFor returns the client for a call to provider. Calls to a workspace's own base URL may only
+// reach public addresses.
+func (s *UnifiedAIService) clientFor(ctx context.Context, provider config.AIProvider) *http.Client {
+	if credential, ok := credentialFor(ctx, provider); ok && credential.BaseURL != "" {
+		return s.publicClient
+	}
+	return s.client
+}
+
 // CallAI routes to the appropriate provider
-func (s *UnifiedAIService) CallAI(messages []models.Message, temperature float64, maxTokens int, model string, provider config.AIProvider) (string, error) {
+func (s *UnifiedAIService) CallAI(ctx context.Context, messages []models.Message, temperature float64, maxTokens int, model string, provider config.AIProvider) (string, error) {
+	completion, err := s.Complete(ctx, messages, temperature, maxTokens, model, provider)
+	if err != nil {
+		return "", err
+	}
+	return completion.Content, nil
+}
+
+// CallWithDefaultProvider uses the configured default provider
+func (s *UnifiedAIService) CallWithDefaultProvider(ctx context.Context, messages []models.Message, temperature float64, maxTokens int, model string) (string, error) {
+	return s.CallAI(ctx, messages, temperature, maxTokens, model, config.Current().DefaultProvider)
+}
+
+// Complete is CallAI with the details of the call: the model that answered, token usage when the
+// provider reports it, and the estimated cost
+func (s *UnifiedAIService) Complete(ctx context.Context, messages []models.Message, temperature float64, maxTokens int, model string, provider config.AIProvider) (completion *models.Completion, err error) {
+	start := time.Now()
+	ctx, span := startCallSpan(ctx, provider, model, temperature, maxTokens)
+	defer func() {
+		observeCall(provider, model, start, completion, err)
+		endCallSpan(span, completion, err)
+		logCall(ctx, provider, model, start, completion, err)
+	}()
+
 	switch provider {
 	case config.ProviderOpenAI:
-		return s.callOpenAI(messages, temperature, maxTokens, model)
+		completion, err = s.callOpenAI(ctx, messages, temperature, maxTokens, model)
 	case config.ProviderAzureOpenAI:
-		return s.callAzureOpenAI(messages, temperature, maxTokens, model)
+		completion, err = s.callAzureOpenAI(ctx, messages, temperature, maxTokens, model)
 	case config.ProviderAnthropic:
-		return s.callAnthropic(messages, temperature, maxTokens, model)
+		completion, err = s.callAnthropic(ctx, messages, temperature, maxTokens, model)
 	default:
-		return "", fmt.Errorf("unsupported AI provider: %s", provider)
+		return nil, fmt.Errorf("unsupported AI provider: %s", provider)
 	}
+	if err != nil {
+		return nil, err
+	}
+
+	completion.Provider = string(provider)
+	completion.Cost = EstimateCost(completion.Model, completion.Usage)
+	return completion, nil
 }
 
-// CallWithDefaultProvider uses the configured default provider
-func (s *UnifiedAIService) CallWithDefaultProvider(messages []models.Message, temperature float64, maxTokens int, model string) (string, error) {
-	return s.CallAI(messages, temperature, maxTokens, model, config.AppConfig.DefaultProvider)
+// CompleteWithDefaultProvider uses the configured default provider
+func (s *UnifiedAIService) CompleteWithDefaultProvider(ctx context.Context, messages []models.Message, temperature float64, maxTokens int, model string) (*models.Completion, error) {
+	return s.Complete(ctx, messages, temperature, maxTokens, model, config.Current().DefaultProvider)
 }
 
-func (s *UnifiedAIService) callOpenAI(messages []models.Message, temperature float64, maxTokens int, model string) (string, error) {
-	if config.AppConfig.OpenAI.APIKey == "" {
-		return "", fmt.Errorf("OpenAI API key not configured")
+// logCall writes a debug entry for every provider call and a warning for failed ones. Entries carry
+// the request ID of the API request the call was made for.
+func logCall(ctx context.Context, provider config.AIProvider, model string, start time.Time, completion *models.Completion, err error) {
+	attrs := []any{
+		"provider", string(provider),
+		"model", model,
+		"duration_ms", time.Since(start).Milliseconds(),
+	}
+	if err != nil {
+		slog.WarnContext(ctx, "provider call failed", append(attrs, "error", err)...)
+		return
+	}
+	if usage := completion.Usage; usage != nil {
+		attrs = append(attrs, "input_tokens", usage.PromptTokens, "output_tokens", usage.CompletionTokens)
+	}
+	slog.DebugContext(ctx, "provider call", attrs...)
+}
+
+// maxProviderMessage bounds the provider error message passed on to API clients
+const maxProviderMessage = 300
+
+// providerError describes a failed provider response without passing on its body, which can echo the
+// prompt. Only the provider's error type and message are kept; the body is logged at debug level,
+// where LOG_REDACT_PROMPTS applies.
+func providerError(ctx context.Context, provider string, status int, body []byte) error {
+	slog.DebugContext(ctx, "provider error response", "provider", provider, "status", status, "response_body", string(body))
+
+	// OpenAI, Azure OpenAI and Anthropic all report {"error": {"message": ..., "type"/"code": ...}}
+	var parsed struct {
+		Error struct {
+			Message string      `json:"message"`
+			Type    string      `json:"type"`
+			Code    interface{} `json:"code"`
+		} `json:"error"`
+	}
+	json.Unmarshal(body, &parsed)
+
+	message := parsed.Error.Message
+	if len(message) > maxProviderMessage {
+		message = strings.ToValidUTF8(message[:maxProviderMessage], "") + "…"
+	}
+	kind := parsed.Error.Type
+	if code, ok := parsed.Error.Code.(string); ok && code != "" {
+		kind = code
+	}
+	switch {
+	case kind != "" && message != "":
+		message = kind + ": " + message
+	case kind != "":
+		message = kind
+	case message == "":
+		return fmt.Errorf("%s API request failed with status %d", provider, status)
+	}
+	return fmt.Errorf("%s API request failed with status %d: %s", provider, status, message)
+}
+
+func (s *UnifiedAIService) callOpenAI(ctx context.Context, messages []models.Message, temperature float64, maxTokens int, model string) (*models.Completion, error) {
+	settings, err := openAISettings(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if settings.APIKey == "" {
+		return nil, fmt.Errorf("OpenAI API key not configured")
 	}
 
 	// Default to gpt-4 if no model specified
@@ -69,51 +173,60 @@ func (s *UnifiedAIService) callOpenAI(messages []models.Message, temperature flo
 
 	jsonData, err := json.Marshal(requestBody)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
-	endpoint := fmt.Sprintf("%s/chat/completions", strings.TrimSuffix(config.AppConfig.OpenAI.BaseURL, "/"))
-	req, err := http.NewRequest("POST", endpoint, bytes.NewBuffer(jsonData))
+	endpoint := fmt.Sprintf("%s/chat/completions", strings.TrimSuffix(settings.BaseURL, "/"))
+	req, err := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewBuffer(jsonData))
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
 	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+config.AppConfig.OpenAI.APIKey)
+	req.Header.Set("Authorization", "Bearer "+settings.APIKey)
 
-	resp, err := s.client.Do(req)
+	resp, err := s.clientFor(ctx, config.ProviderOpenAI).Do(req)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 	defer resp.Body.Close()
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("OpenAI API request failed with status %d: %s", resp.StatusCode, string(body))
+		return nil, providerError(ctx, "OpenAI", resp.StatusCode, body)
 	}
 
 	var openAIResp models.OpenAIResponse
 	if err := json.Unmarshal(body, &openAIResp); err != nil {
-		return "", err
+		return nil, err
 	}
 
 	if len(openAIResp.Choices) == 0 {
-		return "", fmt.Errorf("no response from OpenAI")
+		return nil, fmt.Errorf("no response from OpenAI")
 	}
 
-	return openAIResp.Choices[0].Message.Content, nil
+	return &models.Completion{Content: openAIResp.Choices[0].Message.Content, Model: model, Usage: openAIResp.Usage}, nil
 }
 
-func (s *UnifiedAIService) callAzureOpenAI(messages []models.Message, temperature float64, maxTokens int, model string) (string, error) {
-	if config.AppConfig.AzureOpenAI.APIKey == "" {
-		return "", fmt.Errorf("Azure OpenAI API key not configured")
+// callAzureOpenAI calls the model's deployments in order, moving on to the next region when one is
+// unreachable, rate limited or failing
+func (s *UnifiedAIService) callAzureOpenAI(ctx context.Context, messages []models.Message, temperature float64, maxTokens int, model string) (*models.Completion, error) {
+	settings, err := azureSettings(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !settings.Configured() {
+		return nil, fmt.Errorf("Azure OpenAI API key not configured")
 	}
 
-	endpoint := config.GetEndpointURL(model)
+	deployments := settings.DeploymentsFor(model)
+	if len(deployments) == 0 {
+		return nil, fmt.Errorf("no Azure OpenAI deployment configured for model %q", model)
+	}
 
 	// o3 model only supports temperature = 1
 	if model == "o3" {
@@ -136,47 +249,69 @@ func (s *UnifiedAIService) callAzureOpenAI(messages []models.Message, temperatur
 
 	jsonData, err := json.Marshal(requestBody)
 	if err != nil {
-		return "", err
+		return nil, err
+	}
+
+	for i, deployment := range deployments {
+		completion, retry, err := s.callAzureDeployment(ctx, deployment, jsonData)
+		if err == nil {
+			completion.Model = model
+			return completion, nil
+		}
+		if !retry || i == len(deployments)-1 || ctx.Err() != nil {
+			return nil, err
+		}
+		slog.WarnContext(ctx, "Azure OpenAI deployment failed, trying the next one", "deployment", deployment.Name(), "next", deployments[i+1].Name(), "error", err)
 	}
+	return nil, fmt.Errorf("no Azure OpenAI deployment configured for model %q", model)
+}
 
-	req, err := http.NewRequest("POST", endpoint, bytes.NewBuffer(jsonData))
+// callAzureDeployment sends one request to a deployment. retry reports whether another region may
+// succeed where this one failed.
+func (s *UnifiedAIService) callAzureDeployment(ctx context.Context, deployment config.AzureDeployment, jsonData []byte) (completion *models.Completion, retry bool, err error) {
+	req, err := http.NewRequestWithContext(ctx, "POST", deployment.URL(), bytes.NewBuffer(jsonData))
 	if err != nil {
-		return "", err
+		return nil, false, err
 	}
 
 	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("api-key", config.AppConfig.AzureOpenAI.APIKey)
+	req.Header.Set("api-key", deployment.APIKey)
 
-	resp, err := s.client.Do(req)
+	resp, err := s.clientFor(ctx, config.ProviderAzureOpenAI).Do(req)
 	if err != nil {
-		return "", err
+		return nil, true, err
 	}
 	defer resp.Body.Close()
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return "", err
+		return nil, true, err
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("Azure OpenAI API request failed with status %d: %s", resp.StatusCode, string(body))
+		retry = resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+		return nil, retry, providerError(ctx, "Azure OpenAI", resp.StatusCode, body)
 	}
 
 	var openAIResp models.OpenAIResponse
 	if err := json.Unmarshal(body, &openAIResp); err != nil {
-		return "", err
+		return nil, false, err
 	}
 
 	if len(openAIResp.Choices) == 0 {
-		return "", fmt.Errorf("no response from Azure OpenAI")
+		return nil, false, fmt.Errorf("no response from Azure OpenAI")
 	}
 
-	return openAIResp.Choices[0].Message.Content, nil
+	return &models.Completion{Content: openAIResp.Choices[0].Message.Content, Usage: openAIResp.Usage}, false, nil
 }
 
-func (s *UnifiedAIService) callAnthropic(messages []models.Message, temperature float64, maxTokens int, model string) (string, error) {
-	if config.AppConfig.Anthropic.APIKey == "" {
-		return "", fmt.Errorf("Anthropic API key not configured")
+func (s *UnifiedAIService) callAnthropic(ctx context.Context, messages []models.Message, temperature float64, maxTokens int, model string) (*models.Completion, error) {
+	settings, err := anthropicSettings(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if settings.APIKey == "" {
+		return nil, fmt.Errorf("Anthropic API key not configured")
 	}
 
 	// Default to claude-3-5-sonnet if no model specified
@@ -196,20 +331,7 @@ func (s *UnifiedAIService) callAnthropic(messages []models.Message, temperature
 		temperature = 1
 	}
 
-	// Convert OpenAI format messages to Anthropic format
-	var anthropicMessages []models.AnthropicMessage
-	var systemMessage string
-
-	for _, msg := range messages {
-		if msg.Role == "system" {
-			systemMessage = msg.Content
-		} else {
-			anthropicMessages = append(anthropicMessages, models.AnthropicMessage{
-				Role:    msg.Role,
-				Content: msg.Content,
-			})
-		}
-	}
+	systemMessage, anthropicMessages := toAnthropicMessages(messages)
 
 	requestBody := models.AnthropicRequest{
 		Model:       model,
@@ -228,42 +350,70 @@ func (s *UnifiedAIService) callAnthropic(messages []models.Message, temperature
 
 	jsonData, err := json.Marshal(requestBody)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
-	endpoint := fmt.Sprintf("%s/v1/messages", strings.TrimSuffix(config.AppConfig.Anthropic.BaseURL, "/"))
-	req, err := http.NewRequest("POST", endpoint, bytes.NewBuffer(jsonData))
+	endpoint := fmt.Sprintf("%s/v1/messages", strings.TrimSuffix(settings.BaseURL, "/"))
+	req, err := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewBuffer(jsonData))
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
 	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("x-api-key", config.AppConfig.Anthropic.APIKey)
+	req.Header.Set("x-api-key", settings.APIKey)
 	req.Header.Set("anthropic-version", "2023-06-01")
 
-	resp, err := s.client.Do(req)
+	resp, err := s.clientFor(ctx, config.ProviderAnthropic).Do(req)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 	defer resp.Body.Close()
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("Anthropic API request failed with status %d: %s", resp.StatusCode, string(body))
+		return nil, providerError(ctx, "Anthropic", resp.StatusCode, body)
 	}
 
 	var anthropicResp models.AnthropicResponse
 	if err := json.Unmarshal(body, &anthropicResp); err != nil {
-		return "", err
+		return nil, err
 	}
 
 	if len(anthropicResp.Content) == 0 {
-		return "", fmt.Errorf("no response from Anthropic")
+		return nil, fmt.Errorf("no response from Anthropic")
+	}
+
+	completion := &models.Completion{Content: anthropicResp.Content[0].Text, Model: model}
+	if usage := anthropicResp.Usage; usage != nil {
+		completion.Usage = &models.TokenUsage{
+			PromptTokens:     usage.InputTokens,
+			CompletionTokens: usage.OutputTokens,
+			TotalTokens:      usage.InputTokens + usage.OutputTokens,
+		}
+	}
+	return completion, nil
+}
+
+// toAnthropicMessages converts OpenAI format messages to Anthropic format. Anthropic takes a single
+// system prompt, so system messages (e.g. instructions followed by a conversation summary) are joined.
+func toAnthropicMessages(messages []models.Message) (string, []models.AnthropicMessage) {
+	var anthropicMessages []models.AnthropicMessage
+	var systemParts []string
+
+	for _, msg := range messages {
+		if msg.Role == "system" {
+			systemParts = append(systemParts, msg.Content)
+		} else {
+			anthropicMessages = append(anthropicMessages, models.AnthropicMessage{
+				Role:    msg.Role,
+				Content: msg.Content,
+			})
+		}
 	}
 
-	return anthropicResp.Content[0].Text, nil
+	return strings.Join(systemParts, "\n\n"), anthropicMessages
 }
@@ -0,0 +1,343 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"unicode/utf8"
+
+	"promptforge/internal/config"
+	"promptforge/internal/models"
+)
+
+// ModelContextWindows lists context window sizes in tokens. Models not listed get DefaultContextWindow.
+var ModelContextWindows = map[string]int{
+	"gpt-4":                      8192,
+	"gpt-4-turbo":                128000,
+	"gpt-3.5-turbo":              16384,
+	"gpt-4.1":                    200000,
+	"gpt-4.1-mini":               200000,
+	"o3":                         200000,
+	"claude-3-5-sonnet-20241022": 200000,
+	"claude-3-haiku-20240307":    200000,
+	"claude-3-opus-20240229":     200000,
+}
+
+const (
+	DefaultContextWindow = 128000
+
+	// messageOverheadTokens covers the role and separators every chat message adds
+	messageOverheadTokens = 4
+	summaryPrefix         = "Summary of the earlier conversation:\n\n"
+	summarizerPrompt      = `You compress conversations between a user and a prompt engineering assistant so they can continue without the full history.
+
+Write a concise summary that keeps:
+- What the prompt is for and who will use it
+- Every requirement, constraint and preference the user stated
+- Decisions made and suggestions the user rejected
+- The latest revised prompt, verbatim
+- Questions that are still open
+
+If an earlier summary is given, merge it with the new messages into one summary. Reply with the summary only.`
+)
+
+// ContextWindow returns a model's context window in tokens
+func ContextWindow(model string) int {
+	if window, ok := ModelContextWindows[model]; ok {
+		return window
+	}
+	if strings.HasPrefix(model, "claude") {
+		return 200000
+	}
+	return DefaultContextWindow
+}
+
+// CountTokens estimates how many tokens model's tokenizer produces for text. Claude's tokenizer
+// yields more tokens per character than OpenAI's.
+func CountTokens(model, text string) int {
+	if text == "" {
+		return 0
+	}
+
+	charsPerToken := 4.0
+	if strings.HasPrefix(model, "claude") {
+		charsPerToken = 3.5
+	}
+
+	byChars := int(math.Ceil(float64(utf8.RuneCountInString(text)) / charsPerToken))
+	byWords := int(math.Ceil(float64(len(strings.Fields(text))) * 0.75))
+	return max(byChars, byWords)
+}
+
+// CountMessageTokens estimates the prompt tokens for a chat request
+func CountMessageTokens(model string, messages []models.Message) int {
+	total := 0
+	for _, msg := range messages {
+		total += CountTokens(model, msg.Content) + messageOverheadTokens
+	}
+	return total
+}
+
+// ApplicableSummary returns the summary covering the most of history, or nil. A summary only applies
+// when history passes through the message it was written up to.
+func ApplicableSummary(summaries []models.ConversationSummary, history []models.ConversationMessage) *models.ConversationSummary {
+	var best *models.ConversationSummary
+	for i := range summaries {
+		summary := &summaries[i]
+		n := summary.MessageCount
+		if n < 1 || n > len(history) || history[n-1].ID != summary.ThroughMessageID {
+			continue
+		}
+		if best == nil || n > best.MessageCount {
+			best = summary
+		}
+	}
+	return best
+}
+
+// ContextTurn is one turn of a stored conversation to fit into a model's context window
+type ContextTurn struct {
+	SystemPrompt string
+	History      []models.ConversationMessage // Oldest first
+	Summary      *models.ConversationSummary  // Applicable summary of the start of History, if any
+	UserTurn     string
+	Model        string
+}
+
+// FittedContext is what to send for a turn
+type FittedContext struct {
+	Messages []models.Message
+	Summary  *models.ConversationSummary // New summary to store; nil when the existing one was enough
+	Report   models.ContextReport
+}
+
+// ContextManager keeps conversations within their model's context window by summarizing older
+// turns with a cheaper model
+type ContextManager struct {
+	aiService *UnifiedAIService
+}
+
+func NewContextManager(aiService *UnifiedAIService) *ContextManager {
+	return &ContextManager{
+		aiService: aiService,
+	}
+}
+
+// Limit returns how many prompt tokens a request to model may use while leaving replyTokens for the reply
+func (cm *ContextManager) Limit(model string, replyTokens int) int {
+	threshold := config.Current().Context.Threshold
+	if threshold <= 0 || threshold > 1 {
+		threshold = 0.8
+	}
+	return int(float64(ContextWindow(model))*threshold) - replyTokens
+}
+
+// Fit returns the messages for a turn within limit prompt tokens. When the history does not fit, the
+// oldest messages not yet summarized are folded into the summary, keeping the most recent ones verbatim.
+func (cm *ContextManager) Fit(ctx context.Context, turn ContextTurn, limit int) (*FittedContext, error) {
+	cfg := config.Current().Context
+	history := turn.History
+
+	start, summaryText := 0, ""
+	report := models.ContextReport{Model: turn.Model, ContextWindow: ContextWindow(turn.Model)}
+	if turn.Summary != nil {
+		start, summaryText = turn.Summary.MessageCount, turn.Summary.Content
+		report.SummaryModel = turn.Summary.Model
+	}
+
+	messages := buildContext(turn.SystemPrompt, summaryText, history[start:], turn.UserTurn)
+	tokens := CountMessageTokens(turn.Model, messages)
+	// A stored summary that still makes the turn fit is reused; otherwise the turn is summarized
+	if turn.Summary != nil || tokens > limit {
+		observeSummaryReuse(tokens <= limit)
+	}
+	if tokens <= limit {
+		report.PromptTokens = tokens
+		report.CompactedMessageIDs = messageIDs(history[:start])
+		return &FittedContext{Messages: messages, Report: report}, nil
+	}
+
+	// Keep as many recent messages as fit next to a full-length summary, starting with a user turn
+	reserve := CountTokens(turn.Model, summaryPrefix) + cfg.SummaryMaxTokens + messageOverheadTokens
+	keep := min(max(cfg.KeepRecent, 0), len(history)-start)
+	for keep > 0 && CountMessageTokens(turn.Model, buildContext(turn.SystemPrompt, "", history[len(history)-keep:], turn.UserTurn))+reserve > limit {
+		keep--
+	}
+	split := len(history) - keep
+	for split < len(history) && history[split].Role != "user" {
+		split++
+	}
+	if split <= start {
+		return nil, fmt.Errorf("the latest messages do not fit the %d-token context window of %s", report.ContextWindow, turn.Model)
+	}
+
+	content, summaryModel, err := cm.summarize(ctx, summaryText, history[start:split], turn.Model)
+	if err != nil {
+		return nil, err
+	}
+
+	messages = buildContext(turn.SystemPrompt, content, history[split:], turn.UserTurn)
+	tokens = CountMessageTokens(turn.Model, messages)
+	if tokens > limit {
+		return nil, fmt.Errorf("the latest messages do not fit the %d-token context window of %s", report.ContextWindow, turn.Model)
+	}
+
+	last := history[split-1]
+	report.PromptTokens = tokens
+	report.SummaryModel = summaryModel
+	report.CompactedMessageIDs = messageIDs(history[:split])
+	report.Summarized = true
+
+	return &FittedContext{
+		Messages: messages,
+		Summary: &models.ConversationSummary{
+			ConversationID:   last.ConversationID,
+			ThroughMessageID: last.ID,
+			MessageCount:     split,
+			Content:          content,
+			Model:            summaryModel,
+			TokenCount:       CountTokens(turn.Model, content),
+		},
+		Report: report,
+	}, nil
+}
+
+// summarize folds messages into the previous summary with the configured summary model, falling back
+// to the conversation's own model if the summary model fails. It returns the summary and the model used.
+func (cm *ContextManager) summarize(ctx context.Context, previous string, messages []models.ConversationMessage, model string) (string, string, error) {
+	summaryModel := config.Current().Context.SummaryModel
+	if summaryModel == "" {
+		summaryModel = model
+	}
+
+	summary, err := cm.summarizeWith(ctx, summaryModel, previous, messages)
+	if err != nil && summaryModel != model {
+		summaryModel = model
+		summary, err = cm.summarizeWith(ctx, model, previous, messages)
+	}
+	if err != nil {
+		return "", "", fmt.Errorf("failed to summarize conversation: %v", err)
+	}
+	return summary, summaryModel, nil
+}
+
+// summarizeWith summarizes in as many requests as the model's context window needs, carrying the
+// summary from one request into the next
+func (cm *ContextManager) summarizeWith(ctx context.Context, model, previous string, messages []models.ConversationMessage) (string, error) {
+	maxTokens := config.Current().Context.SummaryMaxTokens
+	// Leave room for the instructions and the running summary
+	limit := cm.Limit(model, maxTokens) - CountTokens(model, summarizerPrompt) - maxTokens - 3*messageOverheadTokens
+	if limit <= 0 {
+		return "", fmt.Errorf("context window of %s is too small to summarize", model)
+	}
+
+	summary := previous
+	for start := 0; start < len(messages); {
+		var transcript strings.Builder
+		used := 0
+		end := start
+		for ; end < len(messages); end++ {
+			turn := formatTurn(messages[end])
+			tokens := CountTokens(model, turn)
+			if used+tokens > limit {
+				if end == start {
+					// A single message larger than the window is summarized from its beginning
+					transcript.WriteString(truncateToTokens(turn, limit))
+					end++
+				}
+				break
+			}
+			transcript.WriteString(turn)
+			used += tokens
+		}
+
+		var err error
+		if summary, err = cm.summarizeChunk(ctx, model, summary, transcript.String()); err != nil {
+			return "", err
+		}
+		start = end
+	}
+
+	return summary, nil
+}
+
+func (cm *ContextManager) summarizeChunk(ctx context.Context, model, previous, transcript string) (string, error) {
+	var content strings.Builder
+	if previous != "" {
+		content.WriteString("Earlier summary:\n")
+		content.WriteString(previous)
+		content.WriteString("\n\n")
+	}
+	content.WriteString("New messages:\n")
+	content.WriteString(transcript)
+
+	messages := []models.Message{
+		{Role: "system", Content: summarizerPrompt},
+		{Role: "user", Content: content.String()},
+	}
+
+	summary, err := cm.aiService.CallWithDefaultProvider(ctx, messages, 0.3, config.Current().Context.SummaryMaxTokens, model)
+	if err != nil {
+		return "", err
+	}
+	summary = strings.TrimSpace(summary)
+	if summary == "" {
+		return "", fmt.Errorf("%s returned an empty summary", model)
+	}
+	return summary, nil
+}
+
+// IsContextLengthError reports whether a provider rejected a request for exceeding the context window
+func IsContextLengthError(err error) bool {
+	if err == nil {
+		return false
+	}
+	message := strings.ToLower(err.Error())
+	for _, marker := range []string{"context_length_exceeded", "maximum context length", "prompt is too long", "context window"} {
+		if strings.Contains(message, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+func buildContext(systemPrompt, summary string, history []models.ConversationMessage, userTurn string) []models.Message {
+	messages := make([]models.Message, 0, len(history)+3)
+	messages = append(messages, models.Message{Role: "system", Content: systemPrompt})
+	if summary != "" {
+		messages = append(messages, models.Message{Role: "system", Content: summaryPrefix + summary})
+	}
+	for _, msg := range history {
+		messages = append(messages, models.Message{Role: msg.Role, Content: msg.Content})
+	}
+	return append(messages, models.Message{Role: "user", Content: userTurn})
+}
+
+func formatTurn(msg models.ConversationMessage) string {
+	role := msg.Role
+	if role != "" {
+		role = strings.ToUpper(role[:1]) + role[1:]
+	}
+	return fmt.Sprintf("%s: %s\n\n", role, msg.Content)
+}
+
+// truncateToTokens cuts text to roughly limit tokens, assuming the densest tokenizer
+func truncateToTokens(text string, limit int) string {
+	runes := []rune(text)
+	if maxRunes := limit * 3; len(runes) > maxRunes {
+		return string(runes[:maxRunes]) + "\n[truncated]\n\n"
+	}
+	return text
+}
+
+func messageIDs(messages []models.ConversationMessage) []int64 {
+	if len(messages) == 0 {
+		return nil
+	}
+	ids := make([]int64, len(messages))
+	for i, msg := range messages {
+		ids[i] = msg.ID
+	}
+	return ids
+}
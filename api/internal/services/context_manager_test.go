@@ -0,0 +1,182 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"promptforge/internal/config"
+	"promptforge/internal/models"
+)
+
+// stubProvider answers summary requests with a numbered summary and other requests with "Reply",
+// recording the model of every request. reject lets a test fail requests before they are answered.
+func stubProvider(t *testing.T, reject func(req models.OpenAIRequest) (int, string)) *[]models.OpenAIRequest {
+	t.Helper()
+
+	var requests []models.OpenAIRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req models.OpenAIRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		requests = append(requests, req)
+
+		if reject != nil {
+			if status, body := reject(req); status != 0 {
+				w.WriteHeader(status)
+				w.Write([]byte(body))
+				return
+			}
+		}
+
+		content := "Reply"
+		if req.Messages[0].Content == summarizerPrompt {
+			content = fmt.Sprintf("Summary %d", len(requests))
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"choices": []map[string]interface{}{{"message": map[string]string{"role": "assistant", "content": content}}},
+		})
+	}))
+	t.Cleanup(server.Close)
+
+	previous := config.Current()
+	config.Set(&config.Config{
+		DefaultProvider: config.ProviderOpenAI,
+		OpenAI:          config.OpenAIConfig{APIKey: "test-key", BaseURL: server.URL},
+		Context:         config.ContextConfig{SummaryModel: "cheap-model", Threshold: 1, KeepRecent: 4, SummaryMaxTokens: 50},
+	})
+	t.Cleanup(func() { config.Set(previous) })
+
+	ModelContextWindows["small-model"] = 1000
+	ModelContextWindows["cheap-model"] = 1000
+	t.Cleanup(func() {
+		delete(ModelContextWindows, "small-model")
+		delete(ModelContextWindows, "cheap-model")
+	})
+
+	return &requests
+}
+
+// longHistory returns alternating user and assistant messages of roughly 100 tokens each
+func longHistory(n int) []models.ConversationMessage {
+	history := make([]models.ConversationMessage, n)
+	for i := range history {
+		role := "user"
+		if i%2 == 1 {
+			role = "assistant"
+		}
+		history[i] = models.ConversationMessage{ID: int64(i + 1), ConversationID: "conv-1", Role: role, Content: strings.Repeat("word ", 100)}
+	}
+	return history
+}
+
+func TestCountTokens(t *testing.T) {
+	text := strings.Repeat("abcdefg ", 50)
+	if CountTokens("gpt-4.1", "") != 0 {
+		t.Error("Expected no tokens for empty text")
+	}
+	if openai, claude := CountTokens("gpt-4.1", text), CountTokens("claude-3-5-sonnet-20241022", text); openai != 100 || claude <= openai {
+		t.Errorf("Expected 100 tokens for OpenAI and more for Claude, got %d and %d", openai, claude)
+	}
+	if ContextWindow("o3") != 200000 || ContextWindow("claude-sonnet-4") != 200000 || ContextWindow("unknown") != DefaultContextWindow {
+		t.Error("Unexpected context windows")
+	}
+}
+
+func TestApplicableSummary(t *testing.T) {
+	history := longHistory(6)
+	summaries := []models.ConversationSummary{
+		{ID: 1, ThroughMessageID: 2, MessageCount: 2},
+		{ID: 2, ThroughMessageID: 4, MessageCount: 4},
+		{ID: 3, ThroughMessageID: 9, MessageCount: 4}, // Written on another branch
+	}
+
+	if summary := ApplicableSummary(summaries, history); summary == nil || summary.ID != 2 {
+		t.Errorf("Expected the deepest summary on this branch, got %+v", summary)
+	}
+	if summary := ApplicableSummary(summaries, history[:3]); summary == nil || summary.ID != 1 {
+		t.Errorf("Expected only summaries within the history to apply, got %+v", summary)
+	}
+}
+
+func TestFitSummarizesOlderTurns(t *testing.T) {
+	requests := stubProvider(t, nil)
+	cm := NewContextManager(NewUnifiedAIService())
+	history := longHistory(10)
+
+	// Short histories are sent as they are
+	fitted, err := cm.Fit(context.Background(), ContextTurn{SystemPrompt: "Be helpful", History: history[:4], UserTurn: "Next", Model: "small-model"}, 1000)
+	if err != nil || fitted.Summary != nil || len(fitted.Messages) != 6 || len(*requests) != 0 {
+		t.Fatalf("Expected the history to fit without summarizing, got %+v (%v)", fitted, err)
+	}
+
+	fitted, err = cm.Fit(context.Background(), ContextTurn{SystemPrompt: "Be helpful", History: history, UserTurn: "Next", Model: "small-model"}, 1000)
+	if err != nil {
+		t.Fatalf("Failed to fit history: %v", err)
+	}
+	if fitted.Summary == nil || fitted.Summary.ThroughMessageID != 6 || fitted.Summary.MessageCount != 6 || fitted.Summary.Model != "cheap-model" {
+		t.Fatalf("Expected the first six messages to be summarized by the summary model, got %+v", fitted.Summary)
+	}
+	if !fitted.Report.Summarized || len(fitted.Report.CompactedMessageIDs) != 6 || fitted.Report.PromptTokens > 1000 {
+		t.Errorf("Unexpected report %+v", fitted.Report)
+	}
+	if fitted.Messages[1].Role != "system" || !strings.HasSuffix(fitted.Messages[1].Content, fitted.Summary.Content) {
+		t.Errorf("Expected the summary as a system message after the instructions, got %+v", fitted.Messages[1])
+	}
+	if len(fitted.Messages) != 7 || fitted.Messages[2].Role != "user" {
+		t.Errorf("Expected the last four messages to follow the summary starting with a user turn, got %d messages", len(fitted.Messages))
+	}
+
+	// With the stored summary the next turn fits without summarizing again
+	*requests = nil
+	next, err := cm.Fit(context.Background(), ContextTurn{SystemPrompt: "Be helpful", History: history, Summary: fitted.Summary, UserTurn: "Next", Model: "small-model"}, 1000)
+	if err != nil || next.Summary != nil || next.Report.Summarized || len(next.Report.CompactedMessageIDs) != 6 || len(*requests) != 0 {
+		t.Errorf("Expected the stored summary to be reused, got %+v (%v)", next, err)
+	}
+}
+
+func TestSummaryModelFallback(t *testing.T) {
+	requests := stubProvider(t, func(req models.OpenAIRequest) (int, string) {
+		if req.Model == "cheap-model" {
+			return http.StatusNotFound, "deployment not found"
+		}
+		return 0, ""
+	})
+	cm := NewContextManager(NewUnifiedAIService())
+
+	fitted, err := cm.Fit(context.Background(), ContextTurn{SystemPrompt: "Be helpful", History: longHistory(10), UserTurn: "Next", Model: "small-model"}, 1000)
+	if err != nil {
+		t.Fatalf("Failed to fit history: %v", err)
+	}
+	if fitted.Summary.Model != "small-model" || (*requests)[len(*requests)-1].Model != "small-model" {
+		t.Errorf("Expected the conversation's model to summarize when the summary model fails, got %+v", fitted.Summary)
+	}
+}
+
+func TestReplyRetriesAfterContextLengthError(t *testing.T) {
+	rejected := false
+	requests := stubProvider(t, func(req models.OpenAIRequest) (int, string) {
+		if req.Messages[0].Content != summarizerPrompt && !rejected {
+			rejected = true
+			return http.StatusBadRequest, `{"error": {"code": "context_length_exceeded"}}`
+		}
+		return 0, ""
+	})
+	// Room for the reply: the history fits at first, but not at half the budget
+	ModelContextWindows["small-model"] = 4000
+	pe := NewPromptEngineer(NewUnifiedAIService())
+
+	reply, err := pe.Reply(context.Background(), SessionTurn{History: longHistory(8), UserTurn: "Next", Model: "small-model"})
+	if err != nil {
+		t.Fatalf("Expected the reply to succeed after compacting, got %v", err)
+	}
+	if reply.Content != "Reply" || reply.Summary == nil || !reply.Context.Summarized {
+		t.Errorf("Expected a reply with a new summary, got %+v", reply)
+	}
+	if len(*requests) < 3 {
+		t.Errorf("Expected a rejected request, a summary and a retry, got %d requests", len(*requests))
+	}
+}
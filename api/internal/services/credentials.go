@@ -0,0 +1,149 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"syscall"
+	"time"
+
+	"promptforge/internal/config"
+)
+
+// Credential is a workspace's own key for a provider, used instead of the server's. BaseURL is
+// optional; for Azure OpenAI it replaces the endpoint of every deployment. Err is set when the key
+// could not be decrypted, and fails calls to the provider rather than falling back to the server's key.
+type Credential struct {
+	APIKey  string
+	BaseURL string
+	Err     error
+}
+
+type credentialsKey struct{}
+
+// WithCredentials makes calls made with ctx use credentials for their providers
+func WithCredentials(ctx context.Context, credentials map[config.AIProvider]Credential) context.Context {
+	return context.WithValue(ctx, credentialsKey{}, credentials)
+}
+
+// HasCredential reports whether calls made with ctx use the workspace's own key for provider
+func HasCredential(ctx context.Context, provider config.AIProvider) bool {
+	_, ok := credentialFor(ctx, provider)
+	return ok
+}
+
+func credentialFor(ctx context.Context, provider config.AIProvider) (Credential, bool) {
+	credentials, _ := ctx.Value(credentialsKey{}).(map[config.AIProvider]Credential)
+	credential, ok := credentials[provider]
+	return credential, ok && (credential.APIKey != "" || credential.Err != nil)
+}
+
+// ValidateBaseURL checks a workspace's base URL: it must use https and its host must resolve only to
+// public addresses, so a workspace cannot make the server call hosts on its own network
+func ValidateBaseURL(ctx context.Context, raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || u.Scheme != "https" || u.Hostname() == "" || u.User != nil {
+		return fmt.Errorf("must be an https URL")
+	}
+	addrs, err := net.DefaultResolver.LookupNetIP(ctx, "ip", u.Hostname())
+	if err != nil {
+		return fmt.Errorf("failed to resolve %s: %v", u.Hostname(), err)
+	}
+	for _, addr := range addrs {
+		if !isPublic(addr) {
+			return fmt.Errorf("%s is not a public address", addr)
+		}
+	}
+	return nil
+}
+
+// sharedAddressSpace is the carrier-grade NAT range, which is not routable on the internet
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+// isPublic rejects loopback, private, link-local, multicast and unspecified addresses
+func isPublic(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	return addr.IsGlobalUnicast() && !addr.IsPrivate() && !sharedAddressSpace.Contains(addr)
+}
+
+// newPublicClient returns a client that only connects to public addresses. The address is checked
+// when dialing, so a host that passed ValidateBaseURL cannot be re-pointed at the server's network.
+func newPublicClient() *http.Client {
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Control: func(_, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if addr, err := netip.ParseAddr(host); err != nil || !isPublic(addr) {
+				return fmt.Errorf("refusing to connect to %s: not a public address", host)
+			}
+			return nil
+		},
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{Transport: transport}
+}
+
+// openAISettings returns the OpenAI settings for a call, with the workspace's credential if it has one
+func openAISettings(ctx context.Context) (config.OpenAIConfig, error) {
+	settings := config.Current().OpenAI
+	if credential, ok := credentialFor(ctx, config.ProviderOpenAI); ok {
+		if credential.Err != nil {
+			return settings, credential.Err
+		}
+		settings.APIKey = credential.APIKey
+		if credential.BaseURL != "" {
+			settings.BaseURL = credential.BaseURL
+		}
+	}
+	return settings, nil
+}
+
+func anthropicSettings(ctx context.Context) (config.AnthropicConfig, error) {
+	settings := config.Current().Anthropic
+	if credential, ok := credentialFor(ctx, config.ProviderAnthropic); ok {
+		if credential.Err != nil {
+			return settings, credential.Err
+		}
+		settings.APIKey = credential.APIKey
+		if credential.BaseURL != "" {
+			settings.BaseURL = credential.BaseURL
+		}
+	}
+	return settings, nil
+}
+
+// azureSettings applies the workspace's credential to every deployment, so its calls never fall
+// back to the server's key
+func azureSettings(ctx context.Context) (config.AzureOpenAIConfig, error) {
+	settings := config.Current().AzureOpenAI
+	credential, ok := credentialFor(ctx, config.ProviderAzureOpenAI)
+	if !ok {
+		return settings, nil
+	}
+	if credential.Err != nil {
+		return settings, credential.Err
+	}
+	settings.APIKey = credential.APIKey
+	if credential.BaseURL != "" {
+		settings.BaseURL = credential.BaseURL
+	}
+	deployments := make([]config.AzureDeployment, len(settings.Deployments))
+	for i, d := range settings.Deployments {
+		d.APIKey = credential.APIKey
+		if credential.BaseURL != "" {
+			d.Endpoint = credential.BaseURL
+		}
+		deployments[i] = d
+	}
+	settings.Deployments = deployments
+	return settings, nil
+}
@@ -1,6 +1,7 @@
 package services
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"strings"
@@ -19,9 +20,9 @@ func NewEvalGenerator(aiService *UnifiedAIService) *EvalGenerator {
 	}
 }
 
-func (e *EvalGenerator) GenerateEvaluationSuite(req models.EvalGenerateRequest) (*models.EvalData, error) {
+func (e *EvalGenerator) GenerateEvaluationSuite(ctx context.Context, req models.EvalGenerateRequest) (*models.EvalData, error) {
 	// Generate test cases
-	testCases, err := e.generateTestCases(req)
+	testCases, err := e.generateTestCases(ctx, req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate test cases: %v", err)
 	}
@@ -49,7 +50,7 @@ func (e *EvalGenerator) GenerateEvaluationSuite(req models.EvalGenerateRequest)
 	return evalData, nil
 }
 
-func (e *EvalGenerator) generateTestCases(req models.EvalGenerateRequest) ([]models.TestCase, error) {
+func (e *EvalGenerator) generateTestCases(ctx context.Context, req models.EvalGenerateRequest) ([]models.TestCase, error) {
 	prompt := e.buildTestCaseGenerationPrompt(req)
 
 	messages := []models.Message{
@@ -61,7 +62,7 @@ func (e *EvalGenerator) generateTestCases(req models.EvalGenerateRequest) ([]mod
 		model = "gpt-4.1"
 	}
 
-	response, err := e.aiService.CallWithDefaultProvider(messages, 0.7, 2000, model)
+	response, err := e.aiService.CallWithDefaultProvider(ctx, messages, 0.7, 2000, model)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,88 @@
+package services
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"promptforge/internal/config"
+	"promptforge/internal/metrics"
+	"promptforge/internal/models"
+)
+
+var (
+	providerRequestDuration = metrics.Factory.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "promptforge_provider_request_duration_seconds",
+		Help:    "Latency of AI provider calls, successful or not.",
+		Buckets: metrics.DefBuckets,
+	}, []string{"provider", "model"})
+	providerErrors = metrics.Factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "promptforge_provider_errors_total",
+		Help: "AI provider calls that failed.",
+	}, []string{"provider", "model"})
+	providerTokens = metrics.Factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "promptforge_provider_tokens_total",
+		Help: "Tokens used by AI provider calls, as reported by the provider.",
+	}, []string{"provider", "model", "type"})
+	providerCost = metrics.Factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "promptforge_provider_cost_usd_total",
+		Help: "Estimated cost of AI provider calls in USD.",
+	}, []string{"provider", "model"})
+	summaryReuse = metrics.Factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "promptforge_conversation_summary_reuse_total",
+		Help: "Prompt engineer turns that needed a conversation summary, by whether the stored one was reused.",
+	}, []string{"result"})
+)
+
+// otherModel labels calls to models outside the known lists, so clients cannot create new series
+const otherModel = "other"
+
+// observeCall records the latency, outcome, token usage and cost of one provider call
+func observeCall(provider config.AIProvider, model string, start time.Time, completion *models.Completion, err error) {
+	if completion != nil && knownModel(provider, completion.Model) {
+		model = completion.Model
+	}
+	if model == "" {
+		model = "default"
+	} else if !knownModel(provider, model) {
+		model = otherModel
+	}
+
+	providerRequestDuration.WithLabelValues(string(provider), model).Observe(time.Since(start).Seconds())
+	if err != nil {
+		providerErrors.WithLabelValues(string(provider), model).Inc()
+		return
+	}
+	if completion.Usage != nil {
+		providerTokens.WithLabelValues(string(provider), model, "prompt").Add(float64(completion.Usage.PromptTokens))
+		providerTokens.WithLabelValues(string(provider), model, "completion").Add(float64(completion.Usage.CompletionTokens))
+	}
+	providerCost.WithLabelValues(string(provider), model).Add(completion.Cost)
+}
+
+// knownModel reports whether model has a price or context window listed, or an Azure OpenAI deployment
+func knownModel(provider config.AIProvider, model string) bool {
+	if _, ok := ModelPrices[model]; ok {
+		return true
+	}
+	if _, ok := ModelContextWindows[model]; ok {
+		return true
+	}
+	if provider == config.ProviderAzureOpenAI {
+		for _, name := range config.Current().AzureOpenAI.Models() {
+			if name == model {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// observeSummaryReuse records whether a turn could reuse the stored conversation summary or had to summarize
+func observeSummaryReuse(reused bool) {
+	result := "summarized"
+	if reused {
+		result = "reused"
+	}
+	summaryReuse.WithLabelValues(result).Inc()
+}
@@ -22,7 +22,11 @@ func NewOpenAIService() *OpenAIService {
 }
 
 func (s *OpenAIService) CallAzureOpenAI(messages []models.Message, temperature float64, maxTokens int, model string) (string, error) {
-	endpoint := config.GetEndpointURL(model)
+	deployments := config.Current().AzureOpenAI.DeploymentsFor(model)
+	if len(deployments) == 0 {
+		return "", fmt.Errorf("no Azure OpenAI deployment configured for model %q", model)
+	}
+	endpoint := deployments[0].URL()
 
 	// o3 model only supports temperature = 1
 	if model == "o3" {
@@ -54,7 +58,7 @@ func (s *OpenAIService) CallAzureOpenAI(messages []models.Message, temperature f
 	}
 
 	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("api-key", config.AppConfig.AzureOpenAI.APIKey)
+	req.Header.Set("api-key", deployments[0].APIKey)
 
 	resp, err := s.client.Do(req)
 	if err != nil {
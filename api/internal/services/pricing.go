@@ -0,0 +1,31 @@
+package services
+
+import "promptforge/internal/models"
+
+// ModelPrice is what a model costs in USD per million tokens
+type ModelPrice struct {
+	Input  float64
+	Output float64
+}
+
+// ModelPrices lists list prices used to estimate execution costs. Models not listed are recorded without a cost.
+var ModelPrices = map[string]ModelPrice{
+	"gpt-4":                      {Input: 30, Output: 60},
+	"gpt-4-turbo":                {Input: 10, Output: 30},
+	"gpt-3.5-turbo":              {Input: 0.5, Output: 1.5},
+	"gpt-4.1":                    {Input: 2, Output: 8},
+	"gpt-4.1-mini":               {Input: 0.4, Output: 1.6},
+	"o3":                         {Input: 2, Output: 8},
+	"claude-3-5-sonnet-20241022": {Input: 3, Output: 15},
+	"claude-3-haiku-20240307":    {Input: 0.25, Output: 1.25},
+	"claude-3-opus-20240229":     {Input: 15, Output: 75},
+}
+
+// EstimateCost returns the cost in USD of a call to model with the given usage, or zero when either is unknown
+func EstimateCost(model string, usage *models.TokenUsage) float64 {
+	price, ok := ModelPrices[model]
+	if !ok || usage == nil {
+		return 0
+	}
+	return (float64(usage.PromptTokens)*price.Input + float64(usage.CompletionTokens)*price.Output) / 1e6
+}
@@ -1,6 +1,7 @@
 package services
 
 import (
+	"context"
 	"fmt"
 	"strings"
 	"unicode"
@@ -19,7 +20,7 @@ func NewPromptAnalyzer(aiService *UnifiedAIService) *PromptAnalyzer {
 }
 
 // AnalyzePrompt performs comprehensive prompt analysis using the enhanced methodology
-func (pa *PromptAnalyzer) AnalyzePrompt(prompt, model string) (string, error) {
+func (pa *PromptAnalyzer) AnalyzePrompt(ctx context.Context, prompt, model string) (string, error) {
 	// Create the enhanced critique system prompt based on the provided template
 	critiqueSystemPrompt := `[Prompt] Act as a perfect prompt engineer. Your task is to analyze the given prompt and provide insights into its structure, content, and potential issues that may affect the model's response.
 
@@ -79,7 +80,7 @@ PROMPT TO ANALYZE:
 		model = models.DefaultGPTModel // Default model
 	}
 
-	response, err := pa.aiService.CallWithDefaultProvider(messages, 0.7, 2000, model)
+	response, err := pa.aiService.CallWithDefaultProvider(ctx, messages, 0.7, 2000, model)
 	if err != nil {
 		return "", fmt.Errorf("failed to get comprehensive analysis: %v", err)
 	}
@@ -88,7 +89,7 @@ PROMPT TO ANALYZE:
 }
 
 // DualAnalyzePrompt performs both quick and detailed analysis in one go
-func (pa *PromptAnalyzer) DualAnalyzePrompt(prompt, model string) (*models.DualAnalysisData, error) {
+func (pa *PromptAnalyzer) DualAnalyzePrompt(ctx context.Context, prompt, model string) (*models.DualAnalysisData, error) {
 	if model == "" {
 		model = models.DefaultGPTModel // Default model
 	}
@@ -103,7 +104,7 @@ func (pa *PromptAnalyzer) DualAnalyzePrompt(prompt, model string) (*models.DualA
 
 	// Quick analysis
 	go func() {
-		report, err := pa.generateQuickAnalysis(prompt, metrics, model)
+		report, err := pa.generateQuickAnalysis(ctx, prompt, metrics, model)
 		if err != nil {
 			errorChan <- fmt.Errorf("quick analysis failed: %v", err)
 			return
@@ -113,7 +114,7 @@ func (pa *PromptAnalyzer) DualAnalyzePrompt(prompt, model string) (*models.DualA
 
 	// Detailed analysis (reuse existing method)
 	go func() {
-		report, err := pa.AnalyzePrompt(prompt, model)
+		report, err := pa.AnalyzePrompt(ctx, prompt, model)
 		if err != nil {
 			errorChan <- fmt.Errorf("detailed analysis failed: %v", err)
 			return
@@ -145,7 +146,7 @@ func (pa *PromptAnalyzer) DualAnalyzePrompt(prompt, model string) (*models.DualA
 }
 
 // generateQuickAnalysis creates a succinct analysis report
-func (pa *PromptAnalyzer) generateQuickAnalysis(prompt string, metrics PromptMetrics, model string) (string, error) {
+func (pa *PromptAnalyzer) generateQuickAnalysis(ctx context.Context, prompt string, metrics PromptMetrics, model string) (string, error) {
 	quickSystemPrompt := `You are a prompt analysis expert. Provide a QUICK, SUCCINCT analysis of the given prompt.
 
 Keep your response focused and brief. Analyze these key aspects:
@@ -175,7 +176,7 @@ PROMPT: %s`, metrics.Characters, metrics.Words, metrics.Lines, prompt)
 		{Role: "user", Content: analysisPrompt},
 	}
 
-	response, err := pa.aiService.CallWithDefaultProvider(messages, 0.5, 500, model)
+	response, err := pa.aiService.CallWithDefaultProvider(ctx, messages, 0.5, 500, model)
 	if err != nil {
 		return "", fmt.Errorf("failed to get quick analysis: %v", err)
 	}
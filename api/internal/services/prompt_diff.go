@@ -0,0 +1,217 @@
+package services
+
+import (
+	"strings"
+
+	"promptforge/internal/models"
+)
+
+// maxDiffLines bounds the lines DiffLines aligns once their common start and end are set aside.
+// Beyond it the differing middle is reported as deleted and inserted as a whole, since aligning it
+// takes time proportional to its length times the number of changes.
+const maxDiffLines = 20000
+
+// DiffLines computes a line-level diff between two texts with Myers' algorithm, in linear space
+func DiffLines(oldText, newText string) []models.DiffLine {
+	d := &differ{old: splitLines(oldText), new: splitLines(newText)}
+
+	// Compare lines by number rather than by text
+	ids := map[string]int{}
+	d.a, d.b = make([]int, len(d.old)), make([]int, len(d.new))
+	for i, line := range d.old {
+		d.a[i] = lineID(ids, line)
+	}
+	for j, line := range d.new {
+		d.b[j] = lineID(ids, line)
+	}
+
+	d.compare(0, len(d.a), 0, len(d.b))
+	return d.diff
+}
+
+func lineID(ids map[string]int, line string) int {
+	id, ok := ids[line]
+	if !ok {
+		id = len(ids)
+		ids[line] = id
+	}
+	return id
+}
+
+// differ builds the diff of old against new in order, a and b holding their lines' IDs
+type differ struct {
+	old, new []string
+	a, b     []int
+	diff     []models.DiffLine
+}
+
+// compare diffs a[aLo:aHi] against b[bLo:bHi]
+func (d *differ) compare(aLo, aHi, bLo, bHi int) {
+	for aLo < aHi && bLo < bHi && d.a[aLo] == d.b[bLo] {
+		d.equal(aLo, bLo)
+		aLo++
+		bLo++
+	}
+	suffix := 0
+	for aLo < aHi-suffix && bLo < bHi-suffix && d.a[aHi-suffix-1] == d.b[bHi-suffix-1] {
+		suffix++
+	}
+	aHi, bHi = aHi-suffix, bHi-suffix
+
+	switch {
+	case aLo == aHi:
+		for j := bLo; j < bHi; j++ {
+			d.insert(j)
+		}
+	case bLo == bHi:
+		for i := aLo; i < aHi; i++ {
+			d.delete(i)
+		}
+	case (aHi-aLo)+(bHi-bLo) > maxDiffLines:
+		for i := aLo; i < aHi; i++ {
+			d.delete(i)
+		}
+		for j := bLo; j < bHi; j++ {
+			d.insert(j)
+		}
+	default:
+		x, y, u, v := d.middleSnake(aLo, aHi, bLo, bHi)
+		d.compare(aLo, aLo+x, bLo, bLo+y)
+		for i, j := aLo+x, bLo+y; i < aLo+u; i, j = i+1, j+1 {
+			d.equal(i, j)
+		}
+		d.compare(aLo+u, aHi, bLo+v, bHi)
+	}
+
+	for k := 0; k < suffix; k++ {
+		d.equal(aHi+k, bHi+k)
+	}
+}
+
+// middleSnake finds the run of equal lines in the middle of a shortest edit script of
+// a[aLo:aHi] against b[bLo:bHi], by searching from both ends until the searches meet. It returns
+// the run's start (x, y) and end (u, v), relative to aLo and bLo. Both ranges must be non-empty and
+// differ at their first and last lines.
+func (d *differ) middleSnake(aLo, aHi, bLo, bHi int) (x, y, u, v int) {
+	n, m := aHi-aLo, bHi-bLo
+	delta := n - m
+	odd := delta%2 != 0
+	limit := (n + m + 1) / 2
+	offset := limit + 1
+	// forward[k] and backward[k] hold how far along a each search has reached on diagonal k, where
+	// the backward search counts from the ends of both ranges
+	forward := make([]int, 2*limit+3)
+	backward := make([]int, 2*limit+3)
+
+	for edits := 0; edits <= limit; edits++ {
+		for k := -edits; k <= edits; k += 2 {
+			var start int
+			if k == -edits || (k != edits && forward[offset+k-1] < forward[offset+k+1]) {
+				start = forward[offset+k+1]
+			} else {
+				start = forward[offset+k-1] + 1
+			}
+			end := start
+			for end < n && end-k < m && d.a[aLo+end] == d.b[bLo+end-k] {
+				end++
+			}
+			forward[offset+k] = end
+			if back := delta - k; odd && back >= -(edits-1) && back <= edits-1 && end+backward[offset+back] >= n {
+				return start, start - k, end, end - k
+			}
+		}
+
+		for k := -edits; k <= edits; k += 2 {
+			var start int
+			if k == -edits || (k != edits && backward[offset+k-1] < backward[offset+k+1]) {
+				start = backward[offset+k+1]
+			} else {
+				start = backward[offset+k-1] + 1
+			}
+			end := start
+			for end < n && end-k < m && d.a[aHi-1-end] == d.b[bHi-1-(end-k)] {
+				end++
+			}
+			backward[offset+k] = end
+			if ahead := delta - k; !odd && ahead >= -edits && ahead <= edits && end+forward[offset+ahead] >= n {
+				return n - end, m - (end - k), n - start, m - (start - k)
+			}
+		}
+	}
+	// Unreachable: the searches meet within limit edits
+	return 0, 0, 0, 0
+}
+
+func (d *differ) equal(i, j int) {
+	d.diff = append(d.diff, models.DiffLine{Op: models.DiffOpEqual, Text: d.old[i], OldLine: i + 1, NewLine: j + 1})
+}
+
+func (d *differ) delete(i int) {
+	d.diff = append(d.diff, models.DiffLine{Op: models.DiffOpDelete, Text: d.old[i], OldLine: i + 1})
+}
+
+func (d *differ) insert(j int) {
+	d.diff = append(d.diff, models.DiffLine{Op: models.DiffOpInsert, Text: d.new[j], NewLine: j + 1})
+}
+
+// DiffPromptVersions compares two snapshots of the same prompt
+func DiffPromptVersions(from, to *models.PromptVersion) *models.PromptDiff {
+	result := &models.PromptDiff{
+		PromptID:      to.PromptID,
+		FromVersion:   from.Version,
+		ToVersion:     to.Version,
+		ChangedFields: []string{},
+		Lines:         DiffLines(from.Content, to.Content),
+	}
+
+	fields := []struct {
+		name     string
+		old, new string
+	}{
+		{"title", from.Title, to.Title},
+		{"content", from.Content, to.Content},
+		{"description", from.Description, to.Description},
+		{"category", from.Category, to.Category},
+		{"tags", from.Tags, to.Tags},
+	}
+	for _, field := range fields {
+		if field.old != field.new {
+			result.ChangedFields = append(result.ChangedFields, field.name)
+		}
+	}
+	if !sameVariables(from.Variables, to.Variables) {
+		result.ChangedFields = append(result.ChangedFields, "variables")
+	}
+
+	for _, line := range result.Lines {
+		switch line.Op {
+		case models.DiffOpInsert:
+			result.Additions++
+		case models.DiffOpDelete:
+			result.Deletions++
+		}
+	}
+
+	return result
+}
+
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
+
+func sameVariables(a, b []models.TemplateVariable) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].Name != b[i].Name || a[i].Type != b[i].Type || a[i].Default != b[i].Default ||
+			a[i].Description != b[i].Description || a[i].Required != b[i].Required ||
+			strings.Join(a[i].Options, "\x00") != strings.Join(b[i].Options, "\x00") {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,110 @@
+package services
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"promptforge/internal/models"
+)
+
+func TestDiffLines(t *testing.T) {
+	diff := DiffLines("a\nb\nc", "a\nc\nd")
+
+	expected := []models.DiffLine{
+		{Op: models.DiffOpEqual, Text: "a", OldLine: 1, NewLine: 1},
+		{Op: models.DiffOpDelete, Text: "b", OldLine: 2},
+		{Op: models.DiffOpEqual, Text: "c", OldLine: 3, NewLine: 2},
+		{Op: models.DiffOpInsert, Text: "d", NewLine: 3},
+	}
+
+	if len(diff) != len(expected) {
+		t.Fatalf("Expected %d diff lines, got %d: %+v", len(expected), len(diff), diff)
+	}
+	for i := range expected {
+		if diff[i] != expected[i] {
+			t.Errorf("Line %d: expected %+v, got %+v", i, expected[i], diff[i])
+		}
+	}
+}
+
+func TestDiffLinesIsMinimal(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	randomText := func() string {
+		lines := make([]string, rng.Intn(30))
+		for i := range lines {
+			lines[i] = string(rune('a' + rng.Intn(4)))
+		}
+		return strings.Join(lines, "\n")
+	}
+
+	for i := 0; i < 500; i++ {
+		oldText, newText := randomText(), randomText()
+		var oldLines, newLines []string
+		edits := 0
+		for _, line := range DiffLines(oldText, newText) {
+			if line.Op != models.DiffOpInsert {
+				oldLines = append(oldLines, line.Text)
+			}
+			if line.Op != models.DiffOpDelete {
+				newLines = append(newLines, line.Text)
+			}
+			if line.Op != models.DiffOpEqual {
+				edits++
+			}
+		}
+		if strings.Join(oldLines, "\n") != oldText || strings.Join(newLines, "\n") != newText {
+			t.Fatalf("Diff of %q and %q does not rebuild both texts", oldText, newText)
+		}
+		a, b := splitLines(oldText), splitLines(newText)
+		if minimal := len(a) + len(b) - 2*lcsLength(a, b); edits != minimal {
+			t.Fatalf("Diff of %q and %q has %d edits, expected %d", oldText, newText, edits, minimal)
+		}
+	}
+}
+
+func lcsLength(a, b []string) int {
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+	return lcs[0][0]
+}
+
+func TestDiffLinesLargeInput(t *testing.T) {
+	oldLines, newLines := make([]string, maxDiffLines), make([]string, maxDiffLines)
+	for i := range oldLines {
+		oldLines[i] = fmt.Sprintf("old %d", i)
+		newLines[i] = fmt.Sprintf("new %d", i)
+	}
+	oldLines[0], newLines[0] = "header", "header"
+
+	diff := DiffLines(strings.Join(oldLines, "\n"), strings.Join(newLines, "\n"))
+	if len(diff) != 2*maxDiffLines-1 || diff[0].Op != models.DiffOpEqual || diff[1].Op != models.DiffOpDelete || diff[len(diff)-1].Op != models.DiffOpInsert {
+		t.Errorf("Expected the common header, then the rest deleted and inserted as a whole, got %d lines", len(diff))
+	}
+}
+
+func TestDiffPromptVersions(t *testing.T) {
+	from := &models.PromptVersion{PromptID: 1, Version: 1, Title: "A", Content: "one\ntwo", Tags: "[]"}
+	to := &models.PromptVersion{PromptID: 1, Version: 2, Title: "B", Content: "one\nthree", Tags: "[]"}
+
+	diff := DiffPromptVersions(from, to)
+
+	if diff.Additions != 1 || diff.Deletions != 1 {
+		t.Errorf("Expected 1 addition and 1 deletion, got %d/%d", diff.Additions, diff.Deletions)
+	}
+	if len(diff.ChangedFields) != 2 || diff.ChangedFields[0] != "title" || diff.ChangedFields[1] != "content" {
+		t.Errorf("Expected title and content to change, got %v", diff.ChangedFields)
+	}
+}
@@ -0,0 +1,151 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"promptforge/internal/models"
+)
+
+// PromptEngineerSystemPrompt starts server-side prompt engineer sessions that do not send their own
+const PromptEngineerSystemPrompt = `You are a professional prompt engineer. Optimize prompts for AI systems through iterative refinement.
+
+Process:
+1. Analyze the user's prompt objective and requirements
+2. Generate two sections:
+   a. Revised prompt: Clear, optimized version
+   b. Questions: Specific clarifications needed for further improvement
+3. Continue refinement until the prompt meets professional standards
+
+Focus on clarity, specificity, and effectiveness.`
+
+const (
+	maxTitleLength = 60
+	// titleMaxTokens leaves room for the hidden reasoning tokens of models like o3
+	titleMaxTokens = 500
+)
+
+// replyMaxTokens is the length limit for prompt engineer replies
+const replyMaxTokens = 2000
+
+type PromptEngineer struct {
+	aiService      *UnifiedAIService
+	contextManager *ContextManager
+}
+
+func NewPromptEngineer(aiService *UnifiedAIService) *PromptEngineer {
+	return &PromptEngineer{
+		aiService:      aiService,
+		contextManager: NewContextManager(aiService),
+	}
+}
+
+// SessionTurn is one prompt engineer turn in a stored conversation
+type SessionTurn struct {
+	SystemPrompt string                       // Defaults to PromptEngineerSystemPrompt
+	History      []models.ConversationMessage // The active branch, oldest first
+	Summaries    []models.ConversationSummary // Stored summaries of the conversation
+	UserTurn     string
+	Model        string
+	Temperature  float64
+}
+
+// SessionReply is the model's reply and how the history was fitted into its context window
+type SessionReply struct {
+	Content string
+	Summary *models.ConversationSummary // New summary to store, if one was written
+	Context models.ContextReport
+}
+
+// Reply continues a session with a new user turn. Older turns are summarized when the history would
+// not fit the model's context window, and again more aggressively if the provider still rejects it.
+func (pe *PromptEngineer) Reply(ctx context.Context, turn SessionTurn) (*SessionReply, error) {
+	systemPrompt := turn.SystemPrompt
+	if systemPrompt == "" {
+		systemPrompt = PromptEngineerSystemPrompt
+	}
+
+	contextTurn := ContextTurn{
+		SystemPrompt: systemPrompt,
+		History:      turn.History,
+		Summary:      ApplicableSummary(turn.Summaries, turn.History),
+		UserTurn:     turn.UserTurn,
+		Model:        turn.Model,
+	}
+	limit := pe.contextManager.Limit(turn.Model, replyMaxTokens)
+
+	var newSummary *models.ConversationSummary
+	for attempt := 0; ; attempt++ {
+		fitted, err := pe.contextManager.Fit(ctx, contextTurn, limit)
+		if err != nil {
+			return nil, err
+		}
+		if fitted.Summary != nil {
+			newSummary = fitted.Summary
+			contextTurn.Summary = fitted.Summary
+		}
+
+		content, err := pe.aiService.CallWithDefaultProvider(ctx, fitted.Messages, turn.Temperature, replyMaxTokens, turn.Model)
+		if err != nil {
+			// Token counts are estimates; retry once with half the budget if the provider disagrees
+			if attempt == 0 && IsContextLengthError(err) {
+				limit /= 2
+				continue
+			}
+			return nil, err
+		}
+
+		fitted.Report.Summarized = newSummary != nil
+		return &SessionReply{Content: content, Summary: newSummary, Context: fitted.Report}, nil
+	}
+}
+
+// GenerateTitle asks the model for a short title describing a session's first exchange
+func (pe *PromptEngineer) GenerateTitle(ctx context.Context, userTurn, reply, model string) (string, error) {
+	messages := []models.Message{
+		{
+			Role:    "system",
+			Content: "You name prompt engineering sessions. Reply with a title of at most six words describing what the user wants a prompt for. Reply with the title only, without quotes or punctuation at the end.",
+		},
+		{
+			Role:    "user",
+			Content: fmt.Sprintf("User:\n%s\n\nAssistant:\n%s", userTurn, reply),
+		},
+	}
+
+	response, err := pe.aiService.CallWithDefaultProvider(ctx, messages, 0.3, titleMaxTokens, model)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate title: %v", err)
+	}
+
+	title := CleanTitle(response)
+	if title == "" {
+		return "", fmt.Errorf("failed to generate title: empty response")
+	}
+	return title, nil
+}
+
+// CleanTitle reduces a model-written title to one short line without quotes or a "Title:" label
+func CleanTitle(raw string) string {
+	title := strings.TrimSpace(raw)
+	if i := strings.IndexByte(title, '\n'); i >= 0 {
+		title = strings.TrimSpace(title[:i])
+	}
+	title = strings.Trim(title, "\"'`*# ")
+	if len(title) >= len("title:") && strings.EqualFold(title[:len("title:")], "title:") {
+		title = strings.Trim(title[len("title:"):], "\"'`*# ")
+	}
+	title = strings.TrimRight(title, ".")
+	return TruncateTitle(title)
+}
+
+// TruncateTitle shortens text to a conversation title, e.g. the first message when the model cannot name a session
+func TruncateTitle(text string) string {
+	text = strings.Join(strings.Fields(text), " ")
+	runes := []rune(text)
+	if len(runes) <= maxTitleLength {
+		return text
+	}
+	return strings.TrimSpace(string(runes[:maxTitleLength])) + "..."
+}
@@ -0,0 +1,20 @@
+package services
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCleanTitle(t *testing.T) {
+	tests := map[string]string{
+		"Support Ticket Triage":             "Support Ticket Triage",
+		"\"Title: Support Ticket Triage.\"": "Support Ticket Triage",
+		"**Code Review Checklist**\nExtra":  "Code Review Checklist",
+		strings.Repeat("word ", 20):         strings.TrimSpace(strings.Repeat("word ", 12)) + "...",
+	}
+	for raw, expected := range tests {
+		if title := CleanTitle(raw); title != expected {
+			t.Errorf("CleanTitle(%q) = %q, expected %q", raw, title, expected)
+		}
+	}
+}
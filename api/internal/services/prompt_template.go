@@ -0,0 +1,222 @@
+package services
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"promptforge/internal/models"
+)
+
+// templateVariablePattern matches {{name}} slots, allowing whitespace inside the braces
+var templateVariablePattern = regexp.MustCompile(`\{\{\s*([^{}]+?)\s*\}\}`)
+
+// TemplateError collects every problem found while validating template values
+type TemplateError struct {
+	Problems []string
+}
+
+func (e *TemplateError) Error() string {
+	return "invalid template variables: " + strings.Join(e.Problems, "; ")
+}
+
+type TemplateEngine struct{}
+
+func NewTemplateEngine() *TemplateEngine {
+	return &TemplateEngine{}
+}
+
+// ExtractVariables returns the distinct variable names in the order they first appear
+func (te *TemplateEngine) ExtractVariables(content string) []string {
+	var names []string
+	seen := make(map[string]bool)
+
+	for _, match := range templateVariablePattern.FindAllStringSubmatch(content, -1) {
+		name := match[1]
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+
+	return names
+}
+
+// ResolveVariables merges the declared variables with the ones detected in the content.
+// Detected variables without a declaration become required strings.
+func (te *TemplateEngine) ResolveVariables(content string, declared []models.TemplateVariable) ([]models.TemplateVariable, error) {
+	detected := te.ExtractVariables(content)
+	detectedSet := make(map[string]bool, len(detected))
+	for _, name := range detected {
+		detectedSet[name] = true
+	}
+
+	var problems []string
+	declarations := make(map[string]models.TemplateVariable, len(declared))
+	for _, variable := range declared {
+		name := strings.TrimSpace(variable.Name)
+		if !detectedSet[name] {
+			problems = append(problems, fmt.Sprintf("variable %q is not used in the prompt content", name))
+			continue
+		}
+		variable.Name = name
+		declarations[name] = variable
+	}
+
+	resolved := make([]models.TemplateVariable, 0, len(detected))
+	for _, name := range detected {
+		variable, exists := declarations[name]
+		if !exists {
+			variable = models.TemplateVariable{Name: name, Required: true}
+		}
+		if variable.Type == "" {
+			variable.Type = models.VariableTypeString
+		}
+
+		if err := validateDeclaration(variable); err != nil {
+			problems = append(problems, err.Error())
+			continue
+		}
+		resolved = append(resolved, variable)
+	}
+
+	if len(problems) > 0 {
+		return nil, &TemplateError{Problems: problems}
+	}
+
+	return resolved, nil
+}
+
+// Render validates the supplied values against the variables and substitutes them into the content
+func (te *TemplateEngine) Render(content string, variables []models.TemplateVariable, values map[string]interface{}) (string, error) {
+	declarations := make(map[string]models.TemplateVariable, len(variables))
+	for _, variable := range variables {
+		declarations[variable.Name] = variable
+	}
+
+	// Ad-hoc templates have no declarations, so treat every slot as a required string
+	for _, name := range te.ExtractVariables(content) {
+		if _, exists := declarations[name]; !exists {
+			declarations[name] = models.TemplateVariable{Name: name, Type: models.VariableTypeString, Required: true}
+		}
+	}
+
+	// Sorted, so the problems are reported in the same order every time
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var problems []string
+	for _, name := range names {
+		if _, exists := declarations[name]; !exists {
+			problems = append(problems, fmt.Sprintf("unknown variable %q", name))
+		}
+	}
+
+	rendered := make(map[string]string, len(declarations))
+	for _, name := range te.ExtractVariables(content) {
+		variable := declarations[name]
+
+		raw, supplied := values[name]
+		if !supplied || raw == nil {
+			if variable.Default != "" {
+				rendered[name] = variable.Default
+				continue
+			}
+			if variable.Required {
+				problems = append(problems, fmt.Sprintf("missing value for required variable %q", name))
+			}
+			rendered[name] = ""
+			continue
+		}
+
+		value, err := formatValue(variable, raw)
+		if err != nil {
+			problems = append(problems, err.Error())
+			continue
+		}
+		rendered[name] = value
+	}
+
+	if len(problems) > 0 {
+		return "", &TemplateError{Problems: problems}
+	}
+
+	return templateVariablePattern.ReplaceAllStringFunc(content, func(slot string) string {
+		name := templateVariablePattern.FindStringSubmatch(slot)[1]
+		return rendered[name]
+	}), nil
+}
+
+func validateDeclaration(variable models.TemplateVariable) error {
+	switch variable.Type {
+	case models.VariableTypeString, models.VariableTypeNumber, models.VariableTypeInteger, models.VariableTypeBoolean:
+	case models.VariableTypeEnum:
+		if len(variable.Options) == 0 {
+			return fmt.Errorf("enum variable %q must declare options", variable.Name)
+		}
+	default:
+		return fmt.Errorf("variable %q has unsupported type %q", variable.Name, variable.Type)
+	}
+
+	if variable.Default != "" {
+		if _, err := formatValue(variable, variable.Default); err != nil {
+			return fmt.Errorf("default for %s", err.Error())
+		}
+	}
+
+	return nil
+}
+
+// formatValue checks a supplied value against the variable type and returns its text form
+func formatValue(variable models.TemplateVariable, raw interface{}) (string, error) {
+	var text string
+	switch v := raw.(type) {
+	case string:
+		text = v
+	case float64:
+		text = strconv.FormatFloat(v, 'f', -1, 64)
+	case int:
+		text = strconv.Itoa(v)
+	case int64:
+		text = strconv.FormatInt(v, 10)
+	case bool:
+		text = strconv.FormatBool(v)
+	default:
+		return "", fmt.Errorf("variable %q must be a scalar value", variable.Name)
+	}
+
+	switch variable.Type {
+	case models.VariableTypeNumber:
+		if _, err := strconv.ParseFloat(text, 64); err != nil {
+			return "", fmt.Errorf("variable %q must be a number", variable.Name)
+		}
+	case models.VariableTypeInteger:
+		if _, err := strconv.ParseInt(text, 10, 64); err != nil {
+			return "", fmt.Errorf("variable %q must be an integer", variable.Name)
+		}
+	case models.VariableTypeBoolean:
+		b, err := strconv.ParseBool(text)
+		if err != nil {
+			return "", fmt.Errorf("variable %q must be a boolean", variable.Name)
+		}
+		text = strconv.FormatBool(b)
+	case models.VariableTypeEnum:
+		allowed := false
+		for _, option := range variable.Options {
+			if option == text {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return "", fmt.Errorf("variable %q must be one of: %s", variable.Name, strings.Join(variable.Options, ", "))
+		}
+	}
+
+	return text, nil
+}
@@ -0,0 +1,145 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"promptforge/internal/models"
+)
+
+func TestExtractVariables(t *testing.T) {
+	engine := NewTemplateEngine()
+
+	names := engine.ExtractVariables("Hi {{customer_name}}, your order {{ order_id }} ships soon. Thanks {{customer_name}}!")
+
+	expected := []string{"customer_name", "order_id"}
+	if len(names) != len(expected) {
+		t.Fatalf("Expected %d variables, got %d: %v", len(expected), len(names), names)
+	}
+	for i, name := range expected {
+		if names[i] != name {
+			t.Errorf("Expected variable %d to be '%s', got '%s'", i, name, names[i])
+		}
+	}
+}
+
+func TestResolveVariables(t *testing.T) {
+	engine := NewTemplateEngine()
+	content := "Write a {{tone}} reply to {{customer_name}} in {{max_words}} words"
+
+	resolved, err := engine.ResolveVariables(content, []models.TemplateVariable{
+		{Name: "tone", Type: models.VariableTypeEnum, Options: []string{"formal", "casual"}, Default: "formal"},
+		{Name: "max_words", Type: models.VariableTypeInteger, Description: "Word budget"},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error resolving variables: %v", err)
+	}
+
+	if len(resolved) != 3 {
+		t.Fatalf("Expected 3 resolved variables, got %d", len(resolved))
+	}
+	if resolved[1].Name != "customer_name" || resolved[1].Type != models.VariableTypeString || !resolved[1].Required {
+		t.Errorf("Expected undeclared variable to default to a required string, got %+v", resolved[1])
+	}
+	if resolved[2].Description != "Word budget" {
+		t.Errorf("Expected declaration to be kept, got %+v", resolved[2])
+	}
+
+	tests := []struct {
+		name     string
+		declared []models.TemplateVariable
+	}{
+		{"Unused declaration", []models.TemplateVariable{{Name: "missing"}}},
+		{"Unsupported type", []models.TemplateVariable{{Name: "tone", Type: "date"}}},
+		{"Enum without options", []models.TemplateVariable{{Name: "tone", Type: models.VariableTypeEnum}}},
+		{"Invalid default", []models.TemplateVariable{{Name: "max_words", Type: models.VariableTypeInteger, Default: "many"}}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := engine.ResolveVariables(content, test.declared)
+			var templateErr *TemplateError
+			if !errors.As(err, &templateErr) {
+				t.Errorf("Expected TemplateError, got %v", err)
+			}
+		})
+	}
+}
+
+func TestRenderTemplate(t *testing.T) {
+	engine := NewTemplateEngine()
+	content := "Write a {{tone}} reply to {{ customer_name }} in {{max_words}} words. Urgent: {{urgent}}"
+	variables := []models.TemplateVariable{
+		{Name: "tone", Type: models.VariableTypeEnum, Options: []string{"formal", "casual"}, Default: "formal"},
+		{Name: "customer_name", Type: models.VariableTypeString, Required: true},
+		{Name: "max_words", Type: models.VariableTypeInteger, Required: true},
+		{Name: "urgent", Type: models.VariableTypeBoolean},
+	}
+
+	rendered, err := engine.Render(content, variables, map[string]interface{}{
+		"customer_name": "Acme",
+		"max_words":     float64(120),
+		"urgent":        true,
+	})
+	if err != nil {
+		t.Fatalf("Unexpected render error: %v", err)
+	}
+
+	expected := "Write a formal reply to Acme in 120 words. Urgent: true"
+	if rendered != expected {
+		t.Errorf("Expected '%s', got '%s'", expected, rendered)
+	}
+
+	tests := []struct {
+		name   string
+		values map[string]interface{}
+	}{
+		{"Missing required", map[string]interface{}{"max_words": 10}},
+		{"Wrong integer", map[string]interface{}{"customer_name": "Acme", "max_words": "ten"}},
+		{"Enum outside options", map[string]interface{}{"customer_name": "Acme", "max_words": 10, "tone": "angry"}},
+		{"Unknown variable", map[string]interface{}{"customer_name": "Acme", "max_words": 10, "extra": "x"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := engine.Render(content, variables, test.values); err == nil {
+				t.Error("Expected render to fail")
+			}
+		})
+	}
+}
+
+func TestRenderReportsUnknownVariablesInOrder(t *testing.T) {
+	engine := NewTemplateEngine()
+	variables := []models.TemplateVariable{{Name: "topic", Type: models.VariableTypeString}}
+	values := map[string]interface{}{"topic": "Go", "zeta": 1, "alpha": 2, "mid": 3}
+
+	for i := 0; i < 10; i++ {
+		_, err := engine.Render("Summarize {{topic}}", variables, values)
+		templateErr, ok := err.(*TemplateError)
+		if !ok {
+			t.Fatalf("Expected a TemplateError, got %v", err)
+		}
+		expected := `unknown variable "alpha",unknown variable "mid",unknown variable "zeta"`
+		if got := strings.Join(templateErr.Problems, ","); got != expected {
+			t.Fatalf("Expected problems sorted by name, got %s", got)
+		}
+	}
+}
+
+func TestRenderAdHocTemplate(t *testing.T) {
+	engine := NewTemplateEngine()
+
+	rendered, err := engine.Render("Summarize {{topic}}", nil, map[string]interface{}{"topic": "Go"})
+	if err != nil {
+		t.Fatalf("Unexpected render error: %v", err)
+	}
+	if rendered != "Summarize Go" {
+		t.Errorf("Expected 'Summarize Go', got '%s'", rendered)
+	}
+
+	if _, err := engine.Render("Summarize {{topic}}", nil, map[string]interface{}{}); err == nil {
+		t.Error("Expected missing ad-hoc variable to fail")
+	}
+}
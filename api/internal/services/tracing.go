@@ -0,0 +1,52 @@
+package services
+
+import (
+	"context"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"promptforge/internal/config"
+	"promptforge/internal/models"
+)
+
+const instrumentationName = "promptforge/internal/services"
+
+// startCallSpan starts a client span for one provider call, with attributes named as in the
+// OpenTelemetry semantic conventions for generative AI
+func startCallSpan(ctx context.Context, provider config.AIProvider, model string, temperature float64, maxTokens int) (context.Context, trace.Span) {
+	return otel.Tracer(instrumentationName).Start(ctx, strings.TrimSpace("chat "+model),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("gen_ai.operation.name", "chat"),
+			attribute.String("gen_ai.system", string(provider)),
+			attribute.String("gen_ai.request.model", model),
+			attribute.Float64("gen_ai.request.temperature", temperature),
+			attribute.Int("gen_ai.request.max_tokens", maxTokens),
+		),
+	)
+}
+
+// endCallSpan records the model that answered, token usage and cost, or the error
+func endCallSpan(span trace.Span, completion *models.Completion, err error) {
+	defer span.End()
+
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return
+	}
+	span.SetAttributes(
+		attribute.String("gen_ai.response.model", completion.Model),
+		attribute.Float64("promptforge.cost_usd", completion.Cost),
+	)
+	if usage := completion.Usage; usage != nil {
+		span.SetAttributes(
+			attribute.Int("gen_ai.usage.input_tokens", usage.PromptTokens),
+			attribute.Int("gen_ai.usage.output_tokens", usage.CompletionTokens),
+		)
+	}
+}
@@ -0,0 +1,56 @@
+package tracing
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "promptforge/internal/tracing"
+
+// Middleware records a server span for every request, named by method and route pattern, and makes
+// it the parent of the spans started while handling the request. A traceparent header continues the
+// caller's trace.
+func Middleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			route := c.Path()
+			if route == "" {
+				route = "unmatched"
+			}
+
+			ctx := otel.GetTextMapPropagator().Extract(req.Context(), propagation.HeaderCarrier(req.Header))
+			ctx, span := otel.Tracer(instrumentationName).Start(ctx, req.Method+" "+route,
+				trace.WithSpanKind(trace.SpanKindServer),
+				trace.WithAttributes(
+					attribute.String("http.request.method", req.Method),
+					attribute.String("http.route", route),
+					attribute.String("url.path", req.URL.Path),
+				),
+			)
+			defer span.End()
+			c.SetRequest(req.WithContext(ctx))
+
+			err := next(c)
+			if err != nil {
+				c.Error(err)
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+			}
+
+			status := c.Response().Status
+			span.SetAttributes(attribute.Int("http.response.status_code", status))
+			if status >= 500 && err == nil {
+				span.SetStatus(codes.Error, fmt.Sprintf("%d %s", status, http.StatusText(status)))
+			}
+			return nil
+		}
+	}
+}
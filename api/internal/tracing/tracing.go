@@ -0,0 +1,50 @@
+// Package tracing sets up the OpenTelemetry SDK to export spans for requests, provider calls and
+// database queries to stdout or to an OTLP/HTTP collector
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// NewExporter returns the exporter named by TRACING_EXPORTER, or nil for "none". The otlp exporter
+// posts to endpoint's /v1/traces, as OTEL_EXPORTER_OTLP_ENDPOINT is defined.
+func NewExporter(ctx context.Context, name, endpoint string) (sdktrace.SpanExporter, error) {
+	switch strings.ToLower(name) {
+	case "", "none":
+		return nil, nil
+	case "stdout":
+		return stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+	case "otlp":
+		if endpoint == "" {
+			return nil, fmt.Errorf("the otlp exporter needs OTEL_EXPORTER_OTLP_ENDPOINT")
+		}
+		return otlptracehttp.New(ctx, otlptracehttp.WithEndpointURL(strings.TrimSuffix(endpoint, "/")+"/v1/traces"))
+	default:
+		return nil, fmt.Errorf("unknown tracing exporter %q, expected none, stdout or otlp", name)
+	}
+}
+
+// Init installs a tracer provider that batches spans to exporter, and the W3C trace context
+// propagator. sampleRatio is the fraction of new traces recorded; traces sampled by a caller are
+// always recorded. Shut the provider down to flush the remaining spans.
+func Init(exporter sdktrace.SpanExporter, serviceName string, sampleRatio float64) *sdktrace.TracerProvider {
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(sampleRatio))),
+		sdktrace.WithResource(resource.NewSchemaless(attribute.String("service.name", serviceName))),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	return provider
+}
@@ -0,0 +1,91 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func startRecording(t *testing.T) *tracetest.SpanRecorder {
+	rec := tracetest.NewSpanRecorder()
+	provider := Init(tracetest.NewInMemoryExporter(), "promptforge", 1)
+	provider.RegisterSpanProcessor(rec)
+	t.Cleanup(func() { provider.Shutdown(context.Background()) })
+	return rec
+}
+
+func byName(spans []sdktrace.ReadOnlySpan, name string) sdktrace.ReadOnlySpan {
+	for _, span := range spans {
+		if span.Name() == name {
+			return span
+		}
+	}
+	return nil
+}
+
+func TestMiddleware(t *testing.T) {
+	rec := startRecording(t)
+
+	e := echo.New()
+	e.Use(Middleware())
+	e.GET("/api/things/:id", func(c echo.Context) error {
+		_, span := otel.Tracer("test").Start(c.Request().Context(), "work")
+		span.End()
+		return echo.NewHTTPError(http.StatusBadGateway, "upstream failed")
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/things/7", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	res := httptest.NewRecorder()
+	e.ServeHTTP(res, req)
+
+	if res.Code != http.StatusBadGateway {
+		t.Fatalf("Expected the handler's error to be rendered, got %d", res.Code)
+	}
+	spans := rec.Ended()
+	server, work := byName(spans, "GET /api/things/:id"), byName(spans, "work")
+	if server == nil || work == nil {
+		t.Fatalf("Expected server and handler spans, got %d spans", len(spans))
+	}
+	if server.SpanContext().TraceID().String() != "4bf92f3577b34da6a3ce929d0e0e4736" || server.Parent().SpanID().String() != "00f067aa0ba902b7" {
+		t.Errorf("Expected the caller's trace to continue, got parent %v", server.Parent())
+	}
+	if work.Parent().SpanID() != server.SpanContext().SpanID() || server.Status().Code != codes.Error {
+		t.Errorf("Expected the handler span under a failed server span, got status %v", server.Status())
+	}
+}
+
+func TestOTLPExporter(t *testing.T) {
+	paths := make(chan string, 1)
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths <- r.URL.Path
+	}))
+	defer collector.Close()
+
+	exporter, err := NewExporter(context.Background(), "otlp", collector.URL+"/")
+	if err != nil {
+		t.Fatalf("Failed to create exporter: %v", err)
+	}
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	_, span := provider.Tracer("test").Start(context.Background(), "chat gpt-4")
+	span.End()
+	provider.Shutdown(context.Background())
+
+	if path := <-paths; path != "/v1/traces" {
+		t.Errorf("Expected spans to be posted to /v1/traces, got %s", path)
+	}
+
+	if exporter, err := NewExporter(context.Background(), "none", ""); exporter != nil || err != nil {
+		t.Errorf("Expected no exporter when tracing is off, got %v, %v", exporter, err)
+	}
+	if _, err := NewExporter(context.Background(), "zipkin", ""); err == nil {
+		t.Error("Expected an unknown exporter to be rejected")
+	}
+}
@@ -1,43 +1,135 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"log/slog"
+	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 
+	"promptforge/internal/audit"
+	"promptforge/internal/auth"
 	"promptforge/internal/config"
-	"promptforge/internal/database"
 	"promptforge/internal/handlers"
+	"promptforge/internal/logging"
+	"promptforge/internal/metrics"
 	"promptforge/internal/services"
+	"promptforge/internal/tracing"
 )
 
 func main() {
-	// Initialize configuration
-	config.InitConfig()
+	// `secrets` manages the keystore that the configuration may refer to, so it runs before loading it
+	if len(os.Args) > 1 && os.Args[1] == "secrets" {
+		if err := runSecrets(os.Args[2:]); err != nil {
+			fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// Initialize configuration, refusing to start with an invalid one
+	if err := config.InitConfig(); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load configuration: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Write structured logs, never including the configured credentials
+	logger, err := logging.New(os.Stdout, config.AppConfig.Logging, func() []string { return config.Current().Secrets() })
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to initialize logging: %v\n", err)
+		os.Exit(1)
+	}
+	slog.SetDefault(logger)
+
+	// CLI subcommands such as `migrate` run instead of the server
+	if runCommand(os.Args[1:]) {
+		return
+	}
 
 	// Initialize database
-	db, err := database.NewDatabase()
+	db, err := initDatabase()
 	if err != nil {
-		fmt.Printf("Failed to initialize database: %v\n", err)
+		slog.Error("failed to initialize database", "error", err)
 		os.Exit(1)
 	}
 	defer db.Close()
 
+	// Export trace spans when configured
+	tracer, err := initTracing()
+	if err != nil {
+		slog.Error("failed to initialize tracing", "error", err)
+		os.Exit(1)
+	}
+	defer shutdownTracing(tracer)
+
 	// Initialize services
 	aiService := services.NewUnifiedAIService()
 
 	// Initialize handlers with dependencies
 	h := handlers.NewHandlers(db, aiService)
 
+	// Mirror the prompt library to git when configured
+	if syncer, err := initLibrarySync(db); err != nil {
+		slog.Error("failed to initialize library sync", "error", err)
+		os.Exit(1)
+	} else if syncer != nil {
+		h.SetLibrarySync(syncer)
+	}
+
+	// Enforce retention limits and take scheduled backups
+	startMaintenance(db)
+
+	// Pick up changed config and secret files
+	startConfigWatcher()
+
 	// Initialize Echo
 	e := echo.New()
+	e.HideBanner = true
+	e.HidePort = true
 
 	// Middleware
-	e.Use(middleware.Logger())
-	e.Use(middleware.Recover())
-	e.Use(middleware.CORS())
+	e.Use(middleware.RequestID())
+	e.Use(logging.Middleware())
+	e.Use(middleware.RecoverWithConfig(middleware.RecoverConfig{
+		LogErrorFunc: func(c echo.Context, err error, stack []byte) error {
+			slog.ErrorContext(c.Request().Context(), "recovered from panic", "error", err, "stack", string(stack))
+			return err
+		},
+	}))
+	if tracer != nil {
+		e.Use(tracing.Middleware())
+	}
+	if config.AppConfig.Metrics.Enabled {
+		e.Use(metrics.Middleware())
+		if listen := config.AppConfig.Metrics.Listen; listen != "" {
+			go serveMetrics(listen)
+		} else {
+			e.GET("/metrics", echo.WrapHandler(metrics.Handler()))
+		}
+	}
+	if origins := config.AppConfig.CORS.AllowedOrigins; len(origins) > 0 {
+		e.Use(middleware.CORSWithConfig(middleware.CORSConfig{
+			AllowOrigins:     origins,
+			AllowCredentials: true,
+		}))
+	}
+
+	// Admin-only routes need a signed-in administrator, so they are refused when authentication is off
+	adminOnly := []echo.MiddlewareFunc{auth.RequireAdmin}
+	if config.AppConfig.Auth.Enabled {
+		e.Use(auth.Middleware(db))
+		e.Use(h.WorkspaceCredentials)
+	}
+	e.Use(audit.Middleware(db, h.AuditSnapshots()))
 
 	// Serve static files
 	e.Static("/", "../frontend")
@@ -45,6 +137,28 @@ func main() {
 	// API Routes
 	api := e.Group("/api")
 	api.GET("/health", h.HealthCheck)
+
+	// Authentication routes
+	api.POST("/auth/login", h.Login)
+	api.POST("/auth/logout", h.Logout)
+	api.GET("/auth/me", h.GetCurrentUser)
+	api.GET("/auth/keys", h.GetAPIKeys)
+	api.POST("/auth/keys", h.CreateAPIKey)
+	api.DELETE("/auth/keys/:id", h.DeleteAPIKey)
+	api.GET("/users", h.GetUsers, adminOnly...)
+	api.POST("/users", h.CreateUser, adminOnly...)
+
+	// Workspace routes. An X-Workspace-ID header selects a workspace for the data routes below, where
+	// viewers are limited to reading.
+	api.GET("/workspaces", h.GetWorkspaces)
+	api.POST("/workspaces", h.CreateWorkspace)
+	api.GET("/workspaces/:id/members", h.GetWorkspaceMembers)
+	api.PUT("/workspaces/:id/members", h.SetWorkspaceMember)
+	api.DELETE("/workspaces/:id/members/:userId", h.RemoveWorkspaceMember)
+	api.GET("/workspaces/:id/credentials", h.GetWorkspaceCredentials)
+	api.PUT("/workspaces/:id/credentials/:provider", h.SetWorkspaceCredential)
+	api.DELETE("/workspaces/:id/credentials/:provider", h.DeleteWorkspaceCredential)
+
 	api.POST("/critique", h.CritiquePrompt)
 	api.POST("/dual-critique", h.DualCritiquePrompt)
 	api.POST("/execute", h.ExecutePrompt)
@@ -57,34 +171,132 @@ func main() {
 	// Conversation management routes
 	api.GET("/conversations", h.GetConversations)
 	api.GET("/conversations/:id", h.GetConversation)
-	api.POST("/conversations", h.SaveConversation)
-	api.DELETE("/conversations/:id", h.DeleteConversation)
+	api.POST("/conversations", h.SaveConversation, auth.RequireEditor)
+	api.POST("/conversations/:id/messages", h.AppendMessages, auth.RequireEditor)
+	api.PUT("/conversations/:id/messages/:messageId", h.EditMessage, auth.RequireEditor)
+	api.POST("/conversations/:id/fork", h.ForkConversation, auth.RequireEditor)
+	api.GET("/conversations/:id/branches", h.GetConversationBranches)
+	api.GET("/conversations/:id/branches/:messageId", h.GetConversationBranch)
+	api.DELETE("/conversations/:id", h.DeleteConversation, auth.RequireEditor)
 
 	// Prompt Library routes
 	api.GET("/prompts", h.GetSavedPrompts)
 	api.GET("/prompts/:id", h.GetSavedPrompt)
-	api.POST("/prompts", h.SavePrompt)
-	api.PUT("/prompts/:id", h.UpdatePrompt)
-	api.DELETE("/prompts/:id", h.DeletePrompt)
+	api.POST("/prompts", h.SavePrompt, auth.RequireEditor)
+	api.PUT("/prompts/:id", h.UpdatePrompt, auth.RequireEditor)
+	api.DELETE("/prompts/:id", h.DeletePrompt, auth.RequireEditor)
 	api.POST("/prompts/:id/use", h.UsePrompt)
+	api.GET("/prompts/:id/versions", h.GetPromptVersions)
+	api.GET("/prompts/:id/versions/diff", h.DiffPromptVersions)
+	api.GET("/prompts/:id/versions/:version", h.GetPromptVersion)
+	api.POST("/prompts/:id/versions/:version/restore", h.RestorePromptVersion, auth.RequireEditor)
+
+	// Tag and category management routes
+	api.GET("/tags", h.GetTags)
+	api.POST("/tags/rename", h.RenameTag, auth.RequireEditor)
+	api.POST("/tags/merge", h.MergeTags, auth.RequireEditor)
+	api.GET("/categories", h.GetCategories)
+	api.POST("/categories/rename", h.RenameCategory, auth.RequireEditor)
+	api.POST("/categories/merge", h.MergeCategories, auth.RequireEditor)
+
+	// Library export/import routes
+	api.GET("/library/export", h.ExportLibrary)
+	api.POST("/library/import", h.ImportLibrary, auth.RequireEditor)
+	api.GET("/library/sync", h.GetLibrarySyncStatus, adminOnly...)
+	api.POST("/library/sync", h.SyncLibrary, adminOnly...)
+	api.POST("/library/sync/pull", h.PullLibrary, adminOnly...)
+	api.POST("/library/sync/resolve", h.ResolveLibraryConflict, adminOnly...)
+
+	// Backup routes
+	api.GET("/backups", h.GetBackups, adminOnly...)
+	api.POST("/backups", h.CreateBackup, adminOnly...)
+
+	// Audit log route
+	api.GET("/audit", h.GetAuditLog, adminOnly...)
+
+	// Search route
+	api.GET("/search", h.Search)
 
 	// Eval Generator routes
 	api.POST("/generate-eval", h.GenerateEval)
+	api.GET("/eval-suites", h.GetEvalSuites)
+	api.GET("/eval-suites/:id", h.GetEvalSuite)
+	api.POST("/eval-suites", h.SaveEvalSuite, auth.RequireEditor)
+	api.PUT("/eval-suites/:id", h.UpdateEvalSuite, auth.RequireEditor)
+	api.DELETE("/eval-suites/:id", h.DeleteEvalSuite, auth.RequireEditor)
 
 	// Provider configuration route
 	api.GET("/providers", h.GetProviders)
+	api.GET("/config", h.GetConfig, adminOnly...)
 
 	// Start server
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8080"
+	port := config.AppConfig.Server.Port
+
+	slog.Info("PromptForge server starting",
+		"port", port,
+		"profile", config.AppConfig.Profile,
+		"config_file", config.AppConfig.File,
+		"database", db.Driver(),
+		"default_provider", config.AppConfig.DefaultProvider,
+		"auth", config.AppConfig.Auth.Enabled,
+		"metrics", config.AppConfig.Metrics.Enabled,
+		"tracing", config.AppConfig.Tracing.Exporter,
+	)
+
+	// Stop on SIGINT or SIGTERM, so the deferred cleanup runs
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := e.Shutdown(shutdownCtx); err != nil {
+			slog.Error("failed to shut down server", "error", err)
+		}
+	}()
+
+	if err := e.Start(":" + port); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		slog.Error("server stopped", "error", err)
+		shutdownTracing(tracer)
+		os.Exit(1)
 	}
+	slog.Info("server stopped")
+}
 
-	fmt.Printf("📚 PromptForge server starting on port %s\n", port)
-	fmt.Printf("📦 Database initialized successfully\n")
-	fmt.Printf("🧠 Enhanced prompt analyzer ready\n")
-	fmt.Printf("🤖 AI Providers: OpenAI, Azure OpenAI, Anthropic\n")
-	fmt.Printf("⚙️  Default Provider: %s\n", config.AppConfig.DefaultProvider)
+// shutdownTracing exports the spans still waiting in the batch processor
+func shutdownTracing(tracer *sdktrace.TracerProvider) {
+	if tracer == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := tracer.Shutdown(ctx); err != nil {
+		slog.Warn("failed to flush trace spans", "error", err)
+	}
+}
+
+// serveMetrics serves /metrics on its own address, so it can be kept off the public network
+func serveMetrics(listen string) {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", metrics.Handler())
+	server := &http.Server{Addr: listen, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	slog.Info("metrics server starting", "listen", listen)
+	if err := server.ListenAndServe(); err != nil {
+		slog.Error("metrics server stopped", "error", err)
+	}
+}
+
+// initTracing installs a tracer provider for the configured span exporter, returning nil when
+// tracing is off
+func initTracing() (*sdktrace.TracerProvider, error) {
+	cfg := config.AppConfig.Tracing
+	exporter, err := tracing.NewExporter(context.Background(), cfg.Exporter, cfg.Endpoint)
+	if err != nil || exporter == nil {
+		return nil, err
+	}
 
-	e.Logger.Fatal(e.Start(":" + port))
+	otel.SetErrorHandler(otel.ErrorHandlerFunc(func(err error) {
+		slog.Warn("failed to export trace spans", "error", err)
+	}))
+	return tracing.Init(exporter, cfg.ServiceName, cfg.SampleRatio), nil
 }
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"log/slog"
+	"time"
+
+	"promptforge/internal/config"
+	"promptforge/internal/database"
+	"promptforge/internal/models"
+)
+
+// startMaintenance runs the retention job and scheduled backups in the background when configured
+func startMaintenance(db *database.Database) {
+	retention := config.AppConfig.Retention
+	if retention.Enabled() && retention.Interval > 0 {
+		go every(retention.Interval, func() { runRetention(db) })
+	}
+
+	backup := config.AppConfig.Backup
+	if backup.Interval > 0 && db.Driver() == database.DriverSQLite {
+		go every(backup.Interval, func() {
+			if err := runBackup(db, backup.Dir, backup.Keep); err != nil {
+				slog.Error("scheduled backup failed", "error", err)
+			}
+		})
+	}
+}
+
+// every runs fn now and then once per interval
+func every(interval time.Duration, fn func()) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		fn()
+		<-ticker.C
+	}
+}
+
+func retentionPolicy() models.RetentionPolicy {
+	cfg := config.AppConfig.Retention
+	return models.RetentionPolicy{
+		HistoryMaxAge:       cfg.HistoryMaxAge,
+		HistoryMaxRows:      cfg.HistoryMaxRows,
+		ConversationMaxAge:  cfg.ConversationMaxAge,
+		ConversationMaxRows: cfg.ConversationMaxRows,
+	}
+}
+
+func runRetention(db *database.Database) {
+	result, err := db.ApplyRetention(retentionPolicy())
+	if err != nil {
+		slog.Error("retention job failed", "error", err)
+		return
+	}
+	if result.HistoryDeleted > 0 || result.ConversationsDeleted > 0 {
+		slog.Info("retention removed old data", "history_entries", result.HistoryDeleted, "conversations", result.ConversationsDeleted)
+	}
+}
+
+// runBackup backs up db to dir and keeps the newest keep backups (all when keep is zero)
+func runBackup(db *database.Database, dir string, keep int) error {
+	backup, err := db.Backup(dir)
+	if err != nil {
+		return err
+	}
+	slog.Info("backed up database", "backup", backup.Name, "bytes", backup.Size)
+
+	if keep > 0 {
+		deleted, err := database.PruneBackups(dir, keep)
+		for _, name := range deleted {
+			slog.Info("deleted old backup", "backup", name)
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"promptforge/internal/config"
+)
+
+// startConfigWatcher reloads the configuration when its files change or on SIGHUP, so rotated API keys
+// take effect without a restart
+func startConfigWatcher() {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	config.Watch(config.AppConfig.Watch.Interval, hup, logConfigReload)
+}
+
+// logConfigReload logs which settings changed. Secret values are never logged, only that they changed.
+func logConfigReload(changes []config.Change, err error) {
+	if err != nil {
+		slog.Error("configuration reload failed, keeping the current configuration", "error", err)
+		return
+	}
+	if len(changes) == 0 {
+		slog.Info("configuration reloaded without changes")
+		return
+	}
+	for _, change := range changes {
+		attrs := []any{"setting", change.Key}
+		if !change.Secret {
+			attrs = append(attrs, "from", change.From, "to", change.To)
+		}
+		if change.Applied {
+			slog.Info("setting changed", attrs...)
+		} else {
+			slog.Warn("setting changed, restart to apply", attrs...)
+		}
+	}
+}
@@ -216,19 +216,7 @@ async function executeTest() {
             
             data = await response.json();
             
-            // Save history to database
-            const historyPrompt = variables.length > 0 ? `[Variables] ${prompt}` : prompt;
-            await saveToHistory({
-                prompt: historyPrompt,
-                model: model,
-                temperature,
-                max_tokens: maxTokens,
-                success: data.success,
-                response: data.success ? data.data : "",
-                error_msg: data.success ? "" : data.error
-            });
-            
-            // Display single model results
+            // Display single model results (the server has recorded the execution in history)
             displaySingleModelResults(data, model, temperature, maxTokens, variables, processedPrompt);
             
         } else {
@@ -91,6 +91,15 @@ async function fetchProviderInfo() {
         AppState.providers = providerInfo;
         AppState.currentProvider = providerInfo.default;
         
+        // Azure OpenAI offers the models it has deployments for
+        if (providerInfo.azure_deployments && providerInfo.azure_deployments.length > 0) {
+            const known = ProviderModels['azure-openai'];
+            const seen = new Set();
+            ProviderModels['azure-openai'] = providerInfo.azure_deployments
+                .filter(d => !seen.has(d.model) && seen.add(d.model))
+                .map(d => known.find(m => m.value === d.model) || { value: d.model, name: d.model, context: 'unknown' });
+        }
+        
         updateProviderUI();
         updateModelDropdowns();
         console.log('✅ Provider info loaded:', AppState.currentProvider);
@@ -358,6 +367,96 @@ async function healthCheck() {
     }
 }
 
+// Show the login form whenever the API asks for authentication. The session cookie is sent with
+// every same-origin request, so nothing else needs to change once signed in. API requests also carry
+// the selected workspace, if any.
+const WORKSPACE_KEY = 'promptforge_workspace';
+const apiFetch = window.fetch.bind(window);
+window.fetch = async (...args) => {
+    const workspace = localStorage.getItem(WORKSPACE_KEY);
+    if (workspace && String(args[0]).startsWith(AppState.API_BASE)) {
+        const options = { ...(args[1] || {}) };
+        options.headers = new Headers(options.headers);
+        options.headers.set('X-Workspace-ID', workspace);
+        args[1] = options;
+    }
+    const response = await apiFetch(...args);
+    if (response.status === 401 && !String(args[0]).endsWith('/auth/login')) {
+        showLoginModal();
+    }
+    return response;
+};
+
+// selectWorkspace switches the library to a workspace's shared prompts, or back to your own with null
+function selectWorkspace(workspaceId) {
+    if (workspaceId) {
+        localStorage.setItem(WORKSPACE_KEY, String(workspaceId));
+    } else {
+        localStorage.removeItem(WORKSPACE_KEY);
+    }
+    window.location.reload();
+}
+
+function showLoginModal() {
+    if (document.getElementById('login-modal')) {
+        return;
+    }
+
+    const modalHtml = `
+        <div class="modal-overlay" id="login-modal">
+            <div class="modal">
+                <div class="modal-header">
+                    <h3 class="modal-title">Sign in to PromptForge</h3>
+                </div>
+                <form class="modal-body" id="login-form">
+                    <div class="form-group">
+                        <label class="form-label" for="login-username">Username</label>
+                        <input class="form-input" id="login-username" autocomplete="username" required>
+                    </div>
+                    <div class="form-group">
+                        <label class="form-label" for="login-password">Password</label>
+                        <input class="form-input" id="login-password" type="password" autocomplete="current-password" required>
+                    </div>
+                    <div class="form-label" id="login-error" style="color: #f44747;"></div>
+                    <div class="modal-footer">
+                        <button class="action-btn" type="submit">Sign in</button>
+                    </div>
+                </form>
+            </div>
+        </div>
+    `;
+
+    document.body.insertAdjacentHTML('beforeend', modalHtml);
+    document.getElementById('login-form').addEventListener('submit', login);
+    document.getElementById('login-username').focus();
+}
+
+async function login(event) {
+    event.preventDefault();
+    const errorEl = document.getElementById('login-error');
+    errorEl.textContent = '';
+
+    try {
+        const response = await fetch(`${AppState.API_BASE}/auth/login`, {
+            method: 'POST',
+            headers: { 'Content-Type': 'application/json' },
+            body: JSON.stringify({
+                username: document.getElementById('login-username').value,
+                password: document.getElementById('login-password').value
+            })
+        });
+        const data = await response.json();
+        if (!data.success) {
+            errorEl.textContent = data.error || 'Sign in failed';
+            return;
+        }
+        // Reload so every panel fetches the signed-in user's data
+        window.location.reload();
+    } catch (error) {
+        errorEl.textContent = `Sign in failed: ${error.message}`;
+    }
+}
+
 // Setup resize handles
 function setupResizeHandles() {
     // Add resize handle to sidebar
@@ -661,6 +760,13 @@ async function showHistory() {
             const statusText = item.success ? 'Success' : 'Error';
             const timestamp = new Date(item.timestamp).toLocaleString();
             const displayPrompt = item.prompt.length > 100 ? item.prompt.substring(0, 100) + '...' : item.prompt;
+            const runDetails = [
+                item.provider,
+                item.latency_ms ? `${item.latency_ms} ms` : '',
+                item.token_usage ? `${item.token_usage.total_tokens} tokens` : '',
+                item.cost ? `$${item.cost.toFixed(4)}` : '',
+                item.prompt_id ? `Prompt #${item.prompt_id}${item.prompt_version ? ` v${item.prompt_version}` : ''}` : ''
+            ].filter(Boolean).join(' | ');
             
             historyHtml += `
                 <div class="history-item">
@@ -671,6 +777,7 @@ async function showHistory() {
                     <div class="history-details">
                         <strong>Prompt:</strong> ${displayPrompt}<br>
                         <strong>Model:</strong> ${item.model} | <strong>Settings:</strong> Temp: ${item.temperature}, Max Tokens: ${item.max_tokens}
+                        ${runDetails ? `<br><strong>Run:</strong> ${runDetails}` : ''}
                     </div>
                     <details style="margin-top: 8px;">
                         <summary style="cursor: pointer; color: #569cd6; font-size: 12px;">View Response</summary>
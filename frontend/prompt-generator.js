@@ -3,16 +3,8 @@ const PromptGeneratorState = {
     conversation: [],
     isConversationActive: false,
     currentConversationId: null,
-    systemPrompt: `You are a professional prompt engineer. Optimize prompts for AI systems through iterative refinement.
-
-Process:
-1. Analyze the user's prompt objective and requirements
-2. Generate two sections:
-   a. Revised prompt: Clear, optimized version
-   b. Questions: Specific clarifications needed for further improvement
-3. Continue refinement until the prompt meets professional standards
-
-Focus on clarity, specificity, and effectiveness.`
+    revision: null, // Server revision of the saved conversation, sent back to detect edits from another tab
+    branches: [] // Branches of the saved conversation, shown as a switcher when there is more than one
 };
 
 // Generate unique conversation ID
@@ -20,8 +12,15 @@ function generateConversationId() {
     return 'conv_' + Date.now() + '_' + Math.random().toString(36).substr(2, 9);
 }
 
-// Save conversation to database
-async function saveConversation() {
+// Save conversation to database. Saves are queued so each one sends the revision returned by the last.
+let conversationSaveQueue = Promise.resolve();
+
+function saveConversation() {
+    conversationSaveQueue = conversationSaveQueue.then(persistConversation);
+    return conversationSaveQueue;
+}
+
+async function persistConversation() {
     if (!PromptGeneratorState.currentConversationId || PromptGeneratorState.conversation.length === 0) {
         return;
     }
@@ -45,12 +44,33 @@ async function saveConversation() {
                     role: msg.role,
                     content: msg.content,
                     timestamp: msg.timestamp
-                }))
+                })),
+                revision: PromptGeneratorState.revision ?? undefined
             })
         });
 
-        if (!response.ok) {
+        const data = await response.json();
+        if (response.status === 409) {
+            // Another tab changed this conversation; show its version rather than overwriting it
+            console.warn('Conversation changed elsewhere, reloading:', data.error);
+            await loadConversation(PromptGeneratorState.currentConversationId);
+        } else if (!response.ok) {
             console.error('Failed to save conversation:', response.status);
+        } else if (data.data) {
+            PromptGeneratorState.revision = data.data.revision;
+
+            // Keep server message IDs so the user can branch from any saved message
+            let assignedIds = false;
+            (data.data.messages || []).forEach((msg, index) => {
+                const local = PromptGeneratorState.conversation[index];
+                if (local && local.id !== msg.id) {
+                    local.id = msg.id;
+                    assignedIds = true;
+                }
+            });
+            if (assignedIds) {
+                await loadBranches();
+            }
         }
     } catch (error) {
         console.error('Error saving conversation:', error);
@@ -64,14 +84,8 @@ async function loadConversation(conversationId) {
         const data = await response.json();
 
         if (data.success && data.data) {
-            PromptGeneratorState.conversation = data.data.messages || [];
-            PromptGeneratorState.currentConversationId = conversationId;
-            PromptGeneratorState.isConversationActive = true;
-            
-            // Show input area and render conversation
-            document.getElementById('conversation-input-area').style.display = 'block';
-            renderConversation();
-            
+            showConversation(data.data);
+            await loadBranches();
             return true;
         }
     } catch (error) {
@@ -80,6 +94,65 @@ async function loadConversation(conversationId) {
     return false;
 }
 
+// Show a conversation returned by the server, with messages from its active branch
+function showConversation(conversation) {
+    PromptGeneratorState.conversation = conversation.messages || [];
+    PromptGeneratorState.currentConversationId = conversation.id;
+    PromptGeneratorState.revision = conversation.revision;
+    PromptGeneratorState.isConversationActive = true;
+
+    // Show input area and render conversation
+    document.getElementById('conversation-input-area').style.display = 'block';
+    renderConversation();
+}
+
+// Load the conversation's branches for the branch switcher
+async function loadBranches() {
+    try {
+        const response = await fetch(`${AppState.API_BASE}/conversations/${PromptGeneratorState.currentConversationId}/branches`);
+        const data = await response.json();
+        PromptGeneratorState.branches = data.success ? data.data || [] : [];
+    } catch (error) {
+        console.error('Error loading branches:', error);
+        PromptGeneratorState.branches = [];
+    }
+    renderConversation();
+}
+
+// Continue the conversation from an earlier message. The messages after it stay on their own branch;
+// forking from the last message of another branch switches to that branch.
+async function branchFromMessage(messageId) {
+    // Let pending saves finish so the fork sees the latest revision
+    await conversationSaveQueue;
+
+    try {
+        const response = await fetch(`${AppState.API_BASE}/conversations/${PromptGeneratorState.currentConversationId}/fork`, {
+            method: 'POST',
+            headers: {
+                'Content-Type': 'application/json',
+            },
+            body: JSON.stringify({
+                message_id: messageId,
+                revision: PromptGeneratorState.revision ?? undefined
+            })
+        });
+
+        const data = await response.json();
+        if (data.success && data.data) {
+            showConversation(data.data);
+            await loadBranches();
+            document.getElementById('conversation-input').focus();
+        } else if (response.status === 409) {
+            await loadConversation(PromptGeneratorState.currentConversationId);
+        } else {
+            showErrorMessage(data.error || 'Failed to branch conversation');
+        }
+    } catch (error) {
+        console.error('Error branching conversation:', error);
+        showErrorMessage(error.message);
+    }
+}
+
 // Load last conversation on page load
 async function loadLastConversation() {
     const savedConversationId = localStorage.getItem('currentConversationId');
@@ -101,6 +174,8 @@ function startNewConversation() {
     PromptGeneratorState.conversation = [];
     PromptGeneratorState.isConversationActive = true;
     PromptGeneratorState.currentConversationId = generateConversationId();
+    PromptGeneratorState.revision = null;
+    PromptGeneratorState.branches = [];
     
     // Save current conversation ID to localStorage
     localStorage.setItem('currentConversationId', PromptGeneratorState.currentConversationId);
@@ -130,6 +205,8 @@ function clearConversation() {
     PromptGeneratorState.conversation = [];
     PromptGeneratorState.isConversationActive = false;
     PromptGeneratorState.currentConversationId = null;
+    PromptGeneratorState.revision = null;
+    PromptGeneratorState.branches = [];
     
     // Clear localStorage
     localStorage.removeItem('currentConversationId');
@@ -171,52 +248,44 @@ async function sendMessage() {
     // Render conversation
     renderConversation();
     
-    // Save conversation after adding user message
-    saveConversation();
-    
     try {
-        // Prepare messages for API
-        const messages = [
-            { role: 'system', content: PromptGeneratorState.systemPrompt },
-            ...PromptGeneratorState.conversation.map(msg => ({
-                role: msg.role,
-                content: msg.content
-            }))
-        ];
-        
-        // Call API
+        // The server loads the history, replies and saves both turns, so the opening message must be saved first
+        await conversationSaveQueue;
+
         const response = await fetch(`${AppState.API_BASE}/prompt-engineer`, {
             method: 'POST',
             headers: {
                 'Content-Type': 'application/json',
             },
             body: JSON.stringify({
-                messages: messages,
+                conversation_id: PromptGeneratorState.currentConversationId,
+                message: message,
                 model: 'o3',
-                temperature: 0.7
+                temperature: 0.7,
+                revision: PromptGeneratorState.revision ?? undefined
             })
         });
         
         const data = await response.json();
         
-        if (data.success) {
-            // Add AI response to conversation
-            const aiMessage = {
-                role: 'assistant',
-                content: data.data,
-                timestamp: new Date()
-            };
-            
-            PromptGeneratorState.conversation.push(aiMessage);
-            renderConversation();
-            
-            // Save conversation to database
-            saveConversation();
+        if (data.success && data.conversation) {
+            showConversation(data.conversation);
+            await loadBranches();
         } else {
-            // Handle error
+            // Nothing was saved; put the message back so it can be resent
+            PromptGeneratorState.conversation.pop();
+            input.value = message;
+            if (response.status === 409) {
+                await loadConversation(PromptGeneratorState.currentConversationId);
+            } else {
+                renderConversation();
+            }
             showErrorMessage(data.error || 'Failed to get response from AI');
         }
     } catch (error) {
+        PromptGeneratorState.conversation.pop();
+        input.value = message;
+        renderConversation();
         showErrorMessage('Network error: ' + error.message);
     } finally {
         // Re-enable send button
@@ -236,12 +305,37 @@ function renderConversation() {
     }
     
     let html = '';
-    
+
+    const branches = PromptGeneratorState.branches;
+    if (branches.length > 1) {
+        const head = PromptGeneratorState.conversation[PromptGeneratorState.conversation.length - 1];
+        html += `
+            <div class="branch-switcher">
+                <label for="branch-select">Branch</label>
+                <select id="branch-select" onchange="branchFromMessage(Number(this.value))">
+                    ${head && !branches.some(branch => branch.leaf_message_id === head.id) ? '<option selected disabled>New branch</option>' : ''}
+                    ${branches.map((branch, index) => `
+                        <option value="${branch.leaf_message_id}" ${branch.active ? 'selected' : ''}>
+                            ${index + 1}. ${escapeHtml(branch.last_message.content.substring(0, 40))} (${branch.message_count} messages)
+                        </option>
+                    `).join('')}
+                </select>
+            </div>
+        `;
+    }
+
     PromptGeneratorState.conversation.forEach((message, index) => {
+        // Earlier saved messages can be branched from to try a different follow-up
+        const isLast = index === PromptGeneratorState.conversation.length - 1;
+        const branchButton = message.id && !isLast
+            ? `<button class="branch-btn" onclick="branchFromMessage(${message.id})" title="Continue from this message in a new branch">Branch from here</button>`
+            : '';
+
         if (message.role === 'user') {
             html += `
                 <div class="conversation-message">
                     <div class="message-user">${escapeHtml(message.content)}</div>
+                    ${branchButton}
                 </div>
             `;
         } else {
@@ -252,6 +346,7 @@ function renderConversation() {
                     <div class="message-ai">
                         ${parsedMessage.html}
                     </div>
+                    ${branchButton}
                 </div>
             `;
         }
@@ -559,6 +654,7 @@ window.loadConversationHistory = loadConversationHistory;
 window.hideConversationHistory = hideConversationHistory;
 window.loadHistoryConversation = loadHistoryConversation;
 window.deleteHistoryConversation = deleteHistoryConversation;
+window.branchFromMessage = branchFromMessage;
 console.log('window.toggleConversationHistory assigned:', typeof window.toggleConversationHistory);
 
 // Test function to verify JavaScript is working
@@ -25,4 +25,4 @@ echo ""
 echo "Press Ctrl+C to stop the server"
 echo "================================"
 
-go run main.go 
\ No newline at end of file
+go run -tags sqlite_fts5 .
\ No newline at end of file